@@ -93,7 +93,12 @@ func NewSideCarQueryServer(appCodec codec.Codec, config domain.Config, logger lo
 	e.Use(middleware.InstrumentMiddleware)
 	e.Use(middleware.TraceWithParamsMiddleware("sqs"))
 
-	routerRepository := routerrepo.New()
+	// Retain the state of the past blocks for the queries at a past height.
+	stateHistoryConfig := domain.StateHistoryConfig{}
+	if config.StateHistory != nil {
+		stateHistoryConfig = *config.StateHistory
+	}
+	routerRepository := routerrepo.NewWithStateHistory(stateHistoryConfig, logger)
 
 	// Initialize pools repository, usecase and HTTP handler
 	poolsUseCase := poolsUseCase.NewPoolsUsecase(config.Pools, config.ChainGRPCGatewayEndpoint, routerRepository)
@@ -101,6 +106,11 @@ func NewSideCarQueryServer(appCodec codec.Codec, config domain.Config, logger lo
 	// Initialize router repository, usecase
 	routerUsecase := routerUseCase.NewRouterUsecase(routerRepository, poolsUseCase, *config.Router, poolsUseCase.GetCosmWasmPoolConfig(), logger, cache.New(), cache.New())
 
+	// Serve every request from the state of a single block.
+	// Only the quotes, pools and prices are served from the state of a past block with the height parameter.
+	heightPaths := []string{"/router/quote", "/pools", "/tokens/prices"}
+	e.Use(middleware.StateSnapshotMiddleware(routerUsecase.GetStateSnapshot, routerUsecase.GetStateSnapshotAtHeight, heightPaths))
+
 	// Initialize system handler
 	chainInfoRepository := chaininforepo.New()
 	chainInfoUseCase := chaininfousecase.NewChainInfoUsecase(chainInfoRepository)
@@ -124,7 +134,7 @@ func NewSideCarQueryServer(appCodec codec.Codec, config domain.Config, logger lo
 	tokensUseCase.RegisterPricingStrategy(domain.ChainPricingSourceType, chainPricingSource)
 
 	// HTTP handlers
-	poolsHttpDelivery.NewPoolsHandler(e, poolsUseCase)
+	poolsHttpDelivery.NewPoolsHandler(e, poolsUseCase, tokensUseCase)
 	systemhttpdelivery.NewSystemHandler(e, config, logger, chainInfoUseCase)
 	if err := tokenshttpdelivery.NewTokensHandler(e, *config.Pricing, tokensUseCase, routerUsecase, logger); err != nil {
 		return nil, err
@@ -31,11 +31,38 @@ var DefaultConfig = domain.Config{
 		RankedRouteCacheExpirySeconds:    300, // 5 minutes
 
 		EnableOverwriteRoutesCache: false,
+
+		TVLBoostDriftThreshold: 0.05, // 5%
+		MaxBatchQuoteWorkers:   8,
+		MaxQuoteSubscriptions:  1000,
+
+		ArbitrageDetectionEnabled:            true,
+		GeneralizedCosmWasmPoolSplitsEnabled: false,
+		AdaptiveSplitsEnabled:                true,
+
+		Gas: domain.GasConfig{
+			PerHop:                     20000,
+			Balancer:                   60000,
+			Stableswap:                 90000,
+			Concentrated:               80000,
+			ConcentratedPerTickCrossed: 30000,
+			Transmuter:                 120000,
+			GeneralizedCosmWasm:        250000,
+		},
+
+		RouteWarming: domain.RouteWarmingConfig{
+			// The hot pairs are network specific so none are configured by default.
+			HotPairs: []domain.HotPair{},
+			// From 1 to 10,000 units of a token with 6 decimals.
+			OrdersOfMagnitude: []int{6, 7, 8, 9, 10},
+			MaxLearnedPairs:   10,
+		},
 	},
 	Pools: &domain.PoolsConfig{
 		// This is what we have on mainnet as of Jan 2024.
 		TransmuterCodeIDs:      []uint64{148},
 		GeneralCosmWasmCodeIDs: []uint64{},
+		NativeCosmWasmCodeIDs:  map[string][]uint64{},
 	},
 
 	Pricing: &domain.PricingConfig{
@@ -47,4 +74,10 @@ var DefaultConfig = domain.Config{
 		MaxRoutes:        5,
 		MinOSMOLiquidity: 50,
 	},
+
+	StateHistory: &domain.StateHistoryConfig{
+		Size:      100,
+		SpillDir:  "",
+		SpillSize: 0,
+	},
 }
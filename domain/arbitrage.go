@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"github.com/osmosis-labs/osmosis/osmomath"
+)
+
+// ArbitrageResult is the result of the arbitrage detection over the pools at a given height.
+type ArbitrageResult struct {
+	// Height of the block after which the pools were inspected.
+	Height uint64 `json:"height"`
+	// Profitable cycles ordered by the spot price product in decreasing order.
+	Cycles []ArbitrageCycle `json:"cycles"`
+}
+
+// ArbitrageCycle is a sequence of swaps that starts and ends in the same denom
+// and returns more of the denom than swapped in.
+type ArbitrageCycle struct {
+	// Denom swapped in at the start and out at the end of the cycle.
+	Denom string         `json:"denom"`
+	Hops  []ArbitrageHop `json:"hops"`
+	// Amount in that maximizes the profit of the cycle.
+	AmountIn  osmomath.Int `json:"amount_in"`
+	AmountOut osmomath.Int `json:"amount_out"`
+	Profit    osmomath.Int `json:"profit"`
+	// Product of the spot prices over the cycle net of the spread factors and taker fees.
+	// Exceeds one for the cycles that are profitable at the margin.
+	SpotPriceProduct osmomath.Dec `json:"spot_price_product"`
+}
+
+// ArbitrageHop is a single swap of an arbitrage cycle.
+type ArbitrageHop struct {
+	PoolID        uint64 `json:"pool_id"`
+	TokenOutDenom string `json:"token_out_denom"`
+}
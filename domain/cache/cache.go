@@ -6,15 +6,33 @@ import (
 )
 
 // Cache is a concurrent cache structure.
+// Items may be set with the IDs of the dependencies they are derived from
+// so that they can be evicted once any of the dependencies changes.
+// Items may also be set with tags, such as the denoms they are computed for,
+// so that they can be evicted by the tags regardless of their dependencies.
+// Items derived from the state at a height may be set with it so that the ones computed
+// over a state that is already evicted are dropped.
 type Cache struct {
-	data  map[string]CacheItem
-	mutex sync.RWMutex
+	data map[string]CacheItem
+	// dependency ID -> keys of the items that depend on it.
+	dependents map[uint64]map[string]struct{}
+	// tag -> keys of the items set with it.
+	tagged map[string]map[string]struct{}
+	// keys of the items set without dependencies.
+	independents map[string]struct{}
+	// Items derived from the state before this height are not set.
+	minHeight uint64
+	mutex     sync.RWMutex
 }
 
 // CacheItem represents an item in the cache.
 type CacheItem struct {
 	Value      interface{}
 	Expiration time.Time
+	// IDs of the dependencies that evict the item on change.
+	DependencyIDs []uint64
+	// Tags that evict the item.
+	Tags []string
 }
 
 const NoExpirationTTL time.Duration = 0
@@ -22,22 +40,80 @@ const NoExpirationTTL time.Duration = 0
 // New creates a new concurrent cache.
 func New() *Cache {
 	return &Cache{
-		data: make(map[string]CacheItem),
+		data:         make(map[string]CacheItem),
+		dependents:   make(map[uint64]map[string]struct{}),
+		tagged:       make(map[string]map[string]struct{}),
+		independents: make(map[string]struct{}),
 	}
 }
 
 // Set adds an item to the cache with a specified key, value, and expiration time.
 func (c *Cache) Set(key string, value interface{}, expiration time.Duration) {
+	c.SetWithDependencies(key, value, expiration, nil)
+}
+
+// SetWithDependencies adds an item to the cache with a specified key, value, and expiration time.
+// The item is evicted by EvictDependents with any of the given dependency IDs before it expires.
+func (c *Cache) SetWithDependencies(key string, value interface{}, expiration time.Duration, dependencyIDs []uint64) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	c.setLocked(key, value, expiration, dependencyIDs, nil)
+}
+
+// SetWithDependenciesAtHeight is SetWithDependencies for an item derived from the state at the given height.
+// The item is also evicted by EvictTagged with any of the given tags.
+// The item is not set if the cache has already been evicted at a later height by EvictDependentsAtHeight.
+// Returns true if the item is set.
+func (c *Cache) SetWithDependenciesAtHeight(key string, value interface{}, expiration time.Duration, dependencyIDs []uint64, tags []string, height uint64) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if height < c.minHeight {
+		return false
+	}
+
+	c.setLocked(key, value, expiration, dependencyIDs, tags)
+	return true
+}
+
+// setLocked adds an item to the cache and indexes its dependencies and tags.
+// CONTRACT: the write mutex is held.
+func (c *Cache) setLocked(key string, value interface{}, expiration time.Duration, dependencyIDs []uint64, tags []string) {
+	// Drop the dependencies of the item being overwritten.
+	c.deleteLocked(key)
+
 	expirationTime := time.Time{}
 	if expiration != NoExpirationTTL {
 		expirationTime = time.Now().Add(expiration)
 	}
 	c.data[key] = CacheItem{
-		Value:      value,
-		Expiration: expirationTime,
+		Value:         value,
+		Expiration:    expirationTime,
+		DependencyIDs: dependencyIDs,
+		Tags:          tags,
+	}
+
+	if len(dependencyIDs) == 0 {
+		c.independents[key] = struct{}{}
+	}
+
+	for _, dependencyID := range dependencyIDs {
+		keys, ok := c.dependents[dependencyID]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.dependents[dependencyID] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	for _, tag := range tags {
+		keys, ok := c.tagged[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tagged[tag] = keys
+		}
+		keys[key] = struct{}{}
 	}
 }
 
@@ -57,7 +133,7 @@ func (c *Cache) Get(key string) (interface{}, bool) {
 
 		// Acquire write mutex.
 		c.mutex.Lock()
-		delete(c.data, key)
+		c.deleteLocked(key)
 		c.mutex.Unlock()
 		return nil, false
 	}
@@ -72,5 +148,140 @@ func (c *Cache) Delete(key string) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	c.deleteLocked(key)
+}
+
+// EvictDependents removes all items that depend on any of the given dependency IDs.
+// Returns the number of removed items.
+func (c *Cache) EvictDependents(dependencyIDs ...uint64) int {
+	return c.EvictDependentsExcept(nil, dependencyIDs...)
+}
+
+// EvictDependentsExcept is EvictDependents that keeps the items with the retained keys
+// so that they can be replaced in place.
+func (c *Cache) EvictDependentsExcept(retainedKeys map[string]struct{}, dependencyIDs ...uint64) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.evictDependentsLocked(retainedKeys, dependencyIDs)
+}
+
+// EvictDependentsAtHeight is EvictDependents for the dependencies changed at the given height.
+// Afterwards, the items derived from the state before the height are no longer set by SetWithDependenciesAtHeight.
+func (c *Cache) EvictDependentsAtHeight(height uint64, dependencyIDs ...uint64) int {
+	return c.EvictDependentsAtHeightExcept(height, nil, dependencyIDs...)
+}
+
+// EvictDependentsAtHeightExcept is EvictDependentsAtHeight that keeps the items with the retained keys
+// so that they can be replaced in place by items derived from the state at the height.
+func (c *Cache) EvictDependentsAtHeightExcept(height uint64, retainedKeys map[string]struct{}, dependencyIDs ...uint64) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if height > c.minHeight {
+		c.minHeight = height
+	}
+
+	return c.evictDependentsLocked(retainedKeys, dependencyIDs)
+}
+
+// evictDependentsLocked removes all items that depend on any of the given dependency IDs
+// except the ones with the retained keys.
+// Returns the number of removed items.
+// CONTRACT: the write mutex is held.
+func (c *Cache) evictDependentsLocked(retainedKeys map[string]struct{}, dependencyIDs []uint64) int {
+	numEvicted := 0
+	for _, dependencyID := range dependencyIDs {
+		for key := range c.dependents[dependencyID] {
+			if _, ok := retainedKeys[key]; ok {
+				continue
+			}
+
+			c.deleteLocked(key)
+			numEvicted++
+		}
+	}
+
+	return numEvicted
+}
+
+// EvictTagged removes all items set with any of the given tags.
+// Returns the number of removed items.
+func (c *Cache) EvictTagged(tags ...string) int {
+	return c.EvictTaggedExcept(nil, tags...)
+}
+
+// EvictTaggedExcept is EvictTagged that keeps the items with the retained keys
+// so that they can be replaced in place.
+func (c *Cache) EvictTaggedExcept(retainedKeys map[string]struct{}, tags ...string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	numEvicted := 0
+	for _, tag := range tags {
+		for key := range c.tagged[tag] {
+			if _, ok := retainedKeys[key]; ok {
+				continue
+			}
+
+			c.deleteLocked(key)
+			numEvicted++
+		}
+	}
+
+	return numEvicted
+}
+
+// EvictIndependents removes all items set without dependencies.
+// Returns the number of removed items.
+func (c *Cache) EvictIndependents() int {
+	return c.EvictIndependentsExcept(nil)
+}
+
+// EvictIndependentsExcept is EvictIndependents that keeps the items with the retained keys
+// so that they can be replaced in place.
+func (c *Cache) EvictIndependentsExcept(retainedKeys map[string]struct{}) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	numEvicted := 0
+	for key := range c.independents {
+		if _, ok := retainedKeys[key]; ok {
+			continue
+		}
+
+		c.deleteLocked(key)
+		numEvicted++
+	}
+
+	return numEvicted
+}
+
+// deleteLocked removes the item and its dependency and tag index entries.
+// CONTRACT: the write mutex is held.
+func (c *Cache) deleteLocked(key string) {
+	item, exists := c.data[key]
+	if !exists {
+		return
+	}
+
+	delete(c.independents, key)
+
+	for _, dependencyID := range item.DependencyIDs {
+		keys := c.dependents[dependencyID]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(c.dependents, dependencyID)
+		}
+	}
+
+	for _, tag := range item.Tags {
+		keys := c.tagged[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(c.tagged, tag)
+		}
+	}
+
 	delete(c.data, key)
 }
@@ -179,3 +179,213 @@ func TestCache_SetExpiration(t *testing.T) {
 		})
 	}
 }
+
+func TestCache_EvictDependents(t *testing.T) {
+	tests := []struct {
+		name string
+		// key -> dependency IDs
+		items map[string][]uint64
+		// Keys overwritten with no dependencies after the initial set.
+		overwrittenKeys []string
+		retainedKeys    []string
+		evictedIDs      []uint64
+
+		expectedNumEvicted int
+		expectedKeys       []string
+	}{
+		{
+			name:               "No dependents",
+			items:              map[string][]uint64{"key1": {1, 2}},
+			evictedIDs:         []uint64{3},
+			expectedNumEvicted: 0,
+			expectedKeys:       []string{"key1"},
+		},
+		{
+			name:               "Single dependency evicts all of its dependents",
+			items:              map[string][]uint64{"key1": {1, 2}, "key2": {2, 3}, "key3": {3}},
+			evictedIDs:         []uint64{2},
+			expectedNumEvicted: 2,
+			expectedKeys:       []string{"key3"},
+		},
+		{
+			name:               "Item depending on several evicted IDs is counted once",
+			items:              map[string][]uint64{"key1": {1, 2}, "key2": {4}},
+			evictedIDs:         []uint64{1, 2},
+			expectedNumEvicted: 1,
+			expectedKeys:       []string{"key2"},
+		},
+		{
+			name:               "Overwrite drops previous dependencies",
+			items:              map[string][]uint64{"key1": {1}, "key2": {1}},
+			overwrittenKeys:    []string{"key1"},
+			evictedIDs:         []uint64{1},
+			expectedNumEvicted: 1,
+			expectedKeys:       []string{"key1"},
+		},
+		{
+			name:               "Items without dependencies are not evicted",
+			items:              map[string][]uint64{"key1": nil},
+			evictedIDs:         []uint64{1},
+			expectedNumEvicted: 0,
+			expectedKeys:       []string{"key1"},
+		},
+		{
+			name:               "Retained items are not evicted",
+			items:              map[string][]uint64{"key1": {1, 2}, "key2": {2}, "key3": {3}},
+			retainedKeys:       []string{"key1", "key3"},
+			evictedIDs:         []uint64{1, 2, 3},
+			expectedNumEvicted: 1,
+			expectedKeys:       []string{"key1", "key3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cache.New()
+
+			for key, dependencyIDs := range tt.items {
+				c.SetWithDependencies(key, key, cache.NoExpiration, dependencyIDs)
+			}
+
+			for _, key := range tt.overwrittenKeys {
+				c.Set(key, key, cache.NoExpiration)
+			}
+
+			var numEvicted int
+			if len(tt.retainedKeys) == 0 {
+				numEvicted = c.EvictDependents(tt.evictedIDs...)
+			} else {
+				retainedKeys := make(map[string]struct{}, len(tt.retainedKeys))
+				for _, key := range tt.retainedKeys {
+					retainedKeys[key] = struct{}{}
+				}
+
+				numEvicted = c.EvictDependentsExcept(retainedKeys, tt.evictedIDs...)
+			}
+			if numEvicted != tt.expectedNumEvicted {
+				t.Errorf("Expected %d evicted items, got: %d", tt.expectedNumEvicted, numEvicted)
+			}
+
+			expectedKeys := make(map[string]struct{}, len(tt.expectedKeys))
+			for _, key := range tt.expectedKeys {
+				expectedKeys[key] = struct{}{}
+			}
+
+			for key := range tt.items {
+				_, expectExist := expectedKeys[key]
+				if _, exists := c.Get(key); exists != expectExist {
+					t.Errorf("Expected key %s to exist: %v, got: %v", key, expectExist, exists)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_SetWithDependenciesAtHeight(t *testing.T) {
+	c := cache.New()
+
+	if !c.SetWithDependenciesAtHeight("key1", "value1", cache.NoExpiration, []uint64{1}, nil, 1) {
+		t.Errorf("Expected the item at height 1 to be set before any eviction")
+	}
+
+	c.EvictDependentsAtHeight(2)
+
+	// The item derived from the state at height 1 is computed before the eviction at height 2
+	// but set afterwards.
+	if c.SetWithDependenciesAtHeight("key2", "value2", cache.NoExpiration, []uint64{1}, nil, 1) {
+		t.Errorf("Expected the item at height 1 not to be set after the eviction at height 2")
+	}
+	if _, exists := c.Get("key2"); exists {
+		t.Errorf("Expected key2 not to exist")
+	}
+
+	// The item set before the eviction is kept since it does not depend on the evicted IDs.
+	if _, exists := c.Get("key1"); !exists {
+		t.Errorf("Expected key1 to exist")
+	}
+
+	if !c.SetWithDependenciesAtHeight("key3", "value3", cache.NoExpiration, []uint64{1}, nil, 2) {
+		t.Errorf("Expected the item at height 2 to be set after the eviction at height 2")
+	}
+
+	// An eviction at a lower height does not lower the height of the items set.
+	c.EvictDependentsAtHeight(1)
+	if c.SetWithDependenciesAtHeight("key4", "value4", cache.NoExpiration, []uint64{1}, nil, 1) {
+		t.Errorf("Expected the item at height 1 not to be set after the eviction at height 2")
+	}
+}
+
+func TestCache_EvictIndependents(t *testing.T) {
+	c := cache.New()
+
+	c.SetWithDependencies("key1", "value1", cache.NoExpiration, nil)
+	c.SetWithDependencies("key2", "value2", cache.NoExpiration, []uint64{1})
+	c.Set("key3", "value3", cache.NoExpiration)
+
+	// Overwriting with dependencies drops the item from the items without dependencies.
+	c.SetWithDependencies("key3", "value3", cache.NoExpiration, []uint64{2})
+
+	numEvicted := c.EvictIndependents()
+	if numEvicted != 1 {
+		t.Errorf("Expected 1 evicted item, got: %d", numEvicted)
+	}
+
+	for key, expectExist := range map[string]bool{"key1": false, "key2": true, "key3": true} {
+		if _, exists := c.Get(key); exists != expectExist {
+			t.Errorf("Expected key %s to exist: %v, got: %v", key, expectExist, exists)
+		}
+	}
+
+	// The retained items without dependencies are kept.
+	c.Set("key4", "value4", cache.NoExpiration)
+	c.Set("key5", "value5", cache.NoExpiration)
+
+	numEvicted = c.EvictIndependentsExcept(map[string]struct{}{"key4": {}})
+	if numEvicted != 1 {
+		t.Errorf("Expected 1 evicted item, got: %d", numEvicted)
+	}
+
+	for key, expectExist := range map[string]bool{"key4": true, "key5": false} {
+		if _, exists := c.Get(key); exists != expectExist {
+			t.Errorf("Expected key %s to exist: %v, got: %v", key, expectExist, exists)
+		}
+	}
+}
+
+func TestCache_EvictTagged(t *testing.T) {
+	c := cache.New()
+
+	c.SetWithDependenciesAtHeight("key1", "value1", cache.NoExpiration, []uint64{1}, []string{"a", "b"}, 1)
+	c.SetWithDependenciesAtHeight("key2", "value2", cache.NoExpiration, []uint64{1}, []string{"b", "c"}, 1)
+	c.SetWithDependenciesAtHeight("key3", "value3", cache.NoExpiration, nil, []string{"c"}, 1)
+	c.SetWithDependenciesAtHeight("key4", "value4", cache.NoExpiration, []uint64{2}, []string{"d"}, 1)
+
+	// Overwriting without tags drops the item from the tagged items.
+	c.SetWithDependencies("key4", "value4", cache.NoExpiration, []uint64{2})
+
+	// The items tagged with several evicted tags are counted once.
+	numEvicted := c.EvictTagged("b", "d")
+	if numEvicted != 2 {
+		t.Errorf("Expected 2 evicted items, got: %d", numEvicted)
+	}
+
+	for key, expectExist := range map[string]bool{"key1": false, "key2": false, "key3": true, "key4": true} {
+		if _, exists := c.Get(key); exists != expectExist {
+			t.Errorf("Expected key %s to exist: %v, got: %v", key, expectExist, exists)
+		}
+	}
+
+	// The retained tagged items are kept.
+	c.SetWithDependenciesAtHeight("key5", "value5", cache.NoExpiration, nil, []string{"c"}, 1)
+
+	numEvicted = c.EvictTaggedExcept(map[string]struct{}{"key5": {}}, "c")
+	if numEvicted != 1 {
+		t.Errorf("Expected 1 evicted item, got: %d", numEvicted)
+	}
+
+	for key, expectExist := range map[string]bool{"key3": false, "key5": true} {
+		if _, exists := c.Get(key); exists != expectExist {
+			t.Errorf("Expected key %s to exist: %v, got: %v", key, expectExist, exists)
+		}
+	}
+}
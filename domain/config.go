@@ -33,6 +33,9 @@ type Config struct {
 	OTEL *OTELConfig `mapstructure:"otel"`
 
 	CORS *CORSConfig `mapstructure:"cors"`
+
+	// StateHistory encapsulates the retention of the state of the past blocks.
+	StateHistory *StateHistoryConfig `mapstructure:"state-history"`
 }
 
 type EndpointOTELConfig struct {
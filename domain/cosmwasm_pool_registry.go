@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"github.com/osmosis-labs/osmosis/osmomath"
+	cwpoolmodel "github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/model"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// NativeCosmWasmPoolFactory creates a routable pool that computes the swaps of the CosmWasm pool natively
+// from the SQS pool model rather than by querying the pool contract on chain.
+type NativeCosmWasmPoolFactory func(pool sqsdomain.PoolI, cosmWasmPool *cwpoolmodel.CosmWasmPool, tokenInDenom, tokenOutDenom string, takerFee osmomath.Dec) (sqsdomain.RoutablePool, error)
+
+// CosmWasmPoolRegistry maps CosmWasm pool code IDs to the native implementations of their pools.
+// The implementations are registered on startup. Afterwards, the registry is safe for concurrent reads.
+// Lookups on a nil registry find nothing.
+type CosmWasmPoolRegistry struct {
+	factoriesByCodeID map[uint64]NativeCosmWasmPoolFactory
+}
+
+// NewCosmWasmPoolRegistry returns a new empty CosmWasm pool registry.
+func NewCosmWasmPoolRegistry() *CosmWasmPoolRegistry {
+	return &CosmWasmPoolRegistry{
+		factoriesByCodeID: make(map[uint64]NativeCosmWasmPoolFactory),
+	}
+}
+
+// Register registers the native implementation for the pools with the given code IDs.
+// Overrides the implementations previously registered for the same code IDs.
+func (r *CosmWasmPoolRegistry) Register(factory NativeCosmWasmPoolFactory, codeIDs ...uint64) {
+	for _, codeID := range codeIDs {
+		r.factoriesByCodeID[codeID] = factory
+	}
+}
+
+// GetFactory returns the native implementation registered for the given code ID.
+// Returns false if none is registered.
+func (r *CosmWasmPoolRegistry) GetFactory(codeID uint64) (NativeCosmWasmPoolFactory, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	factory, ok := r.factoriesByCodeID[codeID]
+	return factory, ok
+}
+
+// IsRegistered returns true if a native implementation is registered for the given code ID.
+func (r *CosmWasmPoolRegistry) IsRegistered(codeID uint64) bool {
+	_, ok := r.GetFactory(codeID)
+	return ok
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// CosmWasmSimulationCache memoizes the swap simulations of the generalized cosmwasm pools
+// that are otherwise made with a network request to chain per quote.
+// The pool state changes with every block so a new cache is created for every state snapshot
+// and the simulations made against one snapshot are never observed by the quotes of another.
+// All methods are no-ops on a nil receiver.
+// CosmWasmSimulationCache is safe for concurrent use.
+type CosmWasmSimulationCache struct {
+	mu                sync.RWMutex
+	tokenOutByTokenIn map[cosmWasmSimulationKey]sdk.Coin
+}
+
+// cosmWasmSimulationKey identifies a swap simulation of exactly the token in for the token out denom in the pool.
+type cosmWasmSimulationKey struct {
+	poolID        uint64
+	tokenIn       string
+	tokenOutDenom string
+}
+
+// NewCosmWasmSimulationCache returns a new empty cosmwasm simulation cache.
+func NewCosmWasmSimulationCache() *CosmWasmSimulationCache {
+	return &CosmWasmSimulationCache{
+		tokenOutByTokenIn: make(map[cosmWasmSimulationKey]sdk.Coin),
+	}
+}
+
+// GetTokenOut returns the memoized token out of swapping the token in for the token out denom in the pool.
+// Returns false if the simulation is not memoized.
+func (c *CosmWasmSimulationCache) GetTokenOut(poolID uint64, tokenIn sdk.Coin, tokenOutDenom string) (sdk.Coin, bool) {
+	if c == nil {
+		return sdk.Coin{}, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tokenOut, ok := c.tokenOutByTokenIn[newCosmWasmSimulationKey(poolID, tokenIn, tokenOutDenom)]
+	return tokenOut, ok
+}
+
+// SetTokenOut memoizes the token out of swapping the token in for the token out denom in the pool.
+func (c *CosmWasmSimulationCache) SetTokenOut(poolID uint64, tokenIn sdk.Coin, tokenOutDenom string, tokenOut sdk.Coin) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokenOutByTokenIn[newCosmWasmSimulationKey(poolID, tokenIn, tokenOutDenom)] = tokenOut
+}
+
+// Len returns the number of memoized simulations.
+func (c *CosmWasmSimulationCache) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.tokenOutByTokenIn)
+}
+
+func newCosmWasmSimulationKey(poolID uint64, tokenIn sdk.Coin, tokenOutDenom string) cosmWasmSimulationKey {
+	return cosmWasmSimulationKey{
+		poolID:        poolID,
+		tokenIn:       tokenIn.String(),
+		tokenOutDenom: tokenOutDenom,
+	}
+}
@@ -139,6 +139,15 @@ func (e ConcentratedNotEnoughLiquidityToCompleteSwapError) Error() string {
 	return fmt.Sprintf("not enough liquidity to complete swap in pool (%d) with amount in (%s)", e.PoolId, e.AmountIn)
 }
 
+type ConcentratedNotEnoughLiquidityToCompleteSwapInGivenOutError struct {
+	PoolId    uint64
+	AmountOut string
+}
+
+func (e ConcentratedNotEnoughLiquidityToCompleteSwapInGivenOutError) Error() string {
+	return fmt.Sprintf("not enough liquidity to complete swap in pool (%d) with amount out (%s)", e.PoolId, e.AmountOut)
+}
+
 type ConcentratedTickModelNotSetError struct {
 	PoolId uint64
 }
@@ -157,6 +166,45 @@ func (e TransmuterInsufficientBalanceError) Error() string {
 	return fmt.Sprintf("insufficient balance of token (%s), balance (%s), amount (%s)", e.Denom, e.BalanceAmount, e.Amount)
 }
 
+type CosmWasmPoolInsufficientLiquidityError struct {
+	PoolId        uint64
+	Denom         string
+	BalanceAmount string
+	Amount        string
+}
+
+func (e CosmWasmPoolInsufficientLiquidityError) Error() string {
+	return fmt.Sprintf("insufficient liquidity of token (%s) in pool (%d), balance (%s), amount (%s)", e.Denom, e.PoolId, e.BalanceAmount, e.Amount)
+}
+
+type OrderbookInvalidDenomsError struct {
+	PoolId        uint64
+	TokenInDenom  string
+	TokenOutDenom string
+}
+
+func (e OrderbookInvalidDenomsError) Error() string {
+	return fmt.Sprintf("orderbook pool (%d) does not trade token in (%s) for token out (%s)", e.PoolId, e.TokenInDenom, e.TokenOutDenom)
+}
+
+type OrderbookNotEnoughLiquidityToCompleteSwapError struct {
+	PoolId   uint64
+	AmountIn string
+}
+
+func (e OrderbookNotEnoughLiquidityToCompleteSwapError) Error() string {
+	return fmt.Sprintf("not enough liquidity to complete swap in orderbook pool (%d) with amount in (%s)", e.PoolId, e.AmountIn)
+}
+
+type OrderbookNotEnoughLiquidityToCompleteSwapInGivenOutError struct {
+	PoolId    uint64
+	AmountOut string
+}
+
+func (e OrderbookNotEnoughLiquidityToCompleteSwapInGivenOutError) Error() string {
+	return fmt.Sprintf("not enough liquidity to complete swap in orderbook pool (%d) with amount out (%s)", e.PoolId, e.AmountOut)
+}
+
 type StaleHeightError struct {
 	StoredHeight            uint64
 	TimeSinceLastUpdate     int
@@ -166,3 +214,37 @@ type StaleHeightError struct {
 func (e StaleHeightError) Error() string {
 	return fmt.Sprintf("stored height (%d) is stale, time since last update (%d), max allowed seconds (%d)", e.StoredHeight, e.TimeSinceLastUpdate, e.MaxAllowedTimeDeltaSecs)
 }
+
+type StateSnapshotNotRetainedError struct {
+	Height       uint64
+	LatestHeight uint64
+}
+
+func (e StateSnapshotNotRetainedError) Error() string {
+	return fmt.Sprintf("state at height (%d) is not retained, latest height (%d)", e.Height, e.LatestHeight)
+}
+
+type StateSnapshotDroppedError struct {
+	Height uint64
+}
+
+func (e StateSnapshotDroppedError) Error() string {
+	return fmt.Sprintf("state at height (%d) was dropped before it was spilled to disk", e.Height)
+}
+
+type GeneralizedCosmWasmPoolPastHeightError struct {
+	PoolId uint64
+	Height uint64
+}
+
+func (e GeneralizedCosmWasmPoolPastHeightError) Error() string {
+	return fmt.Sprintf("generalized cosmwasm pool (%d) is only supported at the latest height, got height (%d)", e.PoolId, e.Height)
+}
+
+type QuoteSubscriptionsLimitReachedError struct {
+	Limit int
+}
+
+func (e QuoteSubscriptionsLimitReachedError) Error() string {
+	return fmt.Sprintf("quote subscriptions limit (%d) reached, try again later", e.Limit)
+}
@@ -16,11 +16,13 @@ import (
 type MockRoutablePool struct {
 	ChainPoolModel       poolmanagertypes.PoolI
 	TickModel            *sqsdomain.TickModel
+	OrderbookModel       *sqsdomain.OrderbookModel
 	ID                   uint64
 	Balances             sdk.Coins
 	Denoms               []string
 	TotalValueLockedUSDC osmomath.Int
 	PoolType             poolmanagertypes.PoolType
+	TokenInDenom         string
 	TokenOutDenom        string
 	TakerFee             osmomath.Dec
 	SpreadFactor         osmomath.Dec
@@ -93,6 +95,21 @@ func (mp *MockRoutablePool) CalculateTokenOutByTokenIn(_ctx context.Context, tok
 	return balancerPool.CalcOutAmtGivenIn(sdk.Context{}, sdk.NewCoins(tokenIn), mp.TokenOutDenom, mp.SpreadFactor)
 }
 
+// CalculateTokenInByTokenOut implements sqsdomain.RoutablePool.
+func (mp *MockRoutablePool) CalculateTokenInByTokenOut(_ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error) {
+	if mp.PoolType == poolmanagertypes.CosmWasm {
+		return sdk.NewCoin(mp.TokenInDenom, tokenOut.Amount), nil
+	}
+
+	// Cast to balancer
+	balancerPool, ok := mp.ChainPoolModel.(*balancer.Pool)
+	if !ok {
+		panic("not a balancer pool")
+	}
+
+	return balancerPool.CalcInAmtGivenOut(sdk.Context{}, sdk.NewCoins(tokenOut), mp.TokenInDenom, mp.SpreadFactor)
+}
+
 // String implements sqsdomain.RoutablePool.
 func (*MockRoutablePool) String() string {
 	panic("unimplemented")
@@ -109,12 +126,31 @@ func (mp *MockRoutablePool) SetTickModel(tickModel *sqsdomain.TickModel) error {
 	return nil
 }
 
+// GetOrderbookModel implements sqsdomain.PoolI.
+func (mp *MockRoutablePool) GetOrderbookModel() (*sqsdomain.OrderbookModel, error) {
+	if mp.OrderbookModel == nil {
+		return nil, sqsdomain.OrderbookPoolNoOrderbookModelError{PoolId: mp.ID}
+	}
+	return mp.OrderbookModel, nil
+}
+
+// SetOrderbookModel implements sqsdomain.PoolI.
+func (mp *MockRoutablePool) SetOrderbookModel(orderbookModel *sqsdomain.OrderbookModel) error {
+	mp.OrderbookModel = orderbookModel
+	return nil
+}
+
 // Validate implements sqsdomain.PoolI.
 func (*MockRoutablePool) Validate(minUOSMOTVL math.Int) error {
 	// Note: always valid for tests.
 	return nil
 }
 
+// GetTokenInDenom implements routerusecase.RoutablePool.
+func (mp *MockRoutablePool) GetTokenInDenom() string {
+	return mp.TokenInDenom
+}
+
 // GetTokenOutDenom implements routerusecase.RoutablePool.
 func (mp *MockRoutablePool) GetTokenOutDenom() string {
 	return mp.TokenOutDenom
@@ -125,6 +161,12 @@ func (mp *MockRoutablePool) ChargeTakerFeeExactIn(tokenIn sdk.Coin) (tokenInAfte
 	return tokenIn.Sub(sdk.NewCoin(tokenIn.Denom, mp.TakerFee.Mul(tokenIn.Amount.ToLegacyDec()).TruncateInt()))
 }
 
+// ChargeTakerFeeExactOut implements sqsdomain.RoutablePool.
+func (mp *MockRoutablePool) ChargeTakerFeeExactOut(tokenIn sdk.Coin) (tokenInAfterFee sdk.Coin) {
+	amountIn := tokenIn.Amount.ToLegacyDec().Quo(osmomath.OneDec().Sub(mp.TakerFee)).Ceil().TruncateInt()
+	return sdk.NewCoin(tokenIn.Denom, amountIn)
+}
+
 // GetTakerFee implements sqsdomain.PoolI.
 func (mp *MockRoutablePool) GetTakerFee() math.LegacyDec {
 	return mp.TakerFee
@@ -179,6 +221,7 @@ func deepCopyPool(mp *MockRoutablePool) *MockRoutablePool {
 
 		// Note these are not deep copied.
 		ChainPoolModel: mp.ChainPoolModel,
+		TokenInDenom:   mp.TokenInDenom,
 		TokenOutDenom:  mp.TokenOutDenom,
 		Balances:       newBalances,
 		TakerFee:       mp.TakerFee.Clone(),
@@ -204,6 +247,12 @@ func WithTokenOutDenom(mockPool *MockRoutablePool, tokenOutDenom string) *MockRo
 	return newPool
 }
 
+func WithTokenInDenom(mockPool *MockRoutablePool, tokenInDenom string) *MockRoutablePool {
+	newPool := deepCopyPool(mockPool)
+	newPool.TokenInDenom = tokenInDenom
+	return newPool
+}
+
 // Allows mocking out quote token out when CalculateTokenOutByTokenIn is called.
 func WithMockedTokenOut(mockPool *MockRoutablePool, tokenOut sdk.Coin) *MockRoutablePool {
 	newPool := deepCopyPool(mockPool)
@@ -34,16 +34,17 @@ func (pm *PoolsUsecaseMock) GetCosmWasmPoolConfig() domain.CosmWasmPoolRouterCon
 }
 
 // GetPools implements mvc.PoolsUsecase.
-func (*PoolsUsecaseMock) GetPools(poolIDs []uint64) ([]sqsdomain.PoolI, error) {
+func (*PoolsUsecaseMock) GetPools(ctx context.Context, poolIDs []uint64) ([]sqsdomain.PoolI, error) {
 	panic("unimplemented")
 }
 
 // GetRoutesFromCandidates implements mvc.PoolsUsecase.
 // Note that taker fee are ignored and not set
 // Note that tick models are not set
-func (pm *PoolsUsecaseMock) GetRoutesFromCandidates(candidateRoutes sqsdomain.CandidateRoutes, tokenInDenom string, tokenOutDenom string) ([]route.RouteImpl, error) {
+func (pm *PoolsUsecaseMock) GetRoutesFromCandidates(ctx context.Context, candidateRoutes sqsdomain.CandidateRoutes, tokenInDenom string, tokenOutDenom string) ([]route.RouteImpl, error) {
 	finalRoutes := make([]route.RouteImpl, 0, len(candidateRoutes.Routes))
 	for _, candidateRoute := range candidateRoutes.Routes {
+		previousTokenOutDenom := tokenInDenom
 		routablePools := make([]sqsdomain.RoutablePool, 0, len(candidateRoute.Pools))
 		for _, candidatePool := range candidateRoute.Pools {
 			// Get the pool data for routing
@@ -59,11 +60,13 @@ func (pm *PoolsUsecaseMock) GetRoutesFromCandidates(candidateRoutes sqsdomain.Ca
 			}
 
 			// TODO: note that taker fee is force set to zero
-			routablePool, err := pools.NewRoutablePool(foundPool, candidatePool.TokenOutDenom, osmomath.ZeroDec(), domain.CosmWasmPoolRouterConfig{})
+			routablePool, err := pools.NewRoutablePool(foundPool, previousTokenOutDenom, candidatePool.TokenOutDenom, osmomath.ZeroDec(), domain.CosmWasmPoolRouterConfig{})
 			if err != nil {
 				return nil, err
 			}
 			routablePools = append(routablePools, routablePool)
+
+			previousTokenOutDenom = candidatePool.TokenOutDenom
 		}
 
 		finalRoutes = append(finalRoutes, route.RouteImpl{
@@ -75,17 +78,17 @@ func (pm *PoolsUsecaseMock) GetRoutesFromCandidates(candidateRoutes sqsdomain.Ca
 }
 
 // GetAllPools implements domain.PoolsUsecase.
-func (pm *PoolsUsecaseMock) GetAllPools() ([]sqsdomain.PoolI, error) {
+func (pm *PoolsUsecaseMock) GetAllPools(ctx context.Context) ([]sqsdomain.PoolI, error) {
 	return pm.Pools, nil
 }
 
 // GetTickModelMap implements mvc.PoolsUsecase.
-func (pm *PoolsUsecaseMock) GetTickModelMap(poolIDs []uint64) (map[uint64]*sqsdomain.TickModel, error) {
+func (pm *PoolsUsecaseMock) GetTickModelMap(ctx context.Context, poolIDs []uint64) (map[uint64]*sqsdomain.TickModel, error) {
 	return pm.TickModelMap, nil
 }
 
 // GetPool implements mvc.PoolsUsecase.
-func (pm *PoolsUsecaseMock) GetPool(poolID uint64) (sqsdomain.PoolI, error) {
+func (pm *PoolsUsecaseMock) GetPool(ctx context.Context, poolID uint64) (sqsdomain.PoolI, error) {
 	panic("unimplemented")
 }
 
@@ -94,4 +97,9 @@ func (*PoolsUsecaseMock) GetPoolSpotPrice(ctx context.Context, poolID uint64, ta
 	panic("unimplemented")
 }
 
+// GetConcentratedLiquidityDepth implements mvc.PoolsUsecase.
+func (*PoolsUsecaseMock) GetConcentratedLiquidityDepth(ctx context.Context, poolID uint64, token0ScalingFactor, token1ScalingFactor osmomath.Dec, priceChangePercentages []osmomath.Dec) (domain.ConcentratedLiquidityDepth, error) {
+	panic("unimplemented")
+}
+
 var _ mvc.PoolsUsecase = &PoolsUsecaseMock{}
@@ -11,24 +11,31 @@ import (
 )
 
 // PoolsUsecase represent the pool's usecases
+// The reads observe the state snapshot pinned to the context with domain.WithStateSnapshot
+// or the latest one if none is pinned.
 type PoolsUsecase interface {
-	GetAllPools() ([]sqsdomain.PoolI, error)
+	GetAllPools(ctx context.Context) ([]sqsdomain.PoolI, error)
 
 	// GetPools returns the pools corresponding to the given IDs.
-	GetPools(poolIDs []uint64) ([]sqsdomain.PoolI, error)
+	GetPools(ctx context.Context, poolIDs []uint64) ([]sqsdomain.PoolI, error)
 
-	// StorePools stores the given pools in the usecase
+	// StorePools stores the given pools in the latest state snapshot.
 	StorePools(pools []sqsdomain.PoolI) error
 
 	// GetRoutesFromCandidates converts candidate routes to routes intrusmented with all the data necessary for estimating
 	// a swap. This data entails the pool data, the taker fee.
-	GetRoutesFromCandidates(candidateRoutes sqsdomain.CandidateRoutes, tokenInDenom, tokenOutDenom string) ([]route.RouteImpl, error)
+	// Over a past state snapshot, the routes containing generalized cosmwasm pools are excluded.
+	GetRoutesFromCandidates(ctx context.Context, candidateRoutes sqsdomain.CandidateRoutes, tokenInDenom, tokenOutDenom string) ([]route.RouteImpl, error)
 
-	GetTickModelMap(poolIDs []uint64) (map[uint64]*sqsdomain.TickModel, error)
+	GetTickModelMap(ctx context.Context, poolIDs []uint64) (map[uint64]*sqsdomain.TickModel, error)
 	// GetPool returns the pool with the given ID.
-	GetPool(poolID uint64) (sqsdomain.PoolI, error)
+	GetPool(ctx context.Context, poolID uint64) (sqsdomain.PoolI, error)
 	// GetPoolSpotPrice returns the spot price of the given pool given the taker fee, quote and base assets.
 	GetPoolSpotPrice(ctx context.Context, poolID uint64, takerFee osmomath.Dec, quoteAsset, baseAsset string) (osmomath.BigDec, error)
+	// GetConcentratedLiquidityDepth returns the liquidity of the given concentrated pool by price range
+	// and the token amounts available up to every given percentage away from the current price.
+	// The prices and the amounts are converted from the chain units with the given token scaling factors.
+	GetConcentratedLiquidityDepth(ctx context.Context, poolID uint64, token0ScalingFactor, token1ScalingFactor osmomath.Dec, priceChangePercentages []osmomath.Dec) (domain.ConcentratedLiquidityDepth, error)
 
 	GetCosmWasmPoolConfig() domain.CosmWasmPoolRouterConfig
 }
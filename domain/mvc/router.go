@@ -11,19 +11,36 @@ import (
 )
 
 // RouterUsecase represent the router's usecases
+// The reads are served from the state snapshot pinned to the context with domain.WithStateSnapshot
+// or from the latest one if none is pinned. A pinned snapshot is kept for all reads made within the call.
 type RouterUsecase interface {
 	// GetOptimalQuote returns the optimal quote for the given tokenIn and tokenOutDenom.
+	// With domain.WithMaxLatency, the best quote found within the time budget is returned flagged as truncated.
 	GetOptimalQuote(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string, opts ...domain.RouterOption) (domain.Quote, error)
+	// GetOptimalQuotes returns the optimal quotes for the given batch of requests.
+	// The quotes are computed concurrently against the same routing graph.
+	// The i-th result corresponds to the i-th request.
+	GetOptimalQuotes(ctx context.Context, requests []domain.QuoteRequest) []domain.QuoteResult
+	// GetQuoteDepth returns the optimal quotes for swapping each of the given amounts of tokenInDenom for tokenOutDenom.
+	// The quotes are computed against the same routing graph.
+	// The i-th result corresponds to the i-th amount.
+	GetQuoteDepth(ctx context.Context, tokenInDenom, tokenOutDenom string, amounts []osmomath.Int, opts ...domain.RouterOption) []domain.QuoteResult
+	// GetOptimalQuoteInGivenOut returns the optimal quote for receiving exactly the given tokenOut in exchange for tokenInDenom.
+	GetOptimalQuoteInGivenOut(ctx context.Context, tokenOut sdk.Coin, tokenInDenom string, opts ...domain.RouterOption) (domain.Quote, error)
 	// GetBestSingleRouteQuote returns the best single route quote for the given tokenIn and tokenOutDenom.
 	GetBestSingleRouteQuote(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (domain.Quote, error)
 	// GetCustomDirectQuote returns the custom direct quote for the given tokenIn, tokenOutDenom and poolID.
 	// It does not search for the route. It directly computes the quote for the given poolID.
 	// This allows to bypass a min liquidity requirement in the router when attempting to swap over a specific pool.
 	GetCustomDirectQuote(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string, poolID uint64) (domain.Quote, error)
+	// GetCustomDirectQuoteMultiPool returns the custom direct quote over the route constructed from the given pool IDs.
+	// The i-th pool swaps into the i-th token out denom. The hops must connect.
+	GetCustomDirectQuoteMultiPool(ctx context.Context, tokenIn sdk.Coin, tokenOutDenoms []string, poolIDs []uint64) (domain.Quote, error)
 	// GetCandidateRoutes returns the candidate routes for the given tokenIn and tokenOutDenom.
-	GetCandidateRoutes(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (sqsdomain.CandidateRoutes, error)
+	// The options may be used to filter the pools and denoms considered for the routes.
+	GetCandidateRoutes(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string, opts ...domain.RouterOption) (sqsdomain.CandidateRoutes, error)
 	// GetTakerFee returns the taker fee for all token pairs in a pool.
-	GetTakerFee(poolID uint64) ([]sqsdomain.TakerFeeForPair, error)
+	GetTakerFee(ctx context.Context, poolID uint64) ([]sqsdomain.TakerFeeForPair, error)
 	// SetTakerFees sets the taker fees for all token pairs in all pools.
 	SetTakerFees(takerFees sqsdomain.TakerFeeMap)
 	// GetPoolSpotPrice returns the spot price of a pool.
@@ -33,10 +50,29 @@ type RouterUsecase interface {
 	// Since we may cache zero routes, it returns false if the routes are not present in cache. Returns true otherwise.
 	// Returns error if cache is disabled.
 	GetCachedCandidateRoutes(ctx context.Context, tokenInDenom, tokenOutDenom string) (sqsdomain.CandidateRoutes, bool, error)
+	// DetectArbitrageAsync detects the profitable cycles over the sorted pools ingested at the given height in the background.
+	DetectArbitrageAsync(height uint64)
+	// WarmRoutesAsync recomputes and caches the routes of the hot pairs over the pools ingested at the given height
+	// in the background so that their quotes are served from a warm cache.
+	// Called before EvictCachedRoutes so that the cached routes of the hot pairs are replaced in place
+	// rather than evicted and recomputed.
+	WarmRoutesAsync(height uint64)
+	// GetArbitrage returns the profitable cycles found by the latest arbitrage detection.
+	GetArbitrage() domain.ArbitrageResult
+	// SubscribeQuote subscribes to the optimal quote for the given request.
+	// The current quote is pushed right away. Afterwards, a fresh quote is pushed after every ingested block
+	// but only if its amount out or route changed. A subscriber that falls behind only receives the latest update.
+	// The quote is computed once per block for all subscriptions with equivalent requests.
+	// The returned function unsubscribes and closes the channel.
+	// Returns error if the max quote subscriptions are live.
+	SubscribeQuote(request domain.QuoteRequest) (<-chan domain.QuoteUpdate, func(), error)
+	// PushQuoteUpdatesAsync recomputes the quotes of all subscriptions over the pools ingested at the given height
+	// in the background and pushes the changed ones.
+	PushQuoteUpdatesAsync(height uint64)
 	// StoreRoutes stores all router state in the files locally. Used for debugging.
 	StoreRouterStateFiles() error
 
-	GetRouterState() (domain.RouterState, error)
+	GetRouterState(ctx context.Context) (domain.RouterState, error)
 
 	// GetSortedPools returns the sorted pools based on the router configuration.
 	GetSortedPools() []sqsdomain.PoolI
@@ -47,4 +83,24 @@ type RouterUsecase interface {
 	// CONTRACT: the pools are already sorted according to the desired parameters.
 	// See sortPools() function.
 	SetSortedPools(pools []sqsdomain.PoolI)
+
+	// GetStateSnapshot returns the latest state snapshot.
+	GetStateSnapshot() *domain.StateSnapshot
+	// GetStateSnapshotAtHeight returns the state snapshot at the given height if it is retained.
+	// Returns domain.StateSnapshotNotRetainedError otherwise.
+	// Returns domain.StateSnapshotDroppedError if the snapshot was evicted from memory but never spilled to disk.
+	GetStateSnapshotAtHeight(height uint64) (*domain.StateSnapshot, error)
+	// StoreStateSnapshot atomically replaces the latest state snapshot with the given one.
+	// The requests that pinned the previous snapshot keep reading from it until they complete.
+	StoreStateSnapshot(snapshot *domain.StateSnapshot)
+
+	// EvictCachedRoutes evicts the cached routes made stale by the block of the latest state snapshot.
+	// The ranked routes through any of the updated or re-ranked pools are evicted while the candidate routes
+	// are only evicted through the re-ranked ones since they do not depend on the pool reserves.
+	// Both are also evicted if either of their denoms is in an added or re-ranked pool, which the routes may now go through.
+	// The cached absences of routes are evicted once the set of sorted pools changes.
+	// Afterwards, the routes computed over the previous snapshots are no longer cached except the ones
+	// kept for the warming started by WarmRoutesAsync at the same height to replace in place.
+	// Called with the changes of every block so that the cache expiry only serves as a safety net.
+	EvictCachedRoutes(updatedPoolIDs []uint64, sortedPoolsDiff domain.SortedPoolsDiff)
 }
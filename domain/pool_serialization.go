@@ -0,0 +1,112 @@
+package domain
+
+import (
+	concentratedmodel "github.com/osmosis-labs/osmosis/v25/x/concentrated-liquidity/model"
+	cosmwasmpoolmodel "github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/model"
+	"github.com/osmosis-labs/osmosis/v25/x/gamm/pool-models/balancer"
+	"github.com/osmosis-labs/osmosis/v25/x/gamm/pool-models/stableswap"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
+
+	"github.com/osmosis-labs/sqs/sqsdomain"
+	"github.com/osmosis-labs/sqs/sqsdomain/json"
+)
+
+// SerializedPool is a struct that is used to serialize a pool to JSON.
+type SerializedPool struct {
+	Type      poolmanagertypes.PoolType `json:"type"`
+	ChainPool json.RawMessage           `json:"data"`
+	SQSModel  sqsdomain.SQSPool         `json:"sqs_model"`
+	TickModel *sqsdomain.TickModel      `json:"tick_model,omitempty"`
+	// Only set for orderbook CosmWasm pools.
+	OrderbookModel *sqsdomain.OrderbookModel `json:"orderbook_model,omitempty"`
+}
+
+// MarshalPool marshals a pool to JSON.
+func MarshalPool(pool sqsdomain.PoolI) (json.RawMessage, error) {
+	poolType := pool.GetType()
+
+	underlyingPool := pool.GetUnderlyingPool()
+
+	chainPoolBz, err := json.Marshal(underlyingPool)
+	if err != nil {
+		return nil, err
+	}
+
+	var tickModel *sqsdomain.TickModel
+	if poolType == poolmanagertypes.Concentrated {
+		tickModel, err = pool.GetTickModel()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var orderbookModel *sqsdomain.OrderbookModel
+	if poolType == poolmanagertypes.CosmWasm {
+		// Not all CosmWasm pools are orderbooks. Serialize the model only if it is set.
+		orderbookModel, _ = pool.GetOrderbookModel()
+	}
+
+	serializedPool := SerializedPool{
+		Type:           poolType,
+		ChainPool:      chainPoolBz,
+		SQSModel:       pool.GetSQSPoolModel(),
+		TickModel:      tickModel,
+		OrderbookModel: orderbookModel,
+	}
+
+	poolData, err := json.Marshal(serializedPool)
+	if err != nil {
+		return nil, err
+	}
+
+	return poolData, nil
+}
+
+// UnmarshalPool unmarshals a pool from JSON.
+func UnmarshalPool(serializedPool SerializedPool) (sqsdomain.PoolI, error) {
+	var (
+		chainModel poolmanagertypes.PoolI
+	)
+
+	switch serializedPool.Type {
+	case poolmanagertypes.Concentrated:
+		var concentratedPool concentratedmodel.Pool
+		err := json.Unmarshal(serializedPool.ChainPool, &concentratedPool)
+		if err != nil {
+			return nil, err
+		}
+		chainModel = &concentratedPool
+	case poolmanagertypes.CosmWasm:
+		var transmuterPool cosmwasmpoolmodel.CosmWasmPool
+		err := json.Unmarshal(serializedPool.ChainPool, &transmuterPool)
+		if err != nil {
+			return nil, err
+		}
+		chainModel = &transmuterPool
+	case poolmanagertypes.Stableswap:
+		var balancerPool stableswap.Pool
+		err := json.Unmarshal(serializedPool.ChainPool, &balancerPool)
+		if err != nil {
+			return nil, err
+		}
+		chainModel = &balancerPool
+	case poolmanagertypes.Balancer:
+		var balancerPool balancer.Pool
+		err := json.Unmarshal(serializedPool.ChainPool, &balancerPool)
+		if err != nil {
+			return nil, err
+		}
+		chainModel = &balancerPool
+	default:
+		return nil, InvalidPoolTypeError{PoolType: int32(serializedPool.Type)}
+	}
+
+	poolWrapper := sqsdomain.PoolWrapper{
+		ChainModel:     chainModel,
+		SQSModel:       serializedPool.SQSModel,
+		TickModel:      serializedPool.TickModel,
+		OrderbookModel: serializedPool.OrderbookModel,
+	}
+
+	return &poolWrapper, nil
+}
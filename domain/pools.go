@@ -1,5 +1,7 @@
 package domain
 
+import "github.com/osmosis-labs/osmosis/osmomath"
+
 // CosmWasmPoolRouterConfig is the config for the CosmWasm pools in the router
 type CosmWasmPoolRouterConfig struct {
 	// code IDs for the transmuter pool type
@@ -8,4 +10,59 @@ type CosmWasmPoolRouterConfig struct {
 	GeneralCosmWasmCodeIDs map[uint64]struct{}
 	// node URI
 	NodeURI string
+	// native implementations of the CosmWasm pools by code ID that compute swaps without
+	// interacting with chain. Take precedence over the transmuter and generalized code IDs.
+	NativePools *CosmWasmPoolRegistry
+	// memoized generalized cosmwasm pool swap simulations of the state snapshot
+	// the routable pools are created from. Nil disables memoization.
+	SimulationCache *CosmWasmSimulationCache
+	// height of the state snapshot the routable pools are created from. The generalized cosmwasm pools
+	// query the contracts at this height so that the memoized simulations match the snapshot.
+	// Zero queries the latest height of the node.
+	Height uint64
+}
+
+// WithStateSnapshot returns a copy of the config that memoizes the simulations in the cache of the given snapshot
+// and queries the contracts at its height.
+func (c CosmWasmPoolRouterConfig) WithStateSnapshot(snapshot *StateSnapshot) CosmWasmPoolRouterConfig {
+	c.SimulationCache = snapshot.GetCosmWasmSimulationCache()
+	c.Height = snapshot.GetHeight()
+	return c
+}
+
+// ConcentratedLiquidityDepth is the distribution of the liquidity of a concentrated pool over prices.
+// The prices are of token0 denominated in token1. The prices and the token amounts are scaled
+// by the precisions of the tokens.
+type ConcentratedLiquidityDepth struct {
+	PoolID       uint64          `json:"pool_id"`
+	Token0       string          `json:"token0"`
+	Token1       string          `json:"token1"`
+	CurrentPrice osmomath.BigDec `json:"current_price"`
+	// Ranges are the price ranges of the liquidity buckets in increasing order.
+	Ranges []LiquidityDepthRange `json:"ranges"`
+	// CumulativeDepths are the token amounts available between the current price
+	// and the prices moved by the requested percentages, in the order requested.
+	CumulativeDepths []CumulativeLiquidityDepth `json:"cumulative_depths"`
+}
+
+// LiquidityDepthRange is the liquidity of a single bucket of a concentrated pool
+// together with the token amounts it holds at the current price.
+// The range below the current price holds only token1 and the range above holds only token0.
+type LiquidityDepthRange struct {
+	LowerPrice   osmomath.BigDec `json:"lower_price"`
+	UpperPrice   osmomath.BigDec `json:"upper_price"`
+	Liquidity    osmomath.Dec    `json:"liquidity"`
+	Token0Amount osmomath.Dec    `json:"token0_amount"`
+	Token1Amount osmomath.Dec    `json:"token1_amount"`
+}
+
+// CumulativeLiquidityDepth is the amount of the tokens held by the liquidity between the current price
+// and the price moved by the given percentage. Moving the price up swaps out token0 and moving it down
+// swaps out token1 so only the respective token amount is non-zero.
+type CumulativeLiquidityDepth struct {
+	// PriceChangePercentage is negative for the prices below the current price.
+	PriceChangePercentage osmomath.Dec    `json:"price_change_percentage"`
+	Price                 osmomath.BigDec `json:"price"`
+	Token0Amount          osmomath.Dec    `json:"token0_amount"`
+	Token1Amount          osmomath.Dec    `json:"token1_amount"`
 }
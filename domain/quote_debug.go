@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// quoteDebugInfoKeyType is the type of the context key for the quote debug info.
+type quoteDebugInfoKeyType struct{}
+
+// CacheLookupResult is the result of a route cache lookup.
+type CacheLookupResult string
+
+const (
+	CacheLookupHit      CacheLookupResult = "hit"
+	CacheLookupMiss     CacheLookupResult = "miss"
+	CacheLookupDisabled CacheLookupResult = "disabled"
+)
+
+// Route filters reported in the quote debug info.
+const (
+	ValidateAndFilterRoutesFilter            = "validateAndFilterRoutes"
+	FilterSharedPoolRoutesFilter             = "filterSharedPoolRoutes"
+	FilterOutGeneralizedCosmWasmRoutesFilter = "filterOutGeneralizedCosmWasmPoolRoutes"
+)
+
+// Selected quote types reported in the quote debug info.
+const (
+	SingleRouteQuoteSelected = "single_route"
+	SplitQuoteSelected       = "split"
+)
+
+// QuoteDebugInfo explains how a quote was computed.
+// It is collected only if attached to the request context with WithQuoteDebugInfo.
+// All methods are no-ops on a nil receiver so that the collection points do not
+// have to check whether debugging is enabled.
+// QuoteDebugInfo is not safe for concurrent use.
+type QuoteDebugInfo struct {
+	// Route cache lookups in the order they were made.
+	CacheLookups []CacheLookupDebugInfo `json:"cache_lookups"`
+	// Candidate routes that were ranked by their direct quotes.
+	CandidateRoutes []sqsdomain.CandidateRoute `json:"candidate_routes"`
+	// Routes that were filtered out together with the filter and the reason.
+	FilteredRoutes []FilteredRouteDebugInfo `json:"filtered_routes"`
+	// Direct quotes of the candidate routes in the ranked order.
+	RankedRoutes []RankedRouteDebugInfo `json:"ranked_routes"`
+	// Outcome of the split computation. Nil if splits were not computed.
+	Split *SplitDebugInfo `json:"split,omitempty"`
+	// Either single_route or split. Empty if the quote failed.
+	SelectedQuote string `json:"selected_quote,omitempty"`
+}
+
+// CacheLookupDebugInfo is a single route cache lookup.
+type CacheLookupDebugInfo struct {
+	Cache  string            `json:"cache"`
+	Key    string            `json:"key"`
+	Result CacheLookupResult `json:"result"`
+}
+
+// FilteredRouteDebugInfo is a route that was filtered out.
+type FilteredRouteDebugInfo struct {
+	Route  sqsdomain.CandidateRoute `json:"route"`
+	Filter string                   `json:"filter"`
+	Reason string                   `json:"reason"`
+}
+
+// RankedRouteDebugInfo is the direct quote of a candidate route.
+type RankedRouteDebugInfo struct {
+	Route     sqsdomain.CandidateRoute `json:"route"`
+	AmountOut osmomath.Int             `json:"amount_out"`
+	// Error of the direct quote. The amount out is zero if set.
+	Error string `json:"error,omitempty"`
+}
+
+// SplitDebugInfo is the outcome of the dynamic programming split computation.
+type SplitDebugInfo struct {
+	Routes []sqsdomain.CandidateRoute `json:"routes"`
+	// Number of increments of the amount in allocated to the route at the same index.
+	RouteIncrements []int `json:"route_increments"`
+	// Number of increments the amount in is divided into.
+	TotalIncrements int          `json:"total_increments"`
+	AmountOut       osmomath.Int `json:"amount_out"`
+	// Error of the split computation. The amount out is zero if set.
+	Error string `json:"error,omitempty"`
+	// Outcome of the refinement of the split over the increments in the adaptive mode.
+	// Nil if the split was not refined.
+	Refinement *SplitRefinementDebugInfo `json:"refinement,omitempty"`
+}
+
+// SplitRefinementDebugInfo is the outcome of the local search refining the split over the increments.
+type SplitRefinementDebugInfo struct {
+	// Amount in allocated to the route at the same index as in the split.
+	RouteAmountsIn []osmomath.Int `json:"route_amounts_in"`
+	AmountOut      osmomath.Int   `json:"amount_out"`
+	// Number of iterations performed until the search ran out of steps, iterations or time.
+	Iterations int `json:"iterations"`
+	// True if the refined split improves on the split over the increments and is selected instead.
+	Improved bool `json:"improved"`
+	// Error of the refinement. The split over the increments is kept if set.
+	Error string `json:"error,omitempty"`
+}
+
+// WithQuoteDebugInfo returns the context with new empty quote debug info attached to be collected
+// during the quote computation.
+func WithQuoteDebugInfo(ctx context.Context) (context.Context, *QuoteDebugInfo) {
+	debugInfo := &QuoteDebugInfo{
+		CacheLookups:    []CacheLookupDebugInfo{},
+		CandidateRoutes: []sqsdomain.CandidateRoute{},
+		FilteredRoutes:  []FilteredRouteDebugInfo{},
+		RankedRoutes:    []RankedRouteDebugInfo{},
+	}
+
+	return context.WithValue(ctx, quoteDebugInfoKeyType{}, debugInfo), debugInfo
+}
+
+// GetQuoteDebugInfoFromContext returns the quote debug info attached to the context.
+// Returns nil if debugging is not enabled.
+func GetQuoteDebugInfoFromContext(ctx context.Context) *QuoteDebugInfo {
+	debugInfo, _ := ctx.Value(quoteDebugInfoKeyType{}).(*QuoteDebugInfo)
+	return debugInfo
+}
+
+// AddCacheLookup records the lookup of the given key in the given route cache.
+func (d *QuoteDebugInfo) AddCacheLookup(cache, key string, result CacheLookupResult) {
+	if d == nil {
+		return
+	}
+
+	d.CacheLookups = append(d.CacheLookups, CacheLookupDebugInfo{
+		Cache:  cache,
+		Key:    key,
+		Result: result,
+	})
+}
+
+// SetCandidateRoutes records the candidate routes that are ranked by their direct quotes.
+func (d *QuoteDebugInfo) SetCandidateRoutes(routes []sqsdomain.CandidateRoute) {
+	if d == nil {
+		return
+	}
+
+	d.CandidateRoutes = routes
+}
+
+// AddFilteredRoute records the route filtered out by the given filter for the given reason.
+func (d *QuoteDebugInfo) AddFilteredRoute(route sqsdomain.CandidateRoute, filter, reason string) {
+	if d == nil {
+		return
+	}
+
+	d.FilteredRoutes = append(d.FilteredRoutes, FilteredRouteDebugInfo{
+		Route:  route,
+		Filter: filter,
+		Reason: reason,
+	})
+}
+
+// SetRankedRoutes records the direct quotes of the candidate routes in the ranked order.
+func (d *QuoteDebugInfo) SetRankedRoutes(rankedRoutes []RankedRouteDebugInfo) {
+	if d == nil {
+		return
+	}
+
+	d.RankedRoutes = rankedRoutes
+}
+
+// SetSplit records the outcome of the split computation.
+func (d *QuoteDebugInfo) SetSplit(split SplitDebugInfo) {
+	if d == nil {
+		return
+	}
+
+	d.Split = &split
+}
+
+// SetSelectedQuote records the type of the selected quote.
+func (d *QuoteDebugInfo) SetSelectedQuote(selectedQuote string) {
+	if d == nil {
+		return
+	}
+
+	d.SelectedQuote = selectedQuote
+}
@@ -2,16 +2,80 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/osmosis-labs/sqs/sqsdomain"
 
 	"github.com/osmosis-labs/osmosis/osmomath"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
 )
 
 type RoutableResultPool interface {
 	sqsdomain.RoutablePool
 	GetBalances() sdk.Coins
+	// GetSwapBreakdown returns the breakdown of the swap over the pool.
+	// Returns nil if the breakdown is not computed.
+	GetSwapBreakdown() *PoolSwapBreakdown
+}
+
+// BatchRoutablePool is implemented by the routable pools that estimate the swaps of multiple
+// token in amounts at once faster than one by one. For example, the generalized cosmwasm pools
+// that query chain concurrently.
+type BatchRoutablePool interface {
+	// CalculateTokenOutByTokenInBatch calculates the token out amount for each of the given token in amounts.
+	// Returns the token outs and the errors at the same indexes as the token ins.
+	CalculateTokenOutByTokenInBatch(ctx context.Context, tokensIn []sdk.Coin) ([]sdk.Coin, []error)
+}
+
+// TickCrossingRoutablePool is implemented by the routable pools whose swap gas grows
+// with the number of initialized ticks crossed. For example, the concentrated liquidity pools.
+type TickCrossingRoutablePool interface {
+	// CalculateTokenOutByTokenInWithTicksCrossed calculates the token out amount for the given token in
+	// and the number of initialized ticks crossed by the swap.
+	CalculateTokenOutByTokenInWithTicksCrossed(ctx context.Context, tokenIn sdk.Coin) (sdk.Coin, uint64, error)
+	// CalculateTokenInByTokenOutWithTicksCrossed calculates the token in amount required to receive the given token out
+	// and the number of initialized ticks crossed by the swap.
+	CalculateTokenInByTokenOutWithTicksCrossed(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, uint64, error)
+	// SwapOutGivenInWithTicksCrossed is similar to StatefulRoutablePool.SwapOutGivenIn
+	// but also returns the number of initialized ticks crossed by the swap.
+	SwapOutGivenInWithTicksCrossed(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (sdk.Coin, uint64, StatefulRoutablePool, error)
+}
+
+// StatefulRoutablePool is implemented by the routable pools that simulate the state of the pool after a swap.
+// It allows the routes that share a pool to be simulated jointly, each swapping over the state
+// of the pool left by the swaps of the previous routes.
+type StatefulRoutablePool interface {
+	// SwapOutGivenIn calculates the token out amount in the given denom for the given token in
+	// and returns the pool with the state after the swap. The receiver is not mutated.
+	SwapOutGivenIn(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (sdk.Coin, StatefulRoutablePool, error)
+}
+
+// SpreadFeeRoutablePool is implemented by the routable pools that do not charge the spread factor
+// from the token in. For example, the pools that charge it from the token out.
+type SpreadFeeRoutablePool interface {
+	// CalculateSpreadFeeCharged returns the spread fee charged by swapping the given token in after the taker fee.
+	CalculateSpreadFeeCharged(ctx context.Context, tokenIn sdk.Coin) (sdk.Coin, error)
+}
+
+// PoolSwapBreakdown is the breakdown of the swap over a single pool in a route.
+// It explains how the amount out of the hop is derived from its amount in.
+type PoolSwapBreakdown struct {
+	// Amount swapped into the pool prior to charging the taker fee.
+	AmountIn sdk.Coin `json:"amount_in"`
+	// Amount swapped out of the pool.
+	AmountOut sdk.Coin `json:"amount_out"`
+	// Taker fee charged from the amount in.
+	TakerFeeCharged sdk.Coin `json:"taker_fee_charged"`
+	// Spread fee charged from the amount in after the taker fee. For the pools implementing
+	// SpreadFeeRoutablePool, the spread fee they charge, which may be in the token out denom.
+	SpreadFeeCharged sdk.Coin `json:"spread_fee_charged"`
+	// Spot price of the pool before the swap with token in as base and token out as quote.
+	// Zero if it fails to be computed.
+	SpotPriceBefore osmomath.Dec `json:"spot_price_before"`
+	// Spot price of the pool state after the swap with token in as base and token out as quote.
+	// Zero if the pool does not expose its state after the swap or if the spot price fails to be computed.
+	SpotPriceAfter osmomath.Dec `json:"spot_price_after"`
 }
 
 type Route interface {
@@ -25,7 +89,11 @@ type Route interface {
 	// CalculateTokenOutByTokenIn calculates the token out amount given the token in amount.
 	// Returns error if the calculation fails.
 	CalculateTokenOutByTokenIn(ctx context.Context, tokenIn sdk.Coin) (sdk.Coin, error)
+	// CalculateTokenInByTokenOut calculates the token in amount required to receive the given token out amount.
+	// Returns error if the calculation fails.
+	CalculateTokenInByTokenOut(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error)
 
+	GetTokenInDenom() string
 	GetTokenOutDenom() string
 
 	// PrepareResultPools strips away unnecessary fields
@@ -36,7 +104,10 @@ type Route interface {
 	// Computes the spot price of the route.
 	// Returns the spot price before swap and effective spot price.
 	// The token in is the base token and the token out is the quote token.
-	PrepareResultPools(ctx context.Context, tokenIn sdk.Coin) ([]sqsdomain.RoutablePool, osmomath.Dec, osmomath.Dec, error)
+	// The pools found in poolStates by ID are swapped over in that state and poolStates is updated
+	// with the states after the swaps of the stateful pools. That way, the routes sharing pools are
+	// prepared the same way they are simulated jointly. Nil poolStates swaps over the original pools.
+	PrepareResultPools(ctx context.Context, tokenIn sdk.Coin, poolStates map[uint64]StatefulRoutablePool) ([]sqsdomain.RoutablePool, osmomath.Dec, osmomath.Dec, error)
 
 	String() string
 }
@@ -45,6 +116,8 @@ type SplitRoute interface {
 	Route
 	GetAmountIn() osmomath.Int
 	GetAmountOut() osmomath.Int
+	// GetEstimatedGas returns the estimated gas of swapping the amount in over the route.
+	GetEstimatedGas() uint64
 }
 
 type Quote interface {
@@ -53,6 +126,8 @@ type Quote interface {
 	GetRoute() []SplitRoute
 	GetEffectiveSpreadFactor() osmomath.Dec
 	GetPriceImpact() osmomath.Dec
+	// GetEstimatedGas returns the estimated gas of executing the quote across all routes.
+	GetEstimatedGas() uint64
 
 	// PrepareResult mutates the quote to prepare
 	// it with the data formatted for output to the client.
@@ -79,11 +154,71 @@ type RouterConfig struct {
 	RankedRouteCacheExpirySeconds    int `mapstructure:"ranked-route-cache-expiry-seconds"`
 	// Flag indicating whether we should have a cache for overwrite routes enabled.
 	EnableOverwriteRoutesCache bool `mapstructure:"enable-overwrite-routes-cache"`
+	// The relative drift of the total TVL across all pools after which all pools are re-rated on ingest.
+	// For example, 0.05 re-rates all pools once the total TVL changes by more than 5%.
+	TVLBoostDriftThreshold float64 `mapstructure:"tvl-boost-drift-threshold"`
+	// The maximum number of quotes computed concurrently for a single batch quote request.
+	MaxBatchQuoteWorkers int `mapstructure:"max-batch-quote-workers"`
+	// The maximum number of live quote subscriptions. Subscribing beyond it is rejected. Zero means no limit.
+	MaxQuoteSubscriptions int `mapstructure:"max-quote-subscriptions"`
+	// Flag indicating whether profitable cycles over the pools are detected after every ingested block.
+	ArbitrageDetectionEnabled bool `mapstructure:"arbitrage-detection-enabled"`
+	// Flag indicating whether the routes containing generalized cosmwasm pools are included in split quotes.
+	// Their simulations are memoized per block but every split increment that is not memoized costs
+	// a query to chain. Disabled by default.
+	GeneralizedCosmWasmPoolSplitsEnabled bool `mapstructure:"generalized-cosmwasm-pool-splits-enabled"`
+	// Flag indicating whether the split found over 10% increments of the amount in is refined further
+	// by moving finer steps of the amount in between the routes. MaxSplitIterations bounds the number
+	// of refinement iterations, each of which either moves a step or halves it.
+	AdaptiveSplitsEnabled bool `mapstructure:"adaptive-splits-enabled"`
+	// The gas table used to estimate the gas of executing quotes.
+	Gas GasConfig `mapstructure:"gas"`
+	// The pairs whose routes are recomputed in the background after every ingested block.
+	RouteWarming RouteWarmingConfig `mapstructure:"route-warming"`
+}
+
+// RouteWarmingConfig defines the pairs whose candidate and ranked routes are recomputed
+// after every ingested block so that their quotes never miss the route cache.
+type RouteWarmingConfig struct {
+	// Pairs warmed for every order of magnitude below.
+	HotPairs []HotPair `mapstructure:"hot-pairs"`
+	// Orders of magnitude of the token in amount of the hot pairs to warm the ranked routes for.
+	// For example, 6 warms the routes cached for amounts from 10^6 to 10^7 - 1.
+	OrdersOfMagnitude []int `mapstructure:"orders-of-magnitude"`
+	// Maximum number of pairs and orders of magnitude learned from the most frequent ranked route
+	// cache lookups warmed in addition to the hot pairs. Zero disables learning.
+	MaxLearnedPairs int `mapstructure:"max-learned-pairs"`
+}
+
+// HotPair is a pair of token in and token out denoms with a high quote volume.
+type HotPair struct {
+	TokenInDenom  string `mapstructure:"token-in-denom"`
+	TokenOutDenom string `mapstructure:"token-out-denom"`
+}
+
+// GasConfig is the estimated gas of swapping over a single pool by pool type.
+// The gas of a route is the sum over its pools plus the per-hop overhead for every pool.
+type GasConfig struct {
+	// Overhead of every hop of a route regardless of the pool type.
+	PerHop     uint64 `mapstructure:"per-hop"`
+	Balancer   uint64 `mapstructure:"balancer"`
+	Stableswap uint64 `mapstructure:"stableswap"`
+	// Gas of a concentrated liquidity swap that does not cross any initialized tick.
+	Concentrated uint64 `mapstructure:"concentrated"`
+	// Additional gas of a concentrated liquidity swap for every initialized tick crossed.
+	ConcentratedPerTickCrossed uint64 `mapstructure:"concentrated-per-tick-crossed"`
+	// Gas of a transmuter swap. Also applies to the other cosmwasm pools that are not generalized.
+	Transmuter uint64 `mapstructure:"transmuter"`
+	// Gas of a generalized cosmwasm pool swap.
+	GeneralizedCosmWasm uint64 `mapstructure:"generalized-cosmwasm"`
 }
 
 type PoolsConfig struct {
 	TransmuterCodeIDs      []uint64 `mapstructure:"transmuter-code-ids"`
 	GeneralCosmWasmCodeIDs []uint64 `mapstructure:"general-cosmwasm-code-ids"`
+	// Code IDs of the CosmWasm pools computed by native implementations keyed by the implementation name.
+	// For example, {"constant-product": [773]}.
+	NativeCosmWasmCodeIDs map[string][]uint64 `mapstructure:"native-cosmwasm-code-ids"`
 }
 
 const DisableSplitRoutes = 0
@@ -109,6 +244,74 @@ type RouterOptions struct {
 	// The number of milliseconds to cache candidate routes for before expiry.
 	CandidateRouteCacheExpirySeconds int
 	RankedRouteCacheExpirySeconds    int
+	// Filters applied to the pools and denoms when searching for candidate routes.
+	CandidateRouteFilters CandidateRouteFilters
+	// The time budget of the quote. Once exceeded, the best quote found so far is returned
+	// flagged as truncated. Zero means no budget.
+	MaxLatency time.Duration
+	// The price of a unit of gas denominated in the token out.
+	// If set, the split is selected by the amount out net of the estimated gas cost.
+	// Nil selects the split by the gross amount out.
+	GasPriceInTokenOut osmomath.Dec
+}
+
+// CandidateRouteFilters defines the pool and denom filters applied when searching for candidate routes.
+// The zero value applies no filtering.
+type CandidateRouteFilters struct {
+	// Pools with these IDs are never used for routing.
+	ExcludedPoolIDs map[uint64]struct{}
+	// If non-empty, only pools with these IDs are used for routing.
+	AllowedPoolIDs map[uint64]struct{}
+	// If non-empty, only pools of these types are used for routing.
+	AllowedPoolTypes map[poolmanagertypes.PoolType]struct{}
+	// These denoms are never used as intermediary hops in a route.
+	// They may still be the token in or the token out denom.
+	ExcludedDenoms map[string]struct{}
+	// If non-empty, the routes with intermediary hops only in these denoms are searched first.
+	// The other routes are only used for the remaining candidate routes, if any.
+	PreferredDenoms map[string]struct{}
+	// If positive, the max pools per route that differs from the default config.
+	// The routes searched with a different max pools per route are cached separately.
+	MaxPoolsPerRoute int
+}
+
+// IsEmpty returns true if no filters are set.
+func (f CandidateRouteFilters) IsEmpty() bool {
+	return len(f.ExcludedPoolIDs) == 0 && len(f.AllowedPoolIDs) == 0 && len(f.AllowedPoolTypes) == 0 && len(f.ExcludedDenoms) == 0 &&
+		len(f.PreferredDenoms) == 0 && f.MaxPoolsPerRoute == 0
+}
+
+// IsPoolAllowed returns true if the pool with the given ID and type passes the pool filters.
+func (f CandidateRouteFilters) IsPoolAllowed(poolID uint64, poolType poolmanagertypes.PoolType) bool {
+	if _, ok := f.ExcludedPoolIDs[poolID]; ok {
+		return false
+	}
+
+	if len(f.AllowedPoolIDs) > 0 {
+		if _, ok := f.AllowedPoolIDs[poolID]; !ok {
+			return false
+		}
+	}
+
+	if len(f.AllowedPoolTypes) > 0 {
+		if _, ok := f.AllowedPoolTypes[poolType]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsDenomExcluded returns true if the given denom must not be used as an intermediary hop.
+func (f CandidateRouteFilters) IsDenomExcluded(denom string) bool {
+	_, ok := f.ExcludedDenoms[denom]
+	return ok
+}
+
+// IsDenomPreferred returns true if the routes through the given denom as an intermediary hop are searched first.
+func (f CandidateRouteFilters) IsDenomPreferred(denom string) bool {
+	_, ok := f.PreferredDenoms[denom]
+	return ok
 }
 
 // DefaultRouterOptions defines the default options for the router
@@ -149,3 +352,115 @@ func WithMaxSplitRoutes(maxSplitRoutes int) RouterOption {
 		o.MaxSplitRoutes = maxSplitRoutes
 	}
 }
+
+// WithExcludedPoolIDs configures the router options to never route through the given pool IDs.
+func WithExcludedPoolIDs(poolIDs ...uint64) RouterOption {
+	return func(o *RouterOptions) {
+		if o.CandidateRouteFilters.ExcludedPoolIDs == nil {
+			o.CandidateRouteFilters.ExcludedPoolIDs = make(map[uint64]struct{}, len(poolIDs))
+		}
+		for _, poolID := range poolIDs {
+			o.CandidateRouteFilters.ExcludedPoolIDs[poolID] = struct{}{}
+		}
+	}
+}
+
+// WithAllowedPoolIDs configures the router options to only route through the given pool IDs.
+func WithAllowedPoolIDs(poolIDs ...uint64) RouterOption {
+	return func(o *RouterOptions) {
+		if o.CandidateRouteFilters.AllowedPoolIDs == nil {
+			o.CandidateRouteFilters.AllowedPoolIDs = make(map[uint64]struct{}, len(poolIDs))
+		}
+		for _, poolID := range poolIDs {
+			o.CandidateRouteFilters.AllowedPoolIDs[poolID] = struct{}{}
+		}
+	}
+}
+
+// WithAllowedPoolTypes configures the router options to only route through pools of the given types.
+func WithAllowedPoolTypes(poolTypes ...poolmanagertypes.PoolType) RouterOption {
+	return func(o *RouterOptions) {
+		if o.CandidateRouteFilters.AllowedPoolTypes == nil {
+			o.CandidateRouteFilters.AllowedPoolTypes = make(map[poolmanagertypes.PoolType]struct{}, len(poolTypes))
+		}
+		for _, poolType := range poolTypes {
+			o.CandidateRouteFilters.AllowedPoolTypes[poolType] = struct{}{}
+		}
+	}
+}
+
+// WithExcludedDenoms configures the router options to never use the given denoms as intermediary hops.
+func WithExcludedDenoms(denoms ...string) RouterOption {
+	return func(o *RouterOptions) {
+		if o.CandidateRouteFilters.ExcludedDenoms == nil {
+			o.CandidateRouteFilters.ExcludedDenoms = make(map[string]struct{}, len(denoms))
+		}
+		for _, denom := range denoms {
+			o.CandidateRouteFilters.ExcludedDenoms[denom] = struct{}{}
+		}
+	}
+}
+
+// WithPreferredDenoms configures the router options to search the routes with intermediary hops
+// only in the given denoms first.
+func WithPreferredDenoms(denoms ...string) RouterOption {
+	return func(o *RouterOptions) {
+		if o.CandidateRouteFilters.PreferredDenoms == nil {
+			o.CandidateRouteFilters.PreferredDenoms = make(map[string]struct{}, len(denoms))
+		}
+		for _, denom := range denoms {
+			o.CandidateRouteFilters.PreferredDenoms[denom] = struct{}{}
+		}
+	}
+}
+
+// WithMaxLatency configures the router options with the time budget of the quote.
+func WithMaxLatency(maxLatency time.Duration) RouterOption {
+	return func(o *RouterOptions) {
+		o.MaxLatency = maxLatency
+	}
+}
+
+// WithGasPrice configures the router options to select the split by the amount out net of the estimated gas cost.
+// gasPrice is the price of a unit of gas in the fee denom and feeDenomPrice is the price of the fee denom
+// denominated in the token out.
+func WithGasPrice(gasPrice, feeDenomPrice osmomath.Dec) RouterOption {
+	return func(o *RouterOptions) {
+		o.GasPriceInTokenOut = gasPrice.Mul(feeDenomPrice)
+	}
+}
+
+// QuoteRequest is a single request in a batch of optimal quotes.
+type QuoteRequest struct {
+	TokenIn       sdk.Coin
+	TokenOutDenom string
+	Options       []RouterOption
+}
+
+// QuoteResult is the result of a single request in a batch of optimal quotes.
+// Exactly one of Quote and Err is set.
+type QuoteResult struct {
+	Quote Quote
+	Err   error
+}
+
+// SortedPoolsDiff is the change of the pools sorted for routing made by a block.
+type SortedPoolsDiff struct {
+	// IDs of the pools that were added, removed or whose neighbours in the sorted order changed.
+	RankChangedPoolIDs []uint64
+	// Denoms of the pools that were added or whose neighbours in the sorted order changed without duplicates.
+	// The routes between them may now go through these pools even if they do not depend on them yet.
+	RankChangedDenoms []string
+	// True if any pool was added to or removed from the sorted pools.
+	IsPoolSetChanged bool
+}
+
+// QuoteUpdate is a quote pushed to a quote subscription.
+// Exactly one of Quote and Err is set.
+type QuoteUpdate struct {
+	// Height of the block after which the quote was computed.
+	// Zero if no block has been ingested since the start.
+	Height uint64
+	Quote  Quote
+	Err    error
+}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/osmosis-labs/osmosis/osmomath"
 	"github.com/osmosis-labs/sqs/domain"
 	"github.com/stretchr/testify/require"
 )
@@ -39,3 +40,109 @@ func TestParseNumbers(t *testing.T) {
 		}
 	}
 }
+
+// TestParseAmounts tests parsing a string of amounts to a slice of osmomath.Int
+func TestParseAmounts(t *testing.T) {
+	testCases := map[string]struct {
+		input           string
+		expectedAmounts []osmomath.Int
+		expectedError   bool
+	}{
+		"empty string":      {input: ""},
+		"single amount":     {input: "42", expectedAmounts: []osmomath.Int{osmomath.NewInt(42)}},
+		"multiple amounts":  {input: "1, 20,300", expectedAmounts: []osmomath.Int{osmomath.NewInt(1), osmomath.NewInt(20), osmomath.NewInt(300)}},
+		"exceeds uint64":    {input: "100000000000000000000000", expectedAmounts: []osmomath.Int{osmomath.NewIntWithDecimal(1, 23)}},
+		"invalid amount":    {input: "1,abc", expectedError: true},
+		"zero amount":       {input: "0", expectedError: true},
+		"negative amount":   {input: "-1", expectedError: true},
+		"fractional amount": {input: "1.5", expectedError: true},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			actualAmounts, err := domain.ParseAmounts(tc.input)
+
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedAmounts, actualAmounts)
+		})
+	}
+}
+
+// TestParsePositiveDecs tests parsing a string of decimals to a slice of osmomath.Dec
+func TestParsePositiveDecs(t *testing.T) {
+	testCases := map[string]struct {
+		input         string
+		expectedDecs  []osmomath.Dec
+		expectedError bool
+	}{
+		"empty string":      {input: ""},
+		"multiple decimals": {input: "1, 2.5,50", expectedDecs: []osmomath.Dec{osmomath.NewDec(1), osmomath.MustNewDecFromStr("2.5"), osmomath.NewDec(50)}},
+		"invalid decimal":   {input: "1,abc", expectedError: true},
+		"zero decimal":      {input: "0", expectedError: true},
+		"negative decimal":  {input: "-1.5", expectedError: true},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			actualDecs, err := domain.ParsePositiveDecs(tc.input)
+
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedDecs, actualDecs)
+		})
+	}
+}
+
+// TestGetLogSpacedAmounts tests that the amounts are evenly spaced on a log scale with both ends included.
+func TestGetLogSpacedAmounts(t *testing.T) {
+	testCases := map[string]struct {
+		minAmount        osmomath.Int
+		numDecades       int
+		amountsPerDecade int
+
+		expectedAmounts []osmomath.Int
+	}{
+		"one amount per decade": {
+			minAmount:        osmomath.NewInt(1_000_000),
+			numDecades:       3,
+			amountsPerDecade: 1,
+
+			expectedAmounts: []osmomath.Int{osmomath.NewInt(1_000_000), osmomath.NewInt(10_000_000), osmomath.NewInt(100_000_000), osmomath.NewInt(1_000_000_000)},
+		},
+		"two amounts per decade": {
+			minAmount:        osmomath.NewInt(1_000_000),
+			numDecades:       2,
+			amountsPerDecade: 2,
+
+			// 10^0.5 = 3.162278 and 10^1.5 = 31.622777 rounded to 6 decimals
+			expectedAmounts: []osmomath.Int{osmomath.NewInt(1_000_000), osmomath.NewInt(3_162_278), osmomath.NewInt(10_000_000), osmomath.NewInt(31_622_777), osmomath.NewInt(100_000_000)},
+		},
+		"zero decades": {
+			minAmount:        osmomath.NewInt(5),
+			numDecades:       0,
+			amountsPerDecade: 4,
+
+			expectedAmounts: []osmomath.Int{osmomath.NewInt(5)},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			actualAmounts := domain.GetLogSpacedAmounts(tc.minAmount, tc.numDecades, tc.amountsPerDecade)
+
+			require.Equal(t, tc.expectedAmounts, actualAmounts)
+		})
+	}
+}
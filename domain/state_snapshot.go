@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// stateSnapshotKeyType is the type of the context key for the state snapshot.
+type stateSnapshotKeyType struct{}
+
+// HeightHeader is the response header with the height of the state snapshot the response was computed from.
+const HeightHeader = "X-Block-Height"
+
+// StateHistoryConfig defines the retention of the state snapshots of the past blocks.
+type StateHistoryConfig struct {
+	// Number of the latest block snapshots kept in memory including the latest one.
+	Size int `mapstructure:"size"`
+	// Directory the snapshots evicted from memory are written to. The spill is disabled if empty.
+	SpillDir string `mapstructure:"spill-dir"`
+	// Number of the snapshots kept in the spill directory. The oldest ones are removed. Zero keeps all.
+	SpillSize int `mapstructure:"spill-size"`
+}
+
+// StateSnapshot is an immutable view of the ingested state after the block at a given height.
+// That is, the pools together with the tick models of the concentrated pools, the taker fees
+// and the pools sorted for routing.
+//
+// A new snapshot is built for every block and swapped in atomically once complete
+// so that the readers never observe a partially ingested block. Readers that make several
+// lookups pin a single snapshot with WithStateSnapshot.
+//
+// CONTRACT: neither the snapshot nor the pools in it are mutated once created.
+// All updates return a new snapshot that shares the unchanged data with the original one.
+type StateSnapshot struct {
+	height uint64
+	// pool ID -> pool.
+	pools     map[uint64]sqsdomain.PoolI
+	takerFees sqsdomain.TakerFeeMap
+	// Pools valid for routing in the order defined by the router.
+	sortedPools []sqsdomain.PoolI
+	// Generalized cosmwasm pool swap simulations made against the pools of the snapshot.
+	cosmWasmSimulationCache *CosmWasmSimulationCache
+}
+
+// NewStateSnapshot returns a new state snapshot at the given height.
+// CONTRACT: the given maps and slice are not mutated afterwards.
+func NewStateSnapshot(height uint64, pools map[uint64]sqsdomain.PoolI, takerFees sqsdomain.TakerFeeMap, sortedPools []sqsdomain.PoolI) *StateSnapshot {
+	if pools == nil {
+		pools = map[uint64]sqsdomain.PoolI{}
+	}
+	if takerFees == nil {
+		takerFees = sqsdomain.TakerFeeMap{}
+	}
+	if sortedPools == nil {
+		sortedPools = []sqsdomain.PoolI{}
+	}
+
+	return &StateSnapshot{
+		height:      height,
+		pools:       pools,
+		takerFees:   takerFees,
+		sortedPools: sortedPools,
+
+		cosmWasmSimulationCache: NewCosmWasmSimulationCache(),
+	}
+}
+
+// WithStateSnapshot returns the context with the given state snapshot pinned
+// so that all reads made with the context observe the same state.
+func WithStateSnapshot(ctx context.Context, snapshot *StateSnapshot) context.Context {
+	return context.WithValue(ctx, stateSnapshotKeyType{}, snapshot)
+}
+
+// GetStateSnapshotFromContext returns the state snapshot pinned to the context.
+// Returns nil if none is pinned.
+func GetStateSnapshotFromContext(ctx context.Context) *StateSnapshot {
+	snapshot, _ := ctx.Value(stateSnapshotKeyType{}).(*StateSnapshot)
+	return snapshot
+}
+
+// GetHeight returns the height of the block after which the snapshot was taken.
+// Zero if no block has been ingested.
+func (s *StateSnapshot) GetHeight() uint64 {
+	return s.height
+}
+
+// GetPool returns the pool with the given ID.
+// Returns PoolNotFoundError if the pool is not present.
+func (s *StateSnapshot) GetPool(poolID uint64) (sqsdomain.PoolI, error) {
+	pool, ok := s.pools[poolID]
+	if !ok {
+		return nil, PoolNotFoundError{PoolID: poolID}
+	}
+	return pool, nil
+}
+
+// GetAllPools returns all pools in no particular order.
+func (s *StateSnapshot) GetAllPools() []sqsdomain.PoolI {
+	pools := make([]sqsdomain.PoolI, 0, len(s.pools))
+	for _, pool := range s.pools {
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+// GetTakerFee returns the taker fee for the given pair of denoms in any order.
+// Returns false if the taker fee is not present.
+func (s *StateSnapshot) GetTakerFee(denom0, denom1 string) (osmomath.Dec, bool) {
+	// Ensure increasing lexicographic order.
+	if denom1 < denom0 {
+		denom0, denom1 = denom1, denom0
+	}
+
+	takerFee, ok := s.takerFees[sqsdomain.DenomPair{Denom0: denom0, Denom1: denom1}]
+	return takerFee, ok
+}
+
+// GetAllTakerFees returns a copy of all taker fees.
+func (s *StateSnapshot) GetAllTakerFees() sqsdomain.TakerFeeMap {
+	takerFees := make(sqsdomain.TakerFeeMap, len(s.takerFees))
+	for denomPair, takerFee := range s.takerFees {
+		takerFees[denomPair] = takerFee
+	}
+	return takerFees
+}
+
+// GetSortedPools returns the pools valid for routing in the order defined by the router.
+// CONTRACT: the caller does not mutate the returned slice.
+func (s *StateSnapshot) GetSortedPools() []sqsdomain.PoolI {
+	return s.sortedPools
+}
+
+// GetCosmWasmSimulationCache returns the cache of the generalized cosmwasm pool swap simulations
+// made against the pools of the snapshot.
+func (s *StateSnapshot) GetCosmWasmSimulationCache() *CosmWasmSimulationCache {
+	return s.cosmWasmSimulationCache
+}
+
+// WithHeight returns a copy of the snapshot at the given height.
+// The copy does not share the cosmwasm pool swap simulations since the chain state differs between heights.
+func (s *StateSnapshot) WithHeight(height uint64) *StateSnapshot {
+	snapshot := *s
+	snapshot.height = height
+	snapshot.cosmWasmSimulationCache = NewCosmWasmSimulationCache()
+	return &snapshot
+}
+
+// WithPools returns a copy of the snapshot with the given pools added or replacing the ones with the same IDs.
+// The copy does not share the cosmwasm pool swap simulations.
+func (s *StateSnapshot) WithPools(pools []sqsdomain.PoolI) *StateSnapshot {
+	snapshot := *s
+	snapshot.cosmWasmSimulationCache = NewCosmWasmSimulationCache()
+	snapshot.pools = make(map[uint64]sqsdomain.PoolI, len(s.pools)+len(pools))
+	for poolID, pool := range s.pools {
+		snapshot.pools[poolID] = pool
+	}
+	for _, pool := range pools {
+		snapshot.pools[pool.GetId()] = pool
+	}
+	return &snapshot
+}
+
+// WithTakerFees returns a copy of the snapshot with the given taker fees added or replacing the ones for the same denom pairs.
+// The denoms of every pair are sorted lexicographically.
+func (s *StateSnapshot) WithTakerFees(takerFees sqsdomain.TakerFeeMap) *StateSnapshot {
+	snapshot := *s
+	snapshot.takerFees = s.GetAllTakerFees()
+	for denomPair, takerFee := range takerFees {
+		// Ensure increasing lexicographic order.
+		if denomPair.Denom1 < denomPair.Denom0 {
+			denomPair.Denom0, denomPair.Denom1 = denomPair.Denom1, denomPair.Denom0
+		}
+		snapshot.takerFees[denomPair] = takerFee
+	}
+	return &snapshot
+}
+
+// WithSortedPools returns a copy of the snapshot with the given sorted pools.
+// CONTRACT: the given slice is not mutated afterwards.
+func (s *StateSnapshot) WithSortedPools(sortedPools []sqsdomain.PoolI) *StateSnapshot {
+	snapshot := *s
+	snapshot.sortedPools = sortedPools
+	return &snapshot
+}
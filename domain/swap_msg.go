@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
+)
+
+// MaxSlippageBps is the maximum slippage tolerance in basis points (100%).
+const MaxSlippageBps = 10_000
+
+// NewSwapMsg returns the poolmanager swap message that executes the given quote on behalf of the sender.
+// Returns MsgSwapExactAmountIn for single route quotes and MsgSplitRouteSwapExactAmountIn for split quotes.
+// Every split route swaps in exactly its quoted amount in. Due to truncation, the sum of those amounts
+// may be slightly smaller than the quote amount in.
+// The token out min amount is the quote amount out reduced by the slippage tolerance given in basis points.
+// Returns error if:
+// - the slippage tolerance is greater than MaxSlippageBps
+// - the quote has no routes or a route has no pools
+// - the token out min amount is not positive
+func NewSwapMsg(quote Quote, sender string, slippageBps uint64) (sdk.Msg, error) {
+	tokenOutMinAmount, err := getTokenOutMinAmount(quote.GetAmountOut(), slippageBps)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := quote.GetRoute()
+	if len(routes) == 0 {
+		return nil, errors.New("quote has no routes")
+	}
+
+	swapRoutes := make([][]poolmanagertypes.SwapAmountInRoute, 0, len(routes))
+	for i, route := range routes {
+		pools := route.GetPools()
+		if len(pools) == 0 {
+			return nil, fmt.Errorf("route (%d) has no pools", i)
+		}
+
+		swapRoute := make([]poolmanagertypes.SwapAmountInRoute, 0, len(pools))
+		for _, pool := range pools {
+			swapRoute = append(swapRoute, poolmanagertypes.SwapAmountInRoute{
+				PoolId:        pool.GetId(),
+				TokenOutDenom: pool.GetTokenOutDenom(),
+			})
+		}
+
+		swapRoutes = append(swapRoutes, swapRoute)
+	}
+
+	tokenIn := quote.GetAmountIn()
+
+	if len(swapRoutes) == 1 {
+		return &poolmanagertypes.MsgSwapExactAmountIn{
+			Sender:            sender,
+			Routes:            swapRoutes[0],
+			TokenIn:           tokenIn,
+			TokenOutMinAmount: tokenOutMinAmount,
+		}, nil
+	}
+
+	splitRoutes := make([]poolmanagertypes.SwapAmountInSplitRoute, 0, len(swapRoutes))
+	for i, swapRoute := range swapRoutes {
+		splitRoutes = append(splitRoutes, poolmanagertypes.SwapAmountInSplitRoute{
+			Pools:         swapRoute,
+			TokenInAmount: routes[i].GetAmountIn(),
+		})
+	}
+
+	return &poolmanagertypes.MsgSplitRouteSwapExactAmountIn{
+		Sender:            sender,
+		Routes:            splitRoutes,
+		TokenInDenom:      tokenIn.Denom,
+		TokenOutMinAmount: tokenOutMinAmount,
+	}, nil
+}
+
+// getTokenOutMinAmount returns the given amount out reduced by the slippage tolerance in basis points.
+// The result is rounded down.
+// Returns error if the slippage tolerance is greater than MaxSlippageBps or if the result is not positive.
+func getTokenOutMinAmount(amountOut osmomath.Int, slippageBps uint64) (osmomath.Int, error) {
+	if slippageBps > MaxSlippageBps {
+		return osmomath.Int{}, fmt.Errorf("slippage tolerance (%d) bps must not exceed (%d) bps", slippageBps, MaxSlippageBps)
+	}
+
+	tokenOutMinAmount := amountOut.MulRaw(int64(MaxSlippageBps - slippageBps)).QuoRaw(MaxSlippageBps)
+	if !tokenOutMinAmount.IsPositive() {
+		return osmomath.Int{}, fmt.Errorf("token out min amount (%s) must be positive, amount out (%s), slippage tolerance (%d) bps", tokenOutMinAmount, amountOut, slippageBps)
+	}
+
+	return tokenOutMinAmount, nil
+}
@@ -0,0 +1,183 @@
+package domain_test
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/domain/mocks"
+	"github.com/osmosis-labs/sqs/router/usecase"
+	"github.com/osmosis-labs/sqs/router/usecase/route"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// swapMsgTestQuote is a quote with only the fields needed for swap message creation.
+type swapMsgTestQuote struct {
+	domain.Quote
+
+	amountIn  sdk.Coin
+	amountOut osmomath.Int
+	routes    []domain.SplitRoute
+}
+
+func (q *swapMsgTestQuote) GetAmountIn() sdk.Coin {
+	return q.amountIn
+}
+
+func (q *swapMsgTestQuote) GetAmountOut() osmomath.Int {
+	return q.amountOut
+}
+
+func (q *swapMsgTestQuote) GetRoute() []domain.SplitRoute {
+	return q.routes
+}
+
+// TestNewSwapMsg tests the mapping of single and split route quotes to the poolmanager swap messages.
+func TestNewSwapMsg(t *testing.T) {
+	const (
+		sender = "osmo1sender"
+
+		denomIn  = "denomIn"
+		denomMid = "denomMid"
+		denomOut = "denomOut"
+	)
+
+	newSplitRoute := func(amountIn int64, pools ...*mocks.MockRoutablePool) domain.SplitRoute {
+		routablePools := make([]sqsdomain.RoutablePool, 0, len(pools))
+		for _, pool := range pools {
+			routablePools = append(routablePools, pool)
+		}
+
+		return &usecase.RouteWithOutAmount{
+			RouteImpl: route.RouteImpl{Pools: routablePools},
+			InAmount:  osmomath.NewInt(amountIn),
+		}
+	}
+
+	var (
+		poolOneInMid   = &mocks.MockRoutablePool{ID: 1, TokenOutDenom: denomMid}
+		poolTwoMidOut  = &mocks.MockRoutablePool{ID: 2, TokenOutDenom: denomOut}
+		poolThreeInOut = &mocks.MockRoutablePool{ID: 3, TokenOutDenom: denomOut}
+
+		twoHopSwapRoute = []poolmanagertypes.SwapAmountInRoute{
+			{PoolId: 1, TokenOutDenom: denomMid},
+			{PoolId: 2, TokenOutDenom: denomOut},
+		}
+	)
+
+	testCases := map[string]struct {
+		quote       domain.Quote
+		slippageBps uint64
+
+		expectedMsg   sdk.Msg
+		expectedError bool
+	}{
+		"single route": {
+			quote: &swapMsgTestQuote{
+				amountIn:  sdk.NewCoin(denomIn, osmomath.NewInt(1_000)),
+				amountOut: osmomath.NewInt(2_001),
+				routes:    []domain.SplitRoute{newSplitRoute(1_000, poolOneInMid, poolTwoMidOut)},
+			},
+			slippageBps: 50,
+
+			expectedMsg: &poolmanagertypes.MsgSwapExactAmountIn{
+				Sender:  sender,
+				Routes:  twoHopSwapRoute,
+				TokenIn: sdk.NewCoin(denomIn, osmomath.NewInt(1_000)),
+				// 2001 * 0.995 = 1990.995, rounded down
+				TokenOutMinAmount: osmomath.NewInt(1_990),
+			},
+		},
+		"split route": {
+			quote: &swapMsgTestQuote{
+				amountIn:  sdk.NewCoin(denomIn, osmomath.NewInt(1_000)),
+				amountOut: osmomath.NewInt(2_000),
+				routes: []domain.SplitRoute{
+					newSplitRoute(700, poolOneInMid, poolTwoMidOut),
+					newSplitRoute(299, poolThreeInOut),
+				},
+			},
+			slippageBps: 100,
+
+			expectedMsg: &poolmanagertypes.MsgSplitRouteSwapExactAmountIn{
+				Sender: sender,
+				Routes: []poolmanagertypes.SwapAmountInSplitRoute{
+					{Pools: twoHopSwapRoute, TokenInAmount: osmomath.NewInt(700)},
+					{Pools: []poolmanagertypes.SwapAmountInRoute{{PoolId: 3, TokenOutDenom: denomOut}}, TokenInAmount: osmomath.NewInt(299)},
+				},
+				TokenInDenom:      denomIn,
+				TokenOutMinAmount: osmomath.NewInt(1_980),
+			},
+		},
+		"zero slippage": {
+			quote: &swapMsgTestQuote{
+				amountIn:  sdk.NewCoin(denomIn, osmomath.NewInt(1_000)),
+				amountOut: osmomath.NewInt(2_001),
+				routes:    []domain.SplitRoute{newSplitRoute(1_000, poolThreeInOut)},
+			},
+
+			expectedMsg: &poolmanagertypes.MsgSwapExactAmountIn{
+				Sender:            sender,
+				Routes:            []poolmanagertypes.SwapAmountInRoute{{PoolId: 3, TokenOutDenom: denomOut}},
+				TokenIn:           sdk.NewCoin(denomIn, osmomath.NewInt(1_000)),
+				TokenOutMinAmount: osmomath.NewInt(2_001),
+			},
+		},
+		"slippage exceeds max": {
+			quote: &swapMsgTestQuote{
+				amountIn:  sdk.NewCoin(denomIn, osmomath.NewInt(1_000)),
+				amountOut: osmomath.NewInt(2_000),
+				routes:    []domain.SplitRoute{newSplitRoute(1_000, poolThreeInOut)},
+			},
+			slippageBps: domain.MaxSlippageBps + 1,
+
+			expectedError: true,
+		},
+		"token out min amount rounds down to zero": {
+			quote: &swapMsgTestQuote{
+				amountIn:  sdk.NewCoin(denomIn, osmomath.NewInt(1)),
+				amountOut: osmomath.NewInt(1),
+				routes:    []domain.SplitRoute{newSplitRoute(1, poolThreeInOut)},
+			},
+			slippageBps: 1,
+
+			expectedError: true,
+		},
+		"no routes": {
+			quote: &swapMsgTestQuote{
+				amountIn:  sdk.NewCoin(denomIn, osmomath.NewInt(1_000)),
+				amountOut: osmomath.NewInt(2_000),
+			},
+
+			expectedError: true,
+		},
+		"route with no pools": {
+			quote: &swapMsgTestQuote{
+				amountIn:  sdk.NewCoin(denomIn, osmomath.NewInt(1_000)),
+				amountOut: osmomath.NewInt(2_000),
+				routes:    []domain.SplitRoute{newSplitRoute(1_000)},
+			},
+
+			expectedError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			msg, err := domain.NewSwapMsg(tc.quote, sender, tc.slippageBps)
+
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedMsg, msg)
+		})
+	}
+}
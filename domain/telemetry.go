@@ -41,6 +41,12 @@ var (
 	// gauge that tracks duration of pricing worker computation
 	SQSPricingWorkerComputeDurationMetricName = "sqs_pricing_worker_compute_duration"
 
+	// sqs_state_snapshot_spill_dropped_total
+	//
+	// counter that measures the number of state snapshots dropped instead of spilled to disk,
+	// either because the spill queue is full or because the write failed
+	SQSStateSnapshotSpillDroppedMetricName = "sqs_state_snapshot_spill_dropped_total"
+
 	SQSIngestHandlerProcessBlockDurationGauge = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: SQSIngestUsecaseProcessBlockDurationMetricName,
@@ -78,6 +84,13 @@ var (
 			Help: "gauge that tracks duration of pricing worker computation",
 		},
 	)
+
+	SQSStateSnapshotSpillDroppedCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: SQSStateSnapshotSpillDroppedMetricName,
+			Help: "counter that measures the number of state snapshots dropped instead of spilled to disk",
+		},
+	)
 )
 
 func init() {
@@ -86,4 +99,5 @@ func init() {
 	prometheus.MustRegister(SQSIngestHandlerPoolParseErrorCounter)
 	prometheus.MustRegister(SQSPricingWorkerComputeDurationGauge)
 	prometheus.MustRegister(SQSPricingWorkerComputeErrorCounter)
+	prometheus.MustRegister(SQSStateSnapshotSpillDroppedCounter)
 }
@@ -1,8 +1,12 @@
 package domain
 
 import (
+	"fmt"
+	"math"
 	"strconv"
 	"strings"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
 )
 
 // ParseNumbers parses a comma-separated list of numbers into a slice of unit64.
@@ -21,6 +25,66 @@ func ParseNumbers(numbersParam string) ([]uint64, error) {
 	return numbers, nil
 }
 
+// ParseDenoms parses a comma-separated list of denoms, trimming the whitespace around each.
+func ParseDenoms(denomsParam string) []string {
+	return splitAndTrim(denomsParam, ",")
+}
+
+// ParseAmounts parses a comma-separated list of positive integer amounts.
+func ParseAmounts(amountsParam string) ([]osmomath.Int, error) {
+	var amounts []osmomath.Int
+	for _, amountStr := range splitAndTrim(amountsParam, ",") {
+		amount, ok := osmomath.NewIntFromString(amountStr)
+		if !ok {
+			return nil, fmt.Errorf("amount (%s) is not a valid integer", amountStr)
+		}
+
+		if !amount.IsPositive() {
+			return nil, fmt.Errorf("amount (%s) must be positive", amountStr)
+		}
+
+		amounts = append(amounts, amount)
+	}
+
+	return amounts, nil
+}
+
+// ParsePositiveDecs parses a comma-separated list of positive decimals.
+func ParsePositiveDecs(decsParam string) ([]osmomath.Dec, error) {
+	var decs []osmomath.Dec
+	for _, decStr := range splitAndTrim(decsParam, ",") {
+		dec, err := osmomath.NewDecFromStr(decStr)
+		if err != nil {
+			return nil, fmt.Errorf("decimal (%s) is invalid: %w", decStr, err)
+		}
+
+		if !dec.IsPositive() {
+			return nil, fmt.Errorf("decimal (%s) must be positive", decStr)
+		}
+
+		decs = append(decs, dec)
+	}
+
+	return decs, nil
+}
+
+// GetLogSpacedAmounts returns the amounts from minAmount to minAmount * 10^numDecades
+// in increasing order, evenly spaced on a log scale with amountsPerDecade amounts per decade.
+// Both ends are included. The amounts are truncated.
+func GetLogSpacedAmounts(minAmount osmomath.Int, numDecades, amountsPerDecade int) []osmomath.Int {
+	numAmounts := numDecades*amountsPerDecade + 1
+
+	amounts := make([]osmomath.Int, 0, numAmounts)
+	for i := 0; i < numAmounts; i++ {
+		multiplier := math.Pow(10, float64(i)/float64(amountsPerDecade))
+		multiplierDec := osmomath.MustNewDecFromStr(strconv.FormatFloat(multiplier, 'f', 6, 64))
+
+		amounts = append(amounts, multiplierDec.MulInt(minAmount).TruncateInt())
+	}
+
+	return amounts
+}
+
 // splitAndTrim splits a string by a separator and trims the resulting strings.
 func splitAndTrim(s, sep string) []string {
 	var result []string
@@ -28,6 +28,11 @@ type ingestUseCase struct {
 	// Worker that computes prices for all tokens with the default quote.
 	defaultQuotePriceUpdateWorker domain.PricingWorker
 
+	// Rated and sorted pools that are updated incrementally with the pools
+	// modified in every block.
+	// Not safe for concurrent use. Blocks are processed sequentially.
+	sortedPools *routerusecase.SortedPools
+
 	logger log.Logger
 }
 
@@ -49,6 +54,8 @@ var (
 
 // NewIngestUsecase will create a new pools use case object
 func NewIngestUsecase(poolsUseCase mvc.PoolsUsecase, routerUseCase mvc.RouterUsecase, chainInfoUseCase mvc.ChainInfoUsecase, codec codec.Codec, quotePriceUpdateWorker domain.PricingWorker, logger log.Logger) (mvc.IngestUsecase, error) {
+	routerConfig := routerUseCase.GetConfig()
+
 	return &ingestUseCase{
 		codec: codec,
 
@@ -59,6 +66,8 @@ func NewIngestUsecase(poolsUseCase mvc.PoolsUsecase, routerUseCase mvc.RouterUse
 		logger: logger,
 
 		defaultQuotePriceUpdateWorker: quotePriceUpdateWorker,
+
+		sortedPools: routerusecase.NewSortedPools(poolsUseCase.GetCosmWasmPoolConfig(), routerConfig.PreferredPoolIDs, routerConfig.TVLBoostDriftThreshold, logger),
 	}, nil
 }
 
@@ -67,28 +76,43 @@ func (p *ingestUseCase) ProcessBlockData(ctx context.Context, height uint64, tak
 
 	startProcessingTime := time.Now()
 
-	p.routerUsecase.SetTakerFees(takerFeesMap)
-
 	// Parse the pools
 	pools, uniqueBlockPoolMetadata, err := p.parsePoolData(ctx, poolData)
 	if err != nil {
 		return err
 	}
 
-	// Store the pools
-	if err := p.poolsUseCase.StorePools(pools); err != nil {
-		return err
-	}
+	// Build the next state snapshot off the latest one. It is not visible to the readers until stored.
+	previousSnapshot := p.routerUsecase.GetStateSnapshot()
+	snapshot := previousSnapshot.WithPools(pools).WithTakerFees(takerFeesMap)
 
-	// Get all pools (already updated with the newly ingested pools)
-	allPools, err := p.poolsUseCase.GetAllPools()
-	if err != nil {
-		return err
+	// On the first block, sort all pools. Afterwards, only re-position the pools modified in the block.
+	updatedPools := pools
+	if p.sortedPools.Len() == 0 {
+		// Get all pools (already updated with the newly ingested pools)
+		updatedPools = snapshot.GetAllPools()
 	}
 
-	// Sort and store pools.
-	p.logger.Info("sorting pools", zap.Uint64("height", height), zap.Duration("duration_since_start", time.Since(startProcessingTime)))
-	p.sortAndStorePools(allPools)
+	// Sort pools.
+	p.logger.Info("sorting pools", zap.Uint64("height", height), zap.Int("num_updated_pools", len(updatedPools)), zap.Duration("duration_since_start", time.Since(startProcessingTime)))
+	snapshot = p.sortPools(snapshot, updatedPools).WithHeight(height)
+
+	// Swap in the complete state of the block at once.
+	p.routerUsecase.StoreStateSnapshot(snapshot)
+
+	// Recompute the routes of the hot pairs in the background. It is started before the eviction
+	// so that their cached routes are replaced in place rather than evicted.
+	p.routerUsecase.WarmRoutesAsync(height)
+
+	// Evict the cached routes that go through the pools modified or re-ranked in the block.
+	sortedPoolsDiff := routerusecase.DiffSortedPools(previousSnapshot.GetSortedPools(), snapshot.GetSortedPools())
+	p.routerUsecase.EvictCachedRoutes(getPoolIDs(pools), sortedPoolsDiff)
+
+	// Push the changed quotes to the quote subscribers in the background.
+	p.routerUsecase.PushQuoteUpdatesAsync(height)
+
+	// Detect arbitrage over the newly sorted pools in the background.
+	p.routerUsecase.DetectArbitrageAsync(height)
 
 	// Note: we must queue the update before we start updating prices as pool liquidity
 	// worker listens for the pricing updates at the same height.
@@ -105,16 +129,11 @@ func (p *ingestUseCase) ProcessBlockData(ctx context.Context, height uint64, tak
 	return nil
 }
 
-// sortAndStorePools sorts the pools and stores them in the router.
-// TODO: instead of resorting all pools every block, we should put the updated pools in the correct position
-func (p *ingestUseCase) sortAndStorePools(pools []sqsdomain.PoolI) {
-	cosmWasmPoolConfig := p.poolsUseCase.GetCosmWasmPoolConfig()
-	routerConfig := p.routerUsecase.GetConfig()
-
-	sortedPools := routerusecase.ValidateAndSortPools(pools, cosmWasmPoolConfig, routerConfig.PreferredPoolIDs, p.logger)
+// sortPools puts the updated pools in their sorted position and returns a copy of the snapshot with all sorted pools.
+func (p *ingestUseCase) sortPools(snapshot *domain.StateSnapshot, updatedPools []sqsdomain.PoolI) *domain.StateSnapshot {
+	p.sortedPools.Update(updatedPools)
 
-	// Sort the pools and store them in the router.
-	p.routerUsecase.SetSortedPools(sortedPools)
+	return snapshot.WithSortedPools(p.sortedPools.GetSortedPools())
 }
 
 // parsePoolData parses the pool data and returns the pool objects.
@@ -170,6 +189,7 @@ func (p *ingestUseCase) parsePoolData(ctx context.Context, poolData []*types.Poo
 
 // parsePool parses the pool data and returns the pool object
 // For concentrated pools, it also processes the tick model
+// For orderbook pools, it also processes the orderbook model
 func (p *ingestUseCase) parsePool(pool *types.PoolData) (sqsdomain.PoolI, error) {
 	poolWrapper := sqsdomain.PoolWrapper{}
 
@@ -188,5 +208,21 @@ func (p *ingestUseCase) parsePool(pool *types.PoolData) (sqsdomain.PoolI, error)
 		}
 	}
 
+	if poolWrapper.GetType() == poolmanagertypes.CosmWasm && len(pool.OrderbookModel) > 0 {
+		poolWrapper.OrderbookModel = &sqsdomain.OrderbookModel{}
+		if err := json.Unmarshal(pool.OrderbookModel, poolWrapper.OrderbookModel); err != nil {
+			return nil, err
+		}
+	}
+
 	return &poolWrapper, nil
 }
+
+// getPoolIDs returns the IDs of the given pools.
+func getPoolIDs(pools []sqsdomain.PoolI) []uint64 {
+	poolIDs := make([]uint64, 0, len(pools))
+	for _, pool := range pools {
+		poolIDs = append(poolIDs, pool.GetId())
+	}
+	return poolIDs
+}
@@ -2,6 +2,11 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -13,6 +18,9 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// heightQueryParam is the query parameter selecting the height of the state to serve the request from.
+const heightQueryParam = "height"
+
 // GoMiddleware represent the data-struct for middleware
 type GoMiddleware struct {
 	corsConfig domain.CORSConfig
@@ -124,3 +132,52 @@ func (m *GoMiddleware) TraceWithParamsMiddleware(tracerName string) echo.Middlew
 		}
 	}
 }
+
+// StateSnapshotMiddleware pins the state snapshot to the request context so that the request
+// is served from the state of a single block. It is the latest snapshot unless the height query parameter
+// selects a past one. The height query parameter is only accepted on the given height paths, the route paths
+// of the endpoints that serve the pinned snapshot only, and is rejected on the others.
+// The height of the snapshot is returned in the domain.HeightHeader response header.
+// WebSocket connections are not pinned since they outlive many blocks.
+func (m *GoMiddleware) StateSnapshotMiddleware(getStateSnapshot func() *domain.StateSnapshot, getStateSnapshotAtHeight func(height uint64) (*domain.StateSnapshot, error), heightPaths []string) echo.MiddlewareFunc {
+	isHeightPath := make(map[string]struct{}, len(heightPaths))
+	for _, path := range heightPaths {
+		isHeightPath[path] = struct{}{}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if strings.EqualFold(c.Request().Header.Get(echo.HeaderUpgrade), "websocket") {
+				return next(c)
+			}
+
+			snapshot := getStateSnapshot()
+
+			if heightStr := c.QueryParam(heightQueryParam); heightStr != "" {
+				if _, ok := isHeightPath[c.Path()]; !ok {
+					return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: fmt.Sprintf("height is not supported by %s", c.Path())})
+				}
+
+				height, err := strconv.ParseUint(heightStr, 10, 64)
+				if err != nil {
+					return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: fmt.Sprintf("invalid height (%s): %s", heightStr, err)})
+				}
+
+				snapshot, err = getStateSnapshotAtHeight(height)
+				if errors.As(err, &domain.StateSnapshotNotRetainedError{}) || errors.As(err, &domain.StateSnapshotDroppedError{}) {
+					return c.JSON(http.StatusNotFound, domain.ResponseError{Message: err.Error()})
+				}
+				if err != nil {
+					return c.JSON(http.StatusInternalServerError, domain.ResponseError{Message: err.Error()})
+				}
+			}
+
+			c.SetRequest(c.Request().WithContext(domain.WithStateSnapshot(c.Request().Context(), snapshot)))
+			c.Response().Header().Set(domain.HeightHeader, strconv.FormatUint(snapshot.GetHeight(), 10))
+			// Allow the browser clients to read the height.
+			c.Response().Header().Add(echo.HeaderAccessControlExposeHeaders, domain.HeightHeader)
+
+			return next(c)
+		}
+	}
+}
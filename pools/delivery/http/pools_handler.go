@@ -1,6 +1,7 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/osmosis-labs/sqs/sqsdomain"
 
 	"github.com/osmosis-labs/osmosis/osmomath"
+	cltypes "github.com/osmosis-labs/osmosis/v25/x/concentrated-liquidity/types"
 	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
 )
 
@@ -24,6 +26,7 @@ type ResponseError struct {
 // PoolsHandler  represent the httphandler for pools
 type PoolsHandler struct {
 	PUsecase mvc.PoolsUsecase
+	TUsecase mvc.TokensUsecase
 }
 
 // PoolsResponse is a structure for serializing pool result returned to clients.
@@ -39,17 +42,23 @@ type PoolResponse struct {
 
 const resourcePrefix = "/pools"
 
+// defaultPriceChangePercentages are the percentages away from the current price
+// of the cumulative liquidity depths if none are given.
+const defaultPriceChangePercentages = "1,2,5,10,25,50"
+
 func formatPoolsResource(resource string) string {
 	return resourcePrefix + resource
 }
 
 // NewPoolsHandler will initialize the pools/ resources endpoint
-func NewPoolsHandler(e *echo.Echo, us mvc.PoolsUsecase) {
+func NewPoolsHandler(e *echo.Echo, us mvc.PoolsUsecase, tu mvc.TokensUsecase) {
 	handler := &PoolsHandler{
 		PUsecase: us,
+		TUsecase: tu,
 	}
 
 	e.GET(formatPoolsResource("/ticks/:id"), handler.GetConcentratedPoolTicks)
+	e.GET(formatPoolsResource("/:id/liquidity-depth"), handler.GetConcentratedPoolLiquidityDepth)
 	e.GET(formatPoolsResource(""), handler.GetPools)
 }
 
@@ -59,9 +68,12 @@ func NewPoolsHandler(e *echo.Echo, us mvc.PoolsUsecase) {
 // @ID get-pools
 // @Produce  json
 // @Param  IDs  query  string  false  "Comma-separated list of pool IDs to fetch, e.g., '1,2,3'"
+// @Param  height  query  int  false  "Height of a past block to return the pools at. Only the recently ingested blocks are retained. The latest height by default."
 // @Success 200  {array}  sqsdomain.PoolI  "List of pool(s) details"
 // @Router /pools [get]
 func (a *PoolsHandler) GetPools(c echo.Context) error {
+	ctx := c.Request().Context()
+
 	// Get pool ID parameters as strings.
 	poolIDsStr := c.QueryParam("IDs")
 
@@ -72,7 +84,7 @@ func (a *PoolsHandler) GetPools(c echo.Context) error {
 
 	// if IDs are not given, get all pools
 	if len(poolIDsStr) == 0 {
-		pools, err = a.PUsecase.GetAllPools()
+		pools, err = a.PUsecase.GetAllPools(ctx)
 		if err != nil {
 			return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
 		}
@@ -84,7 +96,7 @@ func (a *PoolsHandler) GetPools(c echo.Context) error {
 		}
 
 		// Get pools
-		pools, err = a.PUsecase.GetPools(poolIDs)
+		pools, err = a.PUsecase.GetPools(ctx, poolIDs)
 		if err != nil {
 			return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
 		}
@@ -97,13 +109,15 @@ func (a *PoolsHandler) GetPools(c echo.Context) error {
 }
 
 func (a *PoolsHandler) GetConcentratedPoolTicks(c echo.Context) error {
+	ctx := c.Request().Context()
+
 	idStr := c.Param("id")
 	poolID, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ResponseError{Message: err.Error()})
 	}
 
-	pools, err := a.PUsecase.GetTickModelMap([]uint64{poolID})
+	pools, err := a.PUsecase.GetTickModelMap(ctx, []uint64{poolID})
 	if err != nil {
 		return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
 	}
@@ -116,6 +130,64 @@ func (a *PoolsHandler) GetConcentratedPoolTicks(c echo.Context) error {
 	return c.JSON(http.StatusOK, tickModel)
 }
 
+// @Summary Get the liquidity depth of a concentrated pool
+// @Description Returns the liquidity of the concentrated pool by price range and the cumulative token amounts
+// @Description available up to every given percentage below and above the current price.
+// @Description The prices are of token0 denominated in token1. The prices and the amounts are scaled by the token precisions.
+// @ID get-pool-liquidity-depth
+// @Produce  json
+// @Param  id  path  int  true  "ID of the concentrated pool."
+// @Param  priceChangePercentages  query  string  false  "Comma-separated list of positive percentages away from the current price to compute the cumulative depths at. 1,2,5,10,25,50 by default."
+// @Param  height  query  int  false  "Height of a past block to return the liquidity depth at. Only the recently ingested blocks are retained. The latest height by default."
+// @Success 200  {object}  domain.ConcentratedLiquidityDepth  "Liquidity depth of the pool"
+// @Router /pools/{id}/liquidity-depth [get]
+func (a *PoolsHandler) GetConcentratedPoolLiquidityDepth(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	poolID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ResponseError{Message: err.Error()})
+	}
+
+	priceChangePercentagesStr := c.QueryParam("priceChangePercentages")
+	if priceChangePercentagesStr == "" {
+		priceChangePercentagesStr = defaultPriceChangePercentages
+	}
+
+	priceChangePercentages, err := domain.ParsePositiveDecs(priceChangePercentagesStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ResponseError{Message: fmt.Sprintf("priceChangePercentages is invalid: %s", err)})
+	}
+
+	pool, err := a.PUsecase.GetPool(ctx, poolID)
+	if err != nil {
+		return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
+	}
+
+	concentratedPool, ok := pool.GetUnderlyingPool().(cltypes.ConcentratedPoolExtension)
+	if pool.GetType() != poolmanagertypes.Concentrated || !ok {
+		return c.JSON(http.StatusBadRequest, ResponseError{Message: fmt.Sprintf("pool with ID %d is not concentrated", poolID)})
+	}
+
+	// The prices and the amounts are converted from the chain units by the token precisions.
+	token0ScalingFactor, err := a.TUsecase.GetChainScalingFactorByDenomMut(concentratedPool.GetToken0())
+	if err != nil {
+		return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
+	}
+
+	token1ScalingFactor, err := a.TUsecase.GetChainScalingFactorByDenomMut(concentratedPool.GetToken1())
+	if err != nil {
+		return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
+	}
+
+	liquidityDepth, err := a.PUsecase.GetConcentratedLiquidityDepth(ctx, poolID, token0ScalingFactor, token1ScalingFactor, priceChangePercentages)
+	if err != nil {
+		return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, liquidityDepth)
+}
+
 func getStatusCode(err error) int {
 	if err == nil {
 		return http.StatusOK
@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	clmath "github.com/osmosis-labs/osmosis/v25/x/concentrated-liquidity/math"
+	concentratedmodel "github.com/osmosis-labs/osmosis/v25/x/concentrated-liquidity/model"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
+)
+
+var oneHundredDec = osmomath.NewDec(100)
+
+// liquidityBucket is a bucket of the tick model with its sqrt price bounds.
+type liquidityBucket struct {
+	lowerTick      int64
+	upperTick      int64
+	lowerSqrtPrice osmomath.BigDec
+	upperSqrtPrice osmomath.BigDec
+	liquidity      osmomath.Dec
+}
+
+// GetConcentratedLiquidityDepth implements mvc.PoolsUsecase.
+func (p *poolsUseCase) GetConcentratedLiquidityDepth(ctx context.Context, poolID uint64, token0ScalingFactor, token1ScalingFactor osmomath.Dec, priceChangePercentages []osmomath.Dec) (domain.ConcentratedLiquidityDepth, error) {
+	pool, err := p.getStateSnapshot(ctx).GetPool(poolID)
+	if err != nil {
+		return domain.ConcentratedLiquidityDepth{}, err
+	}
+
+	if pool.GetType() != poolmanagertypes.Concentrated {
+		return domain.ConcentratedLiquidityDepth{}, fmt.Errorf("pool with ID %d is not concentrated", poolID)
+	}
+
+	poolWrapper, ok := pool.(*sqsdomain.PoolWrapper)
+	if !ok || poolWrapper.TickModel == nil {
+		return domain.ConcentratedLiquidityDepth{}, domain.ConcentratedTickModelNotSetError{
+			PoolId: poolID,
+		}
+	}
+
+	concentratedPool, ok := pool.GetUnderlyingPool().(*concentratedmodel.Pool)
+	if !ok {
+		return domain.ConcentratedLiquidityDepth{}, fmt.Errorf("failed to cast pool with ID %d to concentrated pool", poolID)
+	}
+
+	if token0ScalingFactor.IsZero() || token1ScalingFactor.IsZero() {
+		return domain.ConcentratedLiquidityDepth{}, fmt.Errorf("scaling factors of pool with ID %d must be positive", poolID)
+	}
+
+	return computeConcentratedLiquidityDepth(concentratedPool, poolWrapper.TickModel, token0ScalingFactor, token1ScalingFactor, priceChangePercentages)
+}
+
+// computeConcentratedLiquidityDepth converts the buckets of the given tick model to price ranges and computes
+// the token amounts between the current price of the pool and the prices moved by every given percentage
+// in both directions. Down moves are capped at the zero price.
+// The amounts are divided by the token scaling factors and the prices are multiplied by
+// token0ScalingFactor / token1ScalingFactor to convert them from the chain units.
+func computeConcentratedLiquidityDepth(concentratedPool *concentratedmodel.Pool, tickModel *sqsdomain.TickModel, token0ScalingFactor, token1ScalingFactor osmomath.Dec, priceChangePercentages []osmomath.Dec) (domain.ConcentratedLiquidityDepth, error) {
+	var (
+		token0ScalingFactorBigDec = osmomath.BigDecFromDec(token0ScalingFactor)
+		token1ScalingFactorBigDec = osmomath.BigDecFromDec(token1ScalingFactor)
+		priceScalingFactor        = token0ScalingFactorBigDec.Quo(token1ScalingFactorBigDec)
+
+		currentSqrtPrice = concentratedPool.GetCurrentSqrtPrice()
+	)
+
+	buckets := make([]liquidityBucket, 0, len(tickModel.Ticks))
+	if !tickModel.HasNoLiquidity {
+		for _, tick := range tickModel.Ticks {
+			lowerSqrtPrice, upperSqrtPrice, err := clmath.TicksToSqrtPrice(tick.LowerTick, tick.UpperTick)
+			if err != nil {
+				return domain.ConcentratedLiquidityDepth{}, err
+			}
+
+			buckets = append(buckets, liquidityBucket{
+				lowerTick:      tick.LowerTick,
+				upperTick:      tick.UpperTick,
+				lowerSqrtPrice: lowerSqrtPrice,
+				upperSqrtPrice: upperSqrtPrice,
+				liquidity:      tick.LiquidityAmount,
+			})
+		}
+	}
+
+	ranges := make([]domain.LiquidityDepthRange, 0, len(buckets))
+	for _, bucket := range buckets {
+		lowerPrice, err := clmath.TickToPrice(bucket.lowerTick)
+		if err != nil {
+			return domain.ConcentratedLiquidityDepth{}, err
+		}
+
+		upperPrice, err := clmath.TickToPrice(bucket.upperTick)
+		if err != nil {
+			return domain.ConcentratedLiquidityDepth{}, err
+		}
+
+		// The liquidity above the current price is held in token0 and below it in token1.
+		token0Amount := getAmount0InSqrtPriceRange(bucket, currentSqrtPrice, bucket.upperSqrtPrice)
+		token1Amount := getAmount1InSqrtPriceRange(bucket, bucket.lowerSqrtPrice, currentSqrtPrice)
+
+		ranges = append(ranges, domain.LiquidityDepthRange{
+			LowerPrice:   lowerPrice.MulMut(priceScalingFactor),
+			UpperPrice:   upperPrice.MulMut(priceScalingFactor),
+			Liquidity:    bucket.liquidity,
+			Token0Amount: token0Amount.QuoMut(token0ScalingFactorBigDec).Dec(),
+			Token1Amount: token1Amount.QuoMut(token1ScalingFactorBigDec).Dec(),
+		})
+	}
+
+	currentPrice := currentSqrtPrice.Mul(currentSqrtPrice)
+
+	cumulativeDepths := make([]domain.CumulativeLiquidityDepth, 0, 2*len(priceChangePercentages))
+	for _, percentage := range priceChangePercentages {
+		if !percentage.IsPositive() {
+			return domain.ConcentratedLiquidityDepth{}, fmt.Errorf("price change percentage (%s) must be positive", percentage)
+		}
+
+		// Moving the price down swaps out token1.
+		downPriceMultiplier := osmomath.OneDec().Sub(percentage.Quo(oneHundredDec))
+		if downPriceMultiplier.IsNegative() {
+			downPriceMultiplier = osmomath.ZeroDec()
+		}
+
+		downSqrtPrice, err := getMovedSqrtPrice(currentSqrtPrice, downPriceMultiplier)
+		if err != nil {
+			return domain.ConcentratedLiquidityDepth{}, err
+		}
+
+		token1Amount := osmomath.ZeroBigDec()
+		for _, bucket := range buckets {
+			token1Amount.AddMut(getAmount1InSqrtPriceRange(bucket, downSqrtPrice, currentSqrtPrice))
+		}
+
+		cumulativeDepths = append(cumulativeDepths, domain.CumulativeLiquidityDepth{
+			PriceChangePercentage: percentage.Neg(),
+			Price:                 currentPrice.MulDec(downPriceMultiplier).MulMut(priceScalingFactor),
+			Token0Amount:          osmomath.ZeroDec(),
+			Token1Amount:          token1Amount.QuoMut(token1ScalingFactorBigDec).Dec(),
+		})
+
+		// Moving the price up swaps out token0.
+		upPriceMultiplier := osmomath.OneDec().Add(percentage.Quo(oneHundredDec))
+
+		upSqrtPrice, err := getMovedSqrtPrice(currentSqrtPrice, upPriceMultiplier)
+		if err != nil {
+			return domain.ConcentratedLiquidityDepth{}, err
+		}
+
+		token0Amount := osmomath.ZeroBigDec()
+		for _, bucket := range buckets {
+			token0Amount.AddMut(getAmount0InSqrtPriceRange(bucket, currentSqrtPrice, upSqrtPrice))
+		}
+
+		cumulativeDepths = append(cumulativeDepths, domain.CumulativeLiquidityDepth{
+			PriceChangePercentage: percentage,
+			Price:                 currentPrice.MulDec(upPriceMultiplier).MulMut(priceScalingFactor),
+			Token0Amount:          token0Amount.QuoMut(token0ScalingFactorBigDec).Dec(),
+			Token1Amount:          osmomath.ZeroDec(),
+		})
+	}
+
+	return domain.ConcentratedLiquidityDepth{
+		PoolID:           concentratedPool.GetId(),
+		Token0:           concentratedPool.GetToken0(),
+		Token1:           concentratedPool.GetToken1(),
+		CurrentPrice:     currentPrice.MulMut(priceScalingFactor),
+		Ranges:           ranges,
+		CumulativeDepths: cumulativeDepths,
+	}, nil
+}
+
+// getMovedSqrtPrice returns the sqrt price of the price given by the current sqrt price
+// with the price multiplied by the given multiplier.
+func getMovedSqrtPrice(currentSqrtPrice osmomath.BigDec, priceMultiplier osmomath.Dec) (osmomath.BigDec, error) {
+	sqrtPriceMultiplier, err := osmomath.BigDecFromDec(priceMultiplier).ApproxSqrt()
+	if err != nil {
+		return osmomath.BigDec{}, err
+	}
+
+	return currentSqrtPrice.Mul(sqrtPriceMultiplier), nil
+}
+
+// getAmount0InSqrtPriceRange returns the amount of token0 held by the liquidity of the bucket
+// between the given sqrt prices. Returns zero if the range does not overlap with the bucket.
+func getAmount0InSqrtPriceRange(bucket liquidityBucket, lowerSqrtPrice, upperSqrtPrice osmomath.BigDec) osmomath.BigDec {
+	lowerSqrtPrice, upperSqrtPrice, ok := intersectSqrtPriceRange(bucket, lowerSqrtPrice, upperSqrtPrice)
+	if !ok {
+		return osmomath.ZeroBigDec()
+	}
+
+	return clmath.CalcAmount0Delta(bucket.liquidity, lowerSqrtPrice, upperSqrtPrice, false)
+}
+
+// getAmount1InSqrtPriceRange returns the amount of token1 held by the liquidity of the bucket
+// between the given sqrt prices. Returns zero if the range does not overlap with the bucket.
+func getAmount1InSqrtPriceRange(bucket liquidityBucket, lowerSqrtPrice, upperSqrtPrice osmomath.BigDec) osmomath.BigDec {
+	lowerSqrtPrice, upperSqrtPrice, ok := intersectSqrtPriceRange(bucket, lowerSqrtPrice, upperSqrtPrice)
+	if !ok {
+		return osmomath.ZeroBigDec()
+	}
+
+	return clmath.CalcAmount1Delta(bucket.liquidity, lowerSqrtPrice, upperSqrtPrice, false)
+}
+
+// intersectSqrtPriceRange returns the intersection of the given sqrt price range with the range of the bucket
+// and true if it is not empty.
+func intersectSqrtPriceRange(bucket liquidityBucket, lowerSqrtPrice, upperSqrtPrice osmomath.BigDec) (osmomath.BigDec, osmomath.BigDec, bool) {
+	if bucket.lowerSqrtPrice.GT(lowerSqrtPrice) {
+		lowerSqrtPrice = bucket.lowerSqrtPrice
+	}
+
+	if bucket.upperSqrtPrice.LT(upperSqrtPrice) {
+		upperSqrtPrice = bucket.upperSqrtPrice
+	}
+
+	return lowerSqrtPrice, upperSqrtPrice, lowerSqrtPrice.LT(upperSqrtPrice)
+}
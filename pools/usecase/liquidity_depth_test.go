@@ -0,0 +1,128 @@
+package usecase_test
+
+import (
+	"context"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/osmosis-labs/osmosis/osmomath"
+
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/pools/usecase"
+	routerrepo "github.com/osmosis-labs/sqs/router/repository"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// Validates that the liquidity depth of a concentrated pool accounts for all of its balances
+// and that the cumulative depths do not decrease with larger price change percentages.
+func (s *PoolsUsecaseTestSuite) TestGetConcentratedLiquidityDepth() {
+	s.Setup()
+
+	concentratedPool := s.PrepareConcentratedPool()
+	s.SetupDefaultPosition(concentratedPool.GetId())
+
+	// Refetch the pool
+	concentratedPool, err := s.App.ConcentratedLiquidityKeeper.GetConcentratedPoolById(s.Ctx, concentratedPool.GetId())
+	s.Require().NoError(err)
+
+	ticks, currentTickIndex, err := s.App.ConcentratedLiquidityKeeper.GetTickLiquidityForFullRange(s.Ctx, concentratedPool.GetId())
+	s.Require().NoError(err)
+
+	balances := s.App.BankKeeper.GetAllBalances(s.Ctx, concentratedPool.GetAddress())
+
+	balancerPoolID := s.PrepareBalancerPoolWithCoins(sdk.NewCoin(denomOne, defaultAmt0), sdk.NewCoin(denomTwo, defaultAmt1))
+	balancerPool, err := s.App.GAMMKeeper.GetPool(s.Ctx, balancerPoolID)
+	s.Require().NoError(err)
+
+	routerRepository := routerrepo.New()
+	poolsUsecase := usecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	err = poolsUsecase.StorePools([]sqsdomain.PoolI{
+		&sqsdomain.PoolWrapper{
+			ChainModel: concentratedPool,
+			TickModel: &sqsdomain.TickModel{
+				Ticks:            ticks,
+				CurrentTickIndex: currentTickIndex,
+			},
+			SQSModel: sqsdomain.SQSPool{
+				Balances:   balances,
+				PoolDenoms: balances.Denoms(),
+			},
+		},
+		&sqsdomain.PoolWrapper{
+			ChainModel: balancerPool,
+			SQSModel: sqsdomain.SQSPool{
+				Balances:   balances,
+				PoolDenoms: balances.Denoms(),
+			},
+		},
+	})
+	s.Require().NoError(err)
+
+	var (
+		// The token amounts are scaled to 6 decimals and the price by 10^(6-2) = 10^4.
+		token0ScalingFactor = osmomath.NewDec(1_000_000)
+		token1ScalingFactor = osmomath.NewDec(100)
+
+		priceChangePercentages = []osmomath.Dec{osmomath.NewDec(1), osmomath.NewDec(50), osmomath.NewDec(100)}
+
+		errTolerance = osmomath.ErrTolerance{MultiplicativeTolerance: osmomath.MustNewDecFromStr("0.000001")}
+	)
+
+	// System under test.
+	liquidityDepth, err := poolsUsecase.GetConcentratedLiquidityDepth(context.Background(), concentratedPool.GetId(), token0ScalingFactor, token1ScalingFactor, priceChangePercentages)
+	s.Require().NoError(err)
+
+	s.Require().Equal(concentratedPool.GetId(), liquidityDepth.PoolID)
+	s.Require().Equal(concentratedPool.GetToken0(), liquidityDepth.Token0)
+	s.Require().Equal(concentratedPool.GetToken1(), liquidityDepth.Token1)
+
+	currentSqrtPrice := concentratedPool.GetCurrentSqrtPrice()
+	expectedCurrentPrice := currentSqrtPrice.Mul(currentSqrtPrice).MulDec(osmomath.NewDec(10_000))
+	s.Require().Equal(expectedCurrentPrice, liquidityDepth.CurrentPrice)
+
+	// The ranges hold the balances of the pool up to rounding.
+	s.Require().Len(liquidityDepth.Ranges, len(ticks))
+	totalToken0Amount, totalToken1Amount := osmomath.ZeroDec(), osmomath.ZeroDec()
+	for i, priceRange := range liquidityDepth.Ranges {
+		s.Require().True(priceRange.LowerPrice.LT(priceRange.UpperPrice))
+		s.Require().Equal(ticks[i].LiquidityAmount, priceRange.Liquidity)
+
+		totalToken0Amount = totalToken0Amount.Add(priceRange.Token0Amount)
+		totalToken1Amount = totalToken1Amount.Add(priceRange.Token1Amount)
+	}
+
+	expectedToken0Amount := balances.AmountOf(concentratedPool.GetToken0()).ToLegacyDec().Quo(token0ScalingFactor)
+	expectedToken1Amount := balances.AmountOf(concentratedPool.GetToken1()).ToLegacyDec().Quo(token1ScalingFactor)
+	s.Require().Zero(errTolerance.CompareDec(expectedToken0Amount, totalToken0Amount), "expected (%s), actual (%s)", expectedToken0Amount, totalToken0Amount)
+	s.Require().Zero(errTolerance.CompareDec(expectedToken1Amount, totalToken1Amount), "expected (%s), actual (%s)", expectedToken1Amount, totalToken1Amount)
+
+	// Every percentage is returned below and above the current price.
+	cumulativeDepths := liquidityDepth.CumulativeDepths
+	s.Require().Len(cumulativeDepths, 2*len(priceChangePercentages))
+	for i, percentage := range priceChangePercentages {
+		downDepth, upDepth := cumulativeDepths[2*i], cumulativeDepths[2*i+1]
+
+		s.Require().Equal(percentage.Neg(), downDepth.PriceChangePercentage)
+		s.Require().True(downDepth.Price.LT(liquidityDepth.CurrentPrice))
+		s.Require().True(downDepth.Token0Amount.IsZero())
+		s.Require().True(downDepth.Token1Amount.IsPositive())
+
+		s.Require().Equal(percentage, upDepth.PriceChangePercentage)
+		s.Require().True(upDepth.Price.GT(liquidityDepth.CurrentPrice))
+		s.Require().True(upDepth.Token0Amount.IsPositive())
+		s.Require().True(upDepth.Token1Amount.IsZero())
+
+		// The default position ends within the larger percentages so the depths do not always increase.
+		if i > 0 {
+			s.Require().True(downDepth.Token1Amount.GTE(cumulativeDepths[2*i-2].Token1Amount))
+			s.Require().True(upDepth.Token0Amount.GTE(cumulativeDepths[2*i-1].Token0Amount))
+		}
+	}
+
+	// Moving the price down to zero swaps out all of token1.
+	s.Require().True(cumulativeDepths[4].Price.IsZero())
+	s.Require().Zero(errTolerance.CompareDec(expectedToken1Amount, cumulativeDepths[4].Token1Amount), "expected (%s), actual (%s)", expectedToken1Amount, cumulativeDepths[4].Token1Amount)
+
+	// The liquidity depth is only available for concentrated pools.
+	_, err = poolsUsecase.GetConcentratedLiquidityDepth(context.Background(), balancerPoolID, token0ScalingFactor, token1ScalingFactor, priceChangePercentages)
+	s.Require().Error(err)
+}
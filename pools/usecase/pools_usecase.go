@@ -3,7 +3,6 @@ package usecase
 import (
 	"context"
 	"fmt"
-	"sync"
 
 	"cosmossdk.io/math"
 	"github.com/osmosis-labs/sqs/sqsdomain"
@@ -19,7 +18,7 @@ import (
 )
 
 type poolsUseCase struct {
-	pools            sync.Map
+	// The pools are stored in the state snapshots of the router repository.
 	routerRepository routerrepo.RouterRepository
 	cosmWasmConfig   domain.CosmWasmPoolRouterConfig
 }
@@ -27,6 +26,7 @@ type poolsUseCase struct {
 var _ mvc.PoolsUsecase = &poolsUseCase{}
 
 // NewPoolsUsecase will create a new pools use case object
+// Panics if the native CosmWasm pool implementations in the config are invalid.
 func NewPoolsUsecase(poolsConfig *domain.PoolsConfig, nodeURI string, routerRepository routerrepo.RouterRepository) mvc.PoolsUsecase {
 	transmuterCodeIDsMap := make(map[uint64]struct{}, len(poolsConfig.TransmuterCodeIDs))
 	for _, codeId := range poolsConfig.TransmuterCodeIDs {
@@ -38,36 +38,37 @@ func NewPoolsUsecase(poolsConfig *domain.PoolsConfig, nodeURI string, routerRepo
 		generalizedCosmWasmCodeIDsMap[codeId] = struct{}{}
 	}
 
+	nativePools, err := pools.NewNativeCosmWasmPoolRegistry(poolsConfig.NativeCosmWasmCodeIDs)
+	if err != nil {
+		panic(err)
+	}
+
 	return &poolsUseCase{
 		cosmWasmConfig: domain.CosmWasmPoolRouterConfig{
 			TransmuterCodeIDs:      transmuterCodeIDsMap,
 			GeneralCosmWasmCodeIDs: generalizedCosmWasmCodeIDsMap,
+			NativePools:            nativePools,
 			NodeURI:                nodeURI,
 		},
 
-		pools:            sync.Map{},
 		routerRepository: routerRepository,
 	}
 }
 
 // GetAllPools returns all pools from the repository.
-func (p *poolsUseCase) GetAllPools() (pools []sqsdomain.PoolI, err error) {
-	p.pools.Range(func(key, value interface{}) bool {
-		pool, ok := value.(sqsdomain.PoolI)
-		if !ok {
-			err = fmt.Errorf("failed to cast pool with value %v", value)
-			return false
-		}
-
-		pools = append(pools, pool)
-		return true
-	})
-
-	return pools, nil
+func (p *poolsUseCase) GetAllPools(ctx context.Context) ([]sqsdomain.PoolI, error) {
+	return p.getStateSnapshot(ctx).GetAllPools(), nil
 }
 
 // GetRoutesFromCandidates implements mvc.PoolsUsecase.
-func (p *poolsUseCase) GetRoutesFromCandidates(candidateRoutes sqsdomain.CandidateRoutes, tokenInDenom, tokenOutDenom string) ([]route.RouteImpl, error) {
+// The pools and the taker fees are read from the same state snapshot.
+// The routes containing generalized cosmwasm pools are excluded over a past state snapshot
+// since these pools are simulated against the latest state of the chain.
+func (p *poolsUseCase) GetRoutesFromCandidates(ctx context.Context, candidateRoutes sqsdomain.CandidateRoutes, tokenInDenom, tokenOutDenom string) ([]route.RouteImpl, error) {
+	snapshot := p.getStateSnapshot(ctx)
+	cosmWasmConfig := p.cosmWasmConfig.WithStateSnapshot(snapshot)
+	isLatestStateSnapshot := snapshot == p.routerRepository.GetStateSnapshot()
+
 	// We track whether a route contains a generalized cosmwasm pool
 	// so that we can exclude it from split quote logic.
 	// The reason for this is that making network requests to chain is expensive.
@@ -79,32 +80,40 @@ func (p *poolsUseCase) GetRoutesFromCandidates(candidateRoutes sqsdomain.Candida
 	for _, candidateRoute := range candidateRoutes.Routes {
 		previousTokenOutDenom := tokenInDenom
 		routablePools := make([]sqsdomain.RoutablePool, 0, len(candidateRoute.Pools))
+		isPastGeneralizedCosmWasmPoolRoute := false
 		for _, candidatePool := range candidateRoute.Pools {
-			pool, err := p.GetPool(candidatePool.ID)
+			pool, err := snapshot.GetPool(candidatePool.ID)
 			if err != nil {
 				return nil, err
 			}
 
-			// Get taker fee
-			takerFee, exists := p.routerRepository.GetTakerFee(previousTokenOutDenom, candidatePool.TokenOutDenom)
+			// Get taker fee of the denoms swapped by this hop
+			takerFee, exists := snapshot.GetTakerFee(previousTokenOutDenom, candidatePool.TokenOutDenom)
 			if !exists {
 				takerFee = sqsdomain.DefaultTakerFee
 			}
 
-			routablePool, err := pools.NewRoutablePool(pool, candidatePool.TokenOutDenom, takerFee, p.cosmWasmConfig)
+			routablePool, err := pools.NewRoutablePool(pool, previousTokenOutDenom, candidatePool.TokenOutDenom, takerFee, cosmWasmConfig)
 			if err != nil {
 				return nil, err
 			}
 
+			previousTokenOutDenom = candidatePool.TokenOutDenom
+
 			isGeneralizedCosmWasmPool := routablePool.IsGeneralizedCosmWasmPool()
 			if isGeneralizedCosmWasmPool {
 				containsGeneralizedCosmWasmPool = true
+				isPastGeneralizedCosmWasmPoolRoute = !isLatestStateSnapshot
 			}
 
 			// Create routable pool
 			routablePools = append(routablePools, routablePool)
 		}
 
+		if isPastGeneralizedCosmWasmPoolRoute {
+			continue
+		}
+
 		routes = append(routes, route.RouteImpl{
 			Pools:                      routablePools,
 			HasGeneralizedCosmWasmPool: containsGeneralizedCosmWasmPool,
@@ -115,10 +124,12 @@ func (p *poolsUseCase) GetRoutesFromCandidates(candidateRoutes sqsdomain.Candida
 }
 
 // GetTickModelMap implements mvc.PoolsUsecase.
-func (p *poolsUseCase) GetTickModelMap(poolIDs []uint64) (map[uint64]*sqsdomain.TickModel, error) {
+func (p *poolsUseCase) GetTickModelMap(ctx context.Context, poolIDs []uint64) (map[uint64]*sqsdomain.TickModel, error) {
+	snapshot := p.getStateSnapshot(ctx)
+
 	tickModelMap := make(map[uint64]*sqsdomain.TickModel, len(poolIDs))
 	for _, poolID := range poolIDs {
-		pool, err := p.GetPool(poolID)
+		pool, err := snapshot.GetPool(poolID)
 		if err != nil {
 			return nil, err
 		}
@@ -141,39 +152,40 @@ func (p *poolsUseCase) GetTickModelMap(poolIDs []uint64) (map[uint64]*sqsdomain.
 }
 
 // GetPool implements mvc.PoolsUsecase.
-func (p *poolsUseCase) GetPool(poolID uint64) (sqsdomain.PoolI, error) {
-	poolObj, ok := p.pools.Load(poolID)
-	if !ok {
-		return nil, domain.PoolNotFoundError{PoolID: poolID}
-	}
-
-	pool, ok := poolObj.(sqsdomain.PoolI)
-	if !ok {
-		return nil, fmt.Errorf("failed to cast pool with ID %d", poolID)
-	}
-
-	return pool, nil
+func (p *poolsUseCase) GetPool(ctx context.Context, poolID uint64) (sqsdomain.PoolI, error) {
+	return p.getStateSnapshot(ctx).GetPool(poolID)
 }
 
 // GetPoolSpotPrice implements mvc.PoolsUsecase.
 func (p *poolsUseCase) GetPoolSpotPrice(ctx context.Context, poolID uint64, takerFee math.LegacyDec, quoteAsset, baseAsset string) (osmomath.BigDec, error) {
-	pool, err := p.GetPool(poolID)
+	snapshot := p.getStateSnapshot(ctx)
+	ctx = domain.WithStateSnapshot(ctx, snapshot)
+
+	pool, err := snapshot.GetPool(poolID)
 	if err != nil {
 		return osmomath.BigDec{}, err
 	}
 
-	// Instrument pool with tick model data if concentrated
-	if err := p.getTicksAndSetTickModelIfConcentrated(pool); err != nil {
-		return osmomath.BigDec{}, err
+	// The tick model of a concentrated pool is attached at ingest. The pool is shared by all readers
+	// of the snapshot so it is only validated here rather than instrumented.
+	if pool.GetType() == poolmanagertypes.Concentrated {
+		if _, err := pool.GetTickModel(); err != nil {
+			return osmomath.BigDec{}, err
+		}
 	}
 
-	// N.B.: Empty string for token out denom because it is irrelevant for calculating spot price.
-	// It is only relevant in the context of routing
-	routablePool, err := pools.NewRoutablePool(pool, "", takerFee, p.cosmWasmConfig)
+	// N.B.: Empty strings for token in and token out denoms because they are irrelevant for calculating spot price.
+	// They are only relevant in the context of routing
+	routablePool, err := pools.NewRoutablePool(pool, "", "", takerFee, p.cosmWasmConfig.WithStateSnapshot(snapshot))
 	if err != nil {
 		return osmomath.BigDec{}, err
 	}
 
+	// Generalized cosmwasm pools are queried against the latest state of the chain.
+	if routablePool.IsGeneralizedCosmWasmPool() && snapshot != p.routerRepository.GetStateSnapshot() {
+		return osmomath.BigDec{}, domain.GeneralizedCosmWasmPoolPastHeightError{PoolId: poolID, Height: snapshot.GetHeight()}
+	}
+
 	return routablePool.CalcSpotPrice(ctx, baseAsset, quoteAsset)
 }
 
@@ -183,53 +195,14 @@ func (p *poolsUseCase) IsGeneralCosmWasmCodeID(codeId uint64) bool {
 	return isGenneralCosmWasmCodeID
 }
 
-// setTickModelMapIfConcentrated sets tick model for concentrated pools. No-op if pool is not concentrated.
-// If the pool is concentrated but the map does not contains the tick model, an error is returned.
-// The input pool parameter is mutated.
-func setTickModelIfConcentrated(pool sqsdomain.PoolI, tickModelMap map[uint64]*sqsdomain.TickModel) error {
-	if pool.GetType() == poolmanagertypes.Concentrated {
-		// Get tick model for concentrated pool
-		tickModel, ok := tickModelMap[pool.GetId()]
-		if !ok {
-			return domain.ConcentratedTickModelNotSetError{
-				PoolId: pool.GetId(),
-			}
-		}
-
-		if err := pool.SetTickModel(tickModel); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// getTicksAndSetTickModelIfConcentrated gets tick model for concentrated pools and sets it if this is a concentrated pool.
-// The input pool parameter is mutated.
-// No-op if pool is not concentrated.
-func (p *poolsUseCase) getTicksAndSetTickModelIfConcentrated(pool sqsdomain.PoolI) error {
-	if pool.GetType() == poolmanagertypes.Concentrated {
-		// Get tick model for concentrated pools
-		tickModelMap, err := p.GetTickModelMap([]uint64{pool.GetId()})
-		if err != nil {
-			return err
-		}
-
-		// Set tick model for concentrated pools
-		if err := setTickModelIfConcentrated(pool, tickModelMap); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
 // GetPools implements mvc.PoolsUsecase.
-func (p *poolsUseCase) GetPools(poolIDs []uint64) ([]sqsdomain.PoolI, error) {
+func (p *poolsUseCase) GetPools(ctx context.Context, poolIDs []uint64) ([]sqsdomain.PoolI, error) {
+	snapshot := p.getStateSnapshot(ctx)
+
 	pools := make([]sqsdomain.PoolI, 0, len(poolIDs))
 
 	for _, poolID := range poolIDs {
-		pool, err := p.GetPool(poolID)
+		pool, err := snapshot.GetPool(poolID)
 		if err != nil {
 			return nil, err
 		}
@@ -242,9 +215,10 @@ func (p *poolsUseCase) GetPools(poolIDs []uint64) ([]sqsdomain.PoolI, error) {
 
 // StorePools implements mvc.PoolsUsecase.
 func (p *poolsUseCase) StorePools(pools []sqsdomain.PoolI) error {
-	for _, pool := range pools {
-		p.pools.Store(pool.GetId(), pool)
-	}
+	p.routerRepository.UpdateStateSnapshot(func(latest *domain.StateSnapshot) *domain.StateSnapshot {
+		return latest.WithPools(pools)
+	})
+
 	return nil
 }
 
@@ -252,3 +226,11 @@ func (p *poolsUseCase) StorePools(pools []sqsdomain.PoolI) error {
 func (p *poolsUseCase) GetCosmWasmPoolConfig() domain.CosmWasmPoolRouterConfig {
 	return p.cosmWasmConfig
 }
+
+// getStateSnapshot returns the state snapshot pinned to the context or the latest one if none is pinned.
+func (p *poolsUseCase) getStateSnapshot(ctx context.Context) *domain.StateSnapshot {
+	if snapshot := domain.GetStateSnapshotFromContext(ctx); snapshot != nil {
+		return snapshot
+	}
+	return p.routerRepository.GetStateSnapshot()
+}
@@ -6,6 +6,7 @@ import (
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/osmosis-labs/osmosis/osmomath"
+	cwpoolmodel "github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/model"
 	"github.com/osmosis-labs/sqs/sqsdomain"
 	"github.com/stretchr/testify/suite"
 
@@ -66,6 +67,38 @@ func (s *PoolsUsecaseTestSuite) TestGetRoutesFromCandidates() {
 		defaultPool,
 	}
 
+	// Setup the second hop pool
+	secondPoolID := s.PrepareBalancerPoolWithCoins(sdk.NewCoin(denomTwo, defaultAmt0), sdk.NewCoin(denomThree, defaultAmt1))
+	secondBalancerPool, err := s.App.GAMMKeeper.GetPool(s.Ctx, secondPoolID)
+	s.Require().NoError(err)
+
+	secondPool := &mocks.MockRoutablePool{
+		ChainPoolModel: secondBalancerPool,
+		ID:             defaultPoolID + 1,
+	}
+
+	multiHopCandidateRoutes := sqsdomain.CandidateRoutes{
+		Routes: []sqsdomain.CandidateRoute{
+			{
+				Pools: []sqsdomain.CandidatePool{
+					{
+						ID:            defaultPoolID,
+						TokenOutDenom: denomTwo,
+					},
+					{
+						ID:            defaultPoolID + 1,
+						TokenOutDenom: denomThree,
+					},
+				},
+			},
+		},
+	}
+
+	var (
+		secondHopTakerFee = osmomath.MustNewDecFromStr("0.003")
+		routeTakerFee     = osmomath.MustNewDecFromStr("0.005")
+	)
+
 	validCandidateRoutes := sqsdomain.CandidateRoutes{
 		Routes: []sqsdomain.CandidateRoute{
 			{
@@ -112,7 +145,7 @@ func (s *PoolsUsecaseTestSuite) TestGetRoutesFromCandidates() {
 			expectedRoutes: []route.RouteImpl{
 				{
 					Pools: []sqsdomain.RoutablePool{
-						s.newRoutablePool(defaultPool, denomTwo, defaultTakerFee, domain.CosmWasmPoolRouterConfig{}),
+						s.newRoutablePool(defaultPool, denomOne, denomTwo, defaultTakerFee, domain.CosmWasmPoolRouterConfig{}),
 					},
 				},
 			},
@@ -132,7 +165,7 @@ func (s *PoolsUsecaseTestSuite) TestGetRoutesFromCandidates() {
 			expectedRoutes: []route.RouteImpl{
 				{
 					Pools: []sqsdomain.RoutablePool{
-						s.newRoutablePool(defaultPool, denomTwo, sqsdomain.DefaultTakerFee, domain.CosmWasmPoolRouterConfig{}),
+						s.newRoutablePool(defaultPool, denomOne, denomTwo, sqsdomain.DefaultTakerFee, domain.CosmWasmPoolRouterConfig{}),
 					},
 				},
 			},
@@ -154,8 +187,32 @@ func (s *PoolsUsecaseTestSuite) TestGetRoutesFromCandidates() {
 			},
 		},
 
+		{
+			name:  "valid conversion of single multi-hop route - taker fees by the denoms of each hop",
+			pools: []sqsdomain.PoolI{defaultPool, secondPool},
+
+			candidateRoutes: multiHopCandidateRoutes,
+			takerFeeMap: sqsdomain.TakerFeeMap{
+				sqsdomain.DenomPair{Denom0: denomOne, Denom1: denomTwo}:   defaultTakerFee,
+				sqsdomain.DenomPair{Denom0: denomTwo, Denom1: denomThree}: secondHopTakerFee,
+				// Must not be used since no hop swaps between the route token in and token out denoms.
+				sqsdomain.DenomPair{Denom0: denomOne, Denom1: denomThree}: routeTakerFee,
+			},
+
+			tokenInDenom:  denomOne,
+			tokenOutDenom: denomThree,
+
+			expectedRoutes: []route.RouteImpl{
+				{
+					Pools: []sqsdomain.RoutablePool{
+						s.newRoutablePool(defaultPool, denomOne, denomTwo, defaultTakerFee, domain.CosmWasmPoolRouterConfig{}),
+						s.newRoutablePool(secondPool, denomTwo, denomThree, secondHopTakerFee, domain.CosmWasmPoolRouterConfig{}),
+					},
+				},
+			},
+		},
+
 		// TODO:
-		// Valid conversion of single multi-hop route
 		// Valid conversion of two routes where one is multi hop
 	}
 
@@ -173,7 +230,7 @@ func (s *PoolsUsecaseTestSuite) TestGetRoutesFromCandidates() {
 			poolsUsecase.StorePools(tc.pools)
 
 			// System under test
-			actualRoutes, err := poolsUsecase.GetRoutesFromCandidates(tc.candidateRoutes, tc.tokenInDenom, tc.tokenOutDenom)
+			actualRoutes, err := poolsUsecase.GetRoutesFromCandidates(context.Background(), tc.candidateRoutes, tc.tokenInDenom, tc.tokenOutDenom)
 
 			if tc.expectedError != nil {
 				s.Require().Error(err)
@@ -192,9 +249,9 @@ func (s *PoolsUsecaseTestSuite) TestGetRoutesFromCandidates() {
 				// helper method for validation.
 				// Note token in is chosen arbitrarily since it is irrelevant for this test
 				tokenIn := sdk.NewCoin(tc.tokenInDenom, sdk.NewInt(100))
-				actualPools, _, _, err := actualRoute.PrepareResultPools(context.TODO(), tokenIn)
+				actualPools, _, _, err := actualRoute.PrepareResultPools(context.TODO(), tokenIn, nil)
 				s.Require().NoError(err)
-				expectedPools, _, _, err := expectedRoute.PrepareResultPools(context.TODO(), tokenIn)
+				expectedPools, _, _, err := expectedRoute.PrepareResultPools(context.TODO(), tokenIn, nil)
 				s.Require().NoError(err)
 
 				// Validates:
@@ -207,8 +264,111 @@ func (s *PoolsUsecaseTestSuite) TestGetRoutesFromCandidates() {
 	}
 }
 
-func (s *PoolsUsecaseTestSuite) newRoutablePool(pool sqsdomain.PoolI, tokenOutDenom string, takerFee osmomath.Dec, cosmWasmPoolIDs domain.CosmWasmPoolRouterConfig) sqsdomain.RoutablePool {
-	routablePool, err := pools.NewRoutablePool(pool, tokenOutDenom, takerFee, cosmWasmPoolIDs)
+func (s *PoolsUsecaseTestSuite) newRoutablePool(pool sqsdomain.PoolI, tokenInDenom string, tokenOutDenom string, takerFee osmomath.Dec, cosmWasmPoolIDs domain.CosmWasmPoolRouterConfig) sqsdomain.RoutablePool {
+	routablePool, err := pools.NewRoutablePool(pool, tokenInDenom, tokenOutDenom, takerFee, cosmWasmPoolIDs)
 	s.Require().NoError(err)
 	return routablePool
 }
+
+// Validates that the spot price of a concentrated pool is computed from the tick model attached at ingest
+// without replacing it and that it fails if the tick model is missing.
+func (s *PoolsUsecaseTestSuite) TestGetPoolSpotPrice_Concentrated() {
+	s.Setup()
+
+	concentratedPool := s.PrepareConcentratedPool()
+	s.SetupDefaultPosition(concentratedPool.GetId())
+
+	// Refetch the pool
+	concentratedPool, err := s.App.ConcentratedLiquidityKeeper.GetConcentratedPoolById(s.Ctx, concentratedPool.GetId())
+	s.Require().NoError(err)
+
+	ticks, currentTickIndex, err := s.App.ConcentratedLiquidityKeeper.GetTickLiquidityForFullRange(s.Ctx, concentratedPool.GetId())
+	s.Require().NoError(err)
+
+	tickModel := &sqsdomain.TickModel{
+		Ticks:            ticks,
+		CurrentTickIndex: currentTickIndex,
+	}
+
+	poolWrapper := &sqsdomain.PoolWrapper{
+		ChainModel: concentratedPool,
+		TickModel:  tickModel,
+	}
+
+	routerRepository := routerrepo.New()
+	poolsUsecase := usecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	err = poolsUsecase.StorePools([]sqsdomain.PoolI{poolWrapper})
+	s.Require().NoError(err)
+
+	expectedSpotPrice, err := concentratedPool.SpotPrice(s.Ctx, concentratedPool.GetToken1(), concentratedPool.GetToken0())
+	s.Require().NoError(err)
+
+	// System under test.
+	spotPrice, err := poolsUsecase.GetPoolSpotPrice(context.Background(), concentratedPool.GetId(), osmomath.ZeroDec(), concentratedPool.GetToken1(), concentratedPool.GetToken0())
+	s.Require().NoError(err)
+	s.Require().Equal(expectedSpotPrice, spotPrice)
+
+	// The stored pool is not mutated.
+	s.Require().Same(tickModel, poolWrapper.TickModel)
+
+	// The spot price fails rather than instrumenting the pool without a tick model.
+	err = poolsUsecase.StorePools([]sqsdomain.PoolI{&sqsdomain.PoolWrapper{ChainModel: concentratedPool}})
+	s.Require().NoError(err)
+
+	_, err = poolsUsecase.GetPoolSpotPrice(context.Background(), concentratedPool.GetId(), osmomath.ZeroDec(), concentratedPool.GetToken1(), concentratedPool.GetToken0())
+	s.Require().ErrorIs(err, sqsdomain.ConcentratedPoolNoTickModelError{PoolId: concentratedPool.GetId()})
+}
+
+// Validates that the routes containing generalized cosmwasm pools are excluded over a past state snapshot
+// and that their spot price fails since these pools are simulated against the latest state of the chain.
+func (s *PoolsUsecaseTestSuite) TestGetRoutesFromCandidates_PastStateSnapshot() {
+	s.Setup()
+
+	const generalizedCosmWasmCodeID = 1
+
+	balancerPoolID := s.PrepareBalancerPoolWithCoins(sdk.NewCoin(denomOne, defaultAmt0), sdk.NewCoin(denomTwo, defaultAmt1))
+	balancerPool, err := s.App.GAMMKeeper.GetPool(s.Ctx, balancerPoolID)
+	s.Require().NoError(err)
+
+	cosmWasmPoolID := balancerPoolID + 1
+	cosmWasmPool := &sqsdomain.PoolWrapper{
+		ChainModel: &cwpoolmodel.CosmWasmPool{
+			PoolId: cosmWasmPoolID,
+			CodeId: generalizedCosmWasmCodeID,
+		},
+		SQSModel: sqsdomain.SQSPool{
+			Balances:   sdk.NewCoins(sdk.NewCoin(denomOne, defaultAmt0), sdk.NewCoin(denomTwo, defaultAmt1)),
+			PoolDenoms: []string{denomOne, denomTwo},
+		},
+	}
+
+	routerRepository := routerrepo.New()
+	poolsUsecase := usecase.NewPoolsUsecase(&domain.PoolsConfig{GeneralCosmWasmCodeIDs: []uint64{generalizedCosmWasmCodeID}}, "http://localhost:26657", routerRepository)
+	err = poolsUsecase.StorePools([]sqsdomain.PoolI{&sqsdomain.PoolWrapper{ChainModel: balancerPool}, cosmWasmPool})
+	s.Require().NoError(err)
+
+	pastSnapshot := routerRepository.GetStateSnapshot()
+	routerRepository.UpdateStateSnapshot(func(latest *domain.StateSnapshot) *domain.StateSnapshot {
+		return latest.WithHeight(1)
+	})
+
+	candidateRoutes := sqsdomain.CandidateRoutes{
+		Routes: []sqsdomain.CandidateRoute{
+			{Pools: []sqsdomain.CandidatePool{{ID: balancerPoolID, TokenOutDenom: denomTwo}}},
+			{Pools: []sqsdomain.CandidatePool{{ID: cosmWasmPoolID, TokenOutDenom: denomTwo}}},
+		},
+	}
+
+	// System under test.
+	routes, err := poolsUsecase.GetRoutesFromCandidates(context.Background(), candidateRoutes, denomOne, denomTwo)
+	s.Require().NoError(err)
+	s.Require().Len(routes, 2)
+
+	routes, err = poolsUsecase.GetRoutesFromCandidates(domain.WithStateSnapshot(context.Background(), pastSnapshot), candidateRoutes, denomOne, denomTwo)
+	s.Require().NoError(err)
+	s.Require().Len(routes, 1)
+	s.Require().Equal(balancerPoolID, routes[0].GetPools()[0].GetId())
+
+	_, err = poolsUsecase.GetPoolSpotPrice(domain.WithStateSnapshot(context.Background(), pastSnapshot), cosmWasmPoolID, osmomath.ZeroDec(), denomTwo, denomOne)
+	s.Require().ErrorIs(err, domain.GeneralizedCosmWasmPoolPastHeightError{PoolId: cosmWasmPoolID, Height: pastSnapshot.GetHeight()})
+}
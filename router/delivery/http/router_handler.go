@@ -1,16 +1,25 @@
 package http
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
 
+	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
 
 	"github.com/osmosis-labs/osmosis/osmomath"
+	appparams "github.com/osmosis-labs/osmosis/v25/app/params"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
 	"github.com/osmosis-labs/sqs/domain"
 	"github.com/osmosis-labs/sqs/domain/mvc"
 	"github.com/osmosis-labs/sqs/log"
@@ -23,10 +32,131 @@ type RouterHandler struct {
 	logger   log.Logger
 }
 
-const routerResource = "/router"
+// BatchQuoteRequest is a single quote request in the POST /router/quotes request body.
+type BatchQuoteRequest struct {
+	// String representation of the sdk.Coin for the token in.
+	TokenIn string `json:"tokenIn"`
+	// Denom of the token out.
+	TokenOutDenom string            `json:"tokenOutDenom"`
+	Options       BatchQuoteOptions `json:"options"`
+}
+
+// BatchQuoteOptions are the per-request options of a batch quote request.
+// These mirror the query parameters of /router/quote.
+type BatchQuoteOptions struct {
+	SingleRoute      bool     `json:"singleRoute"`
+	ExcludedPoolIDs  []uint64 `json:"excludedPoolIDs"`
+	AllowedPoolIDs   []uint64 `json:"allowedPoolIDs"`
+	AllowedPoolTypes []uint64 `json:"allowedPoolTypes"`
+	ExcludedDenoms   []string `json:"excludedDenoms"`
+	// PreferredIntermediateDenoms are the denoms whose routes are searched first.
+	PreferredIntermediateDenoms []string `json:"preferredIntermediateDenoms"`
+	// MaxHops overrides the max pools per route if positive.
+	MaxHops int `json:"maxHops"`
+}
+
+// BatchQuoteResult is a single result in the POST /router/quotes response body.
+// Exactly one of the quote and error is set.
+type BatchQuoteResult struct {
+	Quote domain.Quote `json:"quote,omitempty"`
+	Error string       `json:"error,omitempty"`
+}
+
+// QuoteWithSwapMsgsResponse is the /router/quote response when the sender is given.
+type QuoteWithSwapMsgsResponse struct {
+	Quote domain.Quote `json:"quote"`
+	Msgs  []SwapMsg    `json:"msgs"`
+}
+
+// QuoteWithDebugInfoResponse is the /router/quote response when debug is enabled.
+// The swap messages are only set if the sender is given.
+type QuoteWithDebugInfoResponse struct {
+	Quote domain.Quote           `json:"quote"`
+	Msgs  []SwapMsg              `json:"msgs,omitempty"`
+	Debug *domain.QuoteDebugInfo `json:"debug"`
+}
+
+// QuoteSubscriptionMessage is a message pushed over the /router/quote-subscription WebSocket.
+// Exactly one of the quote and error is set.
+type QuoteSubscriptionMessage struct {
+	// Height of the block after which the quote was computed.
+	// Zero if no block has been ingested since the start.
+	Height uint64       `json:"height"`
+	Quote  domain.Quote `json:"quote,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// SwapMsg is a ready-to-sign swap message.
+type SwapMsg struct {
+	// Type URL of the message, e.g. /osmosis.poolmanager.v1beta1.MsgSwapExactAmountIn.
+	TypeURL string `json:"type_url"`
+	// Proto3 JSON encoding of the message.
+	JSON json.RawMessage `json:"json"`
+	// Proto encoding of the message. Base64 encoded in the response.
+	Proto []byte `json:"proto"`
+}
+
+// DepthResponse is the /router/depth response.
+type DepthResponse struct {
+	TokenInDenom  string      `json:"token_in_denom"`
+	TokenOutDenom string      `json:"token_out_denom"`
+	Rungs         []DepthRung `json:"rungs"`
+}
+
+// DepthRung is the quote for a single amount in of the depth ladder.
+// If the quote fails, only the amount in and the error are set.
+type DepthRung struct {
+	AmountIn  osmomath.Int `json:"amount_in"`
+	AmountOut osmomath.Int `json:"amount_out"`
+	// Amount out per unit of amount in.
+	EffectivePrice osmomath.Dec `json:"effective_price"`
+	PriceImpact    osmomath.Dec `json:"price_impact"`
+	Error          string       `json:"error,omitempty"`
+}
+
+// quoteRequestParameters are the query parameters shared by the quote endpoints.
+// See parseQuoteRequest.
+type quoteRequestParameters struct {
+	tokenIn       sdk.Coin
+	tokenOutDenom string
+	// The pool and denom filters as well as the max hops.
+	routerOpts           []domain.RouterOption
+	isSingleRoute        bool
+	shouldApplyExponents bool
+}
+
+const (
+	routerResource = "/router"
+
+	// maxBatchQuoteRequests is the maximum number of quote requests in a single batch.
+	maxBatchQuoteRequests = 500
+
+	// maxDepthAmounts is the maximum number of amounts in a single depth request.
+	maxDepthAmounts = 100
+	// defaultDepthNumDecades is the number of decades spanned by the default depth amounts
+	// starting from one unit of the token in.
+	defaultDepthNumDecades = 6
+	// defaultDepthAmountsPerDecade is the number of default depth amounts per decade.
+	defaultDepthAmountsPerDecade = 2
+
+	// quoteSubscriptionWriteTimeout is the time allowed to write a message to the quote subscriber.
+	quoteSubscriptionWriteTimeout = 10 * time.Second
+	// quoteSubscriptionPongWait is the time allowed to receive the pong to the last ping
+	// before the quote subscriber is considered gone.
+	quoteSubscriptionPongWait = 60 * time.Second
+	// quoteSubscriptionPingInterval is the interval of the pings sent to the quote subscriber.
+	// Must be less than quoteSubscriptionPongWait.
+	quoteSubscriptionPingInterval = 30 * time.Second
+)
 
 var (
 	oneDec = osmomath.OneDec()
+
+	// quoteSubscriptionUpgrader upgrades the quote subscription requests to WebSocket.
+	// Any origin is accepted since the quotes are public, same as for the HTTP endpoints.
+	quoteSubscriptionUpgrader = websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
 )
 
 func formatRouterResource(resource string) string {
@@ -41,6 +171,11 @@ func NewRouterHandler(e *echo.Echo, us mvc.RouterUsecase, tu mvc.TokensUsecase,
 		logger:   logger,
 	}
 	e.GET(formatRouterResource("/quote"), handler.GetOptimalQuote)
+	e.POST(formatRouterResource("/quotes"), handler.GetOptimalQuotes)
+	e.GET(formatRouterResource("/quote-subscription"), handler.SubscribeOptimalQuote)
+	e.GET(formatRouterResource("/quote-out"), handler.GetOptimalQuoteInGivenOut)
+	e.GET(formatRouterResource("/depth"), handler.GetQuoteDepth)
+	e.GET(formatRouterResource("/arbitrage"), handler.GetArbitrage)
 	e.GET(formatRouterResource("/routes"), handler.GetCandidateRoutes)
 	e.GET(formatRouterResource("/cached-routes"), handler.GetCachedCandidateRoutes)
 	e.GET(formatRouterResource("/spot-price-pool/:id"), handler.GetSpotPriceForPool)
@@ -60,11 +195,407 @@ func NewRouterHandler(e *echo.Echo, us mvc.RouterUsecase, tu mvc.TokensUsecase,
 // @Param  singleRoute  query  bool  false  "Boolean flag indicating whether to return single routes (no splits). False (splits enabled) by default."
 // @Param humanDenoms query bool true "Boolean flag indicating whether the given denoms are human readable or not. Human denoms get converted to chain internally"
 // @Param  applyExponents  query  bool  false  "Boolean flag indicating whether to apply exponents to the spot price. False by default."
+// @Param  excludedPoolIDs  query  string  false  "Comma-separated list of pool IDs that must not be used for routing."
+// @Param  allowedPoolIDs  query  string  false  "Comma-separated list of pool IDs. If set, only these pools are used for routing."
+// @Param  allowedPoolTypes  query  string  false  "Comma-separated list of pool types (0 - balancer, 1 - stableswap, 2 - concentrated, 3 - cosmwasm). If set, only pools of these types are used for routing."
+// @Param  excludedDenoms  query  string  false  "Comma-separated list of denoms that must not be used as intermediary hops."
+// @Param  preferredIntermediateDenoms  query  string  false  "Comma-separated list of denoms whose routes are searched first when used as intermediary hops."
+// @Param  maxHops  query  int  false  "Max number of pools per route. Must not exceed the configured max pools per route."
+// @Param  sender  query  string  false  "Bech32 address of the sender. If set, the response contains the quote together with the swap messages executing it."
+// @Param  slippageBps  query  int  false  "Slippage tolerance in basis points used for the token out min amount of the swap messages. Required if sender is set."
 // @Success 200  {object}  domain.Quote  "The computed best route quote"
+// @Param  debug  query  bool  false  "Boolean flag indicating whether to return the debug info explaining how the quote was computed. False by default."
+// @Param  maxLatencyMs  query  int  false  "Time budget of the quote in milliseconds. Once exceeded, the best quote found so far is returned with the truncated flag set."
+// @Param  height  query  int  false  "Height of a past block to compute the quote at. Only the recently ingested blocks are retained. The latest height by default."
+// @Param  gasPrice  query  string  false  "Price of a unit of gas in the fee denom. If set together with feeDenomPrice, the split is selected by the amount out net of the estimated gas cost."
+// @Param  feeDenomPrice  query  string  false  "Price of the fee denom denominated in the token out. Required if gasPrice is set."
+// @Success 200  {object}  QuoteWithSwapMsgsResponse  "The computed best route quote with the swap messages if sender is set"
+// @Success 200  {object}  QuoteWithDebugInfoResponse  "The computed best route quote with the debug info if debug is set"
 // @Router /router/quote [get]
 func (a *RouterHandler) GetOptimalQuote(c echo.Context) (err error) {
 	ctx := c.Request().Context()
 
+	params, err := a.parseQuoteRequest(c, false)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
+	}
+
+	tokenIn, tokenOutDenom, routerOpts := params.tokenIn, params.tokenOutDenom, params.routerOpts
+
+	isDebugStr := c.QueryParam("debug")
+	isDebug := false
+	if isDebugStr != "" {
+		isDebug, err = strconv.ParseBool(isDebugStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
+		}
+	}
+
+	// The debug info is collected by the router while computing the quote.
+	var debugInfo *domain.QuoteDebugInfo
+	if isDebug {
+		ctx, debugInfo = domain.WithQuoteDebugInfo(ctx)
+	}
+
+	if maxLatencyMsStr := c.QueryParam("maxLatencyMs"); maxLatencyMsStr != "" {
+		maxLatencyMs, err := strconv.ParseUint(maxLatencyMsStr, 10, 64)
+		if err != nil || maxLatencyMs == 0 {
+			return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: fmt.Sprintf("maxLatencyMs (%s) must be a positive integer", maxLatencyMsStr)})
+		}
+
+		routerOpts = append(routerOpts, domain.WithMaxLatency(time.Duration(maxLatencyMs)*time.Millisecond))
+	}
+
+	gasPriceOpt, err := getGasPriceOption(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
+	}
+	if gasPriceOpt != nil {
+		routerOpts = append(routerOpts, gasPriceOpt)
+	}
+
+	sender, slippageBps, err := getSwapMsgParameters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
+	}
+
+	var quote domain.Quote
+	if params.isSingleRoute && len(routerOpts) == 0 {
+		quote, err = a.RUsecase.GetBestSingleRouteQuote(ctx, tokenIn, tokenOutDenom)
+	} else {
+		// The best single route quote does not support filters so
+		// we fallback to the optimal quote with splits disabled.
+		if params.isSingleRoute {
+			routerOpts = append(routerOpts, domain.WithDisableSplitRoutes())
+		}
+
+		quote, err = a.RUsecase.GetOptimalQuote(ctx, tokenIn, tokenOutDenom, routerOpts...)
+	}
+	if err != nil {
+		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
+	}
+
+	scalingFactor := oneDec
+	if params.shouldApplyExponents {
+		scalingFactor = a.getSpotPriceScalingFactor(tokenIn.Denom, tokenOutDenom)
+	}
+
+	// Note that the messages are created prior to preparing the result
+	// since the latter mutates the routes.
+	var swapMsgs []SwapMsg
+	if sender != "" {
+		swapMsgs, err = getSwapMsgs(quote, sender, slippageBps)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
+		}
+	}
+
+	_, _, err = quote.PrepareResult(ctx, scalingFactor)
+	if err != nil {
+		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
+	}
+
+	if isDebug {
+		return c.JSON(http.StatusOK, QuoteWithDebugInfoResponse{
+			Quote: quote,
+			Msgs:  swapMsgs,
+			Debug: debugInfo,
+		})
+	}
+
+	if sender != "" {
+		return c.JSON(http.StatusOK, QuoteWithSwapMsgsResponse{
+			Quote: quote,
+			Msgs:  swapMsgs,
+		})
+	}
+
+	return c.JSON(http.StatusOK, quote)
+}
+
+// @Summary Batch Optimal Quotes
+// @Description returns the best quote for every request in the body. The quotes are computed concurrently over the same pools.
+// Every result contains either the quote or the error for the request at the same index.
+// The options of every request mirror the query parameters of /router/quote.
+// @ID post-route-quotes
+// @Accept  json
+// @Produce  json
+// @Param  requests  body  []BatchQuoteRequest  true  "The quote requests."
+// @Param humanDenoms query bool true "Boolean flag indicating whether the given denoms are human readable or not. Human denoms get converted to chain internally"
+// @Param  applyExponents  query  bool  false  "Boolean flag indicating whether to apply exponents to the spot price. False by default."
+// @Success 200  {array}  BatchQuoteResult  "The quote results in the order of the requests"
+// @Router /router/quotes [post]
+func (a *RouterHandler) GetOptimalQuotes(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	shouldApplyExponentsStr := c.QueryParam("applyExponents")
+	shouldApplyExponents := false
+	if shouldApplyExponentsStr != "" {
+		shouldApplyExponents, err = strconv.ParseBool(shouldApplyExponentsStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
+		}
+	}
+
+	isHumanDenoms, err := getIsHumanDenoms(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
+	}
+
+	var batchRequests []BatchQuoteRequest
+	if err := c.Bind(&batchRequests); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: "request body must be an array of quote requests"})
+	}
+
+	if len(batchRequests) == 0 {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: "at least one quote request is required"})
+	}
+
+	if len(batchRequests) > maxBatchQuoteRequests {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: fmt.Sprintf("at most %d quote requests are allowed, got %d", maxBatchQuoteRequests, len(batchRequests))})
+	}
+
+	results := make([]BatchQuoteResult, len(batchRequests))
+
+	// Invalid requests fail individually. Only the valid ones are routed.
+	quoteRequests := make([]domain.QuoteRequest, 0, len(batchRequests))
+	quoteRequestIndexes := make([]int, 0, len(batchRequests))
+	for i, batchRequest := range batchRequests {
+		quoteRequest, err := a.getQuoteRequest(c, batchRequest, isHumanDenoms)
+		if err != nil {
+			results[i] = BatchQuoteResult{Error: err.Error()}
+			continue
+		}
+
+		quoteRequests = append(quoteRequests, quoteRequest)
+		quoteRequestIndexes = append(quoteRequestIndexes, i)
+	}
+
+	quoteResults := a.RUsecase.GetOptimalQuotes(ctx, quoteRequests)
+
+	for j, quoteResult := range quoteResults {
+		i := quoteRequestIndexes[j]
+
+		if quoteResult.Err != nil {
+			results[i] = BatchQuoteResult{Error: quoteResult.Err.Error()}
+			continue
+		}
+
+		scalingFactor := oneDec
+		if shouldApplyExponents {
+			scalingFactor = a.getSpotPriceScalingFactor(quoteRequests[j].TokenIn.Denom, quoteRequests[j].TokenOutDenom)
+		}
+
+		if _, _, err := quoteResult.Quote.PrepareResult(ctx, scalingFactor); err != nil {
+			results[i] = BatchQuoteResult{Error: err.Error()}
+			continue
+		}
+
+		results[i] = BatchQuoteResult{Quote: quoteResult.Quote}
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// @Summary Optimal Quote Subscription
+// @Description upgrades the connection to a WebSocket that pushes the best quote for the given tokenIn and tokenOutDenom.
+// The current quote is pushed right away. Afterwards, a fresh quote is pushed after every ingested block
+// but only if its amount out or route changed. A client that falls behind only receives the latest quote.
+// The parameters mirror /router/quote. The client is not expected to send any messages.
+// @ID get-route-quote-subscription
+// @Produce  json
+// @Param  tokenIn  query  string  true  "String representation of the sdk.Coin for the token in."
+// @Param  tokenOutDenom  query  string  true  "String representing the denom of the token out."
+// @Param  singleRoute  query  bool  false  "Boolean flag indicating whether to return single routes (no splits). False (splits enabled) by default."
+// @Param humanDenoms query bool true "Boolean flag indicating whether the given denoms are human readable or not. Human denoms get converted to chain internally"
+// @Param  applyExponents  query  bool  false  "Boolean flag indicating whether to apply exponents to the spot price. False by default."
+// @Param  excludedPoolIDs  query  string  false  "Comma-separated list of pool IDs that must not be used for routing."
+// @Param  allowedPoolIDs  query  string  false  "Comma-separated list of pool IDs. If set, only these pools are used for routing."
+// @Param  allowedPoolTypes  query  string  false  "Comma-separated list of pool types (0 - balancer, 1 - stableswap, 2 - concentrated, 3 - cosmwasm). If set, only pools of these types are used for routing."
+// @Param  excludedDenoms  query  string  false  "Comma-separated list of denoms that must not be used as intermediary hops."
+// @Param  preferredIntermediateDenoms  query  string  false  "Comma-separated list of denoms whose routes are searched first when used as intermediary hops."
+// @Param  maxHops  query  int  false  "Max number of pools per route. Must not exceed the configured max pools per route."
+// @Success 101  {object}  QuoteSubscriptionMessage  "Every message pushed over the WebSocket"
+// @Router /router/quote-subscription [get]
+func (a *RouterHandler) SubscribeOptimalQuote(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	params, err := a.parseQuoteRequest(c, false)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
+	}
+
+	routerOpts := params.routerOpts
+	if params.isSingleRoute {
+		routerOpts = append(routerOpts, domain.WithDisableSplitRoutes())
+	}
+
+	scalingFactor := oneDec
+	if params.shouldApplyExponents {
+		scalingFactor = a.getSpotPriceScalingFactor(params.tokenIn.Denom, params.tokenOutDenom)
+	}
+
+	// Subscribe prior to upgrading so that the rejected subscriptions are replied to over HTTP.
+	updates, unsubscribe, err := a.RUsecase.SubscribeQuote(domain.QuoteRequest{
+		TokenIn:       params.tokenIn,
+		TokenOutDenom: params.tokenOutDenom,
+		Options:       routerOpts,
+	})
+	if err != nil {
+		if errors.As(err, &domain.QuoteSubscriptionsLimitReachedError{}) {
+			return c.JSON(http.StatusServiceUnavailable, domain.ResponseError{Message: err.Error()})
+		}
+		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
+	}
+	defer unsubscribe()
+
+	conn, err := quoteSubscriptionUpgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// The upgrader has already replied with the error.
+		a.logger.Debug("failed to upgrade quote subscription", zap.Error(err))
+		return nil
+	}
+	defer conn.Close()
+
+	// Reading processes the pongs and detects the closed connection.
+	// The messages sent by the client are discarded.
+	isConnClosed := make(chan struct{})
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(quoteSubscriptionPongWait))
+	})
+	go func() {
+		defer close(isConnClosed)
+
+		if err := conn.SetReadDeadline(time.Now().Add(quoteSubscriptionPongWait)); err != nil {
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	pingTicker := time.NewTicker(quoteSubscriptionPingInterval)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case <-isConnClosed:
+			return nil
+		case <-pingTicker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(quoteSubscriptionWriteTimeout)); err != nil {
+				return nil
+			}
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+
+			message := QuoteSubscriptionMessage{Height: update.Height}
+			if update.Err == nil {
+				_, _, update.Err = update.Quote.PrepareResult(ctx, scalingFactor)
+			}
+			if update.Err != nil {
+				message.Error = update.Err.Error()
+			} else {
+				message.Quote = update.Quote
+			}
+
+			if err := conn.SetWriteDeadline(time.Now().Add(quoteSubscriptionWriteTimeout)); err != nil {
+				return nil
+			}
+			if err := conn.WriteJSON(message); err != nil {
+				a.logger.Debug("failed to push quote to subscriber", zap.Error(err))
+				return nil
+			}
+		}
+	}
+}
+
+// @Summary Quote Depth
+// @Description returns the amount out, effective price and price impact of the optimal quote for every amount of tokenInDenom.
+// The quotes are computed over the same pools.
+// If `amounts` is not set, the amounts range from one to a million units of the token in, evenly spaced on a log scale.
+// @ID get-route-depth
+// @Produce  json
+// @Param  tokenInDenom  query  string  true  "String representing the denom of the token in."
+// @Param  tokenOutDenom  query  string  true  "String representing the denom of the token out."
+// @Param  amounts  query  string  false  "Comma-separated list of the token in amounts in chain units."
+// @Param humanDenoms query bool true "Boolean flag indicating whether the given denoms are human readable or not. Human denoms get converted to chain internally"
+// @Param  excludedPoolIDs  query  string  false  "Comma-separated list of pool IDs that must not be used for routing."
+// @Param  allowedPoolIDs  query  string  false  "Comma-separated list of pool IDs. If set, only these pools are used for routing."
+// @Param  allowedPoolTypes  query  string  false  "Comma-separated list of pool types (0 - balancer, 1 - stableswap, 2 - concentrated, 3 - cosmwasm). If set, only pools of these types are used for routing."
+// @Param  excludedDenoms  query  string  false  "Comma-separated list of denoms that must not be used as intermediary hops."
+// @Param  preferredIntermediateDenoms  query  string  false  "Comma-separated list of denoms whose routes are searched first when used as intermediary hops."
+// @Param  maxHops  query  int  false  "Max number of pools per route. Must not exceed the configured max pools per route."
+// @Success 200  {object}  DepthResponse  "The quote for every amount in the order of the amounts"
+// @Router /router/depth [get]
+func (a *RouterHandler) GetQuoteDepth(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	params, err := a.parseQuoteRequest(c, true)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
+	}
+
+	tokenInDenom, tokenOutDenom := params.tokenIn.Denom, params.tokenOutDenom
+
+	amounts, err := a.getDepthAmounts(c, tokenInDenom)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
+	}
+
+	quoteResults := a.RUsecase.GetQuoteDepth(ctx, tokenInDenom, tokenOutDenom, amounts, params.routerOpts...)
+
+	rungs := make([]DepthRung, 0, len(quoteResults))
+	for i, quoteResult := range quoteResults {
+		rung := DepthRung{
+			AmountIn:       amounts[i],
+			AmountOut:      osmomath.ZeroInt(),
+			EffectivePrice: osmomath.ZeroDec(),
+			PriceImpact:    osmomath.ZeroDec(),
+		}
+
+		if quoteResult.Err == nil {
+			_, _, quoteResult.Err = quoteResult.Quote.PrepareResult(ctx, oneDec)
+		}
+
+		if quoteResult.Err != nil {
+			rung.Error = quoteResult.Err.Error()
+			rungs = append(rungs, rung)
+			continue
+		}
+
+		rung.AmountOut = quoteResult.Quote.GetAmountOut()
+		rung.EffectivePrice = rung.AmountOut.ToLegacyDec().QuoMut(rung.AmountIn.ToLegacyDec())
+		rung.PriceImpact = quoteResult.Quote.GetPriceImpact()
+
+		rungs = append(rungs, rung)
+	}
+
+	return c.JSON(http.StatusOK, DepthResponse{
+		TokenInDenom:  tokenInDenom,
+		TokenOutDenom: tokenOutDenom,
+		Rungs:         rungs,
+	})
+}
+
+// @Summary Optimal Quote In Given Out
+// @Description returns the best quote it can compute for receiving exactly the given tokenOut in exchange for tokenInDenom.
+// If `singleRoute` parameter is set to true, it gives the best single quote while excluding splits.
+// @ID get-route-quote-out
+// @Produce  json
+// @Param  tokenOut  query  string  true  "String representation of the sdk.Coin for the token out."
+// @Param  tokenInDenom  query  string  true  "String representing the denom of the token in."
+// @Param  singleRoute  query  bool  false  "Boolean flag indicating whether to return single routes (no splits). False (splits enabled) by default."
+// @Param humanDenoms query bool true "Boolean flag indicating whether the given denoms are human readable or not. Human denoms get converted to chain internally"
+// @Param  applyExponents  query  bool  false  "Boolean flag indicating whether to apply exponents to the spot price. False by default."
+// @Success 200  {object}  domain.Quote  "The computed best route quote"
+// @Router /router/quote-out [get]
+func (a *RouterHandler) GetOptimalQuoteInGivenOut(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
 	isSingleRouteStr := c.QueryParam("singleRoute")
 	isSingleRoute := false
 	if isSingleRouteStr != "" {
@@ -83,26 +614,26 @@ func (a *RouterHandler) GetOptimalQuote(c echo.Context) (err error) {
 		}
 	}
 
-	tokenOutDenom, tokenIn, err := getValidRoutingParameters(c)
+	tokenInDenom, tokenOut, err := getValidInGivenOutRoutingParameters(c)
 	if err != nil {
 		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
 	}
 
 	// translate denoms from human to chain if needed
-	tokenOutDenom, tokenInDenom, err := a.getChainDenoms(c, tokenOutDenom, tokenIn.Denom)
+	tokenOutDenom, tokenInDenom, err := a.getChainDenoms(c, tokenOut.Denom, tokenInDenom)
 	if err != nil {
 		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
 	}
 
-	// Update coins token in denom it case it was translated from human to chain.
-	tokenIn.Denom = tokenInDenom
+	// Update coins token out denom it case it was translated from human to chain.
+	tokenOut.Denom = tokenOutDenom
 
-	var quote domain.Quote
+	routerOptions := []domain.RouterOption{}
 	if isSingleRoute {
-		quote, err = a.RUsecase.GetBestSingleRouteQuote(ctx, tokenIn, tokenOutDenom)
-	} else {
-		quote, err = a.RUsecase.GetOptimalQuote(ctx, tokenIn, tokenOutDenom)
+		routerOptions = append(routerOptions, domain.WithDisableSplitRoutes())
 	}
+
+	quote, err := a.RUsecase.GetOptimalQuoteInGivenOut(ctx, tokenOut, tokenInDenom, routerOptions...)
 	if err != nil {
 		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
 	}
@@ -120,12 +651,22 @@ func (a *RouterHandler) GetOptimalQuote(c echo.Context) (err error) {
 	return c.JSON(http.StatusOK, quote)
 }
 
-// GetDirectCustomQuote returns a direct custom quote. It does not search for the route.
-// It directly computes the quote for the given poolID.
+// @Summary Custom Direct Quote
+// @Description returns a direct custom quote. It does not search for the route.
+// It directly computes the quote for the given pool IDs. Multi-hop routes are supported by providing
+// comma-separated pool IDs and token out denoms where the i-th pool swaps into the i-th token out denom.
+// @ID get-route-custom-direct-quote
+// @Produce  json
+// @Param  tokenIn  query  string  true  "String representation of the sdk.Coin for the token in."
+// @Param  tokenOutDenom  query  string  true  "Comma-separated denoms of the token out for each hop."
+// @Param  poolID  query  string  true  "Comma-separated pool IDs for each hop."
+// @Param  applyExponents  query  bool  false  "Boolean flag indicating whether to apply exponents to the spot price. False by default."
+// @Success 200  {object}  domain.Quote  "The computed quote"
+// @Router /router/custom-direct-quote [get]
 func (a *RouterHandler) GetDirectCustomQuote(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	tokenOutDenom, tokenIn, err := getValidRoutingParameters(c)
+	tokenOutDenomsStr, tokenIn, err := getValidRoutingParameters(c)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
 	}
@@ -144,20 +685,25 @@ func (a *RouterHandler) GetDirectCustomQuote(c echo.Context) error {
 		}
 	}
 
-	poolID, err := strconv.ParseUint(poolIDStr, 10, 64)
+	poolIDs, err := domain.ParseNumbers(poolIDStr)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
 	}
 
+	tokenOutDenoms := domain.ParseDenoms(tokenOutDenomsStr)
+	if len(tokenOutDenoms) != len(poolIDs) {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: fmt.Sprintf("number of pool IDs (%d) does not match number of token out denoms (%d)", len(poolIDs), len(tokenOutDenoms))})
+	}
+
 	// Quote
-	quote, err := a.RUsecase.GetCustomDirectQuote(ctx, tokenIn, tokenOutDenom, poolID)
+	quote, err := a.RUsecase.GetCustomDirectQuoteMultiPool(ctx, tokenIn, tokenOutDenoms, poolIDs)
 	if err != nil {
 		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
 	}
 
 	scalingFactor := oneDec
 	if shouldApplyExponents {
-		scalingFactor = a.getSpotPriceScalingFactor(tokenIn.Denom, tokenOutDenom)
+		scalingFactor = a.getSpotPriceScalingFactor(tokenIn.Denom, tokenOutDenoms[len(tokenOutDenoms)-1])
 	}
 
 	_, _, err = quote.PrepareResult(ctx, scalingFactor)
@@ -175,6 +721,12 @@ func (a *RouterHandler) GetDirectCustomQuote(c echo.Context) error {
 // @Param  tokenIn  query  string  true  "The string representation of the denom of the token in"
 // @Param  tokenOutDenom  query  string  true  "The string representation of the denom of the token out"
 // @Param humanDenoms query bool true "Boolean flag indicating whether the given denoms are human readable or not. Human denoms get converted to chain internally"
+// @Param  excludedPoolIDs  query  string  false  "Comma-separated list of pool IDs that must not be used for routing."
+// @Param  allowedPoolIDs  query  string  false  "Comma-separated list of pool IDs. If set, only these pools are used for routing."
+// @Param  allowedPoolTypes  query  string  false  "Comma-separated list of pool types (0 - balancer, 1 - stableswap, 2 - concentrated, 3 - cosmwasm). If set, only pools of these types are used for routing."
+// @Param  excludedDenoms  query  string  false  "Comma-separated list of denoms that must not be used as intermediary hops."
+// @Param  preferredIntermediateDenoms  query  string  false  "Comma-separated list of denoms whose routes are searched first when used as intermediary hops."
+// @Param  maxHops  query  int  false  "Max number of pools per route. Must not exceed the configured max pools per route."
 // @Success 200  {array}  sqsdomain.CandidateRoutes  "An array of possible routing options"
 // @Router /router/routes [get]
 func (a *RouterHandler) GetCandidateRoutes(c echo.Context) error {
@@ -191,7 +743,12 @@ func (a *RouterHandler) GetCandidateRoutes(c echo.Context) error {
 		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
 	}
 
-	routes, err := a.RUsecase.GetCandidateRoutes(ctx, sdk.NewCoin(tokenIn, osmomath.OneInt()), tokenOutDenom)
+	routerOpts, err := a.getRouteFilterOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
+	}
+
+	routes, err := a.RUsecase.GetCandidateRoutes(ctx, sdk.NewCoin(tokenIn, osmomath.OneInt()), tokenOutDenom, routerOpts...)
 	if err != nil {
 		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
 	}
@@ -209,7 +766,7 @@ func (a *RouterHandler) GetTakerFee(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, domain.ResponseError{Message: err.Error()})
 	}
 
-	takerFees, err := a.RUsecase.GetTakerFee(poolID)
+	takerFees, err := a.RUsecase.GetTakerFee(c.Request().Context(), poolID)
 	if err != nil {
 		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
 	}
@@ -232,9 +789,28 @@ func (a *RouterHandler) GetCachedCandidateRoutes(c echo.Context) error {
 		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
 	}
 
+	// The cached routes may be computed at any of the latest heights.
+	c.Response().Header().Del(domain.HeightHeader)
+
 	return c.JSON(http.StatusOK, routes)
 }
 
+// @Summary Arbitrage Cycles
+// @Description returns the profitable cycles over the pools found after the latest ingested block.
+// Every cycle starts and ends in the same denom and is verified by quoting the amount in that maximizes the profit.
+// @ID get-router-arbitrage
+// @Produce  json
+// @Success 200  {object}  domain.ArbitrageResult  "The profitable cycles and the height they were found at"
+// @Router /router/arbitrage [get]
+func (a *RouterHandler) GetArbitrage(c echo.Context) error {
+	arbitrage := a.RUsecase.GetArbitrage()
+
+	// The cycles are detected in the background so they may lag behind the latest height.
+	c.Response().Header().Set(domain.HeightHeader, strconv.FormatUint(arbitrage.Height, 10))
+
+	return c.JSON(http.StatusOK, arbitrage)
+}
+
 // TODO: authentication for the endpoint and enable only in dev mode.
 func (a *RouterHandler) StoreRouterStateInFiles(c echo.Context) error {
 	if err := a.RUsecase.StoreRouterStateFiles(); err != nil {
@@ -245,7 +821,7 @@ func (a *RouterHandler) StoreRouterStateInFiles(c echo.Context) error {
 }
 
 func (a *RouterHandler) GetRouterState(c echo.Context) error {
-	routerState, err := a.RUsecase.GetRouterState()
+	routerState, err := a.RUsecase.GetRouterState(c.Request().Context())
 	if err != nil {
 		return c.JSON(domain.GetStatusCode(err), domain.ResponseError{Message: err.Error()})
 	}
@@ -280,6 +856,63 @@ func (a *RouterHandler) GetSpotPriceForPool(c echo.Context) error {
 	return c.JSON(http.StatusOK, spotPrice)
 }
 
+// parseQuoteRequest parses the query parameters shared by /router/quote, /router/quote-subscription and /router/depth.
+// If isTokenInDenomOnly is set, the token in is given by the tokenInDenom parameter and its amount is zero.
+// Otherwise, it is given by the tokenIn parameter. The denoms are converted to chain denoms if humanDenoms is set.
+// The single route option is not applied to the returned router options since its handling differs per endpoint.
+func (a *RouterHandler) parseQuoteRequest(c echo.Context, isTokenInDenomOnly bool) (quoteRequestParameters, error) {
+	var (
+		params quoteRequestParameters
+		err    error
+	)
+
+	if isSingleRouteStr := c.QueryParam("singleRoute"); isSingleRouteStr != "" {
+		params.isSingleRoute, err = strconv.ParseBool(isSingleRouteStr)
+		if err != nil {
+			return quoteRequestParameters{}, fmt.Errorf("singleRoute is invalid: %w", err)
+		}
+	}
+
+	if shouldApplyExponentsStr := c.QueryParam("applyExponents"); shouldApplyExponentsStr != "" {
+		params.shouldApplyExponents, err = strconv.ParseBool(shouldApplyExponentsStr)
+		if err != nil {
+			return quoteRequestParameters{}, fmt.Errorf("applyExponents is invalid: %w", err)
+		}
+	}
+
+	if isTokenInDenomOnly {
+		tokenInDenom := c.QueryParam("tokenInDenom")
+		if len(tokenInDenom) == 0 {
+			return quoteRequestParameters{}, errors.New("tokenInDenom is required")
+		}
+
+		params.tokenOutDenom = c.QueryParam("tokenOutDenom")
+		if len(params.tokenOutDenom) == 0 {
+			return quoteRequestParameters{}, errors.New("tokenOutDenom is required")
+		}
+
+		params.tokenIn = sdk.Coin{Denom: tokenInDenom, Amount: osmomath.ZeroInt()}
+	} else {
+		params.tokenOutDenom, params.tokenIn, err = getValidRoutingParameters(c)
+		if err != nil {
+			return quoteRequestParameters{}, err
+		}
+	}
+
+	// translate denoms from human to chain if needed
+	params.tokenOutDenom, params.tokenIn.Denom, err = a.getChainDenoms(c, params.tokenOutDenom, params.tokenIn.Denom)
+	if err != nil {
+		return quoteRequestParameters{}, err
+	}
+
+	params.routerOpts, err = a.getRouteFilterOptions(c)
+	if err != nil {
+		return quoteRequestParameters{}, err
+	}
+
+	return params, nil
+}
+
 // returns chain denoms from echo parameters. If human denoms are given, they are converted to chain denoms.
 func (a *RouterHandler) getChainDenoms(c echo.Context, tokenOutDenom, tokenInDenom string) (string, string, error) {
 	isHumanDenomsStr := c.QueryParam("humanDenoms")
@@ -331,6 +964,316 @@ func (a *RouterHandler) getChainDenoms(c echo.Context, tokenOutDenom, tokenInDen
 	return tokenOutDenom, tokenInDenom, nil
 }
 
+// getRouteFilterOptions returns the router options for the pool and denom filters
+// given in the query parameters. Returns no options if none of the filters are set.
+// If humanDenoms is set, the excluded and preferred denoms are converted to chain denoms.
+func (a *RouterHandler) getRouteFilterOptions(c echo.Context) ([]domain.RouterOption, error) {
+	var (
+		options BatchQuoteOptions
+		err     error
+	)
+
+	if excludedPoolIDsStr := c.QueryParam("excludedPoolIDs"); excludedPoolIDsStr != "" {
+		options.ExcludedPoolIDs, err = domain.ParseNumbers(excludedPoolIDsStr)
+		if err != nil {
+			return nil, fmt.Errorf("excludedPoolIDs is invalid: %w", err)
+		}
+	}
+
+	if allowedPoolIDsStr := c.QueryParam("allowedPoolIDs"); allowedPoolIDsStr != "" {
+		options.AllowedPoolIDs, err = domain.ParseNumbers(allowedPoolIDsStr)
+		if err != nil {
+			return nil, fmt.Errorf("allowedPoolIDs is invalid: %w", err)
+		}
+	}
+
+	if allowedPoolTypesStr := c.QueryParam("allowedPoolTypes"); allowedPoolTypesStr != "" {
+		options.AllowedPoolTypes, err = domain.ParseNumbers(allowedPoolTypesStr)
+		if err != nil {
+			return nil, fmt.Errorf("allowedPoolTypes is invalid: %w", err)
+		}
+	}
+
+	if excludedDenomsStr := c.QueryParam("excludedDenoms"); excludedDenomsStr != "" {
+		options.ExcludedDenoms = domain.ParseDenoms(excludedDenomsStr)
+	}
+
+	if preferredDenomsStr := c.QueryParam("preferredIntermediateDenoms"); preferredDenomsStr != "" {
+		options.PreferredIntermediateDenoms = domain.ParseDenoms(preferredDenomsStr)
+	}
+
+	if maxHopsStr := c.QueryParam("maxHops"); maxHopsStr != "" {
+		maxHops, err := strconv.ParseUint(maxHopsStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("maxHops is invalid: %w", err)
+		}
+		if maxHops == 0 {
+			return nil, errors.New("maxHops is invalid: must be positive")
+		}
+		options.MaxHops = int(maxHops)
+	}
+
+	// Only parse humanDenoms if it is needed for the excluded or preferred denoms.
+	isHumanDenoms := false
+	if len(options.ExcludedDenoms) > 0 || len(options.PreferredIntermediateDenoms) > 0 {
+		isHumanDenoms, err = getIsHumanDenoms(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a.newRouteFilterOptions(options, isHumanDenoms)
+}
+
+// newRouteFilterOptions returns the router options for the pool and denom filters
+// as well as the max hops of the given options. The single route option is not handled.
+// Returns no options if none of the filters are set.
+// Returns error if any of the pool types is unknown, if the max hops exceed the configured
+// max pools per route or if any of the human denoms cannot be converted to a chain denom.
+func (a *RouterHandler) newRouteFilterOptions(options BatchQuoteOptions, isHumanDenoms bool) ([]domain.RouterOption, error) {
+	routerOpts := []domain.RouterOption{}
+
+	if len(options.ExcludedPoolIDs) > 0 {
+		routerOpts = append(routerOpts, domain.WithExcludedPoolIDs(options.ExcludedPoolIDs...))
+	}
+
+	if len(options.AllowedPoolIDs) > 0 {
+		routerOpts = append(routerOpts, domain.WithAllowedPoolIDs(options.AllowedPoolIDs...))
+	}
+
+	if len(options.AllowedPoolTypes) > 0 {
+		allowedPoolTypes := make([]poolmanagertypes.PoolType, 0, len(options.AllowedPoolTypes))
+		for _, poolTypeNum := range options.AllowedPoolTypes {
+			if _, ok := poolmanagertypes.PoolType_name[int32(poolTypeNum)]; poolTypeNum > math.MaxInt32 || !ok {
+				return nil, fmt.Errorf("allowedPoolTypes is invalid: unknown pool type (%d)", poolTypeNum)
+			}
+			allowedPoolTypes = append(allowedPoolTypes, poolmanagertypes.PoolType(poolTypeNum))
+		}
+		routerOpts = append(routerOpts, domain.WithAllowedPoolTypes(allowedPoolTypes...))
+	}
+
+	if len(options.ExcludedDenoms) > 0 {
+		chainDenoms, err := a.getChainDenomsIfHuman(options.ExcludedDenoms, isHumanDenoms)
+		if err != nil {
+			return nil, err
+		}
+
+		routerOpts = append(routerOpts, domain.WithExcludedDenoms(chainDenoms...))
+	}
+
+	if len(options.PreferredIntermediateDenoms) > 0 {
+		chainDenoms, err := a.getChainDenomsIfHuman(options.PreferredIntermediateDenoms, isHumanDenoms)
+		if err != nil {
+			return nil, err
+		}
+
+		routerOpts = append(routerOpts, domain.WithPreferredDenoms(chainDenoms...))
+	}
+
+	if options.MaxHops != 0 {
+		maxPoolsPerRoute := a.RUsecase.GetConfig().MaxPoolsPerRoute
+		if options.MaxHops < 0 || options.MaxHops > maxPoolsPerRoute {
+			return nil, fmt.Errorf("maxHops is invalid: must be between 1 and the max pools per route (%d)", maxPoolsPerRoute)
+		}
+
+		routerOpts = append(routerOpts, domain.WithMaxPoolsPerRoute(options.MaxHops))
+	}
+
+	return routerOpts, nil
+}
+
+// getChainDenomsIfHuman returns a copy of the given denoms, converted to chain denoms if isHumanDenoms is set.
+func (a *RouterHandler) getChainDenomsIfHuman(denoms []string, isHumanDenoms bool) ([]string, error) {
+	chainDenoms := make([]string, len(denoms))
+	copy(chainDenoms, denoms)
+
+	if isHumanDenoms {
+		for i, denom := range chainDenoms {
+			chainDenom, err := a.TUsecase.GetChainDenom(denom)
+			if err != nil {
+				return nil, err
+			}
+			chainDenoms[i] = chainDenom
+		}
+	}
+
+	return chainDenoms, nil
+}
+
+// getQuoteRequest validates the given batch quote request and converts it to a quote request.
+// Human denoms are converted to chain denoms if isHumanDenoms is set.
+func (a *RouterHandler) getQuoteRequest(c echo.Context, batchRequest BatchQuoteRequest, isHumanDenoms bool) (domain.QuoteRequest, error) {
+	if len(batchRequest.TokenIn) == 0 {
+		return domain.QuoteRequest{}, errors.New("tokenIn is required")
+	}
+
+	if len(batchRequest.TokenOutDenom) == 0 {
+		return domain.QuoteRequest{}, errors.New("tokenOutDenom is required")
+	}
+
+	tokenIn, err := sdk.ParseCoinNormalized(batchRequest.TokenIn)
+	if err != nil {
+		return domain.QuoteRequest{}, errors.New("tokenIn is invalid - must be in the format amountDenom")
+	}
+
+	// translate denoms from human to chain if needed
+	tokenOutDenom, tokenInDenom, err := a.getChainDenoms(c, batchRequest.TokenOutDenom, tokenIn.Denom)
+	if err != nil {
+		return domain.QuoteRequest{}, err
+	}
+
+	// Update coins token in denom it case it was translated from human to chain.
+	tokenIn.Denom = tokenInDenom
+
+	options := batchRequest.Options
+	routerOpts, err := a.newRouteFilterOptions(options, isHumanDenoms)
+	if err != nil {
+		return domain.QuoteRequest{}, err
+	}
+
+	if options.SingleRoute {
+		routerOpts = append(routerOpts, domain.WithDisableSplitRoutes())
+	}
+
+	return domain.QuoteRequest{
+		TokenIn:       tokenIn,
+		TokenOutDenom: tokenOutDenom,
+		Options:       routerOpts,
+	}, nil
+}
+
+// getSwapMsgParameters returns the sender and the slippage tolerance in basis points from the query parameters.
+// Returns an empty sender if it is not set. The slippage tolerance is required if the sender is set.
+// Returns error if the sender is not a valid osmosis address or if the slippage tolerance is invalid.
+func getSwapMsgParameters(c echo.Context) (string, uint64, error) {
+	sender := c.QueryParam("sender")
+	slippageBpsStr := c.QueryParam("slippageBps")
+
+	if sender == "" {
+		if slippageBpsStr != "" {
+			return "", 0, errors.New("sender is required if slippageBps is set")
+		}
+		return "", 0, nil
+	}
+
+	prefix, _, err := bech32.DecodeAndConvert(sender)
+	if err != nil {
+		return "", 0, fmt.Errorf("sender is invalid: %w", err)
+	}
+	if prefix != appparams.Bech32PrefixAccAddr {
+		return "", 0, fmt.Errorf("sender is invalid: expected prefix (%s), got (%s)", appparams.Bech32PrefixAccAddr, prefix)
+	}
+
+	if slippageBpsStr == "" {
+		return "", 0, errors.New("slippageBps is required if sender is set")
+	}
+
+	slippageBps, err := strconv.ParseUint(slippageBpsStr, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("slippageBps is invalid: %w", err)
+	}
+	if slippageBps > domain.MaxSlippageBps {
+		return "", 0, fmt.Errorf("slippageBps is invalid: must not exceed (%d), got (%d)", domain.MaxSlippageBps, slippageBps)
+	}
+
+	return sender, slippageBps, nil
+}
+
+// getGasPriceOption returns the router option selecting the split by the amount out net of the gas cost
+// from the gas price and the fee denom price query parameters. Returns nil if neither is set.
+// Returns error if only one of them is set or if either is not a non-negative decimal.
+func getGasPriceOption(c echo.Context) (domain.RouterOption, error) {
+	gasPriceStr := c.QueryParam("gasPrice")
+	feeDenomPriceStr := c.QueryParam("feeDenomPrice")
+
+	if gasPriceStr == "" && feeDenomPriceStr == "" {
+		return nil, nil
+	}
+	if gasPriceStr == "" || feeDenomPriceStr == "" {
+		return nil, errors.New("gasPrice and feeDenomPrice must be set together")
+	}
+
+	gasPrice, err := osmomath.NewDecFromStr(gasPriceStr)
+	if err != nil || gasPrice.IsNegative() {
+		return nil, fmt.Errorf("gasPrice (%s) must be a non-negative decimal", gasPriceStr)
+	}
+
+	feeDenomPrice, err := osmomath.NewDecFromStr(feeDenomPriceStr)
+	if err != nil || feeDenomPrice.IsNegative() {
+		return nil, fmt.Errorf("feeDenomPrice (%s) must be a non-negative decimal", feeDenomPriceStr)
+	}
+
+	return domain.WithGasPrice(gasPrice, feeDenomPrice), nil
+}
+
+// getSwapMsgs returns the encoded swap messages that execute the given quote on behalf of the sender.
+func getSwapMsgs(quote domain.Quote, sender string, slippageBps uint64) ([]SwapMsg, error) {
+	msg, err := domain.NewSwapMsg(quote, sender, slippageBps)
+	if err != nil {
+		return nil, err
+	}
+
+	msgJSON, err := codec.ProtoMarshalJSON(msg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// All poolmanager messages are gogoproto generated and implement the proto marshaler.
+	// nolint: forcetypeassert
+	msgProto, err := msg.(codec.ProtoMarshaler).Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return []SwapMsg{
+		{
+			TypeURL: sdk.MsgTypeURL(msg),
+			JSON:    msgJSON,
+			Proto:   msgProto,
+		},
+	}, nil
+}
+
+// getDepthAmounts returns the token in amounts from the amounts query parameter.
+// If it is not set, returns the default log-spaced amounts starting from one unit of the token in.
+// Returns error if the amounts are invalid, if there are too many of them or if the default amounts
+// cannot be computed due to missing token in metadata.
+func (a *RouterHandler) getDepthAmounts(c echo.Context, tokenInDenom string) ([]osmomath.Int, error) {
+	if amountsStr := c.QueryParam("amounts"); amountsStr != "" {
+		amounts, err := domain.ParseAmounts(amountsStr)
+		if err != nil {
+			return nil, fmt.Errorf("amounts is invalid: %w", err)
+		}
+
+		if len(amounts) > maxDepthAmounts {
+			return nil, fmt.Errorf("at most %d amounts are allowed, got %d", maxDepthAmounts, len(amounts))
+		}
+
+		if len(amounts) > 0 {
+			return amounts, nil
+		}
+	}
+
+	tokenInMetadata, err := a.TUsecase.GetMetadataByChainDenom(tokenInDenom)
+	if err != nil {
+		return nil, fmt.Errorf("amounts are required for tokenInDenom (%s) without metadata: %w", tokenInDenom, err)
+	}
+
+	unitAmount := osmomath.NewIntWithDecimal(1, tokenInMetadata.Precision)
+
+	return domain.GetLogSpacedAmounts(unitAmount, defaultDepthNumDecades, defaultDepthAmountsPerDecade), nil
+}
+
+// getIsHumanDenoms returns the value of the humanDenoms query parameter. False if not set.
+func getIsHumanDenoms(c echo.Context) (bool, error) {
+	isHumanDenomsStr := c.QueryParam("humanDenoms")
+	if len(isHumanDenomsStr) == 0 {
+		return false, nil
+	}
+
+	return strconv.ParseBool(isHumanDenomsStr)
+}
+
 // getValidRoutingParameters returns the tokenIn and tokenOutDenom from server context if they are valid.
 func getValidRoutingParameters(c echo.Context) (string, sdk.Coin, error) {
 	tokenOutStr, tokenInStr, err := getValidTokenInTokenOutStr(c)
@@ -346,6 +1289,26 @@ func getValidRoutingParameters(c echo.Context) (string, sdk.Coin, error) {
 	return tokenOutStr, tokenIn, nil
 }
 
+// getValidInGivenOutRoutingParameters returns the tokenInDenom and tokenOut from server context if they are valid.
+func getValidInGivenOutRoutingParameters(c echo.Context) (string, sdk.Coin, error) {
+	tokenOutStr := c.QueryParam("tokenOut")
+	if len(tokenOutStr) == 0 {
+		return "", sdk.Coin{}, errors.New("tokenOut is required")
+	}
+
+	tokenInDenom := c.QueryParam("tokenInDenom")
+	if len(tokenInDenom) == 0 {
+		return "", sdk.Coin{}, errors.New("tokenInDenom is required")
+	}
+
+	tokenOut, err := sdk.ParseCoinNormalized(tokenOutStr)
+	if err != nil {
+		return "", sdk.Coin{}, errors.New("tokenOut is invalid - must be in the format amountDenom")
+	}
+
+	return tokenInDenom, tokenOut, nil
+}
+
 // getSpotPriceScalingFactor returns the spot price scaling factor for a given tokenIn and tokenOutDenom.
 func (a *RouterHandler) getSpotPriceScalingFactor(tokenInDenom, tokenOutDenom string) osmomath.Dec {
 	scalingFactor, err := a.TUsecase.GetSpotPriceScalingFactorByDenom(tokenOutDenom, tokenInDenom)
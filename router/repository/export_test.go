@@ -0,0 +1,19 @@
+package routerrepo
+
+import "time"
+
+// WaitForSpills blocks until the snapshots queued for spilling by the given repository are written.
+func WaitForSpills(repository RouterRepository) {
+	history := repository.(*routerRepo).history
+	for {
+		history.spillMu.Lock()
+		numPendingSpills := len(history.pendingSpills)
+		history.spillMu.Unlock()
+
+		if numPendingSpills == 0 {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
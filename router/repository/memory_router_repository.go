@@ -2,13 +2,18 @@ package routerrepo
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"cosmossdk.io/math"
 	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/log"
 	"github.com/osmosis-labs/sqs/sqsdomain"
 )
 
 // RouterRepository represents the contract for a repository handling router information
+// All the information is stored in the latest state snapshot. The setters replace it
+// with an updated copy. See domain.StateSnapshot for details.
 type RouterRepository interface {
 	// GetTakerFee returns the taker fee for a given pair of denominations
 	// Sorts the denominations lexicographically before looking up the taker fee.
@@ -20,78 +25,116 @@ type RouterRepository interface {
 	// Sorts the denominations lexicographically before storing the taker fee.
 	SetTakerFee(denom0, denom1 string, takerFee osmomath.Dec)
 	SetTakerFees(takerFees sqsdomain.TakerFeeMap)
+
+	// GetStateSnapshot returns the latest state snapshot.
+	GetStateSnapshot() *domain.StateSnapshot
+	// UpdateStateSnapshot atomically replaces the latest state snapshot with the one returned
+	// by the given function from the latest one. The updates are serialized.
+	UpdateStateSnapshot(update func(latest *domain.StateSnapshot) *domain.StateSnapshot)
+	// GetStateSnapshotAtHeight returns the state snapshot at the given height if it is retained.
+	// Returns domain.StateSnapshotNotRetainedError otherwise.
+	// Returns domain.StateSnapshotDroppedError if the snapshot was evicted from memory but never spilled to disk.
+	GetStateSnapshotAtHeight(height uint64) (*domain.StateSnapshot, error)
 }
 
 var _ RouterRepository = &routerRepo{}
 
 type routerRepo struct {
-	takerFeeMap sync.Map
+	stateSnapshot atomic.Pointer[domain.StateSnapshot]
+	// updateMu serializes the state snapshot updates.
+	updateMu sync.Mutex
+
+	// history retains the snapshots of the latest blocks.
+	history   *stateHistory
+	historyMu sync.RWMutex
 }
 
-// New creates a new repository for the router.
+// New creates a new repository for the router that retains only the latest state snapshot.
+// The initial state snapshot is empty at zero height.
 func New() RouterRepository {
-	return &routerRepo{
-		takerFeeMap: sync.Map{},
+	return NewWithStateHistory(domain.StateHistoryConfig{}, &log.NoOpLogger{})
+}
+
+// NewWithStateHistory creates a new repository for the router that retains the state snapshots
+// of the latest blocks as configured.
+// The initial state snapshot is empty at zero height.
+func NewWithStateHistory(config domain.StateHistoryConfig, logger log.Logger) RouterRepository {
+	repo := &routerRepo{
+		history: newStateHistory(config, logger),
 	}
+	repo.stateSnapshot.Store(domain.NewStateSnapshot(0, nil, nil, nil))
+	return repo
 }
 
 // GetAllTakerFees implements RouterRepository.
 func (r *routerRepo) GetAllTakerFees() sqsdomain.TakerFeeMap {
-	takerFeeMap := sqsdomain.TakerFeeMap{}
-
-	r.takerFeeMap.Range(func(key, value interface{}) bool {
-		takerFee, ok := value.(osmomath.Dec)
-		if !ok {
-			return false
-		}
-
-		denomPair, ok := key.(sqsdomain.DenomPair)
-		if !ok {
-			return false
-		}
+	return r.GetStateSnapshot().GetAllTakerFees()
+}
 
-		takerFeeMap[denomPair] = takerFee
+// GetTakerFee implements RouterRepository.
+func (r *routerRepo) GetTakerFee(denom0 string, denom1 string) (math.LegacyDec, bool) {
+	return r.GetStateSnapshot().GetTakerFee(denom0, denom1)
+}
 
-		return true
+// SetTakerFee implements RouterRepository.
+func (r *routerRepo) SetTakerFee(denom0 string, denom1 string, takerFee math.LegacyDec) {
+	r.SetTakerFees(sqsdomain.TakerFeeMap{
+		sqsdomain.DenomPair{Denom0: denom0, Denom1: denom1}: takerFee,
 	})
+}
 
-	return takerFeeMap
+// SetTakerFees implements RouterRepository.
+func (r *routerRepo) SetTakerFees(takerFees sqsdomain.TakerFeeMap) {
+	r.UpdateStateSnapshot(func(latest *domain.StateSnapshot) *domain.StateSnapshot {
+		return latest.WithTakerFees(takerFees)
+	})
 }
 
-// GetTakerFee implements RouterRepository.
-func (r *routerRepo) GetTakerFee(denom0 string, denom1 string) (math.LegacyDec, bool) {
-	// Ensure increasing lexicographic order.
-	if denom1 < denom0 {
-		denom0, denom1 = denom1, denom0
-	}
+// GetStateSnapshot implements RouterRepository.
+func (r *routerRepo) GetStateSnapshot() *domain.StateSnapshot {
+	return r.stateSnapshot.Load()
+}
 
-	takerFeeAny, ok := r.takerFeeMap.Load(sqsdomain.DenomPair{Denom0: denom0, Denom1: denom1})
+// UpdateStateSnapshot implements RouterRepository.
+// The new snapshot is recorded in the history, replacing the one at the same height, if any.
+// The snapshot evicted from the history is spilled to disk in the background if enabled.
+func (r *routerRepo) UpdateStateSnapshot(update func(latest *domain.StateSnapshot) *domain.StateSnapshot) {
+	r.updateMu.Lock()
+	defer r.updateMu.Unlock()
 
-	if !ok {
-		return osmomath.Dec{}, false
-	}
+	snapshot := update(r.stateSnapshot.Load())
+	r.stateSnapshot.Store(snapshot)
 
-	takerFee, ok := takerFeeAny.(osmomath.Dec)
-	if !ok {
-		return osmomath.Dec{}, false
+	// Queued while holding the history lock so that the evicted snapshot is always found by the readers.
+	r.historyMu.Lock()
+	if evicted := r.history.add(snapshot); evicted != nil {
+		r.history.enqueueSpill(evicted)
 	}
-
-	return takerFee, true
+	r.historyMu.Unlock()
 }
 
-// SetTakerFee implements RouterRepository.
-func (r *routerRepo) SetTakerFee(denom0 string, denom1 string, takerFee math.LegacyDec) {
-	// Ensure increasing lexicographic order.
-	if denom1 < denom0 {
-		denom0, denom1 = denom1, denom0
+// GetStateSnapshotAtHeight implements RouterRepository.
+func (r *routerRepo) GetStateSnapshotAtHeight(height uint64) (*domain.StateSnapshot, error) {
+	latest := r.GetStateSnapshot()
+	if latest.GetHeight() == height {
+		return latest, nil
 	}
 
-	r.takerFeeMap.Store(sqsdomain.DenomPair{Denom0: denom0, Denom1: denom1}, takerFee)
-}
+	r.historyMu.RLock()
+	snapshot, ok := r.history.get(height)
+	r.historyMu.RUnlock()
 
-// SetTakerFees implements RouterRepository.
-func (r *routerRepo) SetTakerFees(takerFees sqsdomain.TakerFeeMap) {
-	for denomPair, takerFee := range takerFees {
-		r.SetTakerFee(denomPair.Denom0, denomPair.Denom1, takerFee)
+	// Read without the history lock so that the updates are not blocked by the disk.
+	if !ok {
+		var err error
+		snapshot, ok, err = r.history.getSpilled(height)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !ok {
+		return nil, domain.StateSnapshotNotRetainedError{Height: height, LatestHeight: latest.GetHeight()}
 	}
+
+	return snapshot, nil
 }
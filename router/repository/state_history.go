@@ -0,0 +1,405 @@
+package routerrepo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/log"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+	"github.com/osmosis-labs/sqs/sqsdomain/json"
+)
+
+const (
+	// stateSnapshotFileExtension is the extension of the state snapshot files in the spill directory.
+	stateSnapshotFileExtension = ".json"
+	// spillQueueSize is the maximum number of the snapshots evicted from the ring buffer
+	// waiting to be written to the spill directory.
+	spillQueueSize = 16
+	// spilledSnapshotCacheSize is the number of the snapshots read from the spill directory kept decoded in memory.
+	spilledSnapshotCacheSize = 4
+)
+
+// stateHistory is a ring buffer of the snapshots of the latest blocks.
+// The snapshots evicted from the ring buffer are optionally written to the spill directory in the background.
+// The ring buffer is not safe for concurrent use. The router repository serializes the access.
+// The spilled snapshots are safe for concurrent use.
+type stateHistory struct {
+	config domain.StateHistoryConfig
+	logger log.Logger
+
+	// snapshots is the ring buffer. The snapshot at next is the oldest one once the buffer is full.
+	snapshots []*domain.StateSnapshot
+	next      int
+
+	// spillQueue holds the snapshots waiting to be written by the spill worker.
+	spillQueue chan *domain.StateSnapshot
+	// spillMu guards the pending spills and the spilled heights.
+	spillMu sync.Mutex
+	// pendingSpills are the snapshots in the spill queue or being written by height.
+	// They are served from memory until written.
+	pendingSpills map[uint64]*domain.StateSnapshot
+	// spilledHeights are the heights of the snapshots in the spill directory in increasing order.
+	spilledHeights []uint64
+	// droppedHeights are the heights of the snapshots evicted from the ring buffer but never spilled
+	// in increasing order. Trimmed along with the spilled heights beyond the spill size.
+	droppedHeights []uint64
+	// spilledSnapshots are the snapshots recently read from the spill directory by height.
+	spilledSnapshots *lru.Cache[uint64, *domain.StateSnapshot]
+	// spillReads deduplicates the concurrent reads of the same snapshot from the spill directory.
+	spillReads singleflight.Group
+}
+
+// serializedStateSnapshot is the format of the state snapshots in the spill directory.
+type serializedStateSnapshot struct {
+	Height uint64            `json:"height"`
+	Pools  []json.RawMessage `json:"pools"`
+	// Serialized separately since sqsdomain.TakerFeeMap can only be unmarshalled into an initialized map.
+	TakerFees     json.RawMessage `json:"taker_fees"`
+	SortedPoolIDs []uint64        `json:"sorted_pool_ids"`
+}
+
+// newStateHistory returns a new empty state history with the given config.
+// The ring buffer always has room for at least the latest snapshot.
+func newStateHistory(config domain.StateHistoryConfig, logger log.Logger) *stateHistory {
+	size := config.Size
+	if size < 1 {
+		size = 1
+	}
+
+	history := &stateHistory{
+		config:    config,
+		logger:    logger,
+		snapshots: make([]*domain.StateSnapshot, size),
+	}
+
+	if config.SpillDir == "" {
+		return history
+	}
+
+	if err := os.MkdirAll(config.SpillDir, 0o755); err != nil {
+		logger.Error("failed to create state snapshot spill directory", zap.String("spill_dir", config.SpillDir), zap.Error(err))
+	}
+
+	// The snapshots spilled before the restart are retained.
+	spilledHeights, err := history.getSpilledHeights()
+	if err != nil {
+		logger.Error("failed to list spilled state snapshots", zap.Error(err))
+	}
+
+	// Only fails for a non-positive size.
+	spilledSnapshots, _ := lru.New[uint64, *domain.StateSnapshot](spilledSnapshotCacheSize)
+
+	history.spillQueue = make(chan *domain.StateSnapshot, spillQueueSize)
+	history.pendingSpills = make(map[uint64]*domain.StateSnapshot)
+	history.spilledHeights = spilledHeights
+	history.spilledSnapshots = spilledSnapshots
+
+	go history.runSpillWorker()
+
+	return history
+}
+
+// add records the given snapshot. It replaces the last recorded snapshot if both are at the same height.
+// Returns the snapshot evicted from the ring buffer, if any.
+func (h *stateHistory) add(snapshot *domain.StateSnapshot) *domain.StateSnapshot {
+	lastIndex := (h.next + len(h.snapshots) - 1) % len(h.snapshots)
+	if last := h.snapshots[lastIndex]; last != nil && last.GetHeight() == snapshot.GetHeight() {
+		h.snapshots[lastIndex] = snapshot
+		return nil
+	}
+
+	evicted := h.snapshots[h.next]
+	h.snapshots[h.next] = snapshot
+	h.next = (h.next + 1) % len(h.snapshots)
+
+	return evicted
+}
+
+// get returns the snapshot at the given height from the ring buffer.
+// Returns false if the snapshot is not in the ring buffer.
+func (h *stateHistory) get(height uint64) (*domain.StateSnapshot, bool) {
+	for _, snapshot := range h.snapshots {
+		if snapshot != nil && snapshot.GetHeight() == height {
+			return snapshot, true
+		}
+	}
+
+	return nil, false
+}
+
+// getSpilled returns the snapshot at the given height evicted from the ring buffer.
+// That is, either one waiting to be spilled or one in the spill directory. The latter are read once
+// for all concurrent callers and the recently read ones are served from memory.
+// Returns false if the snapshot is not retained and domain.StateSnapshotDroppedError if it was dropped
+// instead of spilled.
+func (h *stateHistory) getSpilled(height uint64) (*domain.StateSnapshot, bool, error) {
+	if h.config.SpillDir == "" {
+		return nil, false, nil
+	}
+
+	h.spillMu.Lock()
+	pendingSnapshot, isPending := h.pendingSpills[height]
+	isSpilled := containsHeight(h.spilledHeights, height)
+	isDropped := containsHeight(h.droppedHeights, height)
+	h.spillMu.Unlock()
+
+	if isPending {
+		return pendingSnapshot, true, nil
+	}
+
+	if isDropped {
+		return nil, false, domain.StateSnapshotDroppedError{Height: height}
+	}
+
+	if !isSpilled {
+		return nil, false, nil
+	}
+
+	if snapshot, ok := h.spilledSnapshots.Get(height); ok {
+		return snapshot, true, nil
+	}
+
+	snapshot, err, _ := h.spillReads.Do(strconv.FormatUint(height, 10), func() (interface{}, error) {
+		snapshot, err := readStateSnapshot(h.getSpillFilePath(height))
+		if err != nil {
+			return nil, err
+		}
+
+		h.spilledSnapshots.Add(height, snapshot)
+		return snapshot, nil
+	})
+	// Removed beyond the spill size after the lookup above.
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return snapshot.(*domain.StateSnapshot), true, nil
+}
+
+// enqueueSpill queues the given snapshot to be written to the spill directory in the background.
+// The snapshot is dropped if the spill queue is full. The dropped heights are counted and reported
+// to the readers. No-op if the spill is disabled.
+func (h *stateHistory) enqueueSpill(snapshot *domain.StateSnapshot) {
+	if h.config.SpillDir == "" {
+		return
+	}
+
+	h.spillMu.Lock()
+	h.pendingSpills[snapshot.GetHeight()] = snapshot
+	h.spillMu.Unlock()
+
+	select {
+	case h.spillQueue <- snapshot:
+	default:
+		h.spillMu.Lock()
+		delete(h.pendingSpills, snapshot.GetHeight())
+		h.insertDroppedHeightLocked(snapshot.GetHeight())
+		h.spillMu.Unlock()
+
+		h.logger.Error("state snapshot spill queue is full, dropping snapshot", zap.Uint64("height", snapshot.GetHeight()))
+	}
+}
+
+// insertDroppedHeightLocked records the given height as dropped. The caller must hold the spill lock.
+func (h *stateHistory) insertDroppedHeightLocked(height uint64) {
+	h.droppedHeights = insertHeight(h.droppedHeights, height)
+	domain.SQSStateSnapshotSpillDroppedCounter.Inc()
+}
+
+// runSpillWorker spills the queued snapshots in order. It runs for the lifetime of the process.
+func (h *stateHistory) runSpillWorker() {
+	for snapshot := range h.spillQueue {
+		h.spill(snapshot)
+	}
+}
+
+// spill writes the given snapshot to the spill directory and removes the oldest snapshots
+// beyond the spill size.
+func (h *stateHistory) spill(snapshot *domain.StateSnapshot) {
+	height := snapshot.GetHeight()
+	err := writeStateSnapshot(h.getSpillFilePath(height), snapshot)
+
+	h.spillMu.Lock()
+	delete(h.pendingSpills, height)
+	if err != nil {
+		h.insertDroppedHeightLocked(height)
+		h.spillMu.Unlock()
+		h.logger.Error("failed to spill state snapshot", zap.Uint64("height", height), zap.Error(err))
+		return
+	}
+
+	h.spilledHeights = insertHeight(h.spilledHeights, height)
+
+	var removedHeights []uint64
+	if h.config.SpillSize > 0 && len(h.spilledHeights) > h.config.SpillSize {
+		numRemoved := len(h.spilledHeights) - h.config.SpillSize
+		removedHeights = append(removedHeights, h.spilledHeights[:numRemoved]...)
+		h.spilledHeights = append(h.spilledHeights[:0], h.spilledHeights[numRemoved:]...)
+
+		// The dropped heights older than the retained ones are reported as not retained.
+		oldestIndex := sort.Search(len(h.droppedHeights), func(i int) bool { return h.droppedHeights[i] >= h.spilledHeights[0] })
+		h.droppedHeights = append(h.droppedHeights[:0], h.droppedHeights[oldestIndex:]...)
+	}
+	h.spillMu.Unlock()
+
+	// The removed heights are no longer looked up so the files are removed outside of the lock.
+	for _, removedHeight := range removedHeights {
+		h.spilledSnapshots.Remove(removedHeight)
+
+		if err := os.Remove(h.getSpillFilePath(removedHeight)); err != nil && !os.IsNotExist(err) {
+			h.logger.Error("failed to remove spilled state snapshot", zap.Uint64("height", removedHeight), zap.Error(err))
+		}
+	}
+}
+
+// getSpilledHeights returns the heights of the snapshots in the spill directory in increasing order.
+func (h *stateHistory) getSpilledHeights() ([]uint64, error) {
+	entries, err := os.ReadDir(h.config.SpillDir)
+	if err != nil {
+		return nil, err
+	}
+
+	heights := make([]uint64, 0, len(entries))
+	for _, entry := range entries {
+		heightStr, ok := strings.CutSuffix(entry.Name(), stateSnapshotFileExtension)
+		if !ok {
+			continue
+		}
+
+		height, err := strconv.ParseUint(heightStr, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		heights = append(heights, height)
+	}
+
+	sort.Slice(heights, func(i, j int) bool {
+		return heights[i] < heights[j]
+	})
+
+	return heights, nil
+}
+
+// containsHeight returns true if the given heights in increasing order contain the given height.
+func containsHeight(heights []uint64, height uint64) bool {
+	heightIndex := sort.Search(len(heights), func(i int) bool { return heights[i] >= height })
+	return heightIndex < len(heights) && heights[heightIndex] == height
+}
+
+// insertHeight inserts the given height into the given heights in increasing order unless already present.
+func insertHeight(heights []uint64, height uint64) []uint64 {
+	heightIndex := sort.Search(len(heights), func(i int) bool { return heights[i] >= height })
+	if heightIndex < len(heights) && heights[heightIndex] == height {
+		return heights
+	}
+
+	heights = append(heights, 0)
+	copy(heights[heightIndex+1:], heights[heightIndex:])
+	heights[heightIndex] = height
+	return heights
+}
+
+// getSpillFilePath returns the path of the file with the snapshot at the given height in the spill directory.
+func (h *stateHistory) getSpillFilePath(height uint64) string {
+	return filepath.Join(h.config.SpillDir, strconv.FormatUint(height, 10)+stateSnapshotFileExtension)
+}
+
+// writeStateSnapshot writes the snapshot to the given file.
+// The file is written in full under a temporary name first so that readers never observe a partial snapshot.
+func writeStateSnapshot(filePath string, snapshot *domain.StateSnapshot) error {
+	pools := snapshot.GetAllPools()
+	serializedPools := make([]json.RawMessage, 0, len(pools))
+	for _, pool := range pools {
+		serializedPool, err := domain.MarshalPool(pool)
+		if err != nil {
+			return err
+		}
+		serializedPools = append(serializedPools, serializedPool)
+	}
+
+	takerFees, err := json.Marshal(snapshot.GetAllTakerFees())
+	if err != nil {
+		return err
+	}
+
+	sortedPools := snapshot.GetSortedPools()
+	sortedPoolIDs := make([]uint64, 0, len(sortedPools))
+	for _, pool := range sortedPools {
+		sortedPoolIDs = append(sortedPoolIDs, pool.GetId())
+	}
+
+	snapshotBz, err := json.Marshal(serializedStateSnapshot{
+		Height:        snapshot.GetHeight(),
+		Pools:         serializedPools,
+		TakerFees:     takerFees,
+		SortedPoolIDs: sortedPoolIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	tempFilePath := filePath + ".tmp"
+	if err := os.WriteFile(tempFilePath, snapshotBz, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFilePath, filePath)
+}
+
+// readStateSnapshot reads the snapshot from the given file.
+func readStateSnapshot(filePath string) (*domain.StateSnapshot, error) {
+	snapshotBz, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var serializedSnapshot serializedStateSnapshot
+	if err := json.Unmarshal(snapshotBz, &serializedSnapshot); err != nil {
+		return nil, err
+	}
+
+	pools := make(map[uint64]sqsdomain.PoolI, len(serializedSnapshot.Pools))
+	for _, poolBz := range serializedSnapshot.Pools {
+		var serializedPool domain.SerializedPool
+		if err := json.Unmarshal(poolBz, &serializedPool); err != nil {
+			return nil, err
+		}
+
+		pool, err := domain.UnmarshalPool(serializedPool)
+		if err != nil {
+			return nil, err
+		}
+
+		pools[pool.GetId()] = pool
+	}
+
+	takerFees := sqsdomain.TakerFeeMap{}
+	if err := json.Unmarshal(serializedSnapshot.TakerFees, &takerFees); err != nil {
+		return nil, err
+	}
+
+	sortedPools := make([]sqsdomain.PoolI, 0, len(serializedSnapshot.SortedPoolIDs))
+	for _, poolID := range serializedSnapshot.SortedPoolIDs {
+		pool, ok := pools[poolID]
+		if !ok {
+			return nil, fmt.Errorf("sorted pool (%d) is not found in the state snapshot at height (%d)", poolID, serializedSnapshot.Height)
+		}
+		sortedPools = append(sortedPools, pool)
+	}
+
+	return domain.NewStateSnapshot(serializedSnapshot.Height, pools, takerFees, sortedPools), nil
+}
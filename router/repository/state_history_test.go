@@ -0,0 +1,105 @@
+package routerrepo_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/log"
+	routerrepo "github.com/osmosis-labs/sqs/router/repository"
+)
+
+// This test validates that the snapshots evicted from memory are spilled in the background,
+// that the spilled ones beyond the spill size are removed and that the concurrent reads
+// of a spilled snapshot share the snapshot decoded once.
+func TestGetStateSnapshotAtHeight_Spilled(t *testing.T) {
+	spillDir := t.TempDir()
+
+	repository := routerrepo.NewWithStateHistory(domain.StateHistoryConfig{
+		Size:      1,
+		SpillDir:  spillDir,
+		SpillSize: 2,
+	}, &log.NoOpLogger{})
+
+	for height := uint64(1); height <= 4; height++ {
+		repository.UpdateStateSnapshot(func(latest *domain.StateSnapshot) *domain.StateSnapshot {
+			return latest.WithHeight(height)
+		})
+	}
+
+	routerrepo.WaitForSpills(repository)
+
+	_, err := repository.GetStateSnapshotAtHeight(1)
+	require.ErrorIs(t, err, domain.StateSnapshotNotRetainedError{Height: 1, LatestHeight: 4})
+
+	const numReaders = 8
+	snapshots := make([]*domain.StateSnapshot, numReaders)
+
+	var wg sync.WaitGroup
+	for i := 0; i < numReaders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			snapshot, err := repository.GetStateSnapshotAtHeight(2)
+			require.NoError(t, err)
+			snapshots[i] = snapshot
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, uint64(2), snapshots[0].GetHeight())
+	for _, snapshot := range snapshots {
+		require.Same(t, snapshots[0], snapshot)
+	}
+
+	// Served from memory afterwards.
+	snapshot, err := repository.GetStateSnapshotAtHeight(2)
+	require.NoError(t, err)
+	require.Same(t, snapshots[0], snapshot)
+
+	// The spilled heights are retained after a restart.
+	repository = routerrepo.NewWithStateHistory(domain.StateHistoryConfig{
+		Size:      1,
+		SpillDir:  spillDir,
+		SpillSize: 2,
+	}, &log.NoOpLogger{})
+
+	snapshot, err = repository.GetStateSnapshotAtHeight(3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), snapshot.GetHeight())
+}
+
+// This test validates that the snapshots that fail to be spilled are reported as dropped
+// rather than not retained.
+func TestGetStateSnapshotAtHeight_Dropped(t *testing.T) {
+	spillDir := filepath.Join(t.TempDir(), "spill")
+
+	repository := routerrepo.NewWithStateHistory(domain.StateHistoryConfig{
+		Size:      1,
+		SpillDir:  spillDir,
+		SpillSize: 2,
+	}, &log.NoOpLogger{})
+
+	// Writing into a regular file in place of the spill directory fails.
+	require.NoError(t, os.RemoveAll(spillDir))
+	require.NoError(t, os.WriteFile(spillDir, nil, 0o600))
+
+	for height := uint64(1); height <= 3; height++ {
+		repository.UpdateStateSnapshot(func(latest *domain.StateSnapshot) *domain.StateSnapshot {
+			return latest.WithHeight(height)
+		})
+	}
+
+	routerrepo.WaitForSpills(repository)
+
+	_, err := repository.GetStateSnapshotAtHeight(2)
+	require.ErrorIs(t, err, domain.StateSnapshotDroppedError{Height: 2})
+
+	_, err = repository.GetStateSnapshotAtHeight(0)
+	require.ErrorIs(t, err, domain.StateSnapshotNotRetainedError{Height: 0, LatestHeight: 3})
+}
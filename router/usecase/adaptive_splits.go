@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/log"
+	"github.com/osmosis-labs/sqs/router/usecase/route"
+)
+
+// splitEstimator returns the token out amount and the number of initialized ticks crossed of every route
+// given the token in swapped over every route.
+type splitEstimator func(ctx context.Context, tokensIn []sdk.Coin) ([]osmomath.Int, []uint64, error)
+
+// refineSplitQuote refines the split found by the dynamic programming over the coarse increments of the token in.
+// It starts from the given route increments and performs a local search: every iteration moves a step of
+// the token in from one route to another if that increases the amount out (net of gasCosts if given) the most.
+// If no move improves the split, the step is halved instead. The step starts at half of a coarse increment.
+// As a result, maxIterations bounds both the number of route estimates and the granularity of the split.
+// The search stops early and keeps the best split found so far if the context is done.
+// Returns the given coarse quote if the refined split does not improve on it.
+// The outcome of the refinement is returned for the quote debug info.
+// The time complexity is O(n^2 * k) split estimates, where n is the number of routes and k is maxIterations.
+func refineSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Coin, routeIncrements []uint8, gasCosts []osmomath.Int, maxIterations int, coarseQuote domain.Quote, estimate splitEstimator) (domain.Quote, domain.SplitRefinementDebugInfo) {
+	inIncrements := getInIncrements(tokenIn)
+	tokensIn := getRouteTokensIn(routeIncrements, inIncrements)
+
+	outAmounts, ticksCrossed, err := estimate(ctx, tokensIn)
+	if err != nil {
+		return coarseQuote, domain.SplitRefinementDebugInfo{
+			AmountOut: osmomath.ZeroInt(),
+			Error:     err.Error(),
+		}
+	}
+	initialNetOut := getSplitNetAmountOut(outAmounts, tokensIn, gasCosts)
+	bestNetOut := initialNetOut
+
+	step := inIncrements[1].Amount.QuoRaw(2)
+	iteration := 0
+	for ; iteration < maxIterations && step.IsPositive(); iteration++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		var (
+			bestTokensIn     []sdk.Coin
+			bestOutAmount    []osmomath.Int
+			bestTicksCrossed []uint64
+		)
+
+		for from := range routes {
+			if tokensIn[from].Amount.LT(step) {
+				continue
+			}
+
+			for to := range routes {
+				if to == from {
+					continue
+				}
+
+				candidateTokensIn := make([]sdk.Coin, len(tokensIn))
+				copy(candidateTokensIn, tokensIn)
+				candidateTokensIn[from] = sdk.NewCoin(tokenIn.Denom, tokensIn[from].Amount.Sub(step))
+				candidateTokensIn[to] = sdk.NewCoin(tokenIn.Denom, tokensIn[to].Amount.Add(step))
+
+				candidateOutAmounts, candidateTicksCrossed, err := estimate(ctx, candidateTokensIn)
+				if err != nil {
+					continue
+				}
+
+				candidateNetOut := getSplitNetAmountOut(candidateOutAmounts, candidateTokensIn, gasCosts)
+				if candidateNetOut.GT(bestNetOut) {
+					bestTokensIn = candidateTokensIn
+					bestOutAmount = candidateOutAmounts
+					bestTicksCrossed = candidateTicksCrossed
+					bestNetOut = candidateNetOut
+				}
+			}
+		}
+
+		// No move by the current step improves the split. Try a finer one.
+		if bestTokensIn == nil {
+			step = step.QuoRaw(2)
+			continue
+		}
+
+		tokensIn = bestTokensIn
+		outAmounts = bestOutAmount
+		ticksCrossed = bestTicksCrossed
+	}
+
+	refinement := newSplitRefinementDebugInfo(tokensIn, outAmounts, iteration)
+
+	if !bestNetOut.GT(initialNetOut) {
+		return coarseQuote, refinement
+	}
+
+	resultRoutes := make([]domain.SplitRoute, 0, len(routes))
+	totalAmountOut := osmomath.ZeroInt()
+	for i, currentTokenIn := range tokensIn {
+		if currentTokenIn.IsZero() {
+			continue
+		}
+
+		// A route swapping out nothing for a non-zero amount in cannot improve the split.
+		// Keep the coarse quote as it would have been rejected by its validation.
+		if outAmounts[i].IsZero() {
+			refinement.Error = fmt.Sprintf("out amount is zero when in is not (%s), route index (%d)", currentTokenIn.Amount, i)
+			return coarseQuote, refinement
+		}
+
+		resultRoutes = append(resultRoutes, &RouteWithOutAmount{
+			RouteImpl:    routes[i],
+			InAmount:     currentTokenIn.Amount,
+			OutAmount:    outAmounts[i],
+			TicksCrossed: ticksCrossed[i],
+		})
+
+		totalAmountOut = totalAmountOut.Add(outAmounts[i])
+	}
+
+	refinement.Improved = true
+
+	return &quoteImpl{
+		AmountIn:  tokenIn,
+		AmountOut: totalAmountOut,
+		Route:     resultRoutes,
+	}, refinement
+}
+
+// newSplitRefinementDebugInfo returns the debug info for the refined split given by the token in
+// and the token out amount of every route after the given number of iterations.
+func newSplitRefinementDebugInfo(tokensIn []sdk.Coin, outAmounts []osmomath.Int, iterations int) domain.SplitRefinementDebugInfo {
+	routeAmountsIn := make([]osmomath.Int, 0, len(tokensIn))
+	amountOut := osmomath.ZeroInt()
+	for i, tokenIn := range tokensIn {
+		routeAmountsIn = append(routeAmountsIn, tokenIn.Amount)
+		amountOut = amountOut.Add(outAmounts[i])
+	}
+
+	return domain.SplitRefinementDebugInfo{
+		RouteAmountsIn: routeAmountsIn,
+		AmountOut:      amountOut,
+		Iterations:     iterations,
+	}
+}
+
+// newIndependentSplitEstimator returns the split estimator of routes that do not share pools.
+// The routes are estimated independently and every estimate is memoized by the route and the amount in
+// since the refinement only changes the amounts of two routes at a time.
+// Failed estimates are treated as zero out, similarly to computeSplitOutAmounts.
+func newIndependentSplitEstimator(routes []route.RouteImpl) splitEstimator {
+	type routeEstimate struct {
+		outAmount    osmomath.Int
+		ticksCrossed uint64
+	}
+
+	memo := make([]map[string]routeEstimate, len(routes))
+	for i := range routes {
+		memo[i] = make(map[string]routeEstimate)
+	}
+
+	return func(ctx context.Context, tokensIn []sdk.Coin) ([]osmomath.Int, []uint64, error) {
+		outAmounts := make([]osmomath.Int, len(routes))
+		ticksCrossed := make([]uint64, len(routes))
+		for i, tokenIn := range tokensIn {
+			if tokenIn.IsZero() {
+				outAmounts[i] = zero
+				continue
+			}
+
+			key := tokenIn.Amount.String()
+			estimate, ok := memo[i][key]
+			if !ok {
+				tokenOut, routeTicksCrossed, err := routes[i].CalculateTokenOutByTokenInWithTicksCrossed(ctx, tokenIn)
+				if err != nil || tokenOut.IsNil() {
+					tokenOut.Amount = zero
+				}
+
+				estimate = routeEstimate{outAmount: tokenOut.Amount, ticksCrossed: routeTicksCrossed}
+				memo[i][key] = estimate
+			}
+
+			outAmounts[i] = estimate.outAmount
+			ticksCrossed[i] = estimate.ticksCrossed
+		}
+		return outAmounts, ticksCrossed, nil
+	}
+}
+
+// newSharedPoolSplitEstimator returns the split estimator of routes that share pools.
+// The routes are simulated jointly. See simulateSharedPoolSplit for details.
+func newSharedPoolSplitEstimator(routes []route.RouteImpl, logger log.Logger) splitEstimator {
+	return func(ctx context.Context, tokensIn []sdk.Coin) ([]osmomath.Int, []uint64, error) {
+		return simulateSharedPoolSplit(ctx, routes, tokensIn, logger)
+	}
+}
+
+// containsGeneralizedCosmWasmPoolRoute returns true if any of the routes contains a generalized cosmwasm pool.
+// Such routes are not refined since every new amount estimated requires a chain query.
+func containsGeneralizedCosmWasmPoolRoute(routes []route.RouteImpl) bool {
+	for _, route := range routes {
+		if route.ContainsGeneralizedCosmWasmPool() {
+			return true
+		}
+	}
+	return false
+}
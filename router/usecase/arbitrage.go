@@ -0,0 +1,465 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/router/usecase/pools"
+	"github.com/osmosis-labs/sqs/router/usecase/route"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+const (
+	// arbitrageRelaxationTolerance is the minimum decrease of the log-price distance
+	// for an edge to be relaxed. It prevents detecting cycles that are profitable
+	// only due to floating point errors.
+	arbitrageRelaxationTolerance = 1e-9
+
+	// arbitrageAmountInSearchMaxIterations is the maximum number of ternary search iterations
+	// refining the amount in that maximizes the profit of a cycle.
+	arbitrageAmountInSearchMaxIterations = 256
+)
+
+var (
+	// sqs_router_arbitrage_cycles
+	//
+	// gauge that tracks the number of profitable arbitrage cycles verified by quotes
+	// at the latest height.
+	arbitrageCyclesGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sqs_router_arbitrage_cycles",
+			Help: "gauge that tracks the number of profitable arbitrage cycles verified by quotes at the latest height",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(arbitrageCyclesGauge)
+}
+
+// arbitrageEdge is a directed edge of the arbitrage graph that swaps
+// the denom at index from for the denom at index to over a single pool.
+type arbitrageEdge struct {
+	from int
+	to   int
+
+	pool sqsdomain.RoutablePool
+	// Spot price of the token in denom in terms of the token out denom net of the spread factor and the taker fee.
+	netSpotPrice osmomath.Dec
+	// Negative natural logarithm of the net spot price.
+	// Cycles with negative total weight are profitable at the margin.
+	weight float64
+	// Value of the pool liquidity in the token in denom. Upper bound for the amount in.
+	maxAmountIn osmomath.Int
+}
+
+// DetectArbitrageAsync implements mvc.RouterUsecase.
+// The detection runs in the background over the latest state snapshot at the time of the call.
+// It is skipped if it is disabled in the config or if the previous detection is still running.
+func (r *routerUseCaseImpl) DetectArbitrageAsync(height uint64) {
+	if !r.defaultConfig.ArbitrageDetectionEnabled {
+		return
+	}
+
+	if !r.isDetectingArbitrage.CompareAndSwap(false, true) {
+		r.logger.Info("skipping arbitrage detection, previous detection is in progress", zap.Uint64("height", height))
+		return
+	}
+
+	snapshot := r.routerRepository.GetStateSnapshot()
+
+	go func() {
+		defer r.isDetectingArbitrage.Store(false)
+
+		startTime := time.Now()
+
+		result := r.detectArbitrage(domain.WithStateSnapshot(context.Background(), snapshot), snapshot.GetSortedPools(), height)
+
+		r.arbitrageMu.Lock()
+		r.arbitrage = result
+		r.arbitrageMu.Unlock()
+
+		arbitrageCyclesGauge.Set(float64(len(result.Cycles)))
+
+		r.logger.Info("completed arbitrage detection", zap.Uint64("height", height), zap.Int("num_cycles", len(result.Cycles)), zap.Duration("duration", time.Since(startTime)))
+	}()
+}
+
+// GetArbitrage implements mvc.RouterUsecase.
+func (r *routerUseCaseImpl) GetArbitrage() domain.ArbitrageResult {
+	r.arbitrageMu.RLock()
+	defer r.arbitrageMu.RUnlock()
+	return r.arbitrage
+}
+
+// detectArbitrage finds the profitable cycles over the given pools.
+// First, it finds the cycles with a spot price product above one net of fees by detecting
+// negative cycles in the graph of negative log spot prices. Then, it verifies every such cycle
+// by quoting the amount in that maximizes the profit. Only the cycles with positive profit are returned.
+// Cycles with more than MaxPoolsPerRoute pools are skipped.
+// The taker fees are read from the state snapshot pinned to the context or the latest one if none is pinned.
+func (r *routerUseCaseImpl) detectArbitrage(ctx context.Context, sortedPools []sqsdomain.PoolI, height uint64) domain.ArbitrageResult {
+	ctx, snapshot, _ := r.pinStateSnapshot(ctx)
+
+	denoms, edges := r.newArbitrageGraph(ctx, snapshot, sortedPools)
+
+	cycles := findArbitrageCycles(edges, len(denoms), r.defaultConfig.MaxPoolsPerRoute)
+
+	arbitrageCycles := make([]domain.ArbitrageCycle, 0, len(cycles))
+	for _, cycle := range cycles {
+		arbitrageCycle, ok := verifyArbitrageCycle(ctx, denoms, cycle)
+		if !ok {
+			r.logger.Debug("arbitrage cycle is not profitable after quoting", zap.Stringer("cycle", arbitrageCycleStringer{denoms: denoms, cycle: cycle}))
+			continue
+		}
+
+		arbitrageCycles = append(arbitrageCycles, arbitrageCycle)
+	}
+
+	sort.Slice(arbitrageCycles, func(i, j int) bool {
+		return arbitrageCycles[i].SpotPriceProduct.GT(arbitrageCycles[j].SpotPriceProduct)
+	})
+
+	return domain.ArbitrageResult{
+		Height: height,
+		Cycles: arbitrageCycles,
+	}
+}
+
+// newArbitrageGraph returns the denoms and the edges of the arbitrage graph over the given pools.
+// Every ordered pair of denoms in a pool is an edge. The denoms are referenced by their index.
+// Pools below the min liquidity and generalized cosmwasm pools are skipped.
+// The edges for which the spot price cannot be computed are skipped.
+func (r *routerUseCaseImpl) newArbitrageGraph(ctx context.Context, snapshot *domain.StateSnapshot, sortedPools []sqsdomain.PoolI) ([]string, []arbitrageEdge) {
+	minLiquidity := osmomath.NewInt(int64(r.defaultConfig.MinOSMOLiquidity))
+
+	denoms := make([]string, 0)
+	denomIndexes := make(map[string]int)
+	getDenomIndex := func(denom string) int {
+		index, ok := denomIndexes[denom]
+		if !ok {
+			index = len(denoms)
+			denomIndexes[denom] = index
+			denoms = append(denoms, denom)
+		}
+		return index
+	}
+
+	edges := make([]arbitrageEdge, 0)
+	for _, pool := range sortedPools {
+		if r.defaultConfig.MinOSMOLiquidity > 0 && pool.GetTotalValueLockedUSDC().LT(minLiquidity) {
+			continue
+		}
+
+		sqsModel := pool.GetSQSPoolModel()
+
+		for _, tokenInDenom := range sqsModel.PoolDenoms {
+			for _, tokenOutDenom := range sqsModel.PoolDenoms {
+				if tokenInDenom == tokenOutDenom {
+					continue
+				}
+
+				edge, err := r.newArbitrageEdge(ctx, snapshot, pool, tokenInDenom, tokenOutDenom)
+				if err != nil {
+					r.logger.Debug("skipping arbitrage edge", zap.Uint64("pool_id", pool.GetId()), zap.String("token_in_denom", tokenInDenom), zap.String("token_out_denom", tokenOutDenom), zap.Error(err))
+					continue
+				}
+
+				edge.from = getDenomIndex(tokenInDenom)
+				edge.to = getDenomIndex(tokenOutDenom)
+
+				edges = append(edges, edge)
+			}
+		}
+	}
+
+	return denoms, edges
+}
+
+// newArbitrageEdge returns the edge swapping the token in denom for the token out denom over the given pool.
+// The denom indexes are not set.
+// Returns error if the pool is a generalized cosmwasm pool or if the net spot price is not positive.
+func (r *routerUseCaseImpl) newArbitrageEdge(ctx context.Context, snapshot *domain.StateSnapshot, pool sqsdomain.PoolI, tokenInDenom, tokenOutDenom string) (arbitrageEdge, error) {
+	takerFee, exists := snapshot.GetTakerFee(tokenInDenom, tokenOutDenom)
+	if !exists {
+		takerFee = sqsdomain.DefaultTakerFee
+	}
+
+	routablePool, err := pools.NewRoutablePool(pool, tokenInDenom, tokenOutDenom, takerFee, r.cosmWasmPoolsConfig.WithStateSnapshot(snapshot))
+	if err != nil {
+		return arbitrageEdge{}, err
+	}
+
+	// Generalized cosmwasm pools make network requests to chain for every estimate.
+	if routablePool.IsGeneralizedCosmWasmPool() {
+		return arbitrageEdge{}, fmt.Errorf("generalized cosmwasm pools are not supported")
+	}
+
+	spotPrice, err := routablePool.CalcSpotPrice(ctx, tokenInDenom, tokenOutDenom)
+	if err != nil {
+		return arbitrageEdge{}, err
+	}
+
+	if !spotPrice.IsPositive() {
+		return arbitrageEdge{}, fmt.Errorf("spot price (%s) is not positive", spotPrice)
+	}
+
+	netSpotPrice := spotPrice.Dec().MulMut(osmomath.OneDec().SubMut(routablePool.GetSpreadFactor())).MulMut(osmomath.OneDec().SubMut(takerFee))
+	if !netSpotPrice.IsPositive() {
+		return arbitrageEdge{}, fmt.Errorf("net spot price (%s) is not positive", netSpotPrice)
+	}
+
+	netSpotPriceFloat, err := netSpotPrice.Float64()
+	if err != nil {
+		return arbitrageEdge{}, err
+	}
+
+	// The token out liquidity is valued in the token in at the spot price.
+	balances := pool.GetSQSPoolModel().Balances
+	maxAmountIn := balances.AmountOf(tokenInDenom).Add(balances.AmountOf(tokenOutDenom).ToLegacyDec().QuoMut(spotPrice.Dec()).TruncateInt())
+
+	return arbitrageEdge{
+		pool:         routablePool,
+		netSpotPrice: netSpotPrice,
+		weight:       -math.Log(netSpotPriceFloat),
+		maxAmountIn:  maxAmountIn,
+	}, nil
+}
+
+// findArbitrageCycles returns the negative cycles in the graph with the given edges over numDenoms denoms.
+// Every cycle is returned as the edges in the swap order.
+// The cycles are found with the Bellman-Ford algorithm from a virtual source connected to every denom.
+// At most one cycle is found for every edge that can still be relaxed after numDenoms iterations.
+// Cycles with more than maxCycleLen edges are skipped.
+func findArbitrageCycles(edges []arbitrageEdge, numDenoms, maxCycleLen int) [][]arbitrageEdge {
+	distances := make([]float64, numDenoms)
+
+	// Index of the edge through which the denom was last relaxed. -1 if never relaxed.
+	predecessors := make([]int, numDenoms)
+	for i := range predecessors {
+		predecessors[i] = -1
+	}
+
+	canRelax := func(edge arbitrageEdge) bool {
+		return distances[edge.from]+edge.weight < distances[edge.to]-arbitrageRelaxationTolerance
+	}
+
+	for i := 0; i < numDenoms; i++ {
+		isRelaxed := false
+		for j, edge := range edges {
+			if canRelax(edge) {
+				distances[edge.to] = distances[edge.from] + edge.weight
+				predecessors[edge.to] = j
+				isRelaxed = true
+			}
+		}
+
+		if !isRelaxed {
+			return nil
+		}
+	}
+
+	// With no negative cycles, the distances converge in at most numDenoms iterations
+	// given the virtual source. Any edge that can still be relaxed leads from a negative cycle.
+	cycles := make([][]arbitrageEdge, 0)
+	seenCycles := make(map[string]struct{})
+
+	for j, edge := range edges {
+		if !canRelax(edge) {
+			continue
+		}
+
+		predecessors[edge.to] = j
+
+		cycleEdgeIndexes, ok := getPredecessorCycle(edges, predecessors, edge.to, numDenoms)
+		if !ok || len(cycleEdgeIndexes) > maxCycleLen {
+			continue
+		}
+
+		cycleKey := formatArbitrageCycleKey(cycleEdgeIndexes)
+		if _, seen := seenCycles[cycleKey]; seen {
+			continue
+		}
+		seenCycles[cycleKey] = struct{}{}
+
+		cycle := make([]arbitrageEdge, 0, len(cycleEdgeIndexes))
+		totalWeight := 0.0
+		for _, edgeIndex := range cycleEdgeIndexes {
+			cycle = append(cycle, edges[edgeIndex])
+			totalWeight += edges[edgeIndex].weight
+		}
+
+		if totalWeight >= -arbitrageRelaxationTolerance {
+			continue
+		}
+
+		cycles = append(cycles, cycle)
+	}
+
+	return cycles
+}
+
+// getPredecessorCycle returns the indexes of the edges of the predecessor cycle that the given denom leads to,
+// in the swap order. First, it walks back numDenoms predecessors to guarantee landing on the cycle.
+// Returns false if the walk reaches a denom that was never relaxed.
+func getPredecessorCycle(edges []arbitrageEdge, predecessors []int, denom int, numDenoms int) ([]int, bool) {
+	for i := 0; i < numDenoms; i++ {
+		edgeIndex := predecessors[denom]
+		if edgeIndex == -1 {
+			return nil, false
+		}
+		denom = edges[edgeIndex].from
+	}
+
+	cycleEdgeIndexes := make([]int, 0)
+	for cycleDenom := denom; ; {
+		edgeIndex := predecessors[cycleDenom]
+		if edgeIndex == -1 || len(cycleEdgeIndexes) == numDenoms {
+			return nil, false
+		}
+
+		cycleEdgeIndexes = append(cycleEdgeIndexes, edgeIndex)
+
+		cycleDenom = edges[edgeIndex].from
+		if cycleDenom == denom {
+			break
+		}
+	}
+
+	// The cycle is collected backwards.
+	for i, j := 0, len(cycleEdgeIndexes)-1; i < j; i, j = i+1, j-1 {
+		cycleEdgeIndexes[i], cycleEdgeIndexes[j] = cycleEdgeIndexes[j], cycleEdgeIndexes[i]
+	}
+
+	return cycleEdgeIndexes, true
+}
+
+// formatArbitrageCycleKey returns the key identifying the cycle with the given edge indexes
+// regardless of the edge it starts from.
+func formatArbitrageCycleKey(cycleEdgeIndexes []int) string {
+	minIndexPosition := 0
+	for i, edgeIndex := range cycleEdgeIndexes {
+		if edgeIndex < cycleEdgeIndexes[minIndexPosition] {
+			minIndexPosition = i
+		}
+	}
+
+	var builder strings.Builder
+	for i := range cycleEdgeIndexes {
+		edgeIndex := cycleEdgeIndexes[(minIndexPosition+i)%len(cycleEdgeIndexes)]
+		builder.WriteString(fmt.Sprintf("%d%s", edgeIndex, denomSeparatorChar))
+	}
+
+	return builder.String()
+}
+
+// verifyArbitrageCycle quotes the given cycle starting from the token in denom of its first edge
+// at the amount in that maximizes the profit.
+// Returns false if no amount in results in a positive profit.
+func verifyArbitrageCycle(ctx context.Context, denoms []string, cycle []arbitrageEdge) (domain.ArbitrageCycle, bool) {
+	denom := denoms[cycle[0].from]
+
+	routablePools := make([]sqsdomain.RoutablePool, 0, len(cycle))
+	hops := make([]domain.ArbitrageHop, 0, len(cycle))
+	spotPriceProduct := osmomath.OneDec()
+	for _, edge := range cycle {
+		routablePools = append(routablePools, edge.pool)
+		hops = append(hops, domain.ArbitrageHop{
+			PoolID:        edge.pool.GetId(),
+			TokenOutDenom: denoms[edge.to],
+		})
+		spotPriceProduct.MulMut(edge.netSpotPrice)
+	}
+
+	cycleRoute := route.RouteImpl{Pools: routablePools}
+
+	amountIn, amountOut := findOptimalArbitrageAmountIn(ctx, cycleRoute, denom, cycle[0].maxAmountIn)
+	if !amountOut.GT(amountIn) {
+		return domain.ArbitrageCycle{}, false
+	}
+
+	return domain.ArbitrageCycle{
+		Denom:            denom,
+		Hops:             hops,
+		AmountIn:         amountIn,
+		AmountOut:        amountOut,
+		Profit:           amountOut.Sub(amountIn),
+		SpotPriceProduct: spotPriceProduct,
+	}, true
+}
+
+// findOptimalArbitrageAmountIn returns the amount in of the given denom up to maxAmountIn
+// that maximizes the profit of swapping over the given cycle route together with the amount out.
+// Since the profit is unimodal in the amount in, it first finds the best power of two fraction of maxAmountIn
+// and then refines it with a ternary search around it.
+// Failing quotes are treated as a full loss of the amount in.
+func findOptimalArbitrageAmountIn(ctx context.Context, cycleRoute route.RouteImpl, denom string, maxAmountIn osmomath.Int) (osmomath.Int, osmomath.Int) {
+	getAmountOut := func(amountIn osmomath.Int) osmomath.Int {
+		tokenOut, err := cycleRoute.CalculateTokenOutByTokenIn(ctx, sdk.NewCoin(denom, amountIn))
+		if err != nil || tokenOut.Amount.IsNil() {
+			return osmomath.ZeroInt()
+		}
+		return tokenOut.Amount
+	}
+
+	bestAmountIn, bestAmountOut := osmomath.ZeroInt(), osmomath.ZeroInt()
+	bestProfit := osmomath.ZeroInt()
+
+	updateBest := func(amountIn osmomath.Int) osmomath.Int {
+		amountOut := getAmountOut(amountIn)
+		profit := amountOut.Sub(amountIn)
+		if profit.GT(bestProfit) {
+			bestAmountIn, bestAmountOut, bestProfit = amountIn, amountOut, profit
+		}
+		return profit
+	}
+
+	for amountIn := maxAmountIn; amountIn.IsPositive(); amountIn = amountIn.QuoRaw(2) {
+		updateBest(amountIn)
+	}
+
+	if !bestProfit.IsPositive() {
+		return bestAmountIn, bestAmountOut
+	}
+
+	low := bestAmountIn.QuoRaw(2)
+	high := osmomath.MinInt(bestAmountIn.MulRaw(2), maxAmountIn)
+	for i := 0; i < arbitrageAmountInSearchMaxIterations && high.Sub(low).GT(osmomath.NewInt(2)); i++ {
+		third := high.Sub(low).QuoRaw(3)
+		lowThird, highThird := low.Add(third), high.Sub(third)
+
+		if updateBest(lowThird).LT(updateBest(highThird)) {
+			low = lowThird
+		} else {
+			high = highThird
+		}
+	}
+
+	return bestAmountIn, bestAmountOut
+}
+
+// arbitrageCycleStringer formats the cycle as the sequence of pool IDs and denoms for logging.
+type arbitrageCycleStringer struct {
+	denoms []string
+	cycle  []arbitrageEdge
+}
+
+// String implements fmt.Stringer.
+func (s arbitrageCycleStringer) String() string {
+	var builder strings.Builder
+	builder.WriteString(s.denoms[s.cycle[0].from])
+	for _, edge := range s.cycle {
+		builder.WriteString(fmt.Sprintf(" -(%d)-> %s", edge.pool.GetId(), s.denoms[edge.to]))
+	}
+	return builder.String()
+}
@@ -0,0 +1,183 @@
+package usecase_test
+
+import (
+	"context"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/domain/cache"
+	"github.com/osmosis-labs/sqs/log"
+	poolsusecase "github.com/osmosis-labs/sqs/pools/usecase"
+	routerrepo "github.com/osmosis-labs/sqs/router/repository"
+	routerusecase "github.com/osmosis-labs/sqs/router/usecase"
+	"github.com/osmosis-labs/sqs/router/usecase/route"
+	"github.com/osmosis-labs/sqs/router/usecase/routertesting"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// Validates that the mispriced cycles are detected over the liquid pools and verified
+// by quoting the amount in that maximizes the profit.
+func (s *RouterTestSuite) TestDetectArbitrage() {
+	s.Setup()
+
+	const height = 100
+
+	var (
+		liquidityAmount       = osmomath.NewInt(1_000_000_000)
+		doubleLiquidityAmount = liquidityAmount.MulRaw(2)
+		tvl                   = liquidityAmount.Int64()
+
+		poolOneTwo   = withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), tvl)
+		poolTwoThree = withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomTwo, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount)), tvl)
+		poolThreeOne = withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomThree, liquidityAmount), sdk.NewCoin(DenomOne, liquidityAmount)), tvl)
+		// One DenomThree is worth two DenomOne in this pool while it is worth one DenomOne over the other two pools.
+		poolThreeOneMispriced = withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomThree, liquidityAmount), sdk.NewCoin(DenomOne, doubleLiquidityAmount)), tvl)
+	)
+
+	takerFeeMap := sqsdomain.TakerFeeMap{}
+	takerFeeMap.SetTakerFee(DenomOne, DenomTwo, osmomath.MustNewDecFromStr("0.001"))
+	takerFeeMap.SetTakerFee(DenomTwo, DenomOne, osmomath.MustNewDecFromStr("0.001"))
+	takerFeeMap.SetTakerFee(DenomTwo, DenomThree, osmomath.MustNewDecFromStr("0.001"))
+	takerFeeMap.SetTakerFee(DenomThree, DenomTwo, osmomath.MustNewDecFromStr("0.001"))
+	takerFeeMap.SetTakerFee(DenomThree, DenomOne, osmomath.MustNewDecFromStr("0.001"))
+	takerFeeMap.SetTakerFee(DenomOne, DenomThree, osmomath.MustNewDecFromStr("0.001"))
+
+	tests := map[string]struct {
+		pools            []sqsdomain.PoolI
+		maxPoolsPerRoute int
+
+		expectedCyclePoolIDs [][]uint64
+	}{
+		"balanced pools - no cycles": {
+			pools:            []sqsdomain.PoolI{poolOneTwo, poolTwoThree, poolThreeOne},
+			maxPoolsPerRoute: 4,
+		},
+		"mispriced pool - one cycle": {
+			pools:            []sqsdomain.PoolI{poolOneTwo, poolTwoThree, poolThreeOneMispriced},
+			maxPoolsPerRoute: 4,
+
+			expectedCyclePoolIDs: [][]uint64{{poolOneTwo.GetId(), poolTwoThree.GetId(), poolThreeOneMispriced.GetId()}},
+		},
+		"mispriced pool below min liquidity - no cycles": {
+			pools:            []sqsdomain.PoolI{poolOneTwo, poolTwoThree, withTVL(poolThreeOneMispriced, 1)},
+			maxPoolsPerRoute: 4,
+		},
+		"cycle longer than max pools per route - no cycles": {
+			pools:            []sqsdomain.PoolI{poolOneTwo, poolTwoThree, poolThreeOneMispriced},
+			maxPoolsPerRoute: 2,
+		},
+		"mispriced pool against a direct pool - two pool cycle": {
+			pools:            []sqsdomain.PoolI{poolThreeOne, poolThreeOneMispriced},
+			maxPoolsPerRoute: 4,
+
+			expectedCyclePoolIDs: [][]uint64{{poolThreeOne.GetId(), poolThreeOneMispriced.GetId()}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		s.Run(name, func() {
+			routerRepository := routerrepo.New()
+			routerRepository.SetTakerFees(takerFeeMap)
+
+			routerConfig := routertesting.DefaultRouterConfig
+			routerConfig.MaxPoolsPerRoute = tc.maxPoolsPerRoute
+
+			routerUsecase := routerusecase.NewRouterUsecase(routerRepository, nil, routerConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+			routerUseCaseImpl, ok := routerUsecase.(*routerusecase.RouterUseCaseImpl)
+			s.Require().True(ok)
+
+			// System under test.
+			result := routerUseCaseImpl.DetectArbitrage(context.Background(), tc.pools, height)
+
+			s.Require().Equal(uint64(height), result.Height)
+			s.Require().Len(result.Cycles, len(tc.expectedCyclePoolIDs))
+
+			for i, cycle := range result.Cycles {
+				s.Require().ElementsMatch(tc.expectedCyclePoolIDs[i], getArbitrageCyclePoolIDs(cycle))
+				s.validateArbitrageCycle(cycle, tc.pools, routerRepository)
+			}
+		})
+	}
+}
+
+// Validates that the asynchronous detection stores the result only if enabled.
+func (s *RouterTestSuite) TestDetectArbitrageAsync() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+	tvl := liquidityAmount.Int64()
+
+	pools := []sqsdomain.PoolI{
+		withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomThree, liquidityAmount), sdk.NewCoin(DenomOne, liquidityAmount)), tvl),
+		withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomThree, liquidityAmount), sdk.NewCoin(DenomOne, liquidityAmount.MulRaw(2))), tvl),
+	}
+
+	for _, isEnabled := range []bool{true, false} {
+		routerConfig := routertesting.DefaultRouterConfig
+		routerConfig.ArbitrageDetectionEnabled = isEnabled
+
+		routerUsecase := routerusecase.NewRouterUsecase(routerrepo.New(), nil, routerConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+		routerUsecase.SetSortedPools(pools)
+
+		// System under test.
+		routerUsecase.DetectArbitrageAsync(1)
+
+		if !isEnabled {
+			s.Require().Never(func() bool {
+				return routerUsecase.GetArbitrage().Height != 0
+			}, 100*time.Millisecond, 10*time.Millisecond)
+			continue
+		}
+
+		s.Require().Eventually(func() bool {
+			return routerUsecase.GetArbitrage().Height == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		s.Require().Len(routerUsecase.GetArbitrage().Cycles, 1)
+	}
+}
+
+// validateArbitrageCycle validates that the cycle is connected, that its amounts match the quote over the given pools
+// and that it is profitable at the margin and at the amount in.
+func (s *RouterTestSuite) validateArbitrageCycle(cycle domain.ArbitrageCycle, pools []sqsdomain.PoolI, routerRepository routerrepo.RouterRepository) {
+	s.Require().Equal(cycle.Denom, cycle.Hops[len(cycle.Hops)-1].TokenOutDenom)
+	s.Require().True(cycle.SpotPriceProduct.GT(osmomath.OneDec()))
+	s.Require().True(cycle.Profit.IsPositive())
+	s.Require().Equal(cycle.AmountOut.Sub(cycle.AmountIn), cycle.Profit)
+
+	candidateRoute := sqsdomain.CandidateRoute{}
+	for _, hop := range cycle.Hops {
+		candidateRoute.Pools = append(candidateRoute.Pools, sqsdomain.CandidatePool{ID: hop.PoolID, TokenOutDenom: hop.TokenOutDenom})
+	}
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	s.Require().NoError(poolsUsecase.StorePools(pools))
+
+	routes, err := poolsUsecase.GetRoutesFromCandidates(context.Background(), sqsdomain.CandidateRoutes{Routes: []sqsdomain.CandidateRoute{candidateRoute}}, cycle.Denom, cycle.Denom)
+	s.Require().NoError(err)
+
+	getProfit := func(cycleRoute route.RouteImpl, amountIn osmomath.Int) osmomath.Int {
+		tokenOut, err := cycleRoute.CalculateTokenOutByTokenIn(context.Background(), sdk.NewCoin(cycle.Denom, amountIn))
+		s.Require().NoError(err)
+		return tokenOut.Amount.Sub(amountIn)
+	}
+
+	s.Require().Equal(cycle.Profit, getProfit(routes[0], cycle.AmountIn))
+
+	// The amount in is optimal up to a percent.
+	s.Require().True(getProfit(routes[0], cycle.AmountIn.MulRaw(101).QuoRaw(100)).LTE(cycle.Profit))
+	s.Require().True(getProfit(routes[0], cycle.AmountIn.MulRaw(99).QuoRaw(100)).LTE(cycle.Profit))
+}
+
+// getArbitrageCyclePoolIDs returns the pool IDs of the cycle in the swap order.
+func getArbitrageCyclePoolIDs(cycle domain.ArbitrageCycle) []uint64 {
+	poolIDs := make([]uint64, 0, len(cycle.Hops))
+	for _, hop := range cycle.Hops {
+		poolIDs = append(poolIDs, hop.PoolID)
+	}
+	return poolIDs
+}
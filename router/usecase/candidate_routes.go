@@ -1,7 +1,14 @@
 package usecase
 
 import (
+	"context"
+	"strconv"
+	"strings"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
 	"github.com/osmosis-labs/sqs/log"
 	"github.com/osmosis-labs/sqs/sqsdomain"
 )
@@ -15,24 +22,54 @@ type candidatePoolWrapper struct {
 	Idx        int
 }
 
-// GetCandidateRoutes returns candidate routes from tokenInDenom to tokenOutDenom using BFS.
-// TODO: Build a better algorithm for finding routes.
-// Right now we iterate over the etnire sorted list to try building routes. But most of the work is wasted
-// in every iteration, as we have to think about pools that won't relate to the needed asset.
-// instead we should have in router:
-// * sortedPoolsByDenom map[string][]sqsdomain.PoolI. Where the return value is all pools that contain the denom, sorted.
-//   - Right now we have linear time iteration per route rather than N^2 by making every route get created in sorted order.
-//   - We can do similar here by actually making the value of the hashmap be a []struct{global sort index, sqsdomain pool}
-func GetCandidateRoutes(pools []sqsdomain.PoolI, tokenIn sdk.Coin, tokenOutDenom string, maxRoutes, maxPoolsPerRoute int, logger log.Logger) (sqsdomain.CandidateRoutes, error) {
+// GetCandidateRoutes returns candidate routes from tokenInDenom to tokenOutDenom using BFS over the pool graph.
+// At every expansion, only the pools adjacent to the current token in denom are walked
+// in the global sort order.
+//
+// Pools with liquidity below minOSMOLiquidity are skipped. Zero implies no filtering.
+// Pools that do not pass the given filters are skipped. Excluded denoms are never used as intermediary hops.
+// The routes with intermediary hops only in the preferred denoms, if any, are searched first.
+func GetCandidateRoutes(graph *PoolGraph, tokenIn sdk.Coin, tokenOutDenom string, maxRoutes, maxPoolsPerRoute, minOSMOLiquidity int, filters domain.CandidateRouteFilters, logger log.Logger) (sqsdomain.CandidateRoutes, error) {
+	return getCandidateRoutes(context.Background(), graph, tokenIn, tokenOutDenom, maxRoutes, maxPoolsPerRoute, minOSMOLiquidity, filters, nil, logger)
+}
+
+// getCandidateRoutes implements GetCandidateRoutes, recording the routes skipped during validation
+// in the debug info if it is non-nil.
+// If the filters have preferred denoms, the routes with intermediary hops only in the preferred denoms
+// are searched first. The remaining routes, if any, are filled from the search over all denoms.
+// Once the context is done, the search stops as soon as at least one route is found.
+func getCandidateRoutes(ctx context.Context, graph *PoolGraph, tokenIn sdk.Coin, tokenOutDenom string, maxRoutes, maxPoolsPerRoute, minOSMOLiquidity int, filters domain.CandidateRouteFilters, debugInfo *domain.QuoteDebugInfo, logger log.Logger) (sqsdomain.CandidateRoutes, error) {
+	var routes [][]candidatePoolWrapper
+	if len(filters.PreferredDenoms) > 0 {
+		routes = searchCandidateRoutes(ctx, graph, tokenIn, tokenOutDenom, maxRoutes, maxPoolsPerRoute, minOSMOLiquidity, filters, true)
+	}
+
+	if len(routes) < maxRoutes && (len(routes) == 0 || ctx.Err() == nil) {
+		routes = appendUniqueCandidateRoutes(routes, searchCandidateRoutes(ctx, graph, tokenIn, tokenOutDenom, maxRoutes, maxPoolsPerRoute, minOSMOLiquidity, filters, false), maxRoutes)
+	}
+
+	return validateAndFilterRoutes(routes, tokenIn.Denom, debugInfo, logger)
+}
+
+// searchCandidateRoutes returns up to maxRoutes routes found by the BFS over the pool graph.
+// If onlyPreferredDenoms is set, only the preferred denoms of the filters are used as intermediary hops.
+// The routes are not validated.
+func searchCandidateRoutes(ctx context.Context, graph *PoolGraph, tokenIn sdk.Coin, tokenOutDenom string, maxRoutes, maxPoolsPerRoute, minOSMOLiquidity int, filters domain.CandidateRouteFilters, onlyPreferredDenoms bool) [][]candidatePoolWrapper {
 	routes := make([][]candidatePoolWrapper, 0, maxRoutes)
-	// Preallocate third to avoid dynamic reallocations.
-	visited := make([]bool, len(pools))
+	// Indexed by the global sort rank of the pool.
+	visited := make([]bool, graph.numPools)
+
+	minLiquidity := osmomath.NewInt(int64(minOSMOLiquidity))
 
 	// Preallocate third of the pools to avoid dynamic reallocations.
-	queue := make([][]candidatePoolWrapper, 0, len(pools)/3)
+	queue := make([][]candidatePoolWrapper, 0, graph.numPools/3)
 	queue = append(queue, make([]candidatePoolWrapper, 0, maxPoolsPerRoute))
 
 	for len(queue) > 0 && len(routes) < maxRoutes {
+		if len(routes) > 0 && ctx.Err() != nil {
+			break
+		}
+
 		currentRoute := queue[0]
 		queue[0] = nil // Clear the slice to avoid holding onto references
 		queue = queue[1:]
@@ -45,24 +82,32 @@ func GetCandidateRoutes(pools []sqsdomain.PoolI, tokenIn sdk.Coin, tokenOutDenom
 			currenTokenInDenom = lastPool.TokenOutDenom
 		}
 
-		for i := 0; i < len(pools) && len(routes) < maxRoutes; i++ {
-			// Unsafe cast for performance reasons.
-			// nolint: forcetypeassert
-			pool := (pools[i]).(*sqsdomain.PoolWrapper)
+		// Only the pools containing the current token in denom are adjacent.
+		adjacentPools := graph.poolsByDenom[currenTokenInDenom]
+
+		for j := 0; j < len(adjacentPools) && len(routes) < maxRoutes; j++ {
+			pool := adjacentPools[j].pool
+			i := adjacentPools[j].rank
 			poolID := pool.ChainModel.GetId()
 
 			if visited[i] {
 				continue
 			}
 
+			if minOSMOLiquidity > 0 && pool.GetTotalValueLockedUSDC().LT(minLiquidity) {
+				visited[i] = true
+				continue
+			}
+
+			if !filters.IsPoolAllowed(poolID, pool.ChainModel.GetType()) {
+				visited[i] = true
+				continue
+			}
+
 			poolDenoms := pool.SQSModel.PoolDenoms
-			hasTokenIn := false
 			hasTokenOut := false
 			shouldSkipPool := false
 			for _, denom := range poolDenoms {
-				if denom == currenTokenInDenom {
-					hasTokenIn = true
-				}
 				if denom == tokenOutDenom {
 					hasTokenOut = true
 				}
@@ -78,10 +123,6 @@ func GetCandidateRoutes(pools []sqsdomain.PoolI, tokenIn sdk.Coin, tokenOutDenom
 				continue
 			}
 
-			if !hasTokenIn {
-				continue
-			}
-
 			// Microptimization for the first pool in the route.
 			if len(currentRoute) == 0 {
 				currentTokenInAmount := pool.SQSModel.Balances.AmountOf(currenTokenInDenom)
@@ -101,6 +142,10 @@ func GetCandidateRoutes(pools []sqsdomain.PoolI, tokenIn sdk.Coin, tokenOutDenom
 				if hasTokenOut && denom != tokenOutDenom {
 					continue
 				}
+				// Without the token out denom in the pool, the denom becomes an intermediary hop.
+				if !hasTokenOut && (filters.IsDenomExcluded(denom) || (onlyPreferredDenoms && !filters.IsDenomPreferred(denom))) {
+					continue
+				}
 
 				if lastPoolID == uint64(0) || lastPoolID != currentPoolID {
 					newPath := make([]candidatePoolWrapper, len(currentRoute), len(currentRoute)+1)
@@ -133,7 +178,44 @@ func GetCandidateRoutes(pools []sqsdomain.PoolI, tokenIn sdk.Coin, tokenOutDenom
 		}
 	}
 
-	return validateAndFilterRoutes(routes, tokenIn.Denom, logger)
+	return routes
+}
+
+// appendUniqueCandidateRoutes appends the given new routes that are not in the given routes yet
+// until there are maxRoutes routes.
+func appendUniqueCandidateRoutes(routes, newRoutes [][]candidatePoolWrapper, maxRoutes int) [][]candidatePoolWrapper {
+	isIncluded := make(map[string]struct{}, len(routes))
+	for _, route := range routes {
+		isIncluded[formatCandidateRouteKey(route)] = struct{}{}
+	}
+
+	for _, route := range newRoutes {
+		if len(routes) >= maxRoutes {
+			break
+		}
+
+		routeKey := formatCandidateRouteKey(route)
+		if _, ok := isIncluded[routeKey]; ok {
+			continue
+		}
+
+		isIncluded[routeKey] = struct{}{}
+		routes = append(routes, route)
+	}
+
+	return routes
+}
+
+// formatCandidateRouteKey formats the pool IDs and the token out denoms of the given route to a string identifying it.
+func formatCandidateRouteKey(route []candidatePoolWrapper) string {
+	var sb strings.Builder
+	for _, pool := range route {
+		sb.WriteString(strconv.FormatUint(pool.ID, 10))
+		sb.WriteString(denomSeparatorChar)
+		sb.WriteString(pool.TokenOutDenom)
+		sb.WriteString(denomSeparatorChar)
+	}
+	return sb.String()
 }
 
 // Pool represents a pool in the decentralized exchange.
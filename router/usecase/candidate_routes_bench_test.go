@@ -0,0 +1,189 @@
+package usecase_test
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/log"
+	"github.com/osmosis-labs/sqs/router/usecase"
+	"github.com/osmosis-labs/sqs/router/usecase/routertesting"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// Benchmarks the candidate route search over the pool graph for the top pairs by volume.
+// Every iteration is a cache miss.
+func BenchmarkGetCandidateRoutes(b *testing.B) {
+	s, sortedPools := setupCandidateRoutesBenchmark()
+
+	poolGraph := usecase.NewPoolGraph(sortedPools)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		forEachTopVolumePair(func(tokenInDenom, tokenOutDenom string) {
+			// System under test.
+			_, err := usecase.GetCandidateRoutes(poolGraph, sdk.NewCoin(tokenInDenom, one), tokenOutDenom, defaultRouterConfig.MaxRoutes, defaultRouterConfig.MaxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
+			s.Require().NoError(err)
+		})
+	}
+}
+
+// Benchmarks the linear scan over all sorted pools that was used prior to the pool graph.
+// Serves as the baseline for BenchmarkGetCandidateRoutes.
+func BenchmarkGetCandidateRoutes_LinearScan(b *testing.B) {
+	s, sortedPools := setupCandidateRoutesBenchmark()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		forEachTopVolumePair(func(tokenInDenom, tokenOutDenom string) {
+			// System under test.
+			_, err := getCandidateRoutesLinearScan(sortedPools, sdk.NewCoin(tokenInDenom, one), tokenOutDenom, defaultRouterConfig.MaxRoutes, defaultRouterConfig.MaxPoolsPerRoute, noOpLogger)
+			s.Require().NoError(err)
+		})
+	}
+}
+
+// Benchmarks the construction of the pool graph that happens on every SetSortedPools call.
+func BenchmarkNewPoolGraph(b *testing.B) {
+	_, sortedPools := setupCandidateRoutesBenchmark()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// System under test.
+		_ = usecase.NewPoolGraph(sortedPools)
+	}
+}
+
+// setupCandidateRoutesBenchmark sets up the mainnet state and returns the valid sorted pools above the default min liquidity.
+func setupCandidateRoutesBenchmark() (*routertesting.RouterTestHelper, []sqsdomain.PoolI) {
+	// This is a hack to be able to use test suite helpers with the benchmark.
+	// We need to set testing.T for assertings within the helpers. Otherwise, it would block
+	s := &routertesting.RouterTestHelper{}
+	s.SetT(&testing.T{})
+
+	mainnetState := s.SetupMainnetState()
+
+	return s, routertesting.PrepareValidSortedRouterPools(mainnetState.Pools, defaultRouterConfig.MinOSMOLiquidity)
+}
+
+// forEachTopVolumePair calls the given function for every ordered pair of the top 10 denoms by volume.
+func forEachTopVolumePair(fn func(tokenInDenom, tokenOutDenom string)) {
+	// Manually taken from https://info.osmosis.zone/ in Nov 2023.
+	top10ByVolumeDenoms := []string{UOSMO, ATOM, stOSMO, stATOM, USDC, USDCaxl, USDT, WBTC, ETH, AKT}
+
+	for i := 0; i < len(top10ByVolumeDenoms); i++ {
+		for j := 0; j < len(top10ByVolumeDenoms); j++ {
+			if i == j {
+				continue
+			}
+
+			fn(top10ByVolumeDenoms[i], top10ByVolumeDenoms[j])
+		}
+	}
+}
+
+// getCandidateRoutesLinearScan is the candidate route search that iterates over all sorted pools
+// at every BFS expansion. It is kept as the reference for the pool graph search.
+func getCandidateRoutesLinearScan(pools []sqsdomain.PoolI, tokenIn sdk.Coin, tokenOutDenom string, maxRoutes, maxPoolsPerRoute int, logger log.Logger) (sqsdomain.CandidateRoutes, error) {
+	routes := make([][]usecase.CandidatePoolWrapper, 0, maxRoutes)
+	visited := make([]bool, len(pools))
+
+	queue := make([][]usecase.CandidatePoolWrapper, 0, len(pools)/3)
+	queue = append(queue, make([]usecase.CandidatePoolWrapper, 0, maxPoolsPerRoute))
+
+	for len(queue) > 0 && len(routes) < maxRoutes {
+		currentRoute := queue[0]
+		queue[0] = nil
+		queue = queue[1:]
+
+		lastPoolID := uint64(0)
+		currenTokenInDenom := tokenIn.Denom
+		if len(currentRoute) > 0 {
+			lastPool := currentRoute[len(currentRoute)-1]
+			lastPoolID = lastPool.ID
+			currenTokenInDenom = lastPool.TokenOutDenom
+		}
+
+		for i := 0; i < len(pools) && len(routes) < maxRoutes; i++ {
+			// nolint: forcetypeassert
+			pool := (pools[i]).(*sqsdomain.PoolWrapper)
+			poolID := pool.ChainModel.GetId()
+
+			if visited[i] {
+				continue
+			}
+
+			poolDenoms := pool.SQSModel.PoolDenoms
+			hasTokenIn := false
+			hasTokenOut := false
+			shouldSkipPool := false
+			for _, denom := range poolDenoms {
+				if denom == currenTokenInDenom {
+					hasTokenIn = true
+				}
+				if denom == tokenOutDenom {
+					hasTokenOut = true
+				}
+
+				if len(currentRoute) > 0 && denom == tokenIn.Denom {
+					shouldSkipPool = true
+					break
+				}
+			}
+
+			if shouldSkipPool || !hasTokenIn {
+				continue
+			}
+
+			if len(currentRoute) == 0 {
+				if pool.SQSModel.Balances.AmountOf(currenTokenInDenom).LT(tokenIn.Amount) {
+					visited[i] = true
+					continue
+				}
+			}
+
+			for _, denom := range poolDenoms {
+				if denom == currenTokenInDenom {
+					continue
+				}
+				if hasTokenOut && denom != tokenOutDenom {
+					continue
+				}
+
+				if lastPoolID == uint64(0) || lastPoolID != poolID {
+					newPath := make([]usecase.CandidatePoolWrapper, len(currentRoute), len(currentRoute)+1)
+
+					copy(newPath, currentRoute)
+
+					newPath = append(newPath, usecase.CandidatePoolWrapper{
+						CandidatePool: sqsdomain.CandidatePool{
+							ID:            poolID,
+							TokenOutDenom: denom,
+						},
+						PoolDenoms: poolDenoms,
+						Idx:        i,
+					})
+
+					if len(newPath) <= maxPoolsPerRoute {
+						if hasTokenOut {
+							routes = append(routes, newPath)
+							break
+						} else {
+							queue = append(queue, newPath)
+						}
+					}
+				}
+			}
+		}
+
+		for _, pool := range currentRoute {
+			visited[pool.Idx] = true
+		}
+	}
+
+	return usecase.ValidateAndFilterRoutes(routes, tokenIn.Denom, logger)
+}
@@ -3,6 +3,8 @@ package usecase_test
 import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/osmosis-labs/osmosis/osmomath"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
+	"github.com/osmosis-labs/sqs/domain"
 	"github.com/osmosis-labs/sqs/log"
 	routerusecase "github.com/osmosis-labs/sqs/router/usecase"
 	"github.com/osmosis-labs/sqs/router/usecase/routertesting"
@@ -27,9 +29,10 @@ func (s *RouterTestSuite) TestGetCandidateRoutesBFS_OSMOATOM() {
 
 	// Prepare valid and sorted pools
 	poolsAboveMinLiquidity := routertesting.PrepareValidSortedRouterPools(mainnetState.Pools, defaultRouterConfig.MinOSMOLiquidity)
+	poolGraph := routerusecase.NewPoolGraph(poolsAboveMinLiquidity)
 
 	// System under test.
-	candidateRoutes, err := routerusecase.GetCandidateRoutes(poolsAboveMinLiquidity, sdk.NewCoin(UOSMO, one), ATOM, maxRoutes, maxPoolsPerRoute, noOpLogger)
+	candidateRoutes, err := routerusecase.GetCandidateRoutes(poolGraph, sdk.NewCoin(UOSMO, one), ATOM, maxRoutes, maxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
 	s.Require().NoError(err)
 
 	actualRoutes := candidateRoutes.Routes
@@ -58,14 +61,15 @@ func (s *RouterTestSuite) TestGetCandidateRoutesBFS_OSMOstOSMO() {
 
 	// Prepare valid and sorted pools
 	poolsAboveMinLiquidity := routertesting.PrepareValidSortedRouterPools(mainnetState.Pools, minOSMOLiquidity)
+	poolGraph := routerusecase.NewPoolGraph(poolsAboveMinLiquidity)
 
-	candidateRoutesUOSMOIn, err := routerusecase.GetCandidateRoutes(poolsAboveMinLiquidity, sdk.NewCoin(UOSMO, one), stOSMO, maxRoutes, maxPoolsPerRoute, noOpLogger)
+	candidateRoutesUOSMOIn, err := routerusecase.GetCandidateRoutes(poolGraph, sdk.NewCoin(UOSMO, one), stOSMO, maxRoutes, maxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
 	s.Require().NoError(err)
 
 	actualRoutesUOSMOIn := candidateRoutesUOSMOIn.Routes
 
 	// Invert
-	candidateRoutesstOSMOIn, err := routerusecase.GetCandidateRoutes(poolsAboveMinLiquidity, sdk.NewCoin(stOSMO, one), UOSMO, maxRoutes, maxPoolsPerRoute, noOpLogger)
+	candidateRoutesstOSMOIn, err := routerusecase.GetCandidateRoutes(poolGraph, sdk.NewCoin(stOSMO, one), UOSMO, maxRoutes, maxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
 	s.Require().NoError(err)
 
 	actualRoutesStOSMOIn := candidateRoutesstOSMOIn.Routes
@@ -85,8 +89,9 @@ func (s *RouterTestSuite) TestGetCandidateRoutesBFS_ATOMUSDT() {
 
 	// Prepare valid and sorted pools
 	poolsAboveMinLiquidity := routertesting.PrepareValidSortedRouterPools(mainnetState.Pools, minOsmoLiquidity)
+	poolGraph := routerusecase.NewPoolGraph(poolsAboveMinLiquidity)
 
-	candidateRoutesUOSMOIn, err := routerusecase.GetCandidateRoutes(poolsAboveMinLiquidity, sdk.NewCoin(ATOM, one), USDT, maxRoutes, maxPoolsPerRoute, noOpLogger)
+	candidateRoutesUOSMOIn, err := routerusecase.GetCandidateRoutes(poolGraph, sdk.NewCoin(ATOM, one), USDT, maxRoutes, maxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
 	s.Require().NoError(err)
 
 	s.Require().Greater(len(candidateRoutesUOSMOIn.Routes), 0)
@@ -98,8 +103,9 @@ func (s *RouterTestSuite) TestGetCandidateRoutes_USDT_USDC() {
 
 	// Prepare valid and sorted pools
 	poolsAboveMinLiquidity := routertesting.PrepareValidSortedRouterPools(mainnetState.Pools, routertesting.DefaultPricingRouterConfig.MinOSMOLiquidity)
+	poolGraph := routerusecase.NewPoolGraph(poolsAboveMinLiquidity)
 
-	candidateRoutesUOSMOIn, err := routerusecase.GetCandidateRoutes(poolsAboveMinLiquidity, sdk.NewCoin(USDT, one), USDC, routertesting.DefaultPricingRouterConfig.MaxRoutes, routertesting.DefaultPricingRouterConfig.MaxPoolsPerRoute, noOpLogger)
+	candidateRoutesUOSMOIn, err := routerusecase.GetCandidateRoutes(poolGraph, sdk.NewCoin(USDT, one), USDC, routertesting.DefaultPricingRouterConfig.MaxRoutes, routertesting.DefaultPricingRouterConfig.MaxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
 	s.Require().NoError(err)
 
 	s.Require().Greater(len(candidateRoutesUOSMOIn.Routes), 0)
@@ -133,23 +139,299 @@ func (s *RouterTestSuite) TestGetCandidateRoutesBFS_Top10VolumePairs() {
 
 	// Prepare valid and sorted pools
 	poolsAboveMinLiquidity := routertesting.PrepareValidSortedRouterPools(mainnetState.Pools, defaultRouterConfig.MinOSMOLiquidity)
+	poolGraph := routerusecase.NewPoolGraph(poolsAboveMinLiquidity)
 
 	for i := 0; i < len(top10ByVolumeDenoms); i++ {
 		for j := i + 1; j < len(top10ByVolumeDenoms); j++ {
 			tokenI := top10ByVolumeDenoms[i]
 			tokenJ := top10ByVolumeDenoms[j]
 
-			candidateRoutes, err := routerusecase.GetCandidateRoutes(poolsAboveMinLiquidity, sdk.NewCoin(tokenI, one), tokenJ, maxRoutes, maxPoolsPerRoute, noOpLogger)
+			candidateRoutes, err := routerusecase.GetCandidateRoutes(poolGraph, sdk.NewCoin(tokenI, one), tokenJ, maxRoutes, maxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
 			s.Require().NoError(err)
 			s.Require().Greater(len(candidateRoutes.Routes), 0, "tokenI: %s, tokenJ: %s", tokenI, tokenJ)
 
-			candidateRoutes, err = routerusecase.GetCandidateRoutes(poolsAboveMinLiquidity, sdk.NewCoin(tokenJ, one), tokenI, maxRoutes, maxPoolsPerRoute, noOpLogger)
+			candidateRoutes, err = routerusecase.GetCandidateRoutes(poolGraph, sdk.NewCoin(tokenJ, one), tokenI, maxRoutes, maxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
 			s.Require().NoError(err)
 			s.Require().Greater(len(candidateRoutes.Routes), 0, "tokenJ: %s, tokenI: %s", tokenJ, tokenI)
 		}
 	}
 }
 
+// Validates that the search over the pool graph finds exactly the same routes
+// as the linear scan over all sorted pools for the top pairs by volume.
+func (s *RouterTestSuite) TestGetCandidateRoutes_PoolGraph_MatchesLinearScan() {
+	mainnetState := s.SetupMainnetState()
+
+	// Prepare valid and sorted pools
+	sortedPools := routertesting.PrepareValidSortedRouterPools(mainnetState.Pools, defaultRouterConfig.MinOSMOLiquidity)
+	poolGraph := routerusecase.NewPoolGraph(sortedPools)
+
+	forEachTopVolumePair(func(tokenInDenom, tokenOutDenom string) {
+		tokenIn := sdk.NewCoin(tokenInDenom, one)
+
+		expectedRoutes, err := getCandidateRoutesLinearScan(sortedPools, tokenIn, tokenOutDenom, defaultRouterConfig.MaxRoutes, defaultRouterConfig.MaxPoolsPerRoute, noOpLogger)
+		s.Require().NoError(err)
+
+		actualRoutes, err := routerusecase.GetCandidateRoutes(poolGraph, tokenIn, tokenOutDenom, defaultRouterConfig.MaxRoutes, defaultRouterConfig.MaxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
+		s.Require().NoError(err)
+
+		s.Require().Equal(expectedRoutes, actualRoutes, "tokenIn: %s, tokenOut: %s", tokenInDenom, tokenOutDenom)
+	})
+}
+
+// Validates that the search over the pool graph finds exactly the same routes
+// as the linear scan over all sorted pools for a pool set with every pair
+// of the test denoms connected by multiple pools.
+func (s *RouterTestSuite) TestGetCandidateRoutes_PoolGraph_MatchesLinearScan_AllPairs() {
+	s.Setup()
+
+	const (
+		maxRoutes        = 10
+		maxPoolsPerRoute = 3
+	)
+
+	denoms := []string{DenomOne, DenomTwo, DenomThree, DenomFour, DenomFive}
+
+	sortedPools := []sqsdomain.PoolI{}
+	for i := 0; i < len(denoms); i++ {
+		for j := i + 1; j < len(denoms); j++ {
+			// Vary the liquidity so that some pools do not have enough token in.
+			liquidityAmount := osmomath.NewInt(int64((i + j) * 1_000))
+
+			sortedPools = append(sortedPools,
+				s.prepareBalancerPoolWrapper(sdk.NewCoin(denoms[i], liquidityAmount), sdk.NewCoin(denoms[j], liquidityAmount)),
+				s.prepareBalancerPoolWrapper(sdk.NewCoin(denoms[i], liquidityAmount.MulRaw(2)), sdk.NewCoin(denoms[j], liquidityAmount.MulRaw(3))),
+			)
+		}
+	}
+
+	// Interleave the pools so that the sort order differs from the creation order.
+	for i := 0; i < len(sortedPools)/2; i += 2 {
+		sortedPools[i], sortedPools[len(sortedPools)-1-i] = sortedPools[len(sortedPools)-1-i], sortedPools[i]
+	}
+
+	poolGraph := routerusecase.NewPoolGraph(sortedPools)
+
+	for _, tokenInDenom := range denoms {
+		for _, tokenOutDenom := range denoms {
+			if tokenInDenom == tokenOutDenom {
+				continue
+			}
+
+			tokenIn := sdk.NewCoin(tokenInDenom, osmomath.NewInt(3_000))
+
+			expectedRoutes, err := getCandidateRoutesLinearScan(sortedPools, tokenIn, tokenOutDenom, maxRoutes, maxPoolsPerRoute, noOpLogger)
+			s.Require().NoError(err)
+
+			actualRoutes, err := routerusecase.GetCandidateRoutes(poolGraph, tokenIn, tokenOutDenom, maxRoutes, maxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
+			s.Require().NoError(err)
+
+			s.Require().NotEmpty(actualRoutes.Routes)
+			s.Require().Equal(expectedRoutes, actualRoutes, "tokenIn: %s, tokenOut: %s", tokenInDenom, tokenOutDenom)
+		}
+	}
+}
+
+// Validates that the candidate route search respects the pool and denom filters.
+func (s *RouterTestSuite) TestGetCandidateRoutes_Filters() {
+	s.Setup()
+
+	var (
+		maxRoutes        = 10
+		maxPoolsPerRoute = 3
+		liquidityAmount  = osmomath.NewInt(1_000_000_000)
+	)
+
+	poolOneTwo := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount))
+	poolOneTwoSecond := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount))
+	poolOneThree := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount))
+	poolThreeTwo := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomThree, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount))
+
+	poolGraph := routerusecase.NewPoolGraph([]sqsdomain.PoolI{poolOneTwo, poolOneTwoSecond, poolOneThree, poolThreeTwo})
+
+	var (
+		directRoute       = []uint64{poolOneTwo.GetId()}
+		directRouteSecond = []uint64{poolOneTwoSecond.GetId()}
+		twoHopRoute       = []uint64{poolOneThree.GetId(), poolThreeTwo.GetId()}
+	)
+
+	tests := map[string]struct {
+		opts             []domain.RouterOption
+		minOSMOLiquidity int
+
+		expectedRoutes [][]uint64
+	}{
+		"no filters": {
+			expectedRoutes: [][]uint64{directRoute, directRouteSecond, twoHopRoute},
+		},
+		"excluded pool ID": {
+			opts: []domain.RouterOption{domain.WithExcludedPoolIDs(poolOneTwo.GetId())},
+
+			expectedRoutes: [][]uint64{directRouteSecond, twoHopRoute},
+		},
+		"allowed pool IDs": {
+			opts: []domain.RouterOption{domain.WithAllowedPoolIDs(poolOneThree.GetId(), poolThreeTwo.GetId())},
+
+			expectedRoutes: [][]uint64{twoHopRoute},
+		},
+		"allowed and excluded pool IDs": {
+			opts: []domain.RouterOption{domain.WithAllowedPoolIDs(poolOneTwo.GetId(), poolOneThree.GetId(), poolThreeTwo.GetId()), domain.WithExcludedPoolIDs(poolThreeTwo.GetId())},
+
+			expectedRoutes: [][]uint64{directRoute},
+		},
+		"allowed pool types - balancer": {
+			opts: []domain.RouterOption{domain.WithAllowedPoolTypes(poolmanagertypes.Balancer)},
+
+			expectedRoutes: [][]uint64{directRoute, directRouteSecond, twoHopRoute},
+		},
+		"allowed pool types - concentrated": {
+			opts: []domain.RouterOption{domain.WithAllowedPoolTypes(poolmanagertypes.Concentrated)},
+
+			expectedRoutes: [][]uint64{},
+		},
+		"excluded intermediary denom": {
+			opts: []domain.RouterOption{domain.WithExcludedDenoms(DenomThree)},
+
+			expectedRoutes: [][]uint64{directRoute, directRouteSecond},
+		},
+		"excluded token out denom is ignored": {
+			opts: []domain.RouterOption{domain.WithExcludedDenoms(DenomTwo)},
+
+			expectedRoutes: [][]uint64{directRoute, directRouteSecond, twoHopRoute},
+		},
+		"pools below min liquidity": {
+			minOSMOLiquidity: 1,
+
+			expectedRoutes: [][]uint64{},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		s.Run(name, func() {
+			options := domain.RouterOptions{}
+			for _, opt := range tc.opts {
+				opt(&options)
+			}
+
+			candidateRoutes, err := routerusecase.GetCandidateRoutes(poolGraph, sdk.NewCoin(DenomOne, one), DenomTwo, maxRoutes, maxPoolsPerRoute, tc.minOSMOLiquidity, options.CandidateRouteFilters, noOpLogger)
+			s.Require().NoError(err)
+
+			actualRoutes := make([][]uint64, 0, len(candidateRoutes.Routes))
+			for _, route := range candidateRoutes.Routes {
+				routePoolIDs := make([]uint64, 0, len(route.Pools))
+				for _, pool := range route.Pools {
+					routePoolIDs = append(routePoolIDs, pool.ID)
+				}
+				actualRoutes = append(actualRoutes, routePoolIDs)
+			}
+
+			s.Require().ElementsMatch(tc.expectedRoutes, actualRoutes)
+		})
+	}
+}
+
+// Validates that the routes through the preferred intermediary denoms are searched first
+// and kept over the other routes when the max routes are limited.
+func (s *RouterTestSuite) TestGetCandidateRoutes_PreferredDenoms() {
+	s.Setup()
+
+	var (
+		maxPoolsPerRoute = 3
+		liquidityAmount  = osmomath.NewInt(1_000_000_000)
+	)
+
+	poolOneTwo := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount))
+	poolOneThree := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount))
+	poolThreeTwo := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomThree, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount))
+	poolOneFour := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomFour, liquidityAmount))
+	poolFourTwo := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomFour, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount))
+
+	poolGraph := routerusecase.NewPoolGraph([]sqsdomain.PoolI{poolOneTwo, poolOneThree, poolThreeTwo, poolOneFour, poolFourTwo})
+
+	var (
+		directRoute   = []uint64{poolOneTwo.GetId()}
+		viaThreeRoute = []uint64{poolOneThree.GetId(), poolThreeTwo.GetId()}
+		viaFourRoute  = []uint64{poolOneFour.GetId(), poolFourTwo.GetId()}
+	)
+
+	tests := map[string]struct {
+		opts      []domain.RouterOption
+		maxRoutes int
+
+		expectedRoutes [][]uint64
+	}{
+		"no preferred denoms": {
+			maxRoutes: 2,
+
+			expectedRoutes: [][]uint64{directRoute, viaThreeRoute},
+		},
+		"preferred denom routes are kept over the others": {
+			opts:      []domain.RouterOption{domain.WithPreferredDenoms(DenomFour)},
+			maxRoutes: 2,
+
+			expectedRoutes: [][]uint64{directRoute, viaFourRoute},
+		},
+		"remaining routes are filled from the other denoms": {
+			opts:      []domain.RouterOption{domain.WithPreferredDenoms(DenomFour)},
+			maxRoutes: 3,
+
+			expectedRoutes: [][]uint64{directRoute, viaFourRoute, viaThreeRoute},
+		},
+		"preferred and excluded denoms": {
+			opts:      []domain.RouterOption{domain.WithPreferredDenoms(DenomFour), domain.WithExcludedDenoms(DenomThree)},
+			maxRoutes: 3,
+
+			expectedRoutes: [][]uint64{directRoute, viaFourRoute},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		s.Run(name, func() {
+			options := domain.RouterOptions{}
+			for _, opt := range tc.opts {
+				opt(&options)
+			}
+
+			candidateRoutes, err := routerusecase.GetCandidateRoutes(poolGraph, sdk.NewCoin(DenomOne, one), DenomTwo, tc.maxRoutes, maxPoolsPerRoute, 0, options.CandidateRouteFilters, noOpLogger)
+			s.Require().NoError(err)
+
+			actualRoutes := make([][]uint64, 0, len(candidateRoutes.Routes))
+			for _, route := range candidateRoutes.Routes {
+				routePoolIDs := make([]uint64, 0, len(route.Pools))
+				for _, pool := range route.Pools {
+					routePoolIDs = append(routePoolIDs, pool.ID)
+				}
+				actualRoutes = append(actualRoutes, routePoolIDs)
+			}
+
+			s.Require().Equal(tc.expectedRoutes, actualRoutes)
+		})
+	}
+}
+
+// prepareBalancerPoolWrapper creates a balancer pool with the given coins
+// and wraps it into a pool with the sqs model populated.
+func (s *RouterTestSuite) prepareBalancerPoolWrapper(coins ...sdk.Coin) *sqsdomain.PoolWrapper {
+	poolID := s.PrepareBalancerPoolWithCoins(coins...)
+
+	pool, err := s.App.PoolManagerKeeper.GetPool(s.Ctx, poolID)
+	s.Require().NoError(err)
+
+	balances := s.App.BankKeeper.GetAllBalances(s.Ctx, pool.GetAddress())
+
+	return &sqsdomain.PoolWrapper{
+		ChainModel: pool,
+		SQSModel: sqsdomain.SQSPool{
+			SpreadFactor:         pool.GetSpreadFactor(sdk.Context{}),
+			Balances:             balances,
+			PoolDenoms:           balances.Denoms(),
+			TotalValueLockedUSDC: osmomath.ZeroInt(),
+		},
+	}
+}
+
 func (s *RouterTestSuite) validateExpectedPoolIDOneHopRoute(route sqsdomain.CandidateRoute, expectedPoolID uint64) {
 	routePools := route.Pools
 	s.Require().Equal(1, len(routePools))
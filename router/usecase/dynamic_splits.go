@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	"github.com/osmosis-labs/osmosis/osmomath"
 	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/log"
 	"github.com/osmosis-labs/sqs/router/usecase/route"
 )
 
@@ -24,15 +26,27 @@ const totalIncrements = uint8(10)
 // The algorithm is based on the knapsack problem.
 // The time complexity is O(n * m), where n is the number of routes and m is the totalIncrements.
 // The space complexity is O(n * m).
-func getSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Coin) (domain.Quote, error) {
+// The outcome of the split is recorded in the quote debug info if it is attached to the context.
+// If gasCosts are given, the i-th of them is the cost of using the i-th route denominated in the token out.
+// In that case, the split maximizes the amount out net of the gas costs so that a route is only added
+// to the split if it returns more than it costs to execute.
+// If the routes share pools, they are simulated jointly. See getSharedPoolSplitQuote for details.
+// If maxRefinementIterations is positive, the split found over the increments is refined further
+// with finer steps. See refineSplitQuote for details.
+func getSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Coin, gasCosts []osmomath.Int, maxRefinementIterations int, logger log.Logger) (_ domain.Quote, err error) {
 	// Routes must be non-empty
 	if len(routes) == 0 {
 		return nil, errors.New("no routes")
 	}
+	// The routes sharing pools are simulated jointly.
+	if hasSharedPools(routes) {
+		return getSharedPoolSplitQuote(ctx, routes, tokenIn, gasCosts, maxRefinementIterations, logger)
+	}
+
 	// If only one route, return the best single route quote
 	if len(routes) == 1 {
 		route := routes[0]
-		coinOut, err := route.CalculateTokenOutByTokenIn(ctx, tokenIn)
+		coinOut, ticksCrossed, err := route.CalculateTokenOutByTokenInWithTicksCrossed(ctx, tokenIn)
 		if err != nil {
 			return nil, err
 		}
@@ -41,9 +55,10 @@ func getSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Co
 			AmountIn:  tokenIn,
 			AmountOut: coinOut.Amount,
 			Route: []domain.SplitRoute{&RouteWithOutAmount{
-				RouteImpl: route,
-				OutAmount: coinOut.Amount,
-				InAmount:  tokenIn.Amount,
+				RouteImpl:    route,
+				OutAmount:    coinOut.Amount,
+				InAmount:     tokenIn.Amount,
+				TicksCrossed: ticksCrossed,
 			}},
 		}
 
@@ -71,43 +86,11 @@ func getSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Co
 		dp[0][j] = zero
 	}
 
-	inAmountDec := tokenIn.Amount.ToLegacyDec()
-
-	// This function computes the inAmountIncrement for a given proportion p.
-	// It caches the result on the stack to avoid recomputing it.
-	computeAndCacheInAmountIncrement := func(p uint8) osmomath.Int {
-		inAmountIncrement := osmomath.Int{}
-		return func() osmomath.Int {
-			// If the inAmountIncrement has already been computed, return the cached value.
-			// Otherwise, compute the value and cache it.
-			if inAmountIncrement.IsNil() {
-				inAmountIncrement = sdk.NewDec(int64(p)).QuoInt64Mut(int64(totalIncrements)).MulMut(inAmountDec).TruncateInt()
-			}
-			return inAmountIncrement
-		}()
-	}
-
-	// This function computes the outAmountIncrement for a given routeIndex and inAmountIncrement.
-	// It caches the result on the stack to avoid recomputing it.
-	computeAndCacheOutAmount := func(routeIndex int, inAmountIncrement osmomath.Int) osmomath.Int {
-		routeJOutAmountIncrement := osmomath.Int{}
-
-		return func() osmomath.Int {
-			// If the route has already been computed, return the cached value.
-			// Otherwise, compute the value and cache it.
-			if routeJOutAmountIncrement.IsNil() {
-				// This is the expensive computation that we aim to avoid.
-				curRouteOutAmountIncrement, _ := routes[routeIndex].CalculateTokenOutByTokenIn(ctx, sdk.NewCoin(tokenIn.Denom, inAmountIncrement))
-
-				if curRouteOutAmountIncrement.IsNil() || curRouteOutAmountIncrement.IsZero() {
-					curRouteOutAmountIncrement.Amount = zero
-				}
-
-				routeJOutAmountIncrement = curRouteOutAmountIncrement.Amount
-			}
-
-			return routeJOutAmountIncrement
-		}()
+	// outAmounts[j][p] is the token out amount of the j-th route for p increments of the token in
+	// and ticksCrossed[j][p] is the number of initialized ticks crossed by the same swap.
+	outAmounts, ticksCrossed, err := computeSplitOutAmounts(ctx, routes, tokenIn)
+	if err != nil {
+		return nil, err
 	}
 
 	// Step 2: fill the tables
@@ -124,7 +107,10 @@ func getSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Co
 				// The recurrence relation would be:
 				// dp[x][j] = max(dp[x][j−1], dp[x−p][j−1] + output from j - th route with proportion p)
 				noChoice := dp[x][j]
-				choice := dp[x-p][j-1].Add(computeAndCacheOutAmount(j-1, computeAndCacheInAmountIncrement(p)))
+				choice := dp[x-p][j-1].Add(outAmounts[j-1][p])
+				if p > 0 && gasCosts != nil {
+					choice = choice.Sub(gasCosts[j-1])
+				}
 
 				if choice.GT(noChoice) {
 					dp[x][j] = choice
@@ -150,6 +136,23 @@ func getSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Co
 		amountOut:       dp[totalIncrements][len(routes)],
 	}
 
+	// Add back the gas costs of the routes used so that the amount out is gross.
+	if gasCosts != nil {
+		for i, routeIncrement := range optimalProportions {
+			if routeIncrement > 0 {
+				bestSplit.amountOut = bestSplit.amountOut.Add(gasCosts[i])
+			}
+		}
+	}
+
+	// Set if the split is refined so that both the split over the increments and the refined one are recorded.
+	var refinement *domain.SplitRefinementDebugInfo
+	if debugInfo := domain.GetQuoteDebugInfoFromContext(ctx); debugInfo != nil {
+		defer func() {
+			debugInfo.SetSplit(newSplitDebugInfo(routes, bestSplit, refinement, err))
+		}()
+	}
+
 	tokenAmountDec := tokenIn.Amount.ToLegacyDec()
 
 	if bestSplit.amountOut.IsZero() {
@@ -163,7 +166,7 @@ func getSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Co
 	for i, currentRouteIncrement := range bestSplit.routeIncrements {
 		currentRoute := routes[i]
 
-		currentRouteAmtOut := computeAndCacheOutAmount(i, computeAndCacheInAmountIncrement(currentRouteIncrement))
+		currentRouteAmtOut := outAmounts[i][currentRouteIncrement]
 
 		currentRouteSplit := sdk.NewDec(int64(currentRouteIncrement)).QuoInt64Mut(int64(totalIncrements))
 
@@ -185,9 +188,10 @@ func getSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Co
 		}
 
 		resultRoutes = append(resultRoutes, &RouteWithOutAmount{
-			RouteImpl: currentRoute,
-			InAmount:  inAmount,
-			OutAmount: currentRouteAmtOut,
+			RouteImpl:    currentRoute,
+			InAmount:     inAmount,
+			OutAmount:    currentRouteAmtOut,
+			TicksCrossed: ticksCrossed[i][currentRouteIncrement],
 		})
 
 		totalIncrementsInSplits += currentRouteIncrement
@@ -210,5 +214,305 @@ func getSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Co
 		Route:     resultRoutes,
 	}
 
+	if maxRefinementIterations > 0 && !containsGeneralizedCosmWasmPoolRoute(routes) {
+		refinedQuote, refinementDebugInfo := refineSplitQuote(ctx, routes, tokenIn, bestSplit.routeIncrements, gasCosts, maxRefinementIterations, quote, newIndependentSplitEstimator(routes))
+		refinement = &refinementDebugInfo
+		return refinedQuote, nil
+	}
+
 	return quote, nil
 }
+
+// computeSplitOutAmounts returns the token out amount and the number of initialized ticks crossed of every route
+// for every increment of the token in indexed by the route and the number of increments.
+// Failed estimates are treated as zero out.
+// This is the expensive computation that the split aims to do only once per route and increment.
+// The increments of each route are estimated in a single batch. The routes that contain generalized
+// cosmwasm pools are estimated concurrently so that their chain queries take a single network round-trip
+// per hop rather than one per route and increment.
+// Returns the context error if the context is done before all routes are estimated.
+func computeSplitOutAmounts(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Coin) ([][]osmomath.Int, [][]uint64, error) {
+	inIncrements := getInIncrements(tokenIn)
+
+	outAmounts := make([][]osmomath.Int, len(routes))
+	ticksCrossed := make([][]uint64, len(routes))
+
+	computeRouteOutAmounts := func(routeIndex int) {
+		outIncrements, ticksCrossedIncrements, _ := routes[routeIndex].CalculateTokenOutByTokenInBatchWithTicksCrossed(ctx, inIncrements)
+		ticksCrossed[routeIndex] = ticksCrossedIncrements
+
+		outAmounts[routeIndex] = make([]osmomath.Int, len(outIncrements))
+		for p, outIncrement := range outIncrements {
+			if outIncrement.IsNil() || outIncrement.IsZero() {
+				outIncrement.Amount = zero
+			}
+
+			outAmounts[routeIndex][p] = outIncrement.Amount
+		}
+	}
+
+	var wg sync.WaitGroup
+	for j := range routes {
+		if err := ctx.Err(); err != nil {
+			wg.Wait()
+			return nil, nil, err
+		}
+
+		if !routes[j].ContainsGeneralizedCosmWasmPool() {
+			computeRouteOutAmounts(j)
+			continue
+		}
+
+		wg.Add(1)
+		go func(j int) {
+			defer wg.Done()
+			computeRouteOutAmounts(j)
+		}(j)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return outAmounts, ticksCrossed, nil
+}
+
+// getInIncrements returns the token in amount for every number of increments from zero to totalIncrements.
+func getInIncrements(tokenIn sdk.Coin) []sdk.Coin {
+	inAmountDec := tokenIn.Amount.ToLegacyDec()
+
+	inIncrements := make([]sdk.Coin, totalIncrements+1)
+	for p := uint8(0); p <= totalIncrements; p++ {
+		inIncrements[p] = sdk.NewCoin(tokenIn.Denom, sdk.NewDec(int64(p)).QuoInt64Mut(int64(totalIncrements)).MulMut(inAmountDec).TruncateInt())
+	}
+	return inIncrements
+}
+
+// getSplitQuoteInGivenOut returns the best quote for receiving exactly the given tokenOut
+// by splitting it among the routes.
+// Similarly to getSplitQuote, it uses dynamic programming to find the optimal split
+// of the tokenOut. However, the objective is to minimize the total amount in.
+// Increments that a route cannot provide, for example due to insufficient liquidity, are
+// treated as infeasible.
+// Since the increments of token out are truncated, the remainder is assigned to the route
+// with the largest increment so that the quote provides exactly the tokenOut amount.
+// The time complexity is O(n * m^2), where n is the number of routes and m is the totalIncrements.
+// The space complexity is O(n * m).
+func getSplitQuoteInGivenOut(ctx context.Context, routes []route.RouteImpl, tokenOut sdk.Coin) (domain.Quote, error) {
+	// Routes must be non-empty
+	if len(routes) == 0 {
+		return nil, errors.New("no routes")
+	}
+
+	tokenInDenom := routes[0].GetTokenInDenom()
+
+	// If only one route, return the single route quote
+	if len(routes) == 1 {
+		route := routes[0]
+		coinIn, ticksCrossed, err := route.CalculateTokenInByTokenOutWithTicksCrossed(ctx, tokenOut)
+		if err != nil {
+			return nil, err
+		}
+
+		quote := &quoteImpl{
+			AmountIn:  sdk.NewCoin(tokenInDenom, coinIn.Amount),
+			AmountOut: tokenOut.Amount,
+			Route: []domain.SplitRoute{&RouteWithOutAmount{
+				RouteImpl:    route,
+				OutAmount:    tokenOut.Amount,
+				InAmount:     coinIn.Amount,
+				TicksCrossed: ticksCrossed,
+			}},
+		}
+
+		return quote, nil
+	}
+
+	outAmountDec := tokenOut.Amount.ToLegacyDec()
+
+	// outAmountIncrements[p] is the token out amount for p increments.
+	outAmountIncrements := make([]osmomath.Int, totalIncrements+1)
+	for p := uint8(0); p <= totalIncrements; p++ {
+		outAmountIncrements[p] = sdk.NewDec(int64(p)).QuoInt64Mut(int64(totalIncrements)).MulMut(outAmountDec).TruncateInt()
+	}
+
+	// inAmounts[j][p] memoizes the token in amount required by the j-th route for p increments
+	// of the token out. Nil value signifies that the route cannot provide the amount out.
+	// ticksCrossed[j][p] memoizes the number of initialized ticks crossed by the same swap.
+	inAmounts := make([][]osmomath.Int, len(routes))
+	ticksCrossed := make([][]uint64, len(routes))
+	isInAmountComputed := make([][]bool, len(routes))
+	for j := range routes {
+		inAmounts[j] = make([]osmomath.Int, totalIncrements+1)
+		ticksCrossed[j] = make([]uint64, totalIncrements+1)
+		isInAmountComputed[j] = make([]bool, totalIncrements+1)
+	}
+
+	computeInAmount := func(routeIndex int, p uint8) osmomath.Int {
+		if isInAmountComputed[routeIndex][p] {
+			return inAmounts[routeIndex][p]
+		}
+		isInAmountComputed[routeIndex][p] = true
+
+		outAmountIncrement := outAmountIncrements[p]
+		if outAmountIncrement.IsZero() {
+			inAmounts[routeIndex][p] = zero
+			return zero
+		}
+
+		// This is the expensive computation that we aim to avoid.
+		tokenInIncrement, ticksCrossedIncrement, err := routes[routeIndex].CalculateTokenInByTokenOutWithTicksCrossed(ctx, sdk.NewCoin(tokenOut.Denom, outAmountIncrement))
+		if err == nil && !tokenInIncrement.Amount.IsNil() && tokenInIncrement.Amount.IsPositive() {
+			inAmounts[routeIndex][p] = tokenInIncrement.Amount
+			ticksCrossed[routeIndex][p] = ticksCrossedIncrement
+		}
+
+		return inAmounts[routeIndex][p]
+	}
+
+	// proportions[x][j] stores the proportion of tokens out provided by the j-th
+	// route that leads to the optimal value at each state.
+	proportions := make([][]uint8, totalIncrements+1)
+	// dp stores the minimum input values. Nil value signifies an infeasible state.
+	dp := make([][]osmomath.Int, totalIncrements+1)
+
+	// Step 1: initialize tables
+	for i := 0; i < int(totalIncrements+1); i++ {
+		dp[i] = make([]osmomath.Int, len(routes)+1)
+		proportions[i] = make([]uint8, len(routes)+1)
+	}
+
+	// Providing nothing requires nothing.
+	for j := 0; j <= len(routes); j++ {
+		dp[0][j] = zero
+	}
+
+	// Step 2: fill the tables
+	for x := uint8(1); x <= totalIncrements; x++ {
+		for j := 1; j <= len(routes); j++ {
+			dp[x][j] = dp[x][j-1] // Not using the j-th route
+			proportions[x][j] = 0 // Default increment (0% of the token)
+
+			for p := uint8(1); p <= x; p++ {
+				// The recurrence relation is:
+				// dp[x][j] = min(dp[x][j−1], dp[x−p][j−1] + input to j-th route with proportion p)
+				previous := dp[x-p][j-1]
+				if previous.IsNil() {
+					continue
+				}
+
+				routeInAmount := computeInAmount(j-1, p)
+				if routeInAmount.IsNil() {
+					continue
+				}
+
+				choice := previous.Add(routeInAmount)
+				if dp[x][j].IsNil() || choice.LT(dp[x][j]) {
+					dp[x][j] = choice
+					proportions[x][j] = p
+				}
+			}
+		}
+	}
+
+	if dp[totalIncrements][len(routes)].IsNil() {
+		return nil, fmt.Errorf("no split can provide token out (%s)", tokenOut)
+	}
+
+	// Step 3: trace back to find the optimal proportions
+	x, j := totalIncrements, len(routes)
+	optimalProportions := make([]uint8, len(routes)+1)
+	for j > 0 {
+		optimalProportions[j] = proportions[x][j]
+		x -= proportions[x][j]
+		j -= 1
+	}
+
+	optimalProportions = optimalProportions[1:]
+
+	// Step 4: assign the truncation remainder to the route with the largest increment
+	// and compute the final amounts.
+	largestIncrementRouteIndex := 0
+	totalOutFromIncrements := osmomath.ZeroInt()
+	for i, currentRouteIncrement := range optimalProportions {
+		if currentRouteIncrement > optimalProportions[largestIncrementRouteIndex] {
+			largestIncrementRouteIndex = i
+		}
+		totalOutFromIncrements = totalOutFromIncrements.Add(outAmountIncrements[currentRouteIncrement])
+	}
+	outRemainder := tokenOut.Amount.Sub(totalOutFromIncrements)
+
+	resultRoutes := make([]domain.SplitRoute, 0, len(routes))
+	totalAmountIn := osmomath.ZeroInt()
+	for i, currentRouteIncrement := range optimalProportions {
+		if currentRouteIncrement == 0 {
+			continue
+		}
+
+		outAmount := outAmountIncrements[currentRouteIncrement]
+		inAmount := computeInAmount(i, currentRouteIncrement)
+		routeTicksCrossed := ticksCrossed[i][currentRouteIncrement]
+
+		if i == largestIncrementRouteIndex && outRemainder.IsPositive() {
+			outAmount = outAmount.Add(outRemainder)
+
+			tokenIn, tokenInTicksCrossed, err := routes[i].CalculateTokenInByTokenOutWithTicksCrossed(ctx, sdk.NewCoin(tokenOut.Denom, outAmount))
+			if err != nil {
+				return nil, err
+			}
+			inAmount = tokenIn.Amount
+			routeTicksCrossed = tokenInTicksCrossed
+		}
+
+		// May happen if the token out amount is too small to be split.
+		if outAmount.IsZero() {
+			continue
+		}
+
+		if inAmount.IsNil() || !inAmount.IsPositive() {
+			return nil, fmt.Errorf("in amount is zero when out is not (%s), route index (%d)", outAmount, i)
+		}
+
+		resultRoutes = append(resultRoutes, &RouteWithOutAmount{
+			RouteImpl:    routes[i],
+			InAmount:     inAmount,
+			OutAmount:    outAmount,
+			TicksCrossed: routeTicksCrossed,
+		})
+
+		totalAmountIn = totalAmountIn.Add(inAmount)
+	}
+
+	quote := &quoteImpl{
+		AmountIn:  sdk.NewCoin(tokenInDenom, totalAmountIn),
+		AmountOut: tokenOut.Amount,
+		Route:     resultRoutes,
+	}
+
+	return quote, nil
+}
+
+// newSplitDebugInfo returns the debug info for the given split over the given routes,
+// its refinement if any and the error of the split validation if any.
+func newSplitDebugInfo(routes []route.RouteImpl, bestSplit split, refinement *domain.SplitRefinementDebugInfo, err error) domain.SplitDebugInfo {
+	routeIncrements := make([]int, 0, len(bestSplit.routeIncrements))
+	for _, increment := range bestSplit.routeIncrements {
+		routeIncrements = append(routeIncrements, int(increment))
+	}
+
+	splitDebugInfo := domain.SplitDebugInfo{
+		Routes:          convertRankedToCandidateRoutes(routes).Routes,
+		RouteIncrements: routeIncrements,
+		TotalIncrements: int(totalIncrements),
+		AmountOut:       bestSplit.amountOut,
+		Refinement:      refinement,
+	}
+
+	if err != nil {
+		splitDebugInfo.AmountOut = osmomath.ZeroInt()
+		splitDebugInfo.Error = err.Error()
+	}
+
+	return splitDebugInfo
+}
@@ -25,11 +25,11 @@ const (
 )
 
 func ValidateAndFilterRoutes(candidateRoutes [][]candidatePoolWrapper, tokenInDenom string, logger log.Logger) (sqsdomain.CandidateRoutes, error) {
-	return validateAndFilterRoutes(candidateRoutes, tokenInDenom, logger)
+	return validateAndFilterRoutes(candidateRoutes, tokenInDenom, nil, logger)
 }
 
 func (r *routerUseCaseImpl) HandleRoutes(ctx context.Context, pools []sqsdomain.PoolI, tokenIn sdk.Coin, tokenOutDenom string, maxRoutes, maxPoolsPerRoute int) (candidateRoutes sqsdomain.CandidateRoutes, err error) {
-	return r.handleCandidateRoutes(ctx, pools, tokenIn, tokenOutDenom, maxRoutes, maxPoolsPerRoute)
+	return r.handleCandidateRoutes(ctx, NewPoolGraph(pools), tokenIn, tokenOutDenom, maxRoutes, maxPoolsPerRoute, 0, domain.CandidateRouteFilters{})
 }
 
 func EstimateAndRankSingleRouteQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Coin, logger log.Logger) (domain.Quote, []RouteWithOutAmount, error) {
@@ -45,7 +45,7 @@ func ConvertRankedToCandidateRoutes(rankedRoutes []route.RouteImpl) sqsdomain.Ca
 }
 
 func FormatRankedRouteCacheKey(tokenInDenom string, tokenOutDenom string, tokenIOrderOfMagnitude int) string {
-	return formatRankedRouteCacheKey(tokenInDenom, tokenOutDenom, tokenIOrderOfMagnitude)
+	return formatRankedRouteCacheKey(tokenInDenom, tokenOutDenom, tokenIOrderOfMagnitude, domain.CandidateRouteFilters{})
 }
 
 func FormatRouteCacheKey(tokenInDenom string, tokenOutDenom string) string {
@@ -53,7 +53,11 @@ func FormatRouteCacheKey(tokenInDenom string, tokenOutDenom string) string {
 }
 
 func FormatCandidateRouteCacheKey(tokenInDenom string, tokenOutDenom string) string {
-	return formatCandidateRouteCacheKey(tokenInDenom, tokenOutDenom)
+	return formatCandidateRouteCacheKey(tokenInDenom, tokenOutDenom, domain.CandidateRouteFilters{})
+}
+
+func FormatRouteFiltersCacheKey(filters domain.CandidateRouteFilters) string {
+	return formatRouteFiltersCacheKey(filters)
 }
 
 func SortPools(pools []sqsdomain.PoolI, transmuterCodeIDs map[uint64]struct{}, totalTVL osmomath.Int, preferredPoolIDsMap map[uint64]struct{}, logger log.Logger) []sqsdomain.PoolI {
@@ -61,5 +65,35 @@ func SortPools(pools []sqsdomain.PoolI, transmuterCodeIDs map[uint64]struct{}, t
 }
 
 func GetSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Coin) (domain.Quote, error) {
-	return getSplitQuote(ctx, routes, tokenIn)
+	return getSplitQuote(ctx, routes, tokenIn, nil, 0, &log.NoOpLogger{})
+}
+
+func GetSplitQuoteInGivenOut(ctx context.Context, routes []route.RouteImpl, tokenOut sdk.Coin) (domain.Quote, error) {
+	return getSplitQuoteInGivenOut(ctx, routes, tokenOut)
+}
+
+func EstimateAndRankSingleRouteQuoteInGivenOut(ctx context.Context, routes []route.RouteImpl, tokenOut sdk.Coin, logger log.Logger) (domain.Quote, []RouteWithOutAmount, error) {
+	return estimateAndRankSingleRouteQuoteInGivenOut(ctx, routes, tokenOut, logger)
+}
+
+func (s *SortedPools) GetRatingTotalTVL() osmomath.Int {
+	return s.ratingTotalTVL
+}
+
+func (r *routerUseCaseImpl) DetectArbitrage(ctx context.Context, sortedPools []sqsdomain.PoolI, height uint64) domain.ArbitrageResult {
+	return r.detectArbitrage(ctx, sortedPools, height)
+}
+
+func (r *routerUseCaseImpl) FormatQuoteRequestKey(request domain.QuoteRequest) string {
+	return r.formatQuoteRequestKey(request)
+}
+
+// PushQuoteUpdates pushes the quote updates of all subscriptions at the given height synchronously.
+func (r *routerUseCaseImpl) PushQuoteUpdates(height uint64) {
+	r.pushQuoteUpdates(context.Background(), height, r.getQuoteSubscriptions())
+}
+
+// IsWarmingRoutes returns true while the routes are being warmed.
+func (r *routerUseCaseImpl) IsWarmingRoutes() bool {
+	return r.isWarmingRoutes.Load()
 }
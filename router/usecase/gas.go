@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/router/usecase/route"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// setEstimatedGas estimates the gas of every route of the given quote and of the quote in total.
+// The initialized ticks crossed are taken from the simulation that computed the amounts of the routes
+// so that the routes are not simulated again.
+// No-op if the quote is nil or if the context is done, for example once the max latency deadline is exceeded.
+// In that case, the estimated gas is left unset.
+func (r *routerUseCaseImpl) setEstimatedGas(ctx context.Context, quote domain.Quote) {
+	q, ok := quote.(*quoteImpl)
+	if !ok || q == nil || ctx.Err() != nil {
+		return
+	}
+
+	q.EstimatedGas = 0
+	for _, splitRoute := range q.Route {
+		routeWithOutAmount, ok := splitRoute.(*RouteWithOutAmount)
+		if !ok {
+			continue
+		}
+
+		routeWithOutAmount.EstimatedGas = estimateRouteGas(r.defaultConfig.Gas, routeWithOutAmount.GetPools(), routeWithOutAmount.TicksCrossed)
+		q.EstimatedGas += routeWithOutAmount.EstimatedGas
+	}
+}
+
+// estimateRouteGas returns the estimated gas of swapping over the given pools
+// while crossing the given number of initialized ticks.
+func estimateRouteGas(gasConfig domain.GasConfig, pools []sqsdomain.RoutablePool, ticksCrossed uint64) uint64 {
+	return estimateRouteBaseGas(gasConfig, pools) + ticksCrossed*gasConfig.ConcentratedPerTickCrossed
+}
+
+// estimateRouteBaseGas returns the estimated gas of swapping over the given pools
+// excluding the initialized ticks crossed.
+func estimateRouteBaseGas(gasConfig domain.GasConfig, pools []sqsdomain.RoutablePool) uint64 {
+	gas := uint64(0)
+	for _, pool := range pools {
+		gas += gasConfig.PerHop
+
+		switch {
+		case pool.IsGeneralizedCosmWasmPool():
+			gas += gasConfig.GeneralizedCosmWasm
+		case pool.GetType() == poolmanagertypes.Balancer:
+			gas += gasConfig.Balancer
+		case pool.GetType() == poolmanagertypes.Stableswap:
+			gas += gasConfig.Stableswap
+		case pool.GetType() == poolmanagertypes.Concentrated:
+			gas += gasConfig.Concentrated
+		case pool.GetType() == poolmanagertypes.CosmWasm:
+			gas += gasConfig.Transmuter
+		}
+	}
+	return gas
+}
+
+// getSplitGasCosts returns the estimated gas cost of using each of the given routes in a split
+// denominated in the token out. The initialized ticks crossed are not accounted for since
+// they depend on the amount swapped over the route.
+// Returns nil if the gas price is not set.
+func getSplitGasCosts(gasConfig domain.GasConfig, routes []route.RouteImpl, gasPriceInTokenOut osmomath.Dec) []osmomath.Int {
+	if gasPriceInTokenOut.IsNil() {
+		return nil
+	}
+
+	gasCosts := make([]osmomath.Int, 0, len(routes))
+	for _, route := range routes {
+		gasCosts = append(gasCosts, getGasCost(estimateRouteBaseGas(gasConfig, route.GetPools()), gasPriceInTokenOut))
+	}
+	return gasCosts
+}
+
+// getGasCost returns the cost of the given gas denominated in the token out rounded up.
+func getGasCost(gas uint64, gasPriceInTokenOut osmomath.Dec) osmomath.Int {
+	return gasPriceInTokenOut.MulInt(osmomath.NewIntFromUint64(gas)).Ceil().TruncateInt()
+}
+
+// getNetAmountOut returns the amount out of the quote net of its estimated gas cost.
+// Returns the gross amount out if the gas price is not set.
+// CONTRACT: the estimated gas of the quote is set.
+func getNetAmountOut(quote domain.Quote, gasPriceInTokenOut osmomath.Dec) osmomath.Int {
+	if gasPriceInTokenOut.IsNil() {
+		return quote.GetAmountOut()
+	}
+	return quote.GetAmountOut().Sub(getGasCost(quote.GetEstimatedGas(), gasPriceInTokenOut))
+}
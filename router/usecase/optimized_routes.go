@@ -40,11 +40,21 @@ func estimateAndRankSingleRouteQuote(ctx context.Context, routes []route.RouteIm
 
 	errors := []error{}
 
+	debugInfo := domain.GetQuoteDebugInfoFromContext(ctx)
+	// Routes that failed to estimate at the same indexes as the errors.
+	failedRoutes := []route.RouteImpl{}
+
 	for _, route := range routes {
-		directRouteTokenOut, err := route.CalculateTokenOutByTokenIn(ctx, tokenIn)
+		// Once the context is done, the routes estimated so far are ranked.
+		if len(routesWithAmountOut) > 0 && ctx.Err() != nil {
+			break
+		}
+
+		directRouteTokenOut, ticksCrossed, err := route.CalculateTokenOutByTokenInWithTicksCrossed(ctx, tokenIn)
 		if err != nil {
 			logger.Debug("skipping single route due to error in estimate", zap.Error(err))
 			errors = append(errors, err)
+			failedRoutes = append(failedRoutes, route)
 			continue
 		}
 
@@ -53,22 +63,27 @@ func estimateAndRankSingleRouteQuote(ctx context.Context, routes []route.RouteIm
 		}
 
 		routesWithAmountOut = append(routesWithAmountOut, RouteWithOutAmount{
-			RouteImpl: route,
-			InAmount:  tokenIn.Amount,
-			OutAmount: directRouteTokenOut.Amount,
+			RouteImpl:    route,
+			InAmount:     tokenIn.Amount,
+			OutAmount:    directRouteTokenOut.Amount,
+			TicksCrossed: ticksCrossed,
 		})
 	}
 
-	// If we skipped all routes due to errors, return the first error
-	if len(routesWithAmountOut) == 0 && len(errors) > 0 {
-		return nil, nil, errors[0]
-	}
-
 	// Sort by amount out in descending order
 	sort.Slice(routesWithAmountOut, func(i, j int) bool {
 		return routesWithAmountOut[i].OutAmount.GT(routesWithAmountOut[j].OutAmount)
 	})
 
+	if debugInfo != nil {
+		debugInfo.SetRankedRoutes(newRankedRoutesDebugInfo(routesWithAmountOut, failedRoutes, errors))
+	}
+
+	// If we skipped all routes due to errors, return the first error
+	if len(routesWithAmountOut) == 0 && len(errors) > 0 {
+		return nil, nil, errors[0]
+	}
+
 	bestRoute := routesWithAmountOut[0]
 
 	finalQuote := &quoteImpl{
@@ -80,6 +95,92 @@ func estimateAndRankSingleRouteQuote(ctx context.Context, routes []route.RouteIm
 	return finalQuote, routesWithAmountOut, nil
 }
 
+// newRankedRoutesDebugInfo returns the debug info for the routes ranked by amount out
+// followed by the routes that failed to estimate with their errors.
+func newRankedRoutesDebugInfo(routesWithAmountOut []RouteWithOutAmount, failedRoutes []route.RouteImpl, errors []error) []domain.RankedRouteDebugInfo {
+	rankedRoutes := make([]route.RouteImpl, 0, len(routesWithAmountOut))
+	for _, routeWithAmountOut := range routesWithAmountOut {
+		rankedRoutes = append(rankedRoutes, routeWithAmountOut.RouteImpl)
+	}
+
+	rankedRoutesDebugInfo := make([]domain.RankedRouteDebugInfo, 0, len(routesWithAmountOut)+len(failedRoutes))
+	for i, candidateRoute := range convertRankedToCandidateRoutes(rankedRoutes).Routes {
+		rankedRoutesDebugInfo = append(rankedRoutesDebugInfo, domain.RankedRouteDebugInfo{
+			Route:     candidateRoute,
+			AmountOut: routesWithAmountOut[i].OutAmount,
+		})
+	}
+
+	for i, candidateRoute := range convertRankedToCandidateRoutes(failedRoutes).Routes {
+		rankedRoutesDebugInfo = append(rankedRoutesDebugInfo, domain.RankedRouteDebugInfo{
+			Route:     candidateRoute,
+			AmountOut: osmomath.ZeroInt(),
+			Error:     errors[i].Error(),
+		})
+	}
+
+	return rankedRoutesDebugInfo
+}
+
+// estimateAndRankSingleRouteQuoteInGivenOut returns the best quote for receiving the given token out
+// as well as all routes that are able to provide it sorted by amount in in increasing order.
+// Routes that fail to estimate the amount in, for example due to insufficient liquidity, are skipped.
+// Returns error if no route is able to provide the token out.
+func estimateAndRankSingleRouteQuoteInGivenOut(ctx context.Context, routes []route.RouteImpl, tokenOut sdk.Coin, logger log.Logger) (quote domain.Quote, sortedRoutesByAmtIn []RouteWithOutAmount, err error) {
+	if len(routes) == 0 {
+		return nil, nil, fmt.Errorf("no routes were provided for token out (%s)", tokenOut.Denom)
+	}
+
+	routesWithAmountIn := make([]RouteWithOutAmount, 0, len(routes))
+
+	errors := []error{}
+
+	for _, route := range routes {
+		directRouteTokenIn, ticksCrossed, err := route.CalculateTokenInByTokenOutWithTicksCrossed(ctx, tokenOut)
+		if err != nil {
+			logger.Debug("skipping single route due to error in estimate", zap.Error(err))
+			errors = append(errors, err)
+			continue
+		}
+
+		// A route that requires no token in for a non-zero token out is invalid.
+		if directRouteTokenIn.Amount.IsNil() || !directRouteTokenIn.Amount.IsPositive() {
+			continue
+		}
+
+		routesWithAmountIn = append(routesWithAmountIn, RouteWithOutAmount{
+			RouteImpl:    route,
+			InAmount:     directRouteTokenIn.Amount,
+			OutAmount:    tokenOut.Amount,
+			TicksCrossed: ticksCrossed,
+		})
+	}
+
+	if len(routesWithAmountIn) == 0 {
+		// If we skipped all routes due to errors, return the first error
+		if len(errors) > 0 {
+			return nil, nil, errors[0]
+		}
+
+		return nil, nil, fmt.Errorf("no route can provide token out (%s)", tokenOut)
+	}
+
+	// Sort by amount in in ascending order
+	sort.Slice(routesWithAmountIn, func(i, j int) bool {
+		return routesWithAmountIn[i].InAmount.LT(routesWithAmountIn[j].InAmount)
+	})
+
+	bestRoute := routesWithAmountIn[0]
+
+	finalQuote := &quoteImpl{
+		AmountIn:  sdk.NewCoin(bestRoute.GetTokenInDenom(), bestRoute.InAmount),
+		AmountOut: tokenOut.Amount,
+		Route:     []domain.SplitRoute{&bestRoute},
+	}
+
+	return finalQuote, routesWithAmountIn, nil
+}
+
 // validateAndFilterRoutes validates all routes. Specifically:
 // - all routes have at least one pool.
 // - all routes have the same final token out denom.
@@ -88,7 +189,8 @@ func estimateAndRankSingleRouteQuote(ctx context.Context, routes []route.RouteIm
 // - the previous pool token out denom is in the current pool.
 // - the current pool token out denom is in the current pool.
 // Returns error if not. Nil otherwise.
-func validateAndFilterRoutes(candidateRoutes [][]candidatePoolWrapper, tokenInDenom string, logger log.Logger) (sqsdomain.CandidateRoutes, error) {
+// The skipped routes are recorded in the debug info if it is non-nil.
+func validateAndFilterRoutes(candidateRoutes [][]candidatePoolWrapper, tokenInDenom string, debugInfo *domain.QuoteDebugInfo, logger log.Logger) (sqsdomain.CandidateRoutes, error) {
 	var (
 		tokenOutDenom  string
 		filteredRoutes []sqsdomain.CandidateRoute
@@ -117,6 +219,7 @@ ROUTE_LOOP:
 
 			// Skip routes for which we have already seen the pool ID within that route.
 			if _, ok := uniquePoolIDsIntraRoute[currentPool.ID]; ok {
+				debugInfo.AddFilteredRoute(toCandidateRoute(candidateRoute), domain.ValidateAndFilterRoutesFilter, fmt.Sprintf("pool (%d) appears more than once in the route", currentPool.ID))
 				continue ROUTE_LOOP
 			} else {
 				uniquePoolIDsIntraRoute[currentPool.ID] = struct{}{}
@@ -141,12 +244,16 @@ ROUTE_LOOP:
 				// Validate that intermediary pools do not contain the token in denom or token out denom
 				if j > 0 && j < len(candidateRoute)-1 {
 					if denom == tokenInDenom {
-						logger.Warn("route skipped - found token in intermediary pool", zap.Error(RoutePoolWithTokenInDenomError{RouteIndex: i, TokenInDenom: tokenInDenom}))
+						err := RoutePoolWithTokenInDenomError{RouteIndex: i, TokenInDenom: tokenInDenom}
+						logger.Warn("route skipped - found token in intermediary pool", zap.Error(err))
+						debugInfo.AddFilteredRoute(toCandidateRoute(candidateRoute), domain.ValidateAndFilterRoutesFilter, err.Error())
 						continue ROUTE_LOOP
 					}
 
 					if denom == currentRouteTokenOutDenom {
-						logger.Warn("route skipped- found token out in intermediary pool", zap.Error(RoutePoolWithTokenOutDenomError{RouteIndex: i, TokenOutDenom: currentPoolTokenOutDenom}))
+						err := RoutePoolWithTokenOutDenomError{RouteIndex: i, TokenOutDenom: currentPoolTokenOutDenom}
+						logger.Warn("route skipped- found token out in intermediary pool", zap.Error(err))
+						debugInfo.AddFilteredRoute(toCandidateRoute(candidateRoute), domain.ValidateAndFilterRoutesFilter, err.Error())
 						continue ROUTE_LOOP
 					}
 				}
@@ -176,19 +283,7 @@ ROUTE_LOOP:
 		tokenOutDenom = currentRouteTokenOutDenom
 
 		// Update filtered routes if this route passed all checks
-		filteredRoute := sqsdomain.CandidateRoute{
-			Pools: make([]sqsdomain.CandidatePool, 0, len(candidateRoute)),
-		}
-
-		// Convert route to the final output format
-		for _, pool := range candidateRoute {
-			filteredRoute.Pools = append(filteredRoute.Pools, sqsdomain.CandidatePool{
-				ID:            pool.ID,
-				TokenOutDenom: pool.TokenOutDenom,
-			})
-		}
-
-		filteredRoutes = append(filteredRoutes, filteredRoute)
+		filteredRoutes = append(filteredRoutes, toCandidateRoute(candidateRoute))
 	}
 
 	if tokenOutDenom == tokenInDenom {
@@ -201,10 +296,31 @@ ROUTE_LOOP:
 	}, nil
 }
 
+// toCandidateRoute converts the route to the final output format.
+func toCandidateRoute(candidateRoute []candidatePoolWrapper) sqsdomain.CandidateRoute {
+	route := sqsdomain.CandidateRoute{
+		Pools: make([]sqsdomain.CandidatePool, 0, len(candidateRoute)),
+	}
+
+	for _, pool := range candidateRoute {
+		route.Pools = append(route.Pools, sqsdomain.CandidatePool{
+			ID:            pool.ID,
+			TokenOutDenom: pool.TokenOutDenom,
+		})
+	}
+
+	return route
+}
+
 type RouteWithOutAmount struct {
 	route.RouteImpl
 	OutAmount osmomath.Int "json:\"out_amount\""
 	InAmount  osmomath.Int "json:\"in_amount\""
+	// EstimatedGas is the estimated gas of swapping the amount in over the route.
+	EstimatedGas uint64 "json:\"estimated_gas\""
+	// TicksCrossed is the number of initialized ticks crossed by the simulation that computed the amounts.
+	// The gas is estimated from it without simulating the route again.
+	TicksCrossed uint64 "json:\"-\""
 }
 
 var _ domain.SplitRoute = &RouteWithOutAmount{}
@@ -219,6 +335,11 @@ func (r RouteWithOutAmount) GetAmountOut() math.Int {
 	return r.OutAmount
 }
 
+// GetEstimatedGas implements domain.SplitRoute.
+func (r RouteWithOutAmount) GetEstimatedGas() uint64 {
+	return r.EstimatedGas
+}
+
 type Split struct {
 	Routes          []domain.SplitRoute
 	CurrentTotalOut osmomath.Int
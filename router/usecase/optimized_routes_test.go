@@ -3,6 +3,7 @@ package usecase_test
 import (
 	"context"
 	"sort"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/osmosis-labs/sqs/sqsdomain"
@@ -261,6 +262,112 @@ func (s *RouterTestSuite) TestGetBestSplitRoutesQuote() {
 	}
 }
 
+// This test validates that we are able to split the exact amount out over multiple routes.
+// Higher liquidity pools should require a lower amount in for the same amount out, all else equal.
+// As a result, the split must provide exactly the token out while requiring no more token in
+// than the best single route.
+func (s *RouterTestSuite) TestGetSplitQuoteInGivenOut() {
+	s.Setup()
+
+	xLiquidity := sdk.NewCoins(
+		sdk.NewCoin(DenomOne, sdk.NewInt(1_000_000_000_000)),
+		sdk.NewCoin(DenomTwo, sdk.NewInt(2_000_000_000_000)),
+	)
+
+	defaultBalancerPoolID := s.PrepareBalancerPoolWithCoins(xLiquidity...)
+	secondBalancerPoolIDSameDenoms := s.PrepareBalancerPoolWithCoins(coinutil.MulRaw(xLiquidity, 2)...)
+	thirdBalancerPoolIDSameDenoms := s.PrepareBalancerPoolWithCoins(coinutil.MulRaw(xLiquidity, 4)...)
+
+	defaultBalancerPool, err := s.App.PoolManagerKeeper.GetPool(s.Ctx, defaultBalancerPoolID)
+	s.Require().NoError(err)
+	secondBalancerPoolSameDenoms, err := s.App.PoolManagerKeeper.GetPool(s.Ctx, secondBalancerPoolIDSameDenoms)
+	s.Require().NoError(err)
+	thirdBalancerPoolSameDenoms, err := s.App.PoolManagerKeeper.GetPool(s.Ctx, thirdBalancerPoolIDSameDenoms)
+	s.Require().NoError(err)
+
+	mockPoolTwoForOne := mocks.WithTokenInDenom(mocks.WithTokenOutDenom(DefaultMockPool, DenomOne), DenomTwo)
+
+	tests := map[string]struct {
+		routes   []route.RouteImpl
+		tokenOut sdk.Coin
+
+		expectedNumRoutes int
+	}{
+		"valid single route": {
+			routes: []route.RouteImpl{
+				WithRoutePools(route.RouteImpl{}, []sqsdomain.RoutablePool{
+					mocks.WithChainPoolModel(mockPoolTwoForOne, defaultBalancerPool),
+				})},
+			tokenOut: sdk.NewCoin(DenomOne, sdk.NewInt(100)),
+
+			expectedNumRoutes: 1,
+		},
+		"valid two route single hop": {
+			routes: []route.RouteImpl{
+				WithRoutePools(route.RouteImpl{}, []sqsdomain.RoutablePool{
+					mocks.WithChainPoolModel(mockPoolTwoForOne, defaultBalancerPool),
+				}),
+				WithRoutePools(route.RouteImpl{}, []sqsdomain.RoutablePool{
+					mocks.WithPoolID(mocks.WithChainPoolModel(mockPoolTwoForOne, secondBalancerPoolSameDenoms), 2),
+				}),
+			},
+			tokenOut: sdk.NewCoin(DenomOne, sdk.NewInt(50_000_000_000)),
+
+			expectedNumRoutes: 2,
+		},
+		"valid three route single hop with truncation remainder": {
+			routes: []route.RouteImpl{
+				WithRoutePools(route.RouteImpl{}, []sqsdomain.RoutablePool{
+					mocks.WithChainPoolModel(mockPoolTwoForOne, defaultBalancerPool),
+				}),
+				WithRoutePools(route.RouteImpl{}, []sqsdomain.RoutablePool{
+					mocks.WithPoolID(mocks.WithChainPoolModel(mockPoolTwoForOne, thirdBalancerPoolSameDenoms), 3),
+				}),
+				WithRoutePools(route.RouteImpl{}, []sqsdomain.RoutablePool{
+					mocks.WithPoolID(mocks.WithChainPoolModel(mockPoolTwoForOne, secondBalancerPoolSameDenoms), 2),
+				}),
+			},
+			tokenOut: sdk.NewCoin(DenomOne, sdk.NewInt(56_789_321_123)),
+
+			expectedNumRoutes: 3,
+		},
+	}
+
+	for name, tc := range tests {
+		s.Run(name, func() {
+			quote, err := routerusecase.GetSplitQuoteInGivenOut(context.TODO(), tc.routes, tc.tokenOut)
+			s.Require().NoError(err)
+
+			s.Require().Equal(tc.tokenOut.Amount, quote.GetAmountOut())
+			s.Require().Equal(DenomTwo, quote.GetAmountIn().Denom)
+
+			actualRoutes := quote.GetRoute()
+			s.Require().Len(actualRoutes, tc.expectedNumRoutes)
+
+			// Validate that the amounts out add up to the exact token out
+			// and that amounts in add up to the quote amount in.
+			totalOutFromSplits := osmomath.ZeroInt()
+			totalInFromSplits := osmomath.ZeroInt()
+			for _, splitRoute := range actualRoutes {
+				totalOutFromSplits = totalOutFromSplits.Add(splitRoute.GetAmountOut())
+				totalInFromSplits = totalInFromSplits.Add(splitRoute.GetAmountIn())
+
+				// Each split must be consistent with a direct estimate over the route.
+				expectedIn, err := splitRoute.CalculateTokenInByTokenOut(context.TODO(), sdk.NewCoin(tc.tokenOut.Denom, splitRoute.GetAmountOut()))
+				s.Require().NoError(err)
+				s.Require().Equal(expectedIn.Amount, splitRoute.GetAmountIn())
+			}
+			s.Require().Equal(tc.tokenOut.Amount, totalOutFromSplits)
+			s.Require().Equal(quote.GetAmountIn().Amount, totalInFromSplits)
+
+			// The split must require no more than the best single route.
+			bestSingleQuote, _, err := routerusecase.EstimateAndRankSingleRouteQuoteInGivenOut(context.TODO(), tc.routes, tc.tokenOut, noOpLogger)
+			s.Require().NoError(err)
+			s.Require().True(quote.GetAmountIn().Amount.LTE(bestSingleQuote.GetAmountIn().Amount))
+		})
+	}
+}
+
 // This test ensures strict route validation.
 // See individual test cases for details.
 func (s *RouterTestSuite) TestValidateAndFilterRoutes() {
@@ -685,3 +792,746 @@ func (s *RouterTestSuite) validateExpectedPoolIDOneRouteOneHopQuote(quote domain
 	s.Require().Equal(1, len(routePools))
 	s.Require().Equal(expectedPoolID, routePools[0].GetId())
 }
+
+// Validates that custom direct quotes can be constructed over multiple hops.
+// Each hop is charged the taker fee of its own denom pair.
+func (s *RouterTestSuite) TestGetCustomDirectQuoteMultiPool() {
+	s.Setup()
+
+	var (
+		amountIn = osmomath.NewInt(1_000_000)
+
+		takerFeeOneTwo   = osmomath.MustNewDecFromStr("0.001")
+		takerFeeTwoThree = osmomath.MustNewDecFromStr("0.003")
+	)
+
+	sqsPoolOneTwo := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, osmomath.NewInt(1_000_000_000)), sdk.NewCoin(DenomTwo, osmomath.NewInt(2_000_000_000)))
+	sqsPoolTwoThree := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomTwo, osmomath.NewInt(1_000_000_000)), sdk.NewCoin(DenomThree, osmomath.NewInt(3_000_000_000)))
+	// No taker fee is configured for this pair.
+	sqsPoolOneThree := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, osmomath.NewInt(1_000_000_000)), sdk.NewCoin(DenomThree, osmomath.NewInt(1_000_000_000)))
+
+	var (
+		poolIDOneTwo   = sqsPoolOneTwo.GetId()
+		poolIDTwoThree = sqsPoolTwoThree.GetId()
+		poolIDOneThree = sqsPoolOneThree.GetId()
+	)
+
+	takerFeeMap := sqsdomain.TakerFeeMap{}
+	takerFeeMap.SetTakerFee(DenomOne, DenomTwo, takerFeeOneTwo)
+	takerFeeMap.SetTakerFee(DenomTwo, DenomThree, takerFeeTwoThree)
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(takerFeeMap)
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools([]sqsdomain.PoolI{sqsPoolOneTwo, sqsPoolTwoThree, sqsPoolOneThree})
+
+	routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+
+	// Expected amount out is computed by applying the per-pair taker fee at each hop.
+	expectedRoute := route.RouteImpl{
+		Pools: []sqsdomain.RoutablePool{
+			s.newRoutablePool(sqsPoolOneTwo, DenomOne, DenomTwo, takerFeeOneTwo, emptyCosmWasmPoolsRouterConfig),
+			s.newRoutablePool(sqsPoolTwoThree, DenomTwo, DenomThree, takerFeeTwoThree, emptyCosmWasmPoolsRouterConfig),
+		},
+	}
+	expectedAmountOut, err := expectedRoute.CalculateTokenOutByTokenIn(context.Background(), sdk.NewCoin(DenomOne, amountIn))
+	s.Require().NoError(err)
+
+	tests := map[string]struct {
+		tokenOutDenoms []string
+		poolIDs        []uint64
+
+		expectError bool
+	}{
+		"valid two hop route": {
+			tokenOutDenoms: []string{DenomTwo, DenomThree},
+			poolIDs:        []uint64{poolIDOneTwo, poolIDTwoThree},
+		},
+		"number of pool IDs does not match number of denoms": {
+			tokenOutDenoms: []string{DenomTwo},
+			poolIDs:        []uint64{poolIDOneTwo, poolIDTwoThree},
+
+			expectError: true,
+		},
+		"no pool IDs": {
+			tokenOutDenoms: []string{},
+			poolIDs:        []uint64{},
+
+			expectError: true,
+		},
+		"hops do not connect": {
+			tokenOutDenoms: []string{DenomThree, DenomTwo},
+			poolIDs:        []uint64{poolIDOneTwo, poolIDTwoThree},
+
+			expectError: true,
+		},
+		"pool not found": {
+			tokenOutDenoms: []string{DenomTwo, DenomThree},
+			poolIDs:        []uint64{poolIDOneTwo, poolIDOneThree + 100},
+
+			expectError: true,
+		},
+		"taker fee not found": {
+			tokenOutDenoms: []string{DenomThree},
+			poolIDs:        []uint64{poolIDOneThree},
+
+			expectError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		s.Run(name, func() {
+			quote, err := routerUsecase.GetCustomDirectQuoteMultiPool(context.Background(), sdk.NewCoin(DenomOne, amountIn), tc.tokenOutDenoms, tc.poolIDs)
+
+			if tc.expectError {
+				s.Require().Error(err)
+				return
+			}
+			s.Require().NoError(err)
+
+			routes := quote.GetRoute()
+			s.Require().Len(routes, 1)
+
+			routePools := routes[0].GetPools()
+			s.Require().Len(routePools, len(tc.poolIDs))
+			for i, pool := range routePools {
+				s.Require().Equal(tc.poolIDs[i], pool.GetId())
+				s.Require().Equal(tc.tokenOutDenoms[i], pool.GetTokenOutDenom())
+			}
+
+			s.Require().Equal(expectedAmountOut.Amount.String(), quote.GetAmountOut().String())
+		})
+	}
+}
+
+// Validates that the batch of optimal quotes returns the same quotes as the individual optimal quotes
+// in the order of the requests, with per-request errors.
+func (s *RouterTestSuite) TestGetOptimalQuotes() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+	amountIn := osmomath.NewInt(1_000_000)
+
+	poolOneTwo := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwoThree := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomTwo, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount)), liquidityAmount.Int64())
+
+	pools := []sqsdomain.PoolI{poolOneTwo, poolTwoThree}
+
+	takerFeeMap := sqsdomain.TakerFeeMap{}
+	takerFeeMap.SetTakerFee(DenomOne, DenomTwo, osmomath.MustNewDecFromStr("0.001"))
+	takerFeeMap.SetTakerFee(DenomTwo, DenomThree, osmomath.MustNewDecFromStr("0.001"))
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(takerFeeMap)
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools(pools)
+
+	routerConfig := routertesting.DefaultRouterConfig
+	routerConfig.MaxBatchQuoteWorkers = 2
+
+	routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routerConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+	routerUsecase.SetSortedPools(pools)
+
+	requests := []domain.QuoteRequest{
+		{TokenIn: sdk.NewCoin(DenomOne, amountIn), TokenOutDenom: DenomTwo},
+		// No route.
+		{TokenIn: sdk.NewCoin(DenomOne, amountIn), TokenOutDenom: DenomFour},
+		{TokenIn: sdk.NewCoin(DenomOne, amountIn), TokenOutDenom: DenomThree},
+		// The only pool is excluded.
+		{TokenIn: sdk.NewCoin(DenomThree, amountIn), TokenOutDenom: DenomTwo, Options: []domain.RouterOption{domain.WithExcludedPoolIDs(poolTwoThree.GetId())}},
+		{TokenIn: sdk.NewCoin(DenomThree, amountIn), TokenOutDenom: DenomTwo, Options: []domain.RouterOption{domain.WithDisableSplitRoutes()}},
+	}
+
+	s.Run("all requests", func() {
+		// System under test.
+		results := routerUsecase.GetOptimalQuotes(context.Background(), requests)
+
+		s.Require().Len(results, len(requests))
+
+		for i, request := range requests {
+			expectedQuote, expectedErr := routerUsecase.GetOptimalQuote(context.Background(), request.TokenIn, request.TokenOutDenom, request.Options...)
+
+			if expectedErr != nil {
+				s.Require().Error(results[i].Err)
+				s.Require().Nil(results[i].Quote)
+				continue
+			}
+
+			s.Require().NoError(results[i].Err)
+			s.Require().Equal(request.TokenIn, results[i].Quote.GetAmountIn())
+			s.Require().Equal(expectedQuote.GetAmountOut().String(), results[i].Quote.GetAmountOut().String())
+		}
+
+		s.Require().NoError(results[0].Err)
+		s.Require().Error(results[1].Err)
+		s.Require().NoError(results[2].Err)
+		s.Require().Error(results[3].Err)
+		s.Require().NoError(results[4].Err)
+	})
+
+	s.Run("canceled context", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// System under test.
+		results := routerUsecase.GetOptimalQuotes(ctx, requests)
+
+		s.Require().Len(results, len(requests))
+		for _, result := range results {
+			s.Require().ErrorIs(result.Err, context.Canceled)
+		}
+	})
+
+	s.Run("no requests", func() {
+		// System under test.
+		results := routerUsecase.GetOptimalQuotes(context.Background(), nil)
+
+		s.Require().Empty(results)
+	})
+}
+
+// Validates that the depth quotes match the individual optimal quotes and that
+// the ranked routes are cached for every order of magnitude of the amounts.
+func (s *RouterTestSuite) TestGetQuoteDepth() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	poolOneTwo := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwoThree := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomTwo, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount)), liquidityAmount.Int64())
+
+	pools := []sqsdomain.PoolI{poolOneTwo, poolTwoThree}
+
+	takerFeeMap := sqsdomain.TakerFeeMap{}
+	takerFeeMap.SetTakerFee(DenomOne, DenomTwo, osmomath.MustNewDecFromStr("0.001"))
+	takerFeeMap.SetTakerFee(DenomTwo, DenomThree, osmomath.MustNewDecFromStr("0.001"))
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(takerFeeMap)
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools(pools)
+
+	// Two amounts of order of magnitude 6 and one of order of magnitude 7.
+	amounts := []osmomath.Int{osmomath.NewInt(1_000_000), osmomath.NewInt(5_000_000), osmomath.NewInt(10_000_000)}
+
+	s.Run("all amounts", func() {
+		rankedRouteCache := cache.New()
+		routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, rankedRouteCache, cache.New())
+		routerUsecase.SetSortedPools(pools)
+
+		// System under test.
+		results := routerUsecase.GetQuoteDepth(context.Background(), DenomOne, DenomThree, amounts)
+
+		s.Require().Len(results, len(amounts))
+
+		previousAmountOut := osmomath.ZeroInt()
+		for i, amount := range amounts {
+			tokenIn := sdk.NewCoin(DenomOne, amount)
+
+			expectedQuote, err := routerUsecase.GetOptimalQuote(context.Background(), tokenIn, DenomThree)
+			s.Require().NoError(err)
+
+			s.Require().NoError(results[i].Err)
+			s.Require().Equal(tokenIn, results[i].Quote.GetAmountIn())
+			s.Require().Equal(expectedQuote.GetAmountOut().String(), results[i].Quote.GetAmountOut().String())
+
+			// The amount out grows with the amount in.
+			s.Require().True(results[i].Quote.GetAmountOut().GT(previousAmountOut))
+			previousAmountOut = results[i].Quote.GetAmountOut()
+		}
+
+		for _, orderOfMagnitude := range []int{6, 7} {
+			_, found := rankedRouteCache.Get(routerusecase.FormatRankedRouteCacheKey(DenomOne, DenomThree, orderOfMagnitude))
+			s.Require().True(found)
+		}
+	})
+
+	s.Run("route cache disabled", func() {
+		routerConfig := routertesting.DefaultRouterConfig
+		routerConfig.RouteCacheEnabled = false
+
+		routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routerConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+		routerUsecase.SetSortedPools(pools)
+
+		ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+
+		// System under test.
+		results := routerUsecase.GetQuoteDepth(ctx, DenomOne, DenomThree, amounts)
+
+		s.Require().Len(results, len(amounts))
+		for i, amount := range amounts {
+			expectedQuote, err := routerUsecase.GetOptimalQuote(context.Background(), sdk.NewCoin(DenomOne, amount), DenomThree)
+			s.Require().NoError(err)
+
+			s.Require().NoError(results[i].Err)
+			s.Require().Equal(expectedQuote.GetAmountOut().String(), results[i].Quote.GetAmountOut().String())
+		}
+
+		// The caches are only looked up for the routes not computed for the previous amounts of the ladder:
+		// the ranked and the candidate routes of the first amount and the ranked routes of the third amount
+		// of the next order of magnitude. The second amount reuses the ranked routes of the first one.
+		disabled := domain.CacheLookupDisabled
+		s.Require().Equal([]domain.CacheLookupResult{disabled, disabled, disabled}, getCacheLookupResults(debugInfo))
+	})
+
+	s.Run("no route", func() {
+		routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+		routerUsecase.SetSortedPools(pools)
+
+		// System under test.
+		results := routerUsecase.GetQuoteDepth(context.Background(), DenomOne, DenomFour, amounts)
+
+		s.Require().Len(results, len(amounts))
+		for _, result := range results {
+			s.Require().Error(result.Err)
+		}
+	})
+
+	s.Run("canceled context", func() {
+		routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+		routerUsecase.SetSortedPools(pools)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// System under test.
+		results := routerUsecase.GetQuoteDepth(ctx, DenomOne, DenomThree, amounts)
+
+		s.Require().Len(results, len(amounts))
+		for _, result := range results {
+			s.Require().ErrorIs(result.Err, context.Canceled)
+		}
+	})
+}
+
+// Tests that the debug info attached to the context explains how the optimal quote was computed.
+func (s *RouterTestSuite) TestGetOptimalQuote_DebugInfo() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	// Two identical pools so that splitting the large amount in between them beats either single route.
+	poolOne := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwo := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+
+	pools := []sqsdomain.PoolI{poolOne, poolTwo}
+
+	takerFeeMap := sqsdomain.TakerFeeMap{}
+	takerFeeMap.SetTakerFee(DenomOne, DenomTwo, osmomath.MustNewDecFromStr("0.001"))
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(takerFeeMap)
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools(pools)
+
+	routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+	routerUsecase.SetSortedPools(pools)
+
+	tokenIn := sdk.NewCoin(DenomOne, osmomath.NewInt(100_000_000))
+
+	s.Run("cache miss", func() {
+		ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+
+		// System under test.
+		quote, err := routerUsecase.GetOptimalQuote(ctx, tokenIn, DenomTwo)
+		s.Require().NoError(err)
+
+		s.Require().Equal([]domain.CacheLookupResult{domain.CacheLookupMiss, domain.CacheLookupMiss}, getCacheLookupResults(debugInfo))
+		s.Require().Equal("ranked_route", debugInfo.CacheLookups[0].Cache)
+		s.Require().Equal("candidate_route", debugInfo.CacheLookups[1].Cache)
+
+		s.Require().Len(debugInfo.CandidateRoutes, 2)
+		s.Require().Empty(debugInfo.FilteredRoutes)
+
+		s.Require().Len(debugInfo.RankedRoutes, 2)
+		s.Require().Empty(debugInfo.RankedRoutes[0].Error)
+		s.Require().True(debugInfo.RankedRoutes[0].AmountOut.GTE(debugInfo.RankedRoutes[1].AmountOut))
+
+		s.Require().NotNil(debugInfo.Split)
+		s.Require().Empty(debugInfo.Split.Error)
+		s.Require().Len(debugInfo.Split.Routes, 2)
+		s.Require().Equal([]int{5, 5}, debugInfo.Split.RouteIncrements)
+		s.Require().Equal(10, debugInfo.Split.TotalIncrements)
+		s.Require().Equal(quote.GetAmountOut().String(), debugInfo.Split.AmountOut.String())
+
+		s.Require().Equal(domain.SplitQuoteSelected, debugInfo.SelectedQuote)
+	})
+
+	s.Run("ranked route cache hit", func() {
+		ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+
+		// System under test.
+		_, err := routerUsecase.GetOptimalQuote(ctx, tokenIn, DenomTwo)
+		s.Require().NoError(err)
+
+		// The candidate route cache is not looked up when the ranked routes are cached.
+		s.Require().Equal([]domain.CacheLookupResult{domain.CacheLookupHit}, getCacheLookupResults(debugInfo))
+		s.Require().Len(debugInfo.RankedRoutes, 2)
+		s.Require().Equal(domain.SplitQuoteSelected, debugInfo.SelectedQuote)
+	})
+
+	s.Run("splits disabled", func() {
+		ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+
+		// System under test.
+		_, err := routerUsecase.GetOptimalQuote(ctx, tokenIn, DenomTwo, domain.WithDisableSplitRoutes())
+		s.Require().NoError(err)
+
+		s.Require().Nil(debugInfo.Split)
+		s.Require().Equal(domain.SingleRouteQuoteSelected, debugInfo.SelectedQuote)
+	})
+}
+
+// Tests that the best quote found within the latency budget is returned flagged as truncated
+// and that the routes found past the deadline are not cached.
+func (s *RouterTestSuite) TestGetOptimalQuote_MaxLatency() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	// Two identical pools so that splitting the large amount in between them beats either single route.
+	poolOne := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwo := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+
+	pools := []sqsdomain.PoolI{poolOne, poolTwo}
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(sqsdomain.TakerFeeMap{})
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools(pools)
+
+	routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+	routerUsecase.SetSortedPools(pools)
+
+	tokenIn := sdk.NewCoin(DenomOne, osmomath.NewInt(100_000_000))
+
+	s.Run("deadline exceeded returns the best single route found so far", func() {
+		ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+
+		// System under test.
+		quote, err := routerUsecase.GetOptimalQuote(ctx, tokenIn, DenomTwo, domain.WithMaxLatency(time.Nanosecond))
+		s.Require().NoError(err)
+
+		s.Require().True(quote.(*routerusecase.QuoteImpl).Truncated)
+		s.Require().Len(quote.GetRoute(), 1)
+		s.Require().True(quote.GetAmountOut().IsPositive())
+
+		// Ranking stopped after the first route and the split was not computed.
+		s.Require().Len(debugInfo.RankedRoutes, 1)
+		s.Require().Nil(debugInfo.Split)
+		s.Require().Equal(domain.SingleRouteQuoteSelected, debugInfo.SelectedQuote)
+	})
+
+	s.Run("within budget computes the full quote", func() {
+		ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+
+		// System under test.
+		quote, err := routerUsecase.GetOptimalQuote(ctx, tokenIn, DenomTwo, domain.WithMaxLatency(time.Minute))
+		s.Require().NoError(err)
+
+		// The truncated routes were not cached.
+		s.Require().Equal([]domain.CacheLookupResult{domain.CacheLookupMiss, domain.CacheLookupMiss}, getCacheLookupResults(debugInfo))
+
+		s.Require().False(quote.(*routerusecase.QuoteImpl).Truncated)
+		s.Require().Len(quote.GetRoute(), 2)
+		s.Require().Equal(domain.SplitQuoteSelected, debugInfo.SelectedQuote)
+	})
+}
+
+// Tests that the quotes are returned with the estimated gas and that, given a gas price,
+// the split is only selected if it returns more than the gas of its additional routes.
+func (s *RouterTestSuite) TestGetOptimalQuote_EstimatedGas() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	// Two identical pools so that splitting the large amount in between them beats either single route.
+	poolOne := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwo := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+
+	pools := []sqsdomain.PoolI{poolOne, poolTwo}
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(sqsdomain.TakerFeeMap{})
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools(pools)
+
+	routerConfig := routertesting.DefaultRouterConfig
+	routerConfig.Gas = domain.GasConfig{
+		PerHop:   10,
+		Balancer: 100,
+	}
+	const routeGas = uint64(110)
+
+	routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routerConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+	routerUsecase.SetSortedPools(pools)
+
+	// Splitting this amount in half returns roughly 4.3M more than the single route.
+	tokenIn := sdk.NewCoin(DenomOne, osmomath.NewInt(100_000_000))
+
+	tests := []struct {
+		name string
+		opts []domain.RouterOption
+
+		expectedSelectedQuote string
+		expectedRoutes        int
+	}{
+		{
+			name: "no gas price selects the split by the gross amount out",
+
+			expectedSelectedQuote: domain.SplitQuoteSelected,
+			expectedRoutes:        2,
+		},
+		{
+			name: "cheap gas selects the split",
+			opts: []domain.RouterOption{domain.WithGasPrice(osmomath.OneDec(), osmomath.OneDec())},
+
+			expectedSelectedQuote: domain.SplitQuoteSelected,
+			expectedRoutes:        2,
+		},
+		{
+			name: "gas of the additional route exceeding the split gain selects the single route",
+			// 110 gas per route costs 11M of the token out.
+			opts: []domain.RouterOption{domain.WithGasPrice(osmomath.NewDec(1_000), osmomath.NewDec(100))},
+
+			expectedSelectedQuote: domain.SingleRouteQuoteSelected,
+			expectedRoutes:        1,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		s.Run(tc.name, func() {
+			ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+
+			// System under test.
+			quote, err := routerUsecase.GetOptimalQuote(ctx, tokenIn, DenomTwo, tc.opts...)
+			s.Require().NoError(err)
+
+			s.Require().Equal(tc.expectedSelectedQuote, debugInfo.SelectedQuote)
+			s.Require().Len(quote.GetRoute(), tc.expectedRoutes)
+
+			for _, route := range quote.GetRoute() {
+				s.Require().Equal(routeGas, route.GetEstimatedGas())
+			}
+			s.Require().Equal(routeGas*uint64(tc.expectedRoutes), quote.GetEstimatedGas())
+		})
+	}
+
+	s.Run("deadline exceeded skips the estimation", func() {
+		// System under test.
+		quote, err := routerUsecase.GetOptimalQuote(context.Background(), tokenIn, DenomTwo, domain.WithMaxLatency(time.Nanosecond))
+		s.Require().NoError(err)
+
+		s.Require().True(quote.(*routerusecase.QuoteImpl).Truncated)
+		s.Require().Zero(quote.GetEstimatedGas())
+	})
+}
+
+// Tests that the adaptive mode refines the split found over the 10% increments of the amount in.
+func (s *RouterTestSuite) TestGetOptimalQuote_AdaptiveSplit() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+	deepLiquidityAmount := osmomath.NewInt(3_000_000_000)
+
+	// The optimal split is proportional to the liquidity of the pools: 25% and 75%
+	// which is not a multiple of the 10% increments.
+	poolOne := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwo := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, deepLiquidityAmount), sdk.NewCoin(DenomTwo, deepLiquidityAmount)), deepLiquidityAmount.Int64())
+
+	pools := []sqsdomain.PoolI{poolOne, poolTwo}
+
+	tokenIn := sdk.NewCoin(DenomOne, liquidityAmount)
+
+	getQuote := func(adaptiveSplitsEnabled bool) (domain.Quote, *domain.QuoteDebugInfo) {
+		routerRepository := routerrepo.New()
+		routerRepository.SetTakerFees(sqsdomain.TakerFeeMap{})
+
+		poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+		poolsUsecase.StorePools(pools)
+
+		routerConfig := routertesting.DefaultRouterConfig
+		routerConfig.AdaptiveSplitsEnabled = adaptiveSplitsEnabled
+
+		routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routerConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+		routerUsecase.SetSortedPools(pools)
+
+		ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+
+		quote, err := routerUsecase.GetOptimalQuote(ctx, tokenIn, DenomTwo)
+		s.Require().NoError(err)
+		s.Require().Len(quote.GetRoute(), 2)
+
+		return quote, debugInfo
+	}
+
+	coarseQuote, coarseDebugInfo := getQuote(false)
+	refinedQuote, refinedDebugInfo := getQuote(true)
+
+	s.Require().Nil(coarseDebugInfo.Split.Refinement)
+
+	// The coarse split only allocates multiples of 10% of the amount in.
+	increment := tokenIn.Amount.QuoRaw(10)
+	for _, route := range coarseQuote.GetRoute() {
+		s.Require().True(route.GetAmountIn().Mod(increment).IsZero())
+	}
+
+	s.Require().True(refinedQuote.GetAmountOut().GT(coarseQuote.GetAmountOut()), "refined (%s), coarse (%s)", refinedQuote.GetAmountOut(), coarseQuote.GetAmountOut())
+
+	// The refined split is within 1% of the optimal one and still swaps the amount in in full.
+	totalAmountIn := osmomath.ZeroInt()
+	totalAmountOut := osmomath.ZeroInt()
+	for _, route := range refinedQuote.GetRoute() {
+		totalAmountIn = totalAmountIn.Add(route.GetAmountIn())
+		totalAmountOut = totalAmountOut.Add(route.GetAmountOut())
+
+		expectedAmountIn := tokenIn.Amount.QuoRaw(4)
+		if route.GetPools()[0].GetId() == poolTwo.GetId() {
+			expectedAmountIn = tokenIn.Amount.Sub(expectedAmountIn)
+		}
+		s.Require().True(route.GetAmountIn().Sub(expectedAmountIn).Abs().LTE(increment.QuoRaw(10)), "actual (%s), expected (%s)", route.GetAmountIn(), expectedAmountIn)
+	}
+	s.Require().Equal(tokenIn.Amount.String(), totalAmountIn.String())
+	s.Require().Equal(refinedQuote.GetAmountOut().String(), totalAmountOut.String())
+
+	// Both the split over the increments and the refined one are recorded in the debug info.
+	split := refinedDebugInfo.Split
+	s.Require().Equal(coarseDebugInfo.Split.RouteIncrements, split.RouteIncrements)
+	s.Require().Equal(coarseQuote.GetAmountOut().String(), split.AmountOut.String())
+
+	s.Require().NotNil(split.Refinement)
+	s.Require().True(split.Refinement.Improved)
+	s.Require().Empty(split.Refinement.Error)
+	s.Require().Positive(split.Refinement.Iterations)
+	s.Require().Equal(refinedQuote.GetAmountOut().String(), split.Refinement.AmountOut.String())
+	s.Require().Len(split.Refinement.RouteAmountsIn, len(split.Routes))
+	for i, candidateRoute := range split.Routes {
+		for _, route := range refinedQuote.GetRoute() {
+			if route.GetPools()[0].GetId() == candidateRoute.Pools[0].ID {
+				s.Require().Equal(route.GetAmountIn().String(), split.Refinement.RouteAmountsIn[i].String())
+			}
+		}
+	}
+}
+
+// Tests that the routes sharing a pool are not filtered out and are split by simulating them jointly
+// with the swap of the first route applied to the shared pool before the swap of the second one.
+func (s *RouterTestSuite) TestGetOptimalQuote_SharedPoolSplit() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+	sharedLiquidityAmount := osmomath.NewInt(100_000_000_000)
+
+	// Two identical first hop pools that share the deep second hop pool.
+	poolOneTwoA := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolOneTwoB := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwoThree := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomTwo, sharedLiquidityAmount), sdk.NewCoin(DenomThree, sharedLiquidityAmount)), sharedLiquidityAmount.Int64())
+
+	pools := []sqsdomain.PoolI{poolOneTwoA, poolOneTwoB, poolTwoThree}
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(sqsdomain.TakerFeeMap{})
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools(pools)
+
+	routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+	routerUsecase.SetSortedPools(pools)
+
+	tokenIn := sdk.NewCoin(DenomOne, osmomath.NewInt(100_000_000))
+
+	ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+
+	// System under test.
+	quote, err := routerUsecase.GetOptimalQuote(ctx, tokenIn, DenomThree)
+	s.Require().NoError(err)
+
+	// Both routes share the second hop pool and neither is filtered out.
+	s.Require().Empty(debugInfo.FilteredRoutes)
+	s.Require().Len(debugInfo.RankedRoutes, 2)
+
+	s.Require().Equal(domain.SplitQuoteSelected, debugInfo.SelectedQuote)
+	s.Require().Equal([]int{5, 5}, debugInfo.Split.RouteIncrements)
+
+	routes := quote.GetRoute()
+	s.Require().Len(routes, 2)
+	s.Require().Equal(poolTwoThree.GetId(), routes[0].GetPools()[1].GetId())
+	s.Require().Equal(poolTwoThree.GetId(), routes[1].GetPools()[1].GetId())
+	s.Require().Equal(quote.GetAmountOut().String(), routes[0].GetAmountOut().Add(routes[1].GetAmountOut()).String())
+
+	// The first route swaps over the shared pool as is.
+	independentOutFirst, err := routes[0].CalculateTokenOutByTokenIn(context.Background(), sdk.NewCoin(DenomOne, routes[0].GetAmountIn()))
+	s.Require().NoError(err)
+	s.Require().Equal(independentOutFirst.Amount.String(), routes[0].GetAmountOut().String())
+
+	// The second route swaps over the shared pool after the price was moved by the first route.
+	independentOutSecond, err := routes[1].CalculateTokenOutByTokenIn(context.Background(), sdk.NewCoin(DenomOne, routes[1].GetAmountIn()))
+	s.Require().NoError(err)
+	s.Require().True(routes[1].GetAmountOut().LT(independentOutSecond.Amount))
+
+	// The result pools are prepared over the state left by the previous routes so that
+	// the amounts out of the last hops match the joint simulation.
+	_, _, err = quote.PrepareResult(context.Background(), osmomath.OneDec())
+	s.Require().NoError(err)
+
+	for _, route := range quote.GetRoute() {
+		pools := route.GetPools()
+		resultPool, ok := pools[len(pools)-1].(domain.RoutableResultPool)
+		s.Require().True(ok)
+		s.Require().Equal(route.GetAmountOut().String(), resultPool.GetSwapBreakdown().AmountOut.Amount.String())
+	}
+}
+
+// Tests that the exact amount out quotes exclude the routes sharing a pool with a better ranked route
+// even if the pool is stateful since their amounts in cannot be simulated jointly.
+func (s *RouterTestSuite) TestGetOptimalQuoteInGivenOut_SharedPoolRoutesExcluded() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+	sharedLiquidityAmount := osmomath.NewInt(100_000_000_000)
+
+	// Two identical first hop pools that share the deep second hop pool.
+	poolOneTwoA := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolOneTwoB := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwoThree := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomTwo, sharedLiquidityAmount), sdk.NewCoin(DenomThree, sharedLiquidityAmount)), sharedLiquidityAmount.Int64())
+
+	pools := []sqsdomain.PoolI{poolOneTwoA, poolOneTwoB, poolTwoThree}
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(sqsdomain.TakerFeeMap{})
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools(pools)
+
+	routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+	routerUsecase.SetSortedPools(pools)
+
+	tokenOut := sdk.NewCoin(DenomThree, osmomath.NewInt(100_000_000))
+
+	// System under test.
+	quote, err := routerUsecase.GetOptimalQuoteInGivenOut(context.Background(), tokenOut, DenomOne)
+	s.Require().NoError(err)
+
+	// The exact amount in quote of the same size is split across both routes but the second route
+	// is filtered out of the exact amount out one.
+	routes := quote.GetRoute()
+	s.Require().Len(routes, 1)
+	s.Require().Equal(poolTwoThree.GetId(), routes[0].GetPools()[1].GetId())
+}
+
+// getCacheLookupResults returns the results of the cache lookups recorded in the debug info.
+func getCacheLookupResults(debugInfo *domain.QuoteDebugInfo) []domain.CacheLookupResult {
+	results := make([]domain.CacheLookupResult, 0, len(debugInfo.CacheLookups))
+	for _, lookup := range debugInfo.CacheLookups {
+		results = append(results, lookup.Result)
+	}
+	return results
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// rankedPool is a pool together with its index in the globally sorted pools.
+type rankedPool struct {
+	pool *sqsdomain.PoolWrapper
+	rank int
+}
+
+// PoolGraph is the routing graph over the sorted pools.
+// Each denom is mapped to all pools that contain it. The pools of a denom
+// are kept in the global sort order so that the candidate route search
+// walks the adjacent pools in the same order as it would walk the sorted pools.
+// The graph is immutable once constructed and is safe for concurrent reads.
+type PoolGraph struct {
+	poolsByDenom map[string][]rankedPool
+	numPools     int
+}
+
+// NewPoolGraph constructs the routing graph from the given pools.
+// CONTRACT: the pools are already sorted according to the desired parameters.
+// See sortPools() function.
+func NewPoolGraph(sortedPools []sqsdomain.PoolI) *PoolGraph {
+	poolsByDenom := make(map[string][]rankedPool)
+
+	for rank, pool := range sortedPools {
+		// Unsafe cast for performance reasons.
+		// nolint: forcetypeassert
+		poolWrapper := pool.(*sqsdomain.PoolWrapper)
+
+		for _, denom := range poolWrapper.SQSModel.PoolDenoms {
+			poolsByDenom[denom] = append(poolsByDenom[denom], rankedPool{
+				pool: poolWrapper,
+				rank: rank,
+			})
+		}
+	}
+
+	return &PoolGraph{
+		poolsByDenom: poolsByDenom,
+		numPools:     len(sortedPools),
+	}
+}
+
+// GetPoolsByDenom returns the IDs of the pools that contain the given denom in the global sort order.
+func (g *PoolGraph) GetPoolsByDenom(denom string) []uint64 {
+	adjacentPools := g.poolsByDenom[denom]
+
+	poolIDs := make([]uint64, 0, len(adjacentPools))
+	for _, adjacentPool := range adjacentPools {
+		poolIDs = append(poolIDs, adjacentPool.pool.ChainModel.GetId())
+	}
+
+	return poolIDs
+}
+
+// NumPools returns the number of pools in the graph.
+func (g *PoolGraph) NumPools() int {
+	return g.numPools
+}
@@ -0,0 +1,34 @@
+package usecase_test
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	routerusecase "github.com/osmosis-labs/sqs/router/usecase"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// Validates that the pool graph maps every denom to the pools containing it
+// while preserving the global sort order.
+func (s *RouterTestSuite) TestNewPoolGraph() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	poolOneTwo := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount))
+	poolTwoThree := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomTwo, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount))
+	poolOneThree := s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount))
+
+	// Note that the order is intentionally different from the order of pool IDs.
+	sortedPools := []sqsdomain.PoolI{poolOneThree, poolTwoThree, poolOneTwo}
+
+	// System under test.
+	poolGraph := routerusecase.NewPoolGraph(sortedPools)
+
+	s.Require().Equal(len(sortedPools), poolGraph.NumPools())
+
+	s.Require().Equal([]uint64{poolOneThree.GetId(), poolOneTwo.GetId()}, poolGraph.GetPoolsByDenom(DenomOne))
+	s.Require().Equal([]uint64{poolTwoThree.GetId(), poolOneTwo.GetId()}, poolGraph.GetPoolsByDenom(DenomTwo))
+	s.Require().Equal([]uint64{poolOneThree.GetId(), poolTwoThree.GetId()}, poolGraph.GetPoolsByDenom(DenomThree))
+	s.Require().Empty(poolGraph.GetPoolsByDenom(DenomFour))
+}
@@ -2,10 +2,13 @@ package pools
 
 import (
 	"context"
+	"strconv"
 
 	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
 	"github.com/cosmos/cosmos-sdk/client"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
 	"github.com/osmosis-labs/sqs/sqsdomain/json"
+	"google.golang.org/grpc/metadata"
 )
 
 // initializeWasmClient initializes the wasm client given the node URI
@@ -46,3 +49,13 @@ func queryCosmwasmContract[T any, K any](ctx context.Context, wasmClient wasmtyp
 
 	return nil
 }
+
+// withQueryHeight returns the context that makes the queries of the wasm client run against the state
+// at the given height rather than the latest height of the node. Returns the context as is if the height is zero.
+func withQueryHeight(ctx context.Context, height uint64) context.Context {
+	if height == 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatUint(height, 10))
+}
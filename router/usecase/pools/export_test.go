@@ -1,8 +1,39 @@
 package pools
 
+import (
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	cwpoolmodel "github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/model"
+	"github.com/osmosis-labs/sqs/domain"
+)
+
 type (
 	RoutableCFMMPoolImpl         = routableBalancerPoolImpl
 	RoutableConcentratedPoolImpl = routableConcentratedPoolImpl
 	RoutableTransmuterPoolImpl   = routableTransmuterPoolImpl
 	RoutableResultPoolImpl       = routableResultPoolImpl
 )
+
+type RoutableCosmWasmPoolImpl = routableCosmWasmPoolImpl
+
+const MaxConcurrentCosmWasmSimulations = maxConcurrentCosmWasmSimulations
+
+// NewRoutableCosmWasmPool returns a generalized cosmwasm routable pool querying the given wasm client.
+func NewRoutableCosmWasmPool(chainPool *cwpoolmodel.CosmWasmPool, balances sdk.Coins, tokenOutDenom string, wasmClient wasmtypes.QueryClient, simulationCache *domain.CosmWasmSimulationCache) *RoutableCosmWasmPoolImpl {
+	return &routableCosmWasmPoolImpl{
+		ChainPool:       chainPool,
+		Balances:        balances,
+		TokenOutDenom:   tokenOutDenom,
+		TakerFee:        osmomath.ZeroDec(),
+		SpreadFactor:    osmomath.ZeroDec(),
+		wasmClient:      wasmClient,
+		simulationCache: simulationCache,
+	}
+}
+
+// SetHeight sets the height the contract is queried at.
+func (r *routableCosmWasmPoolImpl) SetHeight(height uint64) {
+	r.height = height
+}
@@ -0,0 +1,45 @@
+package pools
+
+import (
+	"fmt"
+
+	"github.com/osmosis-labs/sqs/domain"
+)
+
+// Names of the native CosmWasm pool implementations referenced by the pools config.
+const (
+	TransmuterNativeCosmWasmPool      = "transmuter"
+	ConstantProductNativeCosmWasmPool = "constant-product"
+	OrderbookNativeCosmWasmPool       = "orderbook"
+)
+
+// nativeCosmWasmPoolFactories are the native CosmWasm pool implementations by name.
+var nativeCosmWasmPoolFactories = map[string]domain.NativeCosmWasmPoolFactory{
+	TransmuterNativeCosmWasmPool:      newRoutableTransmuterPool,
+	ConstantProductNativeCosmWasmPool: newRoutableConstantProductPool,
+	OrderbookNativeCosmWasmPool:       newRoutableOrderbookPool,
+}
+
+// NewNativeCosmWasmPoolRegistry returns the registry with the native implementations registered
+// for the code IDs given by implementation name.
+// Returns error if an implementation name is unknown or if a code ID is given for more than one implementation.
+func NewNativeCosmWasmPoolRegistry(codeIDsByImplementation map[string][]uint64) (*domain.CosmWasmPoolRegistry, error) {
+	registry := domain.NewCosmWasmPoolRegistry()
+
+	for name, codeIDs := range codeIDsByImplementation {
+		factory, ok := nativeCosmWasmPoolFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown native cosmwasm pool implementation (%s)", name)
+		}
+
+		for _, codeID := range codeIDs {
+			if registry.IsRegistered(codeID) {
+				return nil, fmt.Errorf("code ID (%d) is given for more than one native cosmwasm pool implementation", codeID)
+			}
+		}
+
+		registry.Register(factory, codeIDs...)
+	}
+
+	return registry, nil
+}
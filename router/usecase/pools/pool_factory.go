@@ -14,8 +14,9 @@ import (
 )
 
 // NewRoutablePool creates a new RoutablePool.
+// The token in denom is only required for computing quotes by token out.
 // Panics if pool is of invalid type or if does not contain tick data when a concentrated pool.
-func NewRoutablePool(pool sqsdomain.PoolI, tokenOutDenom string, takerFee osmomath.Dec, cosmWasmConfig domain.CosmWasmPoolRouterConfig) (sqsdomain.RoutablePool, error) {
+func NewRoutablePool(pool sqsdomain.PoolI, tokenInDenom string, tokenOutDenom string, takerFee osmomath.Dec, cosmWasmConfig domain.CosmWasmPoolRouterConfig) (sqsdomain.RoutablePool, error) {
 	poolType := pool.GetType()
 	chainPool := pool.GetUnderlyingPool()
 	if poolType == poolmanagertypes.Concentrated {
@@ -36,6 +37,7 @@ func NewRoutablePool(pool sqsdomain.PoolI, tokenOutDenom string, takerFee osmoma
 		return &routableConcentratedPoolImpl{
 			ChainPool:     concentratedPool,
 			TickModel:     tickModel,
+			TokenInDenom:  tokenInDenom,
 			TokenOutDenom: tokenOutDenom,
 			TakerFee:      takerFee,
 		}, nil
@@ -55,6 +57,7 @@ func NewRoutablePool(pool sqsdomain.PoolI, tokenOutDenom string, takerFee osmoma
 
 		return &routableBalancerPoolImpl{
 			ChainPool:     balancerPool,
+			TokenInDenom:  tokenInDenom,
 			TokenOutDenom: tokenOutDenom,
 			TakerFee:      takerFee,
 		}, nil
@@ -79,17 +82,18 @@ func NewRoutablePool(pool sqsdomain.PoolI, tokenOutDenom string, takerFee osmoma
 
 		return &routableStableswapPoolImpl{
 			ChainPool:     stableswapPool,
+			TokenInDenom:  tokenInDenom,
 			TokenOutDenom: tokenOutDenom,
 			TakerFee:      takerFee,
 		}, nil
 	}
 
-	return newRoutableCosmWasmPool(pool, cosmWasmConfig, tokenOutDenom, takerFee)
+	return newRoutableCosmWasmPool(pool, cosmWasmConfig, tokenInDenom, tokenOutDenom, takerFee)
 }
 
 // newRoutableCosmWasmPool creates a new RoutablePool for CosmWasm pools.
 // Panics if the given pool is not a cosmwasm pool or if the
-func newRoutableCosmWasmPool(pool sqsdomain.PoolI, cosmWasmConfig domain.CosmWasmPoolRouterConfig, tokenOutDenom string, takerFee osmomath.Dec) (sqsdomain.RoutablePool, error) {
+func newRoutableCosmWasmPool(pool sqsdomain.PoolI, cosmWasmConfig domain.CosmWasmPoolRouterConfig, tokenInDenom string, tokenOutDenom string, takerFee osmomath.Dec) (sqsdomain.RoutablePool, error) {
 	chainPool := pool.GetUnderlyingPool()
 	poolType := pool.GetType()
 
@@ -101,19 +105,16 @@ func newRoutableCosmWasmPool(pool sqsdomain.PoolI, cosmWasmConfig domain.CosmWas
 		}
 	}
 
+	// Native implementations compute swaps from the SQS pool model without interacting with the chain.
+	if newNativePool, ok := cosmWasmConfig.NativePools.GetFactory(cosmwasmPool.CodeId); ok {
+		return newNativePool(pool, cosmwasmPool, tokenInDenom, tokenOutDenom, takerFee)
+	}
+
 	// Check if the pool is a transmuter pool
 	_, isTransmuter := cosmWasmConfig.TransmuterCodeIDs[cosmwasmPool.CodeId]
 	if isTransmuter {
-		spreadFactor := pool.GetSQSPoolModel().SpreadFactor
-
 		// Transmuter has a custom implementation since it does not need to interact with the chain.
-		return &routableTransmuterPoolImpl{
-			ChainPool:     cosmwasmPool,
-			Balances:      pool.GetSQSPoolModel().Balances,
-			TokenOutDenom: tokenOutDenom,
-			TakerFee:      takerFee,
-			SpreadFactor:  spreadFactor,
-		}, nil
+		return newRoutableTransmuterPool(pool, cosmwasmPool, tokenInDenom, tokenOutDenom, takerFee)
 	}
 
 	_, isGeneralizedCosmWasmPool := cosmWasmConfig.GeneralCosmWasmCodeIDs[cosmwasmPool.CodeId]
@@ -130,10 +131,14 @@ func newRoutableCosmWasmPool(pool sqsdomain.PoolI, cosmWasmConfig domain.CosmWas
 		return &routableCosmWasmPoolImpl{
 			ChainPool:     cosmwasmPool,
 			Balances:      pool.GetSQSPoolModel().Balances,
+			TokenInDenom:  tokenInDenom,
 			TokenOutDenom: tokenOutDenom,
 			TakerFee:      takerFee,
 			SpreadFactor:  spreadFactor,
 			wasmClient:    wasmClient,
+
+			simulationCache: cosmWasmConfig.SimulationCache,
+			height:          cosmWasmConfig.Height,
 		}, nil
 	}
 
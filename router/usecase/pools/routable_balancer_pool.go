@@ -8,6 +8,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
 	"github.com/osmosis-labs/sqs/sqsdomain"
 
 	"github.com/osmosis-labs/osmosis/v25/x/poolmanager"
@@ -16,10 +17,14 @@ import (
 	"github.com/osmosis-labs/osmosis/v25/x/gamm/pool-models/balancer"
 )
 
-var _ sqsdomain.RoutablePool = &routableBalancerPoolImpl{}
+var (
+	_ sqsdomain.RoutablePool      = &routableBalancerPoolImpl{}
+	_ domain.StatefulRoutablePool = &routableBalancerPoolImpl{}
+)
 
 type routableBalancerPoolImpl struct {
 	ChainPool     *balancer.Pool "json:\"pool\""
+	TokenInDenom  string         "json:\"token_in_denom,omitempty\""
 	TokenOutDenom string         "json:\"token_out_denom\""
 	TakerFee      osmomath.Dec   "json:\"taker_fee\""
 }
@@ -34,6 +39,50 @@ func (r *routableBalancerPoolImpl) CalculateTokenOutByTokenIn(ctx context.Contex
 	return tokenOut, nil
 }
 
+// SwapOutGivenIn implements domain.StatefulRoutablePool.
+// The pool assets are copied so that the receiver is not mutated.
+func (r *routableBalancerPoolImpl) SwapOutGivenIn(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (sdk.Coin, domain.StatefulRoutablePool, error) {
+	tokenOut, err := r.ChainPool.CalcOutAmtGivenIn(sdk.Context{}, sdk.Coins{tokenIn}, tokenOutDenom, r.GetSpreadFactor())
+	if err != nil {
+		return sdk.Coin{}, nil, err
+	}
+
+	chainPool := *r.ChainPool
+	chainPool.PoolAssets = make([]balancer.PoolAsset, len(r.ChainPool.PoolAssets))
+	copy(chainPool.PoolAssets, r.ChainPool.PoolAssets)
+
+	for i, poolAsset := range chainPool.PoolAssets {
+		switch poolAsset.Token.Denom {
+		case tokenIn.Denom:
+			chainPool.PoolAssets[i].Token.Amount = poolAsset.Token.Amount.Add(tokenIn.Amount)
+		case tokenOut.Denom:
+			chainPool.PoolAssets[i].Token.Amount = poolAsset.Token.Amount.Sub(tokenOut.Amount)
+		}
+	}
+
+	return tokenOut, &routableBalancerPoolImpl{
+		ChainPool:     &chainPool,
+		TokenInDenom:  r.TokenInDenom,
+		TokenOutDenom: r.TokenOutDenom,
+		TakerFee:      r.TakerFee,
+	}, nil
+}
+
+// CalculateTokenInByTokenOut implements RoutablePool.
+func (r *routableBalancerPoolImpl) CalculateTokenInByTokenOut(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error) {
+	tokenIn, err := r.ChainPool.CalcInAmtGivenOut(sdk.Context{}, sdk.Coins{tokenOut}, r.TokenInDenom, r.GetSpreadFactor())
+	if err != nil {
+		return sdk.Coin{}, err
+	}
+
+	return tokenIn, nil
+}
+
+// GetTokenInDenom implements RoutablePool.
+func (r *routableBalancerPoolImpl) GetTokenInDenom() string {
+	return r.TokenInDenom
+}
+
 // GetTokenOutDenom implements RoutablePool.
 func (r *routableBalancerPoolImpl) GetTokenOutDenom() string {
 	return r.TokenOutDenom
@@ -51,6 +100,13 @@ func (r *routableBalancerPoolImpl) ChargeTakerFeeExactIn(tokenIn sdk.Coin) (toke
 	return tokenInAfterTakerFee
 }
 
+// ChargeTakerFeeExactOut implements sqsdomain.RoutablePool.
+// Returns the token in amount that, after the taker fee is charged, equals the given token in.
+func (r *routableBalancerPoolImpl) ChargeTakerFeeExactOut(tokenIn sdk.Coin) (tokenInAfterFee sdk.Coin) {
+	tokenInAfterTakerFee, _ := poolmanager.CalcTakerFeeExactOut(tokenIn, r.TakerFee)
+	return tokenInAfterTakerFee
+}
+
 // GetTakerFee implements sqsdomain.RoutablePool.
 func (r *routableBalancerPoolImpl) GetTakerFee() math.LegacyDec {
 	return r.TakerFee
@@ -20,12 +20,17 @@ import (
 	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
 )
 
-var _ sqsdomain.RoutablePool = &routableConcentratedPoolImpl{}
+var (
+	_ sqsdomain.RoutablePool          = &routableConcentratedPoolImpl{}
+	_ domain.TickCrossingRoutablePool = &routableConcentratedPoolImpl{}
+	_ domain.StatefulRoutablePool     = &routableConcentratedPoolImpl{}
+)
 var zeroBigDec = osmomath.ZeroBigDec()
 
 type routableConcentratedPoolImpl struct {
 	ChainPool     *concentratedmodel.Pool "json:\"cl_pool\""
 	TickModel     *sqsdomain.TickModel    "json:\"tick_model\""
+	TokenInDenom  string                  "json:\"token_in_denom,omitempty\""
 	TokenOutDenom string                  "json:\"token_out_denom\""
 	TakerFee      osmomath.Dec            "json:\"taker_fee\""
 }
@@ -82,43 +87,106 @@ func (r *routableConcentratedPoolImpl) GetTakerFee() math.LegacyDec {
 // - the current sqrt price is zero
 // - rans out of ticks during swap (token in is too high for liquidity in the pool)
 func (r *routableConcentratedPoolImpl) CalculateTokenOutByTokenIn(ctx context.Context, tokenIn sdk.Coin) (sdk.Coin, error) {
-	concentratedPool := r.ChainPool
-	tickModel := r.TickModel
+	tokenOut, _, err := r.CalculateTokenOutByTokenInWithTicksCrossed(ctx, tokenIn)
+	return tokenOut, err
+}
 
-	if tickModel == nil {
-		return sdk.Coin{}, domain.ConcentratedPoolNoTickModelError{
-			PoolId: r.ChainPool.Id,
-		}
+// CalculateTokenOutByTokenInWithTicksCrossed implements domain.TickCrossingRoutablePool.
+// It calculates the amount of token out given the amount of token in similarly to CalculateTokenOutByTokenIn
+// and counts the initialized ticks crossed by the swap. A tick is crossed every time the liquidity
+// of a bucket is exhausted, including when the swap ends exactly at the boundary of the bucket.
+func (r *routableConcentratedPoolImpl) CalculateTokenOutByTokenInWithTicksCrossed(ctx context.Context, tokenIn sdk.Coin) (sdk.Coin, uint64, error) {
+	result, err := r.swapOutGivenIn(tokenIn)
+	if err != nil {
+		return sdk.Coin{}, 0, err
 	}
 
-	// Ensure pool has liquidity.
-	if tickModel.HasNoLiquidity {
-		return sdk.Coin{}, domain.ConcentratedNoLiquidityError{
-			PoolId: concentratedPool.Id,
-		}
+	return result.tokenOut, result.ticksCrossed, nil
+}
+
+// SwapOutGivenIn implements domain.StatefulRoutablePool.
+// The current sqrt price, tick and bucket of the returned pool are moved to where the swap ends.
+// The liquidity of the buckets is not changed by swaps so the ticks are shared with the receiver.
+// Note that the token out denom is implied by the token in denom.
+func (r *routableConcentratedPoolImpl) SwapOutGivenIn(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (sdk.Coin, domain.StatefulRoutablePool, error) {
+	tokenOut, _, pool, err := r.SwapOutGivenInWithTicksCrossed(ctx, tokenIn, tokenOutDenom)
+	return tokenOut, pool, err
+}
+
+// SwapOutGivenInWithTicksCrossed implements domain.TickCrossingRoutablePool.
+// See SwapOutGivenIn and CalculateTokenOutByTokenInWithTicksCrossed for details.
+func (r *routableConcentratedPoolImpl) SwapOutGivenInWithTicksCrossed(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (sdk.Coin, uint64, domain.StatefulRoutablePool, error) {
+	result, err := r.swapOutGivenIn(tokenIn)
+	if err != nil {
+		return sdk.Coin{}, 0, nil, err
 	}
 
-	// Ensure that the current bucket is within the available bucket range.
-	currentBucketIndex := tickModel.CurrentTickIndex
+	ticks := r.TickModel.Ticks
+	currentBucketIndex := result.currentBucketIndex
+
+	var currentTick int64
+	isZeroForOne := tokenIn.Denom == r.ChainPool.Token0
+	switch {
+	case result.isBucketBoundaryReached && isZeroForOne && currentBucketIndex > 0:
+		// Similarly to the chain, the swap ending exactly at the lower tick of the bucket crosses it
+		// and moves the current tick below it into the lower bucket.
+		currentTick = ticks[currentBucketIndex].LowerTick - 1
+		currentBucketIndex--
+	case result.isBucketBoundaryReached && !isZeroForOne && currentBucketIndex < int64(len(ticks))-1:
+		// The swap ending exactly at the upper tick of the bucket crosses it into the upper bucket.
+		currentTick = ticks[currentBucketIndex].UpperTick
+		currentBucketIndex++
+	default:
+		currentTick, err = clmath.CalculateSqrtPriceToTick(result.currentSqrtPrice)
+		if err != nil {
+			return sdk.Coin{}, 0, nil, err
+		}
 
-	if currentBucketIndex < 0 || currentBucketIndex >= int64(len(tickModel.Ticks)) {
-		return sdk.Coin{}, domain.ConcentratedCurrentTickNotWithinBucketError{
-			PoolId:             concentratedPool.Id,
-			CurrentBucketIndex: currentBucketIndex,
-			TotalBuckets:       int64(len(tickModel.Ticks)),
+		// The tick computed from the sqrt price may fall outside of the last bucket swapped in due to rounding.
+		for currentBucketIndex > 0 && currentTick < ticks[currentBucketIndex].LowerTick {
+			currentBucketIndex--
+		}
+		for currentBucketIndex < int64(len(ticks))-1 && currentTick >= ticks[currentBucketIndex].UpperTick {
+			currentBucketIndex++
 		}
 	}
 
-	currentBucket := tickModel.Ticks[currentBucketIndex]
+	chainPool := *r.ChainPool
+	chainPool.CurrentSqrtPrice = result.currentSqrtPrice
+	chainPool.CurrentTick = currentTick
+	chainPool.CurrentTickLiquidity = ticks[currentBucketIndex].LiquidityAmount
 
-	isCurrentTickWithinBucket := concentratedPool.IsCurrentTickInRange(currentBucket.LowerTick, currentBucket.UpperTick)
-	if !isCurrentTickWithinBucket {
-		return sdk.Coin{}, domain.ConcentratedCurrentTickAndBucketMismatchError{
-			PoolID:      concentratedPool.Id,
-			CurrentTick: concentratedPool.CurrentTick,
-			LowerTick:   currentBucket.LowerTick,
-			UpperTick:   currentBucket.UpperTick,
-		}
+	tickModel := *r.TickModel
+	tickModel.CurrentTickIndex = currentBucketIndex
+
+	pool := *r
+	pool.ChainPool = &chainPool
+	pool.TickModel = &tickModel
+
+	return result.tokenOut, result.ticksCrossed, &pool, nil
+}
+
+// concentratedSwapResult is the outcome of a swap over the buckets of a concentrated liquidity pool.
+type concentratedSwapResult struct {
+	tokenOut     sdk.Coin
+	ticksCrossed uint64
+	// currentSqrtPrice is the sqrt price after the swap.
+	currentSqrtPrice osmomath.BigDec
+	// currentBucketIndex is the index of the last bucket swapped in.
+	currentBucketIndex int64
+	// isBucketBoundaryReached is true if the swap ends exactly at the boundary of the last bucket swapped in.
+	isBucketBoundaryReached bool
+}
+
+// swapOutGivenIn computes the swap of the given token in over the buckets of the pool.
+// See CalculateTokenOutByTokenIn for the failure conditions.
+func (r *routableConcentratedPoolImpl) swapOutGivenIn(tokenIn sdk.Coin) (concentratedSwapResult, error) {
+	concentratedPool := r.ChainPool
+	tickModel := r.TickModel
+
+	currentBucketIndex, err := r.validateCurrentBucket()
+	if err != nil {
+		return concentratedSwapResult{}, err
 	}
 
 	// Set the appropriate token out denom.
@@ -137,10 +205,14 @@ func (r *routableConcentratedPoolImpl) CalculateTokenOutByTokenIn(ctx context.Co
 
 		amountRemainingIn = tokenIn.Amount.ToLegacyDec()
 		amountOutTotal    = osmomath.ZeroDec()
+
+		ticksCrossed            uint64
+		lastBucketIndex         = currentBucketIndex
+		isBucketBoundaryReached bool
 	)
 
 	if currentSqrtPrice.IsZero() {
-		return sdk.Coin{}, domain.ConcentratedZeroCurrentSqrtPriceError{
+		return concentratedSwapResult{}, domain.ConcentratedZeroCurrentSqrtPriceError{
 			PoolId: concentratedPool.Id,
 		}
 	}
@@ -150,13 +222,14 @@ func (r *routableConcentratedPoolImpl) CalculateTokenOutByTokenIn(ctx context.Co
 		if currentBucketIndex >= int64(len(tickModel.Ticks)) || currentBucketIndex < 0 {
 			// This happens when there is not enough liquidity in the pool to complete the swap
 			// for a given amount of token in.
-			return sdk.Coin{}, domain.ConcentratedNotEnoughLiquidityToCompleteSwapError{
+			return concentratedSwapResult{}, domain.ConcentratedNotEnoughLiquidityToCompleteSwapError{
 				PoolId:   concentratedPool.Id,
 				AmountIn: sdk.NewCoins(tokenIn).String(),
 			}
 		}
 
-		currentBucket = tickModel.Ticks[currentBucketIndex]
+		currentBucket := tickModel.Ticks[currentBucketIndex]
+		lastBucketIndex = currentBucketIndex
 
 		// Compute the next initialized tick index depending on the swap direction.
 		// Zero for one - in the lower tick direction.
@@ -173,7 +246,7 @@ func (r *routableConcentratedPoolImpl) CalculateTokenOutByTokenIn(ctx context.Co
 		// Get the sqrt price for the next initialized tick index.
 		sqrtPriceTarget, err := getTickToSqrtPrice(nextInitializedTickIndex)
 		if err != nil {
-			return sdk.Coin{}, err
+			return concentratedSwapResult{}, err
 		}
 
 		// Compute the swap within current bucket
@@ -185,11 +258,173 @@ func (r *routableConcentratedPoolImpl) CalculateTokenOutByTokenIn(ctx context.Co
 
 		// Update current sqrt price
 		currentSqrtPrice = sqrtPriceNext
+
+		// The bucket is exhausted so the swap crosses its tick. Similarly to the chain, this includes
+		// the swap ending exactly at the boundary of the bucket.
+		isBucketBoundaryReached = sqrtPriceNext.Equal(sqrtPriceTarget)
+		if isBucketBoundaryReached {
+			ticksCrossed++
+		}
+	}
+
+	return concentratedSwapResult{
+		tokenOut:                sdk.Coin{Denom: tokenOutDenom, Amount: amountOutTotal.TruncateInt()},
+		ticksCrossed:            ticksCrossed,
+		currentSqrtPrice:        currentSqrtPrice,
+		currentBucketIndex:      lastBucketIndex,
+		isBucketBoundaryReached: isBucketBoundaryReached,
+	}, nil
+}
+
+// CalculateTokenInByTokenOut implements sqsdomain.RoutablePool.
+// It calculates the amount of token in required to receive exactly the given token out
+// from a concentrated liquidity pool by walking the buckets in the swap direction.
+// The spread factor is charged on the amount in.
+// Fails if:
+// - the tick model is invalid for the same reasons as in CalculateTokenOutByTokenIn
+// - runs out of ticks during swap (token out is too high for liquidity in the pool)
+func (r *routableConcentratedPoolImpl) CalculateTokenInByTokenOut(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error) {
+	tokenIn, _, err := r.CalculateTokenInByTokenOutWithTicksCrossed(ctx, tokenOut)
+	return tokenIn, err
+}
+
+// CalculateTokenInByTokenOutWithTicksCrossed implements domain.TickCrossingRoutablePool.
+// It calculates the amount of token in required to receive the given token out similarly to CalculateTokenInByTokenOut
+// and counts the initialized ticks crossed by the swap. A tick is crossed every time the liquidity
+// of a bucket is exhausted, including when the swap ends exactly at the boundary of the bucket.
+func (r *routableConcentratedPoolImpl) CalculateTokenInByTokenOutWithTicksCrossed(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, uint64, error) {
+	concentratedPool := r.ChainPool
+	tickModel := r.TickModel
+
+	currentBucketIndex, err := r.validateCurrentBucket()
+	if err != nil {
+		return sdk.Coin{}, 0, err
+	}
+
+	// Token in is token zero when token one is requested out.
+	isZeroForOne := tokenOut.Denom == concentratedPool.Token1
+	tokenInDenom := concentratedPool.Token1
+	if isZeroForOne {
+		tokenInDenom = concentratedPool.Token0
+	}
+
+	// Initialize the swap strategy.
+	swapStrategy := swapstrategy.New(isZeroForOne, zeroBigDec, &storetypes.KVStoreKey{}, concentratedPool.SpreadFactor)
+
+	var (
+		// Swap state
+		currentSqrtPrice = concentratedPool.GetCurrentSqrtPrice()
+
+		amountRemainingOut = tokenOut.Amount.ToLegacyDec()
+		amountInTotal      = osmomath.ZeroDec()
+
+		ticksCrossed uint64
+	)
+
+	if currentSqrtPrice.IsZero() {
+		return sdk.Coin{}, 0, domain.ConcentratedZeroCurrentSqrtPriceError{
+			PoolId: concentratedPool.Id,
+		}
+	}
+
+	// Compute swap over all buckets.
+	for amountRemainingOut.IsPositive() {
+		if currentBucketIndex >= int64(len(tickModel.Ticks)) || currentBucketIndex < 0 {
+			// This happens when there is not enough liquidity in the pool to complete the swap
+			// for a given amount of token out.
+			return sdk.Coin{}, 0, domain.ConcentratedNotEnoughLiquidityToCompleteSwapInGivenOutError{
+				PoolId:    concentratedPool.Id,
+				AmountOut: sdk.NewCoins(tokenOut).String(),
+			}
+		}
+
+		currentBucket := tickModel.Ticks[currentBucketIndex]
+
+		// Compute the next initialized tick index depending on the swap direction.
+		var nextInitializedTickIndex int64
+		if isZeroForOne {
+			nextInitializedTickIndex = currentBucket.LowerTick
+			currentBucketIndex--
+		} else {
+			nextInitializedTickIndex = currentBucket.UpperTick
+			currentBucketIndex++
+		}
+
+		// Get the sqrt price for the next initialized tick index.
+		sqrtPriceTarget, err := getTickToSqrtPrice(nextInitializedTickIndex)
+		if err != nil {
+			return sdk.Coin{}, 0, err
+		}
+
+		// Compute the swap within current bucket
+		sqrtPriceNext, amountOutConsumed, amountInComputed, spreadRewardChargeTotal := swapStrategy.ComputeSwapWithinBucketInGivenOut(currentSqrtPrice, sqrtPriceTarget, currentBucket.LiquidityAmount, amountRemainingOut)
+
+		// Update swap state for next iteration
+		amountRemainingOut = amountRemainingOut.SubMut(amountOutConsumed)
+		amountInTotal = amountInTotal.AddMut(amountInComputed).AddMut(spreadRewardChargeTotal)
+
+		// Update current sqrt price
+		currentSqrtPrice = sqrtPriceNext
+
+		// The bucket is exhausted so the swap crosses its tick. See swapOutGivenIn for details.
+		if sqrtPriceNext.Equal(sqrtPriceTarget) {
+			ticksCrossed++
+		}
+	}
+
+	// Round up to charge in the pool's favor.
+	return sdk.Coin{Denom: tokenInDenom, Amount: amountInTotal.Ceil().TruncateInt()}, ticksCrossed, nil
+}
+
+// validateCurrentBucket validates that the tick model is present, has liquidity and that
+// the current bucket matches the current tick of the pool.
+// Returns the current bucket index on success, error otherwise.
+func (r *routableConcentratedPoolImpl) validateCurrentBucket() (int64, error) {
+	concentratedPool := r.ChainPool
+	tickModel := r.TickModel
+
+	if tickModel == nil {
+		return 0, domain.ConcentratedPoolNoTickModelError{
+			PoolId: r.ChainPool.Id,
+		}
+	}
+
+	// Ensure pool has liquidity.
+	if tickModel.HasNoLiquidity {
+		return 0, domain.ConcentratedNoLiquidityError{
+			PoolId: concentratedPool.Id,
+		}
+	}
+
+	// Ensure that the current bucket is within the available bucket range.
+	currentBucketIndex := tickModel.CurrentTickIndex
+
+	if currentBucketIndex < 0 || currentBucketIndex >= int64(len(tickModel.Ticks)) {
+		return 0, domain.ConcentratedCurrentTickNotWithinBucketError{
+			PoolId:             concentratedPool.Id,
+			CurrentBucketIndex: currentBucketIndex,
+			TotalBuckets:       int64(len(tickModel.Ticks)),
+		}
 	}
 
-	// Return the total amount out.
-	//nolint:all
-	return sdk.Coin{tokenOutDenom, amountOutTotal.TruncateInt()}, nil
+	currentBucket := tickModel.Ticks[currentBucketIndex]
+
+	isCurrentTickWithinBucket := concentratedPool.IsCurrentTickInRange(currentBucket.LowerTick, currentBucket.UpperTick)
+	if !isCurrentTickWithinBucket {
+		return 0, domain.ConcentratedCurrentTickAndBucketMismatchError{
+			PoolID:      concentratedPool.Id,
+			CurrentTick: concentratedPool.CurrentTick,
+			LowerTick:   currentBucket.LowerTick,
+			UpperTick:   currentBucket.UpperTick,
+		}
+	}
+
+	return currentBucketIndex, nil
+}
+
+// GetTokenInDenom implements RoutablePool.
+func (r *routableConcentratedPoolImpl) GetTokenInDenom() string {
+	return r.TokenInDenom
 }
 
 // GetTokenOutDenom implements RoutablePool.
@@ -210,6 +445,13 @@ func (r *routableConcentratedPoolImpl) ChargeTakerFeeExactIn(tokenIn sdk.Coin) (
 	return tokenInAfterTakerFee
 }
 
+// ChargeTakerFeeExactOut implements sqsdomain.RoutablePool.
+// Returns the token in amount that, after the taker fee is charged, equals the given token in.
+func (r *routableConcentratedPoolImpl) ChargeTakerFeeExactOut(tokenIn sdk.Coin) (tokenInAfterFee sdk.Coin) {
+	tokenInAfterTakerFee, _ := poolmanager.CalcTakerFeeExactOut(tokenIn, r.GetTakerFee())
+	return tokenInAfterTakerFee
+}
+
 // SetTokenOutDenom implements sqsdomain.RoutablePool.
 func (r *routableConcentratedPoolImpl) SetTokenOutDenom(tokenOutDenom string) {
 	r.TokenOutDenom = tokenOutDenom
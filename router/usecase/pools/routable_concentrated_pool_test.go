@@ -88,13 +88,112 @@ func (s *RoutablePoolTestSuite) TestCalculateTokenOutByTokenIn_Concentrated_Succ
 					PoolDenoms:            []string{"foo", "bar"},
 				},
 			}
-			routablePool, err := pools.NewRoutablePool(poolWrapper, tc.TokenOutDenom, noTakerFee, domain.CosmWasmPoolRouterConfig{})
+			routablePool, err := pools.NewRoutablePool(poolWrapper, tc.TokenIn.Denom, tc.TokenOutDenom, noTakerFee, domain.CosmWasmPoolRouterConfig{})
 			s.Require().NoError(err)
 
 			tokenOut, err := routablePool.CalculateTokenOutByTokenIn(context.TODO(), tc.TokenIn)
 
 			s.Require().NoError(err)
 			s.Require().Equal(tc.ExpectedTokenOut.String(), tokenOut.String())
+
+			// The ticks crossed are the bucket boundaries between the current and the expected tick.
+			tokenOut, ticksCrossed, err := routablePool.(domain.TickCrossingRoutablePool).CalculateTokenOutByTokenInWithTicksCrossed(context.TODO(), tc.TokenIn)
+			s.Require().NoError(err)
+			s.Require().Equal(tc.ExpectedTokenOut.String(), tokenOut.String())
+			s.Require().Equal(countBoundariesCrossed(ticks, concentratedPool.GetCurrentTick(), tc.ExpectedTick), ticksCrossed)
+
+			// Swapping the token in as two halves over the updated pool state is equivalent to swapping it at once
+			// up to the truncation of the intermediate amounts which can only decrease the token out.
+			firstHalf := sdk.NewCoin(tc.TokenIn.Denom, tc.TokenIn.Amount.QuoRaw(2))
+			secondHalf := tc.TokenIn.Sub(firstHalf)
+
+			firstTokenOut, poolState, err := routablePool.(domain.StatefulRoutablePool).SwapOutGivenIn(context.TODO(), firstHalf, tc.TokenOutDenom)
+			s.Require().NoError(err)
+
+			// The stateful swap crosses the same ticks as the estimate.
+			_, firstTicksCrossed, _, err := routablePool.(domain.TickCrossingRoutablePool).SwapOutGivenInWithTicksCrossed(context.TODO(), firstHalf, tc.TokenOutDenom)
+			s.Require().NoError(err)
+			_, expectedFirstTicksCrossed, err := routablePool.(domain.TickCrossingRoutablePool).CalculateTokenOutByTokenInWithTicksCrossed(context.TODO(), firstHalf)
+			s.Require().NoError(err)
+			s.Require().Equal(expectedFirstTicksCrossed, firstTicksCrossed)
+			secondTokenOut, _, err := poolState.SwapOutGivenIn(context.TODO(), secondHalf, tc.TokenOutDenom)
+			s.Require().NoError(err)
+
+			errTolerance := osmomath.ErrTolerance{MultiplicativeTolerance: osmomath.MustNewDecFromStr("0.001"), RoundingDir: osmomath.RoundDown}
+			actualTokenOut := firstTokenOut.Amount.Add(secondTokenOut.Amount)
+			s.Require().Zero(errTolerance.Compare(tc.ExpectedTokenOut.Amount, actualTokenOut), "expected (%s), actual (%s)", tc.ExpectedTokenOut.Amount, actualTokenOut)
+		})
+	}
+}
+
+// countBoundariesCrossed returns the number of bucket boundaries strictly between the given ticks.
+func countBoundariesCrossed(ticks []sqsdomain.LiquidityDepthsWithRange, currentTick, expectedTick int64) uint64 {
+	lowerTick, upperTick := min(currentTick, expectedTick), max(currentTick, expectedTick)
+
+	boundariesCrossed := uint64(0)
+	for _, bucket := range ticks[1:] {
+		if bucket.LowerTick > lowerTick && bucket.LowerTick <= upperTick {
+			boundariesCrossed++
+		}
+	}
+	return boundariesCrossed
+}
+
+// Tests the CalculateTokenInByTokenOut method of the RoutableConcentratedPoolImpl struct
+// when the pool is concentrated.
+//
+// It reuses the chain swap setups and validates that the computed token in
+// matches the chain logic for the expected token out of each case.
+func (s *RoutablePoolTestSuite) TestCalculateTokenInByTokenOut_Concentrated_ChainVectors() {
+	tests := apptesting.SwapOutGivenInCases
+
+	for name, tc := range tests {
+		s.Run(name, func() {
+			if strings.Contains(name, "slippage protection") {
+				s.T().Skip("no slippage protection in router quote tests")
+			}
+
+			s.SetupAndFundSwapTest()
+			concentratedPool := s.PreparePoolWithCustSpread(tc.SpreadFactor)
+			// add default position
+			s.SetupDefaultPosition(concentratedPool.GetId())
+			s.SetupSecondPosition(tc, concentratedPool)
+
+			// Refetch the pool
+			concentratedPool, err := s.App.ConcentratedLiquidityKeeper.GetConcentratedPoolById(s.Ctx, concentratedPool.GetId())
+			s.Require().NoError(err)
+
+			// Get liquidity for full range
+			ticks, currentTickIndex, err := s.App.ConcentratedLiquidityKeeper.GetTickLiquidityForFullRange(s.Ctx, concentratedPool.GetId())
+			s.Require().NoError(err)
+
+			tokenOut := tc.ExpectedTokenOut
+			expectedTokenIn, err := s.App.ConcentratedLiquidityKeeper.CalcInAmtGivenOut(s.Ctx, concentratedPool, tokenOut, tc.TokenIn.Denom, tc.SpreadFactor)
+			s.Require().NoError(err)
+
+			routablePool := pools.RoutableConcentratedPoolImpl{
+				ChainPool: concentratedPool.(*concentratedmodel.Pool),
+				TickModel: &sqsdomain.TickModel{
+					Ticks:            ticks,
+					CurrentTickIndex: currentTickIndex,
+					HasNoLiquidity:   false,
+				},
+				TokenInDenom:  tc.TokenIn.Denom,
+				TokenOutDenom: tokenOut.Denom,
+				TakerFee:      noTakerFee,
+			}
+
+			tokenIn, err := routablePool.CalculateTokenInByTokenOut(context.TODO(), tokenOut)
+			s.Require().NoError(err)
+
+			s.Require().Equal(expectedTokenIn.Amount.String(), tokenIn.Amount.String())
+			s.Require().Equal(expectedTokenIn.Denom, tokenIn.Denom)
+
+			// The ticks crossed are the bucket boundaries between the current and the expected tick.
+			tokenIn, ticksCrossed, err := routablePool.CalculateTokenInByTokenOutWithTicksCrossed(context.TODO(), tokenOut)
+			s.Require().NoError(err)
+			s.Require().Equal(expectedTokenIn.Amount.String(), tokenIn.Amount.String())
+			s.Require().Equal(countBoundariesCrossed(ticks, concentratedPool.GetCurrentTick(), tc.ExpectedTick), ticksCrossed)
 		})
 	}
 }
@@ -282,3 +381,83 @@ func (s *RoutablePoolTestSuite) TestCalculateTokenOutByTokenIn_Concentrated_Erro
 		})
 	}
 }
+
+// Tests that a zero for one swap ending exactly at the lower tick of the current bucket crosses it
+// and moves the pool state into the lower bucket, similarly to the chain. Continuing the swap
+// from that state is then equivalent to swapping the total amount at once.
+func (s *RoutablePoolTestSuite) TestSwapOutGivenIn_Concentrated_EndsAtBucketBoundary() {
+	const (
+		// Sqrt price of 0.5.
+		boundaryTick = int64(-7_500_000)
+		lowerTick    = int64(-9_000_000)
+		upperTick    = int64(1_000_000)
+	)
+
+	var (
+		liquidity      = osmomath.NewDec(1_000_000)
+		lowerLiquidity = osmomath.NewDec(2_000_000)
+
+		// Swapping the liquidity amount of token0 moves the sqrt price from 1 to exactly 0.5
+		// and swaps out half of the liquidity amount of token1.
+		boundaryTokenIn          = sdk.NewCoin(Denom0, liquidity.TruncateInt())
+		expectedBoundaryTokenOut = sdk.NewCoin(Denom1, liquidity.QuoInt64(2).TruncateInt())
+
+		remainingTokenIn = sdk.NewCoin(Denom0, osmomath.NewInt(100_000))
+	)
+
+	routablePool := &pools.RoutableConcentratedPoolImpl{
+		ChainPool: &concentratedmodel.Pool{
+			Id:                   defaultPoolID,
+			Token0:               Denom0,
+			Token1:               Denom1,
+			CurrentSqrtPrice:     osmomath.OneBigDec(),
+			CurrentTick:          0,
+			CurrentTickLiquidity: liquidity,
+			SpreadFactor:         osmomath.ZeroDec(),
+		},
+		TickModel: &sqsdomain.TickModel{
+			Ticks: []sqsdomain.LiquidityDepthsWithRange{
+				{LowerTick: lowerTick, UpperTick: boundaryTick, LiquidityAmount: lowerLiquidity},
+				{LowerTick: boundaryTick, UpperTick: upperTick, LiquidityAmount: liquidity},
+			},
+			CurrentTickIndex: 1,
+		},
+		TokenOutDenom: Denom1,
+		TakerFee:      osmomath.ZeroDec(),
+	}
+
+	// System under test.
+	boundaryTokenOut, boundaryTicksCrossed, poolState, err := routablePool.SwapOutGivenInWithTicksCrossed(context.TODO(), boundaryTokenIn, Denom1)
+	s.Require().NoError(err)
+
+	s.Require().Equal(expectedBoundaryTokenOut, boundaryTokenOut)
+	s.Require().Equal(uint64(1), boundaryTicksCrossed)
+
+	// The current tick is moved below the boundary tick into the lower bucket.
+	poolAfterSwap, ok := poolState.(*pools.RoutableConcentratedPoolImpl)
+	s.Require().True(ok)
+	s.Require().Equal(boundaryTick-1, poolAfterSwap.ChainPool.CurrentTick)
+	s.Require().Equal(osmomath.MustNewBigDecFromStr("0.5"), poolAfterSwap.ChainPool.CurrentSqrtPrice)
+	s.Require().Equal(lowerLiquidity, poolAfterSwap.ChainPool.CurrentTickLiquidity)
+	s.Require().Equal(int64(0), poolAfterSwap.TickModel.CurrentTickIndex)
+
+	// The receiver is not mutated.
+	s.Require().Equal(int64(0), routablePool.ChainPool.CurrentTick)
+	s.Require().Equal(int64(1), routablePool.TickModel.CurrentTickIndex)
+
+	// Continuing the swap does not cross the boundary tick again.
+	remainingTokenOut, remainingTicksCrossed, _, err := poolAfterSwap.SwapOutGivenInWithTicksCrossed(context.TODO(), remainingTokenIn, Denom1)
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(0), remainingTicksCrossed)
+
+	totalTokenOut, totalTicksCrossed, err := routablePool.CalculateTokenOutByTokenInWithTicksCrossed(context.TODO(), boundaryTokenIn.Add(remainingTokenIn))
+	s.Require().NoError(err)
+	s.Require().Equal(totalTokenOut, boundaryTokenOut.Add(remainingTokenOut))
+	s.Require().Equal(totalTicksCrossed, boundaryTicksCrossed+remainingTicksCrossed)
+
+	// Swapping back in the other direction crosses the boundary tick into the upper bucket.
+	_, reverseTicksCrossed, reversePoolState, err := poolAfterSwap.SwapOutGivenInWithTicksCrossed(context.TODO(), sdk.NewCoin(Denom1, osmomath.NewInt(100_000)), Denom0)
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(1), reverseTicksCrossed)
+	s.Require().Equal(int64(1), reversePoolState.(*pools.RoutableConcentratedPoolImpl).TickModel.CurrentTickIndex)
+}
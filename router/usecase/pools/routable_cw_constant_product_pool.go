@@ -0,0 +1,207 @@
+package pools
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	cwpoolmodel "github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/model"
+	"github.com/osmosis-labs/osmosis/v25/x/poolmanager"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
+)
+
+var (
+	_ sqsdomain.RoutablePool       = &routableConstantProductPoolImpl{}
+	_ domain.SpreadFeeRoutablePool = &routableConstantProductPoolImpl{}
+)
+
+// routableConstantProductPoolImpl is a native implementation of the CosmWasm pools
+// that follow the constant product (x * y = k) invariant, such as the Astroport XYK pairs.
+// The swaps are computed from the pool balances with the spread factor charged on the token out
+// as the commission of the Astroport XYK pairs is.
+type routableConstantProductPoolImpl struct {
+	ChainPool     *cwpoolmodel.CosmWasmPool "json:\"pool\""
+	Balances      sdk.Coins                 "json:\"balances\""
+	TokenInDenom  string                    "json:\"token_in_denom,omitempty\""
+	TokenOutDenom string                    "json:\"token_out_denom\""
+	TakerFee      osmomath.Dec              "json:\"taker_fee\""
+	SpreadFactor  osmomath.Dec              "json:\"spread_factor\""
+}
+
+// newRoutableConstantProductPool creates a new constant product routable pool.
+// It implements domain.NativeCosmWasmPoolFactory.
+func newRoutableConstantProductPool(pool sqsdomain.PoolI, cosmWasmPool *cwpoolmodel.CosmWasmPool, tokenInDenom, tokenOutDenom string, takerFee osmomath.Dec) (sqsdomain.RoutablePool, error) {
+	return &routableConstantProductPoolImpl{
+		ChainPool:     cosmWasmPool,
+		Balances:      pool.GetSQSPoolModel().Balances,
+		TokenInDenom:  tokenInDenom,
+		TokenOutDenom: tokenOutDenom,
+		TakerFee:      takerFee,
+		SpreadFactor:  pool.GetSQSPoolModel().SpreadFactor,
+	}, nil
+}
+
+// GetId implements sqsdomain.RoutablePool.
+func (r *routableConstantProductPoolImpl) GetId() uint64 {
+	return r.ChainPool.PoolId
+}
+
+// GetPoolDenoms implements sqsdomain.RoutablePool.
+func (r *routableConstantProductPoolImpl) GetPoolDenoms() []string {
+	return r.Balances.Denoms()
+}
+
+// GetType implements sqsdomain.RoutablePool.
+func (*routableConstantProductPoolImpl) GetType() poolmanagertypes.PoolType {
+	return poolmanagertypes.CosmWasm
+}
+
+// GetSpreadFactor implements sqsdomain.RoutablePool.
+func (r *routableConstantProductPoolImpl) GetSpreadFactor() math.LegacyDec {
+	return r.SpreadFactor
+}
+
+// CalculateTokenOutByTokenIn implements sqsdomain.RoutablePool.
+// token out before spread = balance out * token in / (balance in + token in), rounded down.
+// token out = token out before spread - token out before spread * spread factor, with the spread rounded down.
+// Returns error if either of the denoms has no balance in the pool.
+func (r *routableConstantProductPoolImpl) CalculateTokenOutByTokenIn(ctx context.Context, tokenIn sdk.Coin) (sdk.Coin, error) {
+	tokenOutBeforeSpread, spreadAmount, err := r.swapOutGivenIn(tokenIn)
+	if err != nil {
+		return sdk.Coin{}, err
+	}
+
+	return sdk.NewCoin(r.TokenOutDenom, tokenOutBeforeSpread.Sub(spreadAmount)), nil
+}
+
+// CalculateSpreadFeeCharged implements domain.SpreadFeeRoutablePool.
+// The spread is charged from the token out so the fee is in the token out denom.
+func (r *routableConstantProductPoolImpl) CalculateSpreadFeeCharged(ctx context.Context, tokenIn sdk.Coin) (sdk.Coin, error) {
+	_, spreadAmount, err := r.swapOutGivenIn(tokenIn)
+	if err != nil {
+		return sdk.Coin{}, err
+	}
+
+	return sdk.NewCoin(r.TokenOutDenom, spreadAmount), nil
+}
+
+// swapOutGivenIn returns the token out amount before the spread and the spread amount for the given token in.
+// See CalculateTokenOutByTokenIn for the formulas.
+func (r *routableConstantProductPoolImpl) swapOutGivenIn(tokenIn sdk.Coin) (osmomath.Int, osmomath.Int, error) {
+	balanceIn, balanceOut, err := r.getBalances(tokenIn.Denom, r.TokenOutDenom)
+	if err != nil {
+		return osmomath.Int{}, osmomath.Int{}, err
+	}
+
+	tokenOutBeforeSpread := osmomath.BigDecFromSDKInt(balanceOut).MulMut(osmomath.BigDecFromSDKInt(tokenIn.Amount)).QuoMut(osmomath.BigDecFromSDKInt(balanceIn.Add(tokenIn.Amount))).Dec().TruncateInt()
+
+	spreadAmount := r.SpreadFactor.MulInt(tokenOutBeforeSpread).TruncateInt()
+
+	return tokenOutBeforeSpread, spreadAmount, nil
+}
+
+// CalculateTokenInByTokenOut implements sqsdomain.RoutablePool.
+// token out before spread = token out / (1 - spread factor), rounded up.
+// token in = balance in * token out before spread / (balance out - token out before spread), rounded up.
+// Returns error if either of the denoms has no balance in the pool or if the token out before spread
+// is not less than the balance out.
+func (r *routableConstantProductPoolImpl) CalculateTokenInByTokenOut(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error) {
+	balanceIn, balanceOut, err := r.getBalances(r.TokenInDenom, tokenOut.Denom)
+	if err != nil {
+		return sdk.Coin{}, err
+	}
+
+	tokenOutBeforeSpread := osmomath.BigDecFromSDKInt(tokenOut.Amount).QuoMut(osmomath.BigDecFromDec(osmomath.OneDec().Sub(r.SpreadFactor))).Ceil().Dec().TruncateInt()
+
+	if tokenOutBeforeSpread.GTE(balanceOut) {
+		return sdk.Coin{}, domain.CosmWasmPoolInsufficientLiquidityError{
+			PoolId:        r.GetId(),
+			Denom:         tokenOut.Denom,
+			BalanceAmount: balanceOut.String(),
+			Amount:        tokenOutBeforeSpread.String(),
+		}
+	}
+
+	tokenInAmount := osmomath.BigDecFromSDKInt(balanceIn).MulMut(osmomath.BigDecFromSDKInt(tokenOutBeforeSpread)).QuoMut(osmomath.BigDecFromSDKInt(balanceOut.Sub(tokenOutBeforeSpread))).Ceil().Dec().TruncateInt()
+
+	return sdk.NewCoin(r.TokenInDenom, tokenInAmount), nil
+}
+
+// getBalances returns the balances of the given token in and token out denoms.
+// Returns error if either of the balances is not positive.
+func (r *routableConstantProductPoolImpl) getBalances(tokenInDenom, tokenOutDenom string) (osmomath.Int, osmomath.Int, error) {
+	balanceIn := r.Balances.AmountOf(tokenInDenom)
+	balanceOut := r.Balances.AmountOf(tokenOutDenom)
+
+	for denom, balance := range map[string]osmomath.Int{tokenInDenom: balanceIn, tokenOutDenom: balanceOut} {
+		if !balance.IsPositive() {
+			return osmomath.Int{}, osmomath.Int{}, domain.CosmWasmPoolInsufficientLiquidityError{
+				PoolId:        r.GetId(),
+				Denom:         denom,
+				BalanceAmount: balance.String(),
+				Amount:        osmomath.ZeroInt().String(),
+			}
+		}
+	}
+
+	return balanceIn, balanceOut, nil
+}
+
+// GetTokenInDenom implements RoutablePool.
+func (r *routableConstantProductPoolImpl) GetTokenInDenom() string {
+	return r.TokenInDenom
+}
+
+// GetTokenOutDenom implements RoutablePool.
+func (r *routableConstantProductPoolImpl) GetTokenOutDenom() string {
+	return r.TokenOutDenom
+}
+
+// String implements sqsdomain.RoutablePool.
+func (r *routableConstantProductPoolImpl) String() string {
+	return fmt.Sprintf("pool (%d), pool type (%d) Constant Product CosmWasm, pool denoms (%v), token out (%s)", r.ChainPool.PoolId, poolmanagertypes.CosmWasm, r.GetPoolDenoms(), r.TokenOutDenom)
+}
+
+// ChargeTakerFeeExactIn implements sqsdomain.RoutablePool.
+func (r *routableConstantProductPoolImpl) ChargeTakerFeeExactIn(tokenIn sdk.Coin) (inAmountAfterFee sdk.Coin) {
+	tokenInAfterTakerFee, _ := poolmanager.CalcTakerFeeExactIn(tokenIn, r.GetTakerFee())
+	return tokenInAfterTakerFee
+}
+
+// ChargeTakerFeeExactOut implements sqsdomain.RoutablePool.
+func (r *routableConstantProductPoolImpl) ChargeTakerFeeExactOut(tokenIn sdk.Coin) (inAmountAfterFee sdk.Coin) {
+	tokenInAfterTakerFee, _ := poolmanager.CalcTakerFeeExactOut(tokenIn, r.GetTakerFee())
+	return tokenInAfterTakerFee
+}
+
+// GetTakerFee implements sqsdomain.RoutablePool.
+func (r *routableConstantProductPoolImpl) GetTakerFee() math.LegacyDec {
+	return r.TakerFee
+}
+
+// CalcSpotPrice implements sqsdomain.RoutablePool.
+// The spot price of the base denom in the quote denom is the ratio of the quote balance to the base balance.
+func (r *routableConstantProductPoolImpl) CalcSpotPrice(ctx context.Context, baseDenom string, quoteDenom string) (osmomath.BigDec, error) {
+	balanceBase, balanceQuote, err := r.getBalances(baseDenom, quoteDenom)
+	if err != nil {
+		return osmomath.BigDec{}, err
+	}
+
+	return osmomath.BigDecFromSDKInt(balanceQuote).QuoMut(osmomath.BigDecFromSDKInt(balanceBase)), nil
+}
+
+// IsGeneralizedCosmWasmPool implements sqsdomain.RoutablePool.
+func (*routableConstantProductPoolImpl) IsGeneralizedCosmWasmPool() bool {
+	return false
+}
+
+// GetCodeID implements sqsdomain.RoutablePool.
+func (r *routableConstantProductPoolImpl) GetCodeID() uint64 {
+	return r.ChainPool.CodeId
+}
@@ -0,0 +1,178 @@
+package pools_test
+
+import (
+	"context"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/domain/mocks"
+	"github.com/osmosis-labs/sqs/router/usecase/pools"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	cwpoolmodel "github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/model"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
+)
+
+// Tests the constant product quotes computed by the native implementation registered for the pool code ID.
+func (s *RoutablePoolTestSuite) TestCalculateTokenOutByTokenIn_ConstantProduct() {
+	defaultBalances := sdk.NewCoins(sdk.NewCoin(USDC, osmomath.NewInt(1_000)), sdk.NewCoin(ETH, osmomath.NewInt(2_000)))
+
+	tests := map[string]struct {
+		tokenIn       sdk.Coin
+		tokenOutDenom string
+		balances      sdk.Coins
+
+		expectedTokenOut osmomath.Int
+		// denom of the insufficient liquidity error if expected.
+		expectErrorDenom string
+	}{
+		"valid constant product quote": {
+			tokenIn:       sdk.NewCoin(USDC, osmomath.NewInt(100)),
+			tokenOutDenom: ETH,
+			balances:      defaultBalances,
+
+			// 2000 * 100 / (1000 + 100) = 181.81, rounded down to 181
+			// 181 - 181 * 0.005 = 181 - 0.905, with the spread rounded down to 0
+			expectedTokenOut: osmomath.NewInt(181),
+		},
+		"error: no balance of token out": {
+			tokenIn:       sdk.NewCoin(USDC, osmomath.NewInt(100)),
+			tokenOutDenom: ETH,
+			balances:      sdk.NewCoins(sdk.NewCoin(USDC, osmomath.NewInt(1_000))),
+
+			expectErrorDenom: ETH,
+		},
+	}
+
+	for name, tc := range tests {
+		s.Run(name, func() {
+			routablePool := s.newConstantProductRoutablePool(tc.balances, tc.tokenIn.Denom, tc.tokenOutDenom)
+
+			tokenOut, err := routablePool.CalculateTokenOutByTokenIn(context.TODO(), tc.tokenIn)
+
+			if tc.expectErrorDenom != "" {
+				var liquidityErr domain.CosmWasmPoolInsufficientLiquidityError
+				s.Require().ErrorAs(err, &liquidityErr)
+				s.Require().Equal(tc.expectErrorDenom, liquidityErr.Denom)
+				return
+			}
+			s.Require().NoError(err)
+
+			s.Require().Equal(sdk.NewCoin(tc.tokenOutDenom, tc.expectedTokenOut), tokenOut)
+		})
+	}
+}
+
+// Tests the constant product quotes in the out given in direction and the spot price.
+func (s *RoutablePoolTestSuite) TestCalculateTokenInByTokenOut_ConstantProduct() {
+	balances := sdk.NewCoins(sdk.NewCoin(USDC, osmomath.NewInt(1_000)), sdk.NewCoin(ETH, osmomath.NewInt(2_000)))
+	routablePool := s.newConstantProductRoutablePool(balances, USDC, ETH)
+
+	// 180 / 0.995 = 180.90, rounded up to 181
+	// 1000 * 181 / (2000 - 181) = 99.50, rounded up to 100
+	tokenIn, err := routablePool.CalculateTokenInByTokenOut(context.TODO(), sdk.NewCoin(ETH, osmomath.NewInt(180)))
+	s.Require().NoError(err)
+	s.Require().Equal(sdk.NewCoin(USDC, osmomath.NewInt(100)), tokenIn)
+
+	// Token out before spread must be less than the balance of token out.
+	_, err = routablePool.CalculateTokenInByTokenOut(context.TODO(), sdk.NewCoin(ETH, osmomath.NewInt(1_995)))
+	s.Require().Error(err)
+
+	spotPrice, err := routablePool.CalcSpotPrice(context.TODO(), USDC, ETH)
+	s.Require().NoError(err)
+	s.Require().Equal(osmomath.NewBigDec(2), spotPrice)
+}
+
+// Tests the constant product quotes against the simulation of an Astroport XYK pair with the 0.3% commission
+// that is charged on the return amount.
+func (s *RoutablePoolTestSuite) TestCalculateTokenOutByTokenIn_ConstantProduct_AstroportSimulation() {
+	var (
+		balances     = sdk.NewCoins(sdk.NewCoin(USDC, osmomath.NewInt(1_000_000_000)), sdk.NewCoin(ETH, osmomath.NewInt(2_000_000_000)))
+		spreadFactor = osmomath.MustNewDecFromStr("0.003")
+		tokenIn      = sdk.NewCoin(USDC, osmomath.NewInt(10_000_000))
+
+		// The Astroport simulation of swapping the token in:
+		// return amount = 2_000_000_000 - 2_000_000_000 * 1_000_000_000 / (1_000_000_000 + 10_000_000) = 19_801_980.19, rounded down
+		// commission amount = 19_801_980 * 0.003 = 59_405.94, rounded down
+		// return amount after commission = 19_801_980 - 59_405
+		expectedTokenOut = sdk.NewCoin(ETH, osmomath.NewInt(19_742_575))
+	)
+
+	cosmwasmPool := &cwpoolmodel.CosmWasmPool{PoolId: defaultPoolID, CodeId: 773, ContractAddress: "osmo1contract"}
+
+	nativePools, err := pools.NewNativeCosmWasmPoolRegistry(map[string][]uint64{
+		pools.ConstantProductNativeCosmWasmPool: {cosmwasmPool.CodeId},
+	})
+	s.Require().NoError(err)
+
+	pool := &sqsdomain.PoolWrapper{
+		ChainModel: cosmwasmPool,
+		SQSModel: sqsdomain.SQSPool{
+			Balances:     balances,
+			PoolDenoms:   balances.Denoms(),
+			SpreadFactor: spreadFactor,
+		},
+	}
+
+	routablePool, err := pools.NewRoutablePool(pool, USDC, ETH, noTakerFee, domain.CosmWasmPoolRouterConfig{
+		NativePools: nativePools,
+	})
+	s.Require().NoError(err)
+
+	tokenOut, err := routablePool.CalculateTokenOutByTokenIn(context.TODO(), tokenIn)
+	s.Require().NoError(err)
+	s.Require().Equal(expectedTokenOut, tokenOut)
+
+	// The token in quoted for the token out swaps out at least the token out.
+	// 19_742_575 / 0.997 = 19_801_980.94, rounded up to 19_801_981
+	// 1_000_000_000 * 19_801_981 / (2_000_000_000 - 19_801_981) = 10_000_000.41, rounded up
+	quotedTokenIn, err := routablePool.CalculateTokenInByTokenOut(context.TODO(), expectedTokenOut)
+	s.Require().NoError(err)
+	s.Require().Equal(sdk.NewCoin(USDC, osmomath.NewInt(10_000_001)), quotedTokenIn)
+
+	tokenOut, err = routablePool.CalculateTokenOutByTokenIn(context.TODO(), quotedTokenIn)
+	s.Require().NoError(err)
+	s.Require().True(tokenOut.Amount.GTE(expectedTokenOut.Amount))
+}
+
+// Tests the validation of the native CosmWasm pool implementations config.
+func (s *RoutablePoolTestSuite) TestNewNativeCosmWasmPoolRegistry() {
+	registry, err := pools.NewNativeCosmWasmPoolRegistry(map[string][]uint64{
+		pools.TransmuterNativeCosmWasmPool:      {1, 2},
+		pools.ConstantProductNativeCosmWasmPool: {3},
+	})
+	s.Require().NoError(err)
+	s.Require().True(registry.IsRegistered(2))
+	s.Require().True(registry.IsRegistered(3))
+	s.Require().False(registry.IsRegistered(4))
+
+	_, err = pools.NewNativeCosmWasmPoolRegistry(map[string][]uint64{"unknown": {1}})
+	s.Require().Error(err)
+
+	_, err = pools.NewNativeCosmWasmPoolRegistry(map[string][]uint64{
+		pools.TransmuterNativeCosmWasmPool:      {1},
+		pools.ConstantProductNativeCosmWasmPool: {1},
+	})
+	s.Require().Error(err)
+}
+
+// newConstantProductRoutablePool creates a routable pool for a CosmWasm pool whose code ID
+// is registered with the constant product native implementation.
+func (s *RoutablePoolTestSuite) newConstantProductRoutablePool(balances sdk.Coins, tokenInDenom, tokenOutDenom string) sqsdomain.RoutablePool {
+	cosmwasmPool := &cwpoolmodel.CosmWasmPool{PoolId: defaultPoolID, CodeId: 773, ContractAddress: "osmo1contract"}
+
+	nativePools, err := pools.NewNativeCosmWasmPoolRegistry(map[string][]uint64{
+		pools.ConstantProductNativeCosmWasmPool: {cosmwasmPool.CodeId},
+	})
+	s.Require().NoError(err)
+
+	mock := &mocks.MockRoutablePool{ChainPoolModel: cosmwasmPool, Balances: balances, PoolType: poolmanagertypes.CosmWasm}
+	routablePool, err := pools.NewRoutablePool(mock, tokenInDenom, tokenOutDenom, noTakerFee, domain.CosmWasmPoolRouterConfig{
+		NativePools: nativePools,
+	})
+	s.Require().NoError(err)
+
+	return routablePool
+}
@@ -0,0 +1,261 @@
+package pools
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	cwpoolmodel "github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/model"
+	"github.com/osmosis-labs/osmosis/v25/x/poolmanager"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
+)
+
+var _ sqsdomain.RoutablePool = &routableOrderbookPoolImpl{}
+
+// routableOrderbookPoolImpl is a native implementation of the limit orderbook CosmWasm pools.
+// The swaps are computed by filling the resting orders of the ingested orderbook model
+// level by level, starting from the best price.
+type routableOrderbookPoolImpl struct {
+	ChainPool      *cwpoolmodel.CosmWasmPool "json:\"pool\""
+	OrderbookModel *sqsdomain.OrderbookModel "json:\"orderbook_model\""
+	TokenInDenom   string                    "json:\"token_in_denom,omitempty\""
+	TokenOutDenom  string                    "json:\"token_out_denom\""
+	TakerFee       osmomath.Dec              "json:\"taker_fee\""
+	SpreadFactor   osmomath.Dec              "json:\"spread_factor\""
+}
+
+// newRoutableOrderbookPool creates a new orderbook routable pool.
+// Returns error if the orderbook model is not set on the pool.
+// It implements domain.NativeCosmWasmPoolFactory.
+func newRoutableOrderbookPool(pool sqsdomain.PoolI, cosmWasmPool *cwpoolmodel.CosmWasmPool, tokenInDenom, tokenOutDenom string, takerFee osmomath.Dec) (sqsdomain.RoutablePool, error) {
+	orderbookModel, err := pool.GetOrderbookModel()
+	if err != nil {
+		return nil, err
+	}
+
+	return &routableOrderbookPoolImpl{
+		ChainPool:      cosmWasmPool,
+		OrderbookModel: orderbookModel,
+		TokenInDenom:   tokenInDenom,
+		TokenOutDenom:  tokenOutDenom,
+		TakerFee:       takerFee,
+		SpreadFactor:   pool.GetSQSPoolModel().SpreadFactor,
+	}, nil
+}
+
+// GetId implements sqsdomain.RoutablePool.
+func (r *routableOrderbookPoolImpl) GetId() uint64 {
+	return r.ChainPool.PoolId
+}
+
+// GetPoolDenoms implements sqsdomain.RoutablePool.
+func (r *routableOrderbookPoolImpl) GetPoolDenoms() []string {
+	return []string{r.OrderbookModel.BaseDenom, r.OrderbookModel.QuoteDenom}
+}
+
+// GetType implements sqsdomain.RoutablePool.
+func (*routableOrderbookPoolImpl) GetType() poolmanagertypes.PoolType {
+	return poolmanagertypes.CosmWasm
+}
+
+// GetSpreadFactor implements sqsdomain.RoutablePool.
+func (r *routableOrderbookPoolImpl) GetSpreadFactor() math.LegacyDec {
+	return r.SpreadFactor
+}
+
+// CalculateTokenOutByTokenIn implements sqsdomain.RoutablePool.
+// Swapping in the base denom fills the bids from the highest price.
+// Swapping in the quote denom fills the asks from the lowest price.
+// Returns error if the token in is not fully consumed by the resting orders.
+func (r *routableOrderbookPoolImpl) CalculateTokenOutByTokenIn(ctx context.Context, tokenIn sdk.Coin) (sdk.Coin, error) {
+	isSellingBase, err := r.isSellingBase(tokenIn.Denom, r.TokenOutDenom)
+	if err != nil {
+		return sdk.Coin{}, err
+	}
+
+	remainingIn := osmomath.BigDecFromSDKInt(tokenIn.Amount)
+	tokenOut := osmomath.ZeroBigDec()
+
+	for _, level := range r.getLevels(isSellingBase) {
+		if !remainingIn.IsPositive() {
+			break
+		}
+
+		price := osmomath.BigDecFromDec(level.Price)
+		quantity := osmomath.BigDecFromSDKInt(level.Quantity)
+
+		// The level quantity is in the token out denom. Convert the remaining
+		// token in to the token out denom at the level price.
+		var remainingInAsOut osmomath.BigDec
+		if isSellingBase {
+			remainingInAsOut = remainingIn.Mul(price)
+		} else {
+			remainingInAsOut = remainingIn.Quo(price)
+		}
+
+		if remainingInAsOut.LTE(quantity) {
+			tokenOut.AddMut(remainingInAsOut)
+			remainingIn = osmomath.ZeroBigDec()
+			break
+		}
+
+		// Fill the whole level.
+		tokenOut.AddMut(quantity)
+		if isSellingBase {
+			remainingIn.SubMut(quantity.Quo(price))
+		} else {
+			remainingIn.SubMut(quantity.Mul(price))
+		}
+	}
+
+	if remainingIn.IsPositive() {
+		return sdk.Coin{}, domain.OrderbookNotEnoughLiquidityToCompleteSwapError{
+			PoolId:   r.GetId(),
+			AmountIn: tokenIn.String(),
+		}
+	}
+
+	return sdk.NewCoin(r.TokenOutDenom, tokenOut.Dec().TruncateInt()), nil
+}
+
+// CalculateTokenInByTokenOut implements sqsdomain.RoutablePool.
+// Swapping out the quote denom fills the bids from the highest price.
+// Swapping out the base denom fills the asks from the lowest price.
+// Returns error if the resting orders cannot provide the token out.
+func (r *routableOrderbookPoolImpl) CalculateTokenInByTokenOut(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error) {
+	isSellingBase, err := r.isSellingBase(r.TokenInDenom, tokenOut.Denom)
+	if err != nil {
+		return sdk.Coin{}, err
+	}
+
+	remainingOut := osmomath.BigDecFromSDKInt(tokenOut.Amount)
+	tokenIn := osmomath.ZeroBigDec()
+
+	for _, level := range r.getLevels(isSellingBase) {
+		if !remainingOut.IsPositive() {
+			break
+		}
+
+		price := osmomath.BigDecFromDec(level.Price)
+		filledOut := osmomath.MinBigDec(remainingOut, osmomath.BigDecFromSDKInt(level.Quantity))
+
+		if isSellingBase {
+			tokenIn.AddMut(filledOut.Quo(price))
+		} else {
+			tokenIn.AddMut(filledOut.Mul(price))
+		}
+		remainingOut.SubMut(filledOut)
+	}
+
+	if remainingOut.IsPositive() {
+		return sdk.Coin{}, domain.OrderbookNotEnoughLiquidityToCompleteSwapInGivenOutError{
+			PoolId:    r.GetId(),
+			AmountOut: tokenOut.String(),
+		}
+	}
+
+	return sdk.NewCoin(r.TokenInDenom, tokenIn.Ceil().Dec().TruncateInt()), nil
+}
+
+// isSellingBase returns true if the swap sells the base denom for the quote denom
+// and false if it buys the base denom with the quote denom.
+// Returns error if the denoms are not the base and quote denoms of the orderbook.
+func (r *routableOrderbookPoolImpl) isSellingBase(tokenInDenom, tokenOutDenom string) (bool, error) {
+	switch {
+	case tokenInDenom == r.OrderbookModel.BaseDenom && tokenOutDenom == r.OrderbookModel.QuoteDenom:
+		return true, nil
+	case tokenInDenom == r.OrderbookModel.QuoteDenom && tokenOutDenom == r.OrderbookModel.BaseDenom:
+		return false, nil
+	default:
+		return false, domain.OrderbookInvalidDenomsError{
+			PoolId:        r.GetId(),
+			TokenInDenom:  tokenInDenom,
+			TokenOutDenom: tokenOutDenom,
+		}
+	}
+}
+
+// getLevels returns the levels filled by the swap in the order of filling.
+func (r *routableOrderbookPoolImpl) getLevels(isSellingBase bool) []sqsdomain.OrderbookLevel {
+	if isSellingBase {
+		return r.OrderbookModel.Bids
+	}
+	return r.OrderbookModel.Asks
+}
+
+// GetTokenInDenom implements RoutablePool.
+func (r *routableOrderbookPoolImpl) GetTokenInDenom() string {
+	return r.TokenInDenom
+}
+
+// GetTokenOutDenom implements RoutablePool.
+func (r *routableOrderbookPoolImpl) GetTokenOutDenom() string {
+	return r.TokenOutDenom
+}
+
+// String implements sqsdomain.RoutablePool.
+func (r *routableOrderbookPoolImpl) String() string {
+	return fmt.Sprintf("pool (%d), pool type (%d) Orderbook CosmWasm, pool denoms (%v), token out (%s)", r.ChainPool.PoolId, poolmanagertypes.CosmWasm, r.GetPoolDenoms(), r.TokenOutDenom)
+}
+
+// ChargeTakerFeeExactIn implements sqsdomain.RoutablePool.
+func (r *routableOrderbookPoolImpl) ChargeTakerFeeExactIn(tokenIn sdk.Coin) (inAmountAfterFee sdk.Coin) {
+	tokenInAfterTakerFee, _ := poolmanager.CalcTakerFeeExactIn(tokenIn, r.GetTakerFee())
+	return tokenInAfterTakerFee
+}
+
+// ChargeTakerFeeExactOut implements sqsdomain.RoutablePool.
+func (r *routableOrderbookPoolImpl) ChargeTakerFeeExactOut(tokenIn sdk.Coin) (inAmountAfterFee sdk.Coin) {
+	tokenInAfterTakerFee, _ := poolmanager.CalcTakerFeeExactOut(tokenIn, r.GetTakerFee())
+	return tokenInAfterTakerFee
+}
+
+// GetTakerFee implements sqsdomain.RoutablePool.
+func (r *routableOrderbookPoolImpl) GetTakerFee() math.LegacyDec {
+	return r.TakerFee
+}
+
+// CalcSpotPrice implements sqsdomain.RoutablePool.
+// The spot price is the mid price between the best bid and the best ask.
+// If only one side of the book has liquidity, its best price is returned.
+func (r *routableOrderbookPoolImpl) CalcSpotPrice(ctx context.Context, baseDenom string, quoteDenom string) (osmomath.BigDec, error) {
+	isSellingBase, err := r.isSellingBase(baseDenom, quoteDenom)
+	if err != nil {
+		return osmomath.BigDec{}, err
+	}
+
+	var midPrice osmomath.BigDec
+	switch bids, asks := r.OrderbookModel.Bids, r.OrderbookModel.Asks; {
+	case len(bids) > 0 && len(asks) > 0:
+		midPrice = osmomath.BigDecFromDec(bids[0].Price.Add(asks[0].Price)).QuoInt64(2)
+	case len(bids) > 0:
+		midPrice = osmomath.BigDecFromDec(bids[0].Price)
+	case len(asks) > 0:
+		midPrice = osmomath.BigDecFromDec(asks[0].Price)
+	default:
+		return osmomath.BigDec{}, fmt.Errorf("orderbook pool (%d) has no liquidity", r.GetId())
+	}
+
+	// The orderbook prices are of the base denom in the quote denom.
+	if !isSellingBase {
+		return osmomath.OneBigDec().Quo(midPrice), nil
+	}
+
+	return midPrice, nil
+}
+
+// IsGeneralizedCosmWasmPool implements sqsdomain.RoutablePool.
+func (*routableOrderbookPoolImpl) IsGeneralizedCosmWasmPool() bool {
+	return false
+}
+
+// GetCodeID implements sqsdomain.RoutablePool.
+func (r *routableOrderbookPoolImpl) GetCodeID() uint64 {
+	return r.ChainPool.CodeId
+}
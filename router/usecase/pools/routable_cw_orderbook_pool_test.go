@@ -0,0 +1,162 @@
+package pools_test
+
+import (
+	"context"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/domain/mocks"
+	"github.com/osmosis-labs/sqs/router/usecase/pools"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	cwpoolmodel "github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/model"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
+)
+
+// defaultOrderbookModel is an ETH/USDC orderbook with two levels on each side.
+var defaultOrderbookModel = &sqsdomain.OrderbookModel{
+	BaseDenom:  ETH,
+	QuoteDenom: USDC,
+	Bids: []sqsdomain.OrderbookLevel{
+		{TickId: -100, Price: osmomath.NewDec(2), Quantity: osmomath.NewInt(100)},
+		{TickId: -200, Price: osmomath.MustNewDecFromStr("1.5"), Quantity: osmomath.NewInt(300)},
+	},
+	Asks: []sqsdomain.OrderbookLevel{
+		{TickId: 100, Price: osmomath.MustNewDecFromStr("2.5"), Quantity: osmomath.NewInt(40)},
+		{TickId: 200, Price: osmomath.NewDec(4), Quantity: osmomath.NewInt(100)},
+	},
+}
+
+// Tests the orderbook quotes walking the price levels in both directions.
+func (s *RoutablePoolTestSuite) TestCalculateTokenOutByTokenIn_Orderbook() {
+	tests := map[string]struct {
+		tokenIn       sdk.Coin
+		tokenOutDenom string
+
+		expectedTokenOut osmomath.Int
+		expectError      error
+	}{
+		"sell base: fills the best bid and part of the next one": {
+			tokenIn:       sdk.NewCoin(ETH, osmomath.NewInt(80)),
+			tokenOutDenom: USDC,
+
+			// 50 ETH fill 100 USDC at 2, the remaining 30 ETH fill 45 USDC at 1.5
+			expectedTokenOut: osmomath.NewInt(145),
+		},
+		"buy base: fills the best ask and part of the next one": {
+			tokenIn:       sdk.NewCoin(USDC, osmomath.NewInt(200)),
+			tokenOutDenom: ETH,
+
+			// 100 USDC fill 40 ETH at 2.5, the remaining 100 USDC fill 25 ETH at 4
+			expectedTokenOut: osmomath.NewInt(65),
+		},
+		"partial fill rounds down": {
+			tokenIn:       sdk.NewCoin(USDC, osmomath.NewInt(3)),
+			tokenOutDenom: ETH,
+
+			// 3 / 2.5 = 1.2
+			expectedTokenOut: osmomath.NewInt(1),
+		},
+		"error: not enough liquidity": {
+			tokenIn:       sdk.NewCoin(ETH, osmomath.NewInt(251)),
+			tokenOutDenom: USDC,
+
+			expectError: domain.OrderbookNotEnoughLiquidityToCompleteSwapError{
+				PoolId:   defaultPoolID,
+				AmountIn: sdk.NewCoin(ETH, osmomath.NewInt(251)).String(),
+			},
+		},
+		"error: denom not in orderbook": {
+			tokenIn:       sdk.NewCoin(USDT, osmomath.NewInt(100)),
+			tokenOutDenom: USDC,
+
+			expectError: domain.OrderbookInvalidDenomsError{
+				PoolId:        defaultPoolID,
+				TokenInDenom:  USDT,
+				TokenOutDenom: USDC,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		s.Run(name, func() {
+			routablePool := s.newOrderbookRoutablePool(defaultOrderbookModel, tc.tokenIn.Denom, tc.tokenOutDenom)
+
+			tokenOut, err := routablePool.CalculateTokenOutByTokenIn(context.TODO(), tc.tokenIn)
+
+			if tc.expectError != nil {
+				s.Require().Error(err)
+				s.Require().ErrorIs(err, tc.expectError)
+				return
+			}
+			s.Require().NoError(err)
+
+			s.Require().Equal(sdk.NewCoin(tc.tokenOutDenom, tc.expectedTokenOut), tokenOut)
+		})
+	}
+}
+
+// Tests the orderbook quotes in the out given in direction and the spot price.
+func (s *RoutablePoolTestSuite) TestCalculateTokenInByTokenOut_Orderbook() {
+	// 100 USDC take 50 ETH at 2, the remaining 45 USDC take 30 ETH at 1.5
+	sellBasePool := s.newOrderbookRoutablePool(defaultOrderbookModel, ETH, USDC)
+	tokenIn, err := sellBasePool.CalculateTokenInByTokenOut(context.TODO(), sdk.NewCoin(USDC, osmomath.NewInt(145)))
+	s.Require().NoError(err)
+	s.Require().Equal(sdk.NewCoin(ETH, osmomath.NewInt(80)), tokenIn)
+
+	// 40 ETH take 100 USDC at 2.5, the remaining 25 ETH take 100 USDC at 4
+	buyBasePool := s.newOrderbookRoutablePool(defaultOrderbookModel, USDC, ETH)
+	tokenIn, err = buyBasePool.CalculateTokenInByTokenOut(context.TODO(), sdk.NewCoin(ETH, osmomath.NewInt(65)))
+	s.Require().NoError(err)
+	s.Require().Equal(sdk.NewCoin(USDC, osmomath.NewInt(200)), tokenIn)
+
+	// Only 140 ETH rest on the asks.
+	_, err = buyBasePool.CalculateTokenInByTokenOut(context.TODO(), sdk.NewCoin(ETH, osmomath.NewInt(141)))
+	s.Require().Error(err)
+
+	// Mid price between the best bid at 2 and the best ask at 2.5.
+	spotPrice, err := buyBasePool.CalcSpotPrice(context.TODO(), ETH, USDC)
+	s.Require().NoError(err)
+	s.Require().Equal(osmomath.MustNewBigDecFromStr("2.25"), spotPrice)
+
+	spotPrice, err = buyBasePool.CalcSpotPrice(context.TODO(), USDC, ETH)
+	s.Require().NoError(err)
+	s.Require().Equal(osmomath.OneBigDec().Quo(osmomath.MustNewBigDecFromStr("2.25")), spotPrice)
+}
+
+// Tests that the orderbook routable pool cannot be created without the orderbook model.
+func (s *RoutablePoolTestSuite) TestNewRoutablePool_OrderbookNoModel() {
+	cosmwasmPool := &cwpoolmodel.CosmWasmPool{PoolId: defaultPoolID, CodeId: 885, ContractAddress: "osmo1orderbook"}
+
+	nativePools, err := pools.NewNativeCosmWasmPoolRegistry(map[string][]uint64{
+		pools.OrderbookNativeCosmWasmPool: {cosmwasmPool.CodeId},
+	})
+	s.Require().NoError(err)
+
+	mock := &mocks.MockRoutablePool{ID: defaultPoolID, ChainPoolModel: cosmwasmPool, PoolType: poolmanagertypes.CosmWasm}
+	_, err = pools.NewRoutablePool(mock, ETH, USDC, noTakerFee, domain.CosmWasmPoolRouterConfig{
+		NativePools: nativePools,
+	})
+	s.Require().ErrorIs(err, sqsdomain.OrderbookPoolNoOrderbookModelError{PoolId: defaultPoolID})
+}
+
+// newOrderbookRoutablePool creates a routable pool for a CosmWasm pool whose code ID
+// is registered with the orderbook native implementation.
+func (s *RoutablePoolTestSuite) newOrderbookRoutablePool(orderbookModel *sqsdomain.OrderbookModel, tokenInDenom, tokenOutDenom string) sqsdomain.RoutablePool {
+	cosmwasmPool := &cwpoolmodel.CosmWasmPool{PoolId: defaultPoolID, CodeId: 885, ContractAddress: "osmo1orderbook"}
+
+	nativePools, err := pools.NewNativeCosmWasmPoolRegistry(map[string][]uint64{
+		pools.OrderbookNativeCosmWasmPool: {cosmwasmPool.CodeId},
+	})
+	s.Require().NoError(err)
+
+	mock := &mocks.MockRoutablePool{ChainPoolModel: cosmwasmPool, OrderbookModel: orderbookModel, PoolType: poolmanagertypes.CosmWasm}
+	routablePool, err := pools.NewRoutablePool(mock, tokenInDenom, tokenOutDenom, noTakerFee, domain.CosmWasmPoolRouterConfig{
+		NativePools: nativePools,
+	})
+	s.Require().NoError(err)
+
+	return routablePool
+}
@@ -3,6 +3,7 @@ package pools
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"cosmossdk.io/math"
 	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
@@ -22,22 +23,38 @@ const (
 	notCosmWasmPoolCodeID = 0
 
 	astroportCodeID = 773
+
+	// maxConcurrentCosmWasmSimulations is the maximum number of the generalized cosmwasm pool
+	// simulations queried from chain concurrently by all batches.
+	maxConcurrentCosmWasmSimulations = 8
 )
 
-var _ sqsdomain.RoutablePool = &routableCosmWasmPoolImpl{}
+var (
+	_ sqsdomain.RoutablePool   = &routableCosmWasmPoolImpl{}
+	_ domain.BatchRoutablePool = &routableCosmWasmPoolImpl{}
+)
 
 // routableCosmWasmPool is an implemenation of the cosm wasm pool
 // that interacts with the chain for quotes and spot price.
 type routableCosmWasmPoolImpl struct {
 	ChainPool     *cwpoolmodel.CosmWasmPool "json:\"pool\""
 	Balances      sdk.Coins                 "json:\"balances\""
+	TokenInDenom  string                    "json:\"token_in_denom,omitempty\""
 	TokenOutDenom string                    "json:\"token_out_denom\""
 	TakerFee      osmomath.Dec              "json:\"taker_fee\""
 	SpreadFactor  osmomath.Dec              "json:\"spread_factor\""
 	wasmClient    wasmtypes.QueryClient     "json:\"-\""
+	// memoized simulations shared by all routable pools over the same block.
+	simulationCache *domain.CosmWasmSimulationCache "json:\"-\""
+	// height the contract is queried at. Zero queries the latest height of the node.
+	height uint64 "json:\"-\""
 }
 
 var (
+	// cosmWasmSimulationSemaphore bounds the batched simulations queried from chain concurrently
+	// so that the split quotes of many requests do not flood the node.
+	cosmWasmSimulationSemaphore = make(chan struct{}, maxConcurrentCosmWasmSimulations)
+
 	// Assumming precision of 6, this is 10 units.
 	// This is naive since precision can be greater but should work for most cases.
 	tenE7 = sdk.NewInt(10_000_000)
@@ -74,6 +91,50 @@ func (r *routableCosmWasmPoolImpl) CalculateTokenOutByTokenIn(ctx context.Contex
 	return r.calculateTokenOutByTokenIn(ctx, tokenIn, r.TokenOutDenom)
 }
 
+// CalculateTokenOutByTokenInBatch implements domain.BatchRoutablePool.
+// The simulations that are not memoized for the state snapshot are queried from chain concurrently.
+// At most maxConcurrentCosmWasmSimulations are in flight across all batches so a batch larger than that
+// takes several network round-trips. Every amount that is not memoized costs a query to chain.
+func (r *routableCosmWasmPoolImpl) CalculateTokenOutByTokenInBatch(ctx context.Context, tokensIn []sdk.Coin) ([]sdk.Coin, []error) {
+	tokensOut := make([]sdk.Coin, len(tokensIn))
+	errs := make([]error, len(tokensIn))
+
+	var wg sync.WaitGroup
+	for i, tokenIn := range tokensIn {
+		if tokenOut, ok := r.simulationCache.GetTokenOut(r.GetId(), tokenIn, r.TokenOutDenom); ok {
+			tokensOut[i] = tokenOut
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, tokenIn sdk.Coin) {
+			defer wg.Done()
+
+			select {
+			case cosmWasmSimulationSemaphore <- struct{}{}:
+				defer func() { <-cosmWasmSimulationSemaphore }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+
+			defer func() {
+				if r := recover(); r != nil {
+					tokensOut[i] = sdk.Coin{}
+					errs[i] = fmt.Errorf("error when calculating out by in in cosmwasm pool: %v", r)
+				}
+			}()
+
+			tokensOut[i], errs[i] = r.calculateTokenOutByTokenIn(ctx, tokenIn, r.TokenOutDenom)
+		}(i, tokenIn)
+	}
+	wg.Wait()
+
+	return tokensOut, errs
+}
+
+// calculateTokenOutByTokenIn returns the memoized simulation of swapping the token in for the token out denom
+// if present. Otherwise, queries the pool contract and memoizes the result.
 func (r *routableCosmWasmPoolImpl) calculateTokenOutByTokenIn(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (sdk.Coin, error) {
 	poolType := r.GetType()
 
@@ -82,19 +143,52 @@ func (r *routableCosmWasmPoolImpl) calculateTokenOutByTokenIn(ctx context.Contex
 		return sdk.Coin{}, domain.InvalidPoolTypeError{PoolType: int32(poolType)}
 	}
 
+	if tokenOut, ok := r.simulationCache.GetTokenOut(r.GetId(), tokenIn, tokenOutDenom); ok {
+		return tokenOut, nil
+	}
+
 	// Configure the calc query message
 	calcMessage := msg.NewCalcOutAmtGivenInRequest(tokenIn, tokenOutDenom, r.SpreadFactor)
 
 	calcOutAmtGivenInResponse := msg.CalcOutAmtGivenInResponse{}
-	if err := queryCosmwasmContract(ctx, r.wasmClient, r.ChainPool.ContractAddress, &calcMessage, &calcOutAmtGivenInResponse); err != nil {
+	if err := queryCosmwasmContract(withQueryHeight(ctx, r.height), r.wasmClient, r.ChainPool.ContractAddress, &calcMessage, &calcOutAmtGivenInResponse); err != nil {
 		return sdk.Coin{}, err
 	}
 
+	r.simulationCache.SetTokenOut(r.GetId(), tokenIn, tokenOutDenom, calcOutAmtGivenInResponse.TokenOut)
+
 	// No slippage swaps - just return the same amount of token out as token in
 	// as long as there is enough liquidity in the pool.
 	return calcOutAmtGivenInResponse.TokenOut, nil
 }
 
+// CalculateTokenInByTokenOut implements sqsdomain.RoutablePool.
+// It queries the pool contract for the amount of token in required for the given token out.
+// Returns error if the pool is not of cosmwasm type or if the query fails.
+func (r *routableCosmWasmPoolImpl) CalculateTokenInByTokenOut(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error) {
+	poolType := r.GetType()
+
+	// Ensure that the pool is cosmwasm
+	if poolType != poolmanagertypes.CosmWasm {
+		return sdk.Coin{}, domain.InvalidPoolTypeError{PoolType: int32(poolType)}
+	}
+
+	// Configure the calc query message
+	calcMessage := msg.NewCalcInAmtGivenOutRequest(r.TokenInDenom, tokenOut, r.SpreadFactor)
+
+	calcInAmtGivenOutResponse := msg.CalcInAmtGivenOutResponse{}
+	if err := queryCosmwasmContract(withQueryHeight(ctx, r.height), r.wasmClient, r.ChainPool.ContractAddress, &calcMessage, &calcInAmtGivenOutResponse); err != nil {
+		return sdk.Coin{}, err
+	}
+
+	return calcInAmtGivenOutResponse.TokenIn, nil
+}
+
+// GetTokenInDenom implements RoutablePool.
+func (r *routableCosmWasmPoolImpl) GetTokenInDenom() string {
+	return r.TokenInDenom
+}
+
 // GetTokenOutDenom implements RoutablePool.
 func (r *routableCosmWasmPoolImpl) GetTokenOutDenom() string {
 	return r.TokenOutDenom
@@ -112,6 +206,12 @@ func (r *routableCosmWasmPoolImpl) ChargeTakerFeeExactIn(tokenIn sdk.Coin) (inAm
 	return tokenInAfterTakerFee
 }
 
+// ChargeTakerFeeExactOut implements sqsdomain.RoutablePool.
+func (r *routableCosmWasmPoolImpl) ChargeTakerFeeExactOut(tokenIn sdk.Coin) (inAmountAfterFee sdk.Coin) {
+	tokenInAfterTakerFee, _ := poolmanager.CalcTakerFeeExactOut(tokenIn, r.GetTakerFee())
+	return tokenInAfterTakerFee
+}
+
 // GetTakerFee implements sqsdomain.RoutablePool.
 func (r *routableCosmWasmPoolImpl) GetTakerFee() math.LegacyDec {
 	return r.TakerFee
@@ -148,7 +248,7 @@ func (r *routableCosmWasmPoolImpl) CalcSpotPrice(ctx context.Context, baseDenom
 	}
 
 	response := &msg.SpotPriceQueryMsgResponse{}
-	if err := queryCosmwasmContract(ctx, r.wasmClient, r.ChainPool.ContractAddress, &request, response); err != nil {
+	if err := queryCosmwasmContract(withQueryHeight(ctx, r.height), r.wasmClient, r.ChainPool.ContractAddress, &request, response); err != nil {
 		return osmomath.BigDec{}, err
 	}
 
@@ -0,0 +1,207 @@
+package pools_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/router/usecase/pools"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/cosmwasm/msg"
+	cwpoolmodel "github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/model"
+)
+
+// doublingWasmClient is a wasm query client simulating swaps that return
+// twice the token in amount. Token in amounts above maxAmountIn fail.
+// Every query takes queryDuration and the maximum number of concurrent queries is tracked.
+type doublingWasmClient struct {
+	wasmtypes.QueryClient
+
+	tokenOutDenom string
+	maxAmountIn   osmomath.Int
+	queryDuration time.Duration
+
+	numQueries     atomic.Int32
+	numInFlight    atomic.Int32
+	maxNumInFlight atomic.Int32
+
+	// height header of the latest query. Empty if it is not set.
+	lastQueryHeight atomic.Value
+}
+
+func (c *doublingWasmClient) SmartContractState(ctx context.Context, req *wasmtypes.QuerySmartContractStateRequest, _ ...grpc.CallOption) (*wasmtypes.QuerySmartContractStateResponse, error) {
+	c.numQueries.Add(1)
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	c.lastQueryHeight.Store(strings.Join(md.Get(grpctypes.GRPCBlockHeightHeader), ","))
+
+	numInFlight := c.numInFlight.Add(1)
+	defer c.numInFlight.Add(-1)
+	for maxNumInFlight := c.maxNumInFlight.Load(); numInFlight > maxNumInFlight; maxNumInFlight = c.maxNumInFlight.Load() {
+		if c.maxNumInFlight.CompareAndSwap(maxNumInFlight, numInFlight) {
+			break
+		}
+	}
+
+	time.Sleep(c.queryDuration)
+
+	request := msg.CalcOutAmtGivenInRequest{}
+	if err := json.Unmarshal(req.QueryData, &request); err != nil {
+		return nil, err
+	}
+
+	tokenIn := request.CalcOutAmtGivenIn.TokenIn
+	if tokenIn.Amount.GT(c.maxAmountIn) {
+		return nil, errors.New("insufficient liquidity")
+	}
+
+	bz, err := json.Marshal(msg.CalcOutAmtGivenInResponse{
+		TokenOut: sdk.NewCoin(c.tokenOutDenom, tokenIn.Amount.MulRaw(2)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &wasmtypes.QuerySmartContractStateResponse{Data: bz}, nil
+}
+
+// Tests that the generalized cosmwasm pool simulations are memoized and batched.
+func (s *RoutablePoolTestSuite) TestCalculateTokenOutByTokenIn_CosmWasm_Memoized() {
+	const poolID = 1
+
+	var (
+		ctx = context.Background()
+
+		chainPool = &cwpoolmodel.CosmWasmPool{PoolId: poolID, ContractAddress: "osmo1contract"}
+		balances  = sdk.NewCoins(sdk.NewCoin(USDC, DefaultAmt0), sdk.NewCoin(ETH, DefaultAmt0))
+
+		maxAmountIn = osmomath.NewInt(1_000)
+	)
+
+	s.Run("single simulation is memoized", func() {
+		wasmClient := &doublingWasmClient{tokenOutDenom: ETH, maxAmountIn: maxAmountIn}
+		simulationCache := domain.NewCosmWasmSimulationCache()
+		routablePool := pools.NewRoutableCosmWasmPool(chainPool, balances, ETH, wasmClient, simulationCache)
+
+		tokenIn := sdk.NewCoin(USDC, osmomath.NewInt(100))
+
+		for i := 0; i < 3; i++ {
+			tokenOut, err := routablePool.CalculateTokenOutByTokenIn(ctx, tokenIn)
+			s.Require().NoError(err)
+			s.Require().Equal(sdk.NewCoin(ETH, osmomath.NewInt(200)), tokenOut)
+		}
+		s.Require().Equal(int32(1), wasmClient.numQueries.Load())
+
+		// Another routable pool over the same block shares the memoized simulations.
+		otherRoutablePool := pools.NewRoutableCosmWasmPool(chainPool, balances, ETH, wasmClient, simulationCache)
+		_, err := otherRoutablePool.CalculateTokenOutByTokenIn(ctx, tokenIn)
+		s.Require().NoError(err)
+		s.Require().Equal(int32(1), wasmClient.numQueries.Load())
+
+		// The simulations are queried again against the snapshot of the next block.
+		snapshot := domain.NewStateSnapshot(1, nil, nil, nil)
+		nextSnapshot := snapshot.WithHeight(2)
+		nextRoutablePool := pools.NewRoutableCosmWasmPool(chainPool, balances, ETH, wasmClient, nextSnapshot.GetCosmWasmSimulationCache())
+		_, err = nextRoutablePool.CalculateTokenOutByTokenIn(ctx, tokenIn)
+		s.Require().NoError(err)
+		s.Require().Equal(int32(2), wasmClient.numQueries.Load())
+
+		// A late simulation against the previous snapshot is not observed by the next one.
+		previousRoutablePool := pools.NewRoutableCosmWasmPool(chainPool, balances, ETH, wasmClient, snapshot.GetCosmWasmSimulationCache())
+		_, err = previousRoutablePool.CalculateTokenOutByTokenIn(ctx, sdk.NewCoin(USDC, osmomath.NewInt(300)))
+		s.Require().NoError(err)
+		s.Require().Equal(1, snapshot.GetCosmWasmSimulationCache().Len())
+		s.Require().Equal(1, nextSnapshot.GetCosmWasmSimulationCache().Len())
+	})
+
+	s.Run("simulations are queried at the height of the snapshot", func() {
+		wasmClient := &doublingWasmClient{tokenOutDenom: ETH, maxAmountIn: maxAmountIn}
+		routablePool := pools.NewRoutableCosmWasmPool(chainPool, balances, ETH, wasmClient, domain.NewCosmWasmSimulationCache())
+
+		// The latest height of the node is queried if the height is not known.
+		_, err := routablePool.CalculateTokenOutByTokenIn(ctx, sdk.NewCoin(USDC, osmomath.NewInt(100)))
+		s.Require().NoError(err)
+		s.Require().Equal("", wasmClient.lastQueryHeight.Load())
+
+		routablePool.SetHeight(5)
+
+		_, err = routablePool.CalculateTokenOutByTokenIn(ctx, sdk.NewCoin(USDC, osmomath.NewInt(200)))
+		s.Require().NoError(err)
+		s.Require().Equal("5", wasmClient.lastQueryHeight.Load())
+	})
+
+	s.Run("failed simulations are not memoized", func() {
+		wasmClient := &doublingWasmClient{tokenOutDenom: ETH, maxAmountIn: maxAmountIn}
+		simulationCache := domain.NewCosmWasmSimulationCache()
+		routablePool := pools.NewRoutableCosmWasmPool(chainPool, balances, ETH, wasmClient, simulationCache)
+
+		_, err := routablePool.CalculateTokenOutByTokenIn(ctx, sdk.NewCoin(USDC, maxAmountIn.AddRaw(1)))
+		s.Require().Error(err)
+		s.Require().Zero(simulationCache.Len())
+	})
+
+	s.Run("batch", func() {
+		wasmClient := &doublingWasmClient{tokenOutDenom: ETH, maxAmountIn: maxAmountIn}
+		simulationCache := domain.NewCosmWasmSimulationCache()
+		routablePool := pools.NewRoutableCosmWasmPool(chainPool, balances, ETH, wasmClient, simulationCache)
+
+		// Memoize one of the amounts upfront.
+		_, err := routablePool.CalculateTokenOutByTokenIn(ctx, sdk.NewCoin(USDC, osmomath.NewInt(300)))
+		s.Require().NoError(err)
+
+		tokensIn := []sdk.Coin{
+			sdk.NewCoin(USDC, osmomath.NewInt(100)),
+			sdk.NewCoin(USDC, osmomath.NewInt(300)),
+			sdk.NewCoin(USDC, maxAmountIn.AddRaw(1)),
+			sdk.NewCoin(USDC, osmomath.NewInt(500)),
+		}
+
+		// System under test.
+		tokensOut, errs := routablePool.CalculateTokenOutByTokenInBatch(ctx, tokensIn)
+
+		s.Require().Len(tokensOut, len(tokensIn))
+		s.Require().Len(errs, len(tokensIn))
+
+		s.Require().NoError(errs[0])
+		s.Require().Equal(sdk.NewCoin(ETH, osmomath.NewInt(200)), tokensOut[0])
+		s.Require().NoError(errs[1])
+		s.Require().Equal(sdk.NewCoin(ETH, osmomath.NewInt(600)), tokensOut[1])
+		s.Require().Error(errs[2])
+		s.Require().NoError(errs[3])
+		s.Require().Equal(sdk.NewCoin(ETH, osmomath.NewInt(1_000)), tokensOut[3])
+
+		// One query upfront and one for each of the three amounts that were not memoized.
+		s.Require().Equal(int32(4), wasmClient.numQueries.Load())
+		s.Require().Equal(3, simulationCache.Len())
+	})
+
+	s.Run("batch concurrency is bounded", func() {
+		wasmClient := &doublingWasmClient{tokenOutDenom: ETH, maxAmountIn: maxAmountIn, queryDuration: 10 * time.Millisecond}
+		routablePool := pools.NewRoutableCosmWasmPool(chainPool, balances, ETH, wasmClient, domain.NewCosmWasmSimulationCache())
+
+		tokensIn := make([]sdk.Coin, 3*pools.MaxConcurrentCosmWasmSimulations)
+		for i := range tokensIn {
+			tokensIn[i] = sdk.NewCoin(USDC, osmomath.NewInt(int64(i+1)))
+		}
+
+		// System under test.
+		_, errs := routablePool.CalculateTokenOutByTokenInBatch(ctx, tokensIn)
+		for _, err := range errs {
+			s.Require().NoError(err)
+		}
+
+		s.Require().Equal(int32(len(tokensIn)), wasmClient.numQueries.Load())
+		s.Require().LessOrEqual(wasmClient.maxNumInFlight.Load(), int32(pools.MaxConcurrentCosmWasmSimulations))
+	})
+}
@@ -16,16 +16,33 @@ import (
 	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
 )
 
-var _ sqsdomain.RoutablePool = &routableTransmuterPoolImpl{}
+var (
+	_ sqsdomain.RoutablePool      = &routableTransmuterPoolImpl{}
+	_ domain.StatefulRoutablePool = &routableTransmuterPoolImpl{}
+)
 
 type routableTransmuterPoolImpl struct {
 	ChainPool     *cwpoolmodel.CosmWasmPool "json:\"pool\""
 	Balances      sdk.Coins                 "json:\"balances\""
+	TokenInDenom  string                    "json:\"token_in_denom,omitempty\""
 	TokenOutDenom string                    "json:\"token_out_denom\""
 	TakerFee      osmomath.Dec              "json:\"taker_fee\""
 	SpreadFactor  osmomath.Dec              "json:\"spread_factor\""
 }
 
+// newRoutableTransmuterPool creates a new transmuter routable pool.
+// It implements domain.NativeCosmWasmPoolFactory.
+func newRoutableTransmuterPool(pool sqsdomain.PoolI, cosmWasmPool *cwpoolmodel.CosmWasmPool, tokenInDenom, tokenOutDenom string, takerFee osmomath.Dec) (sqsdomain.RoutablePool, error) {
+	return &routableTransmuterPoolImpl{
+		ChainPool:     cosmWasmPool,
+		Balances:      pool.GetSQSPoolModel().Balances,
+		TokenInDenom:  tokenInDenom,
+		TokenOutDenom: tokenOutDenom,
+		TakerFee:      takerFee,
+		SpreadFactor:  pool.GetSQSPoolModel().SpreadFactor,
+	}, nil
+}
+
 // GetId implements sqsdomain.RoutablePool.
 func (r *routableTransmuterPoolImpl) GetId() uint64 {
 	return r.ChainPool.PoolId
@@ -74,6 +91,49 @@ func (r *routableTransmuterPoolImpl) CalculateTokenOutByTokenIn(ctx context.Cont
 	return sdk.Coin{r.TokenOutDenom, tokenIn.Amount}, nil
 }
 
+// SwapOutGivenIn implements domain.StatefulRoutablePool.
+// The token in is added to the balances and the token out is removed from them.
+func (r *routableTransmuterPoolImpl) SwapOutGivenIn(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (sdk.Coin, domain.StatefulRoutablePool, error) {
+	// Validate token out balance
+	if err := validateBalance(tokenIn.Amount, r.Balances, tokenOutDenom); err != nil {
+		return sdk.Coin{}, nil, err
+	}
+
+	tokenOut := sdk.NewCoin(tokenOutDenom, tokenIn.Amount)
+
+	pool := *r
+	pool.Balances = r.Balances.Add(tokenIn).Sub(tokenOut)
+
+	return tokenOut, &pool, nil
+}
+
+// CalculateTokenInByTokenOut implements sqsdomain.RoutablePool.
+// It calculates the amount of token in required for the given token out for a transmuter pool.
+// Similarly to CalculateTokenOutByTokenIn, it returns the same amount of token in as token out.
+// Returns error if:
+// - the underlying chain pool set on the routable pool is not of transmuter type
+// - the token out amount is greater than the balance of the token out
+func (r *routableTransmuterPoolImpl) CalculateTokenInByTokenOut(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error) {
+	poolType := r.GetType()
+
+	// Ensure that the pool is cosmwasm
+	if poolType != poolmanagertypes.CosmWasm {
+		return sdk.Coin{}, domain.InvalidPoolTypeError{PoolType: int32(poolType)}
+	}
+
+	// Validate token out balance
+	if err := validateBalance(tokenOut.Amount, r.Balances, tokenOut.Denom); err != nil {
+		return sdk.Coin{}, err
+	}
+
+	return sdk.Coin{Denom: r.TokenInDenom, Amount: tokenOut.Amount}, nil
+}
+
+// GetTokenInDenom implements RoutablePool.
+func (r *routableTransmuterPoolImpl) GetTokenInDenom() string {
+	return r.TokenInDenom
+}
+
 // GetTokenOutDenom implements RoutablePool.
 func (r *routableTransmuterPoolImpl) GetTokenOutDenom() string {
 	return r.TokenOutDenom
@@ -90,6 +150,12 @@ func (r *routableTransmuterPoolImpl) ChargeTakerFeeExactIn(tokenIn sdk.Coin) (in
 	return tokenInAfterTakerFee
 }
 
+// ChargeTakerFeeExactOut implements sqsdomain.RoutablePool.
+func (r *routableTransmuterPoolImpl) ChargeTakerFeeExactOut(tokenIn sdk.Coin) (inAmountAfterFee sdk.Coin) {
+	tokenInAfterTakerFee, _ := poolmanager.CalcTakerFeeExactOut(tokenIn, r.GetTakerFee())
+	return tokenInAfterTakerFee
+}
+
 // validateBalance validates that the balance of the denom to validate is greater than the token in amount.
 // Returns nil on success, error otherwise.
 func validateBalance(tokenInAmount osmomath.Int, balances sdk.Coins, denomToValidate string) error {
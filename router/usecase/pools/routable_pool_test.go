@@ -93,7 +93,7 @@ func (s *RoutablePoolTestSuite) TestCalculateTokenOutByTokenIn_CFMM() {
 			s.Require().NoError(err)
 
 			mock := &mocks.MockRoutablePool{ChainPoolModel: pool, PoolType: tc.poolType}
-			routablePool, err := pools.NewRoutablePool(mock, tc.tokenOutDenom, noTakerFee, domain.CosmWasmPoolRouterConfig{})
+			routablePool, err := pools.NewRoutablePool(mock, tc.tokenIn.Denom, tc.tokenOutDenom, noTakerFee, domain.CosmWasmPoolRouterConfig{})
 			s.Require().NoError(err)
 
 			tokenOut, err := routablePool.CalculateTokenOutByTokenIn(context.TODO(), tc.tokenIn)
@@ -111,3 +111,51 @@ func (s *RoutablePoolTestSuite) TestCalculateTokenOutByTokenIn_CFMM() {
 		})
 	}
 }
+
+// Test exact amount out quote logic over a specific pool that is of CFMM type.
+// Validates that the computed token in is sufficient to receive the token out.
+func (s *RoutablePoolTestSuite) TestCalculateTokenInByTokenOut_CFMM() {
+	tests := map[string]struct {
+		tokenOut     sdk.Coin
+		tokenInDenom string
+		poolType     poolmanagertypes.PoolType
+	}{
+		"balancer pool - valid calculation": {
+			tokenOut:     sdk.NewCoin("bar", sdk.NewInt(100)),
+			tokenInDenom: "foo",
+			poolType:     poolmanagertypes.Balancer,
+		},
+		"stableswap pool - valid calculation": {
+			tokenOut:     sdk.NewCoin("bar", sdk.NewInt(100)),
+			tokenInDenom: "foo",
+			poolType:     poolmanagertypes.Stableswap,
+		},
+	}
+
+	for name, tc := range tests {
+		s.Run(name, func() {
+			s.Setup()
+
+			poolID := s.CreatePoolFromType(tc.poolType)
+			pool, err := s.App.PoolManagerKeeper.GetPool(s.Ctx, poolID)
+			s.Require().NoError(err)
+
+			mock := &mocks.MockRoutablePool{ChainPoolModel: pool, PoolType: tc.poolType}
+			routablePool, err := pools.NewRoutablePool(mock, tc.tokenInDenom, tc.tokenOut.Denom, noTakerFee, domain.CosmWasmPoolRouterConfig{})
+			s.Require().NoError(err)
+
+			tokenIn, err := routablePool.CalculateTokenInByTokenOut(context.TODO(), tc.tokenOut)
+			s.Require().NoError(err)
+			s.Require().Equal(tc.tokenInDenom, tokenIn.Denom)
+			s.Require().True(tokenIn.IsPositive())
+
+			// Swapping the computed token in must yield at least the token out.
+			routablePool, err = pools.NewRoutablePool(mock, tc.tokenInDenom, tc.tokenOut.Denom, noTakerFee, domain.CosmWasmPoolRouterConfig{})
+			s.Require().NoError(err)
+
+			tokenOut, err := routablePool.CalculateTokenOutByTokenIn(context.TODO(), tokenIn)
+			s.Require().NoError(err)
+			s.Require().True(tokenOut.Amount.GTE(tc.tokenOut.Amount))
+		})
+	}
+}
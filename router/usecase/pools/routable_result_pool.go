@@ -32,6 +32,8 @@ type routableResultPoolImpl struct {
 	TokenOutDenom string                    "json:\"token_out_denom\""
 	TakerFee      osmomath.Dec              "json:\"taker_fee\""
 	CodeID        uint64                    "json:\"code_id,omitempty\""
+	// SwapBreakdown is the breakdown of the swap over the pool in the quote.
+	SwapBreakdown *domain.PoolSwapBreakdown "json:\"swap_breakdown,omitempty\""
 }
 
 // GetCodeID implements sqsdomain.RoutablePool.
@@ -51,6 +53,20 @@ func NewRoutableResultPool(ID uint64, poolType poolmanagertypes.PoolType, spread
 	}
 }
 
+// NewRoutableResultPoolWithSwapBreakdown returns the new routable result pool with the given parameters
+// and the breakdown of the swap over the pool.
+func NewRoutableResultPoolWithSwapBreakdown(ID uint64, poolType poolmanagertypes.PoolType, spreadFactor osmomath.Dec, tokenOutDenom string, takerFee osmomath.Dec, codeID uint64, swapBreakdown domain.PoolSwapBreakdown) sqsdomain.RoutablePool {
+	return &routableResultPoolImpl{
+		ID:            ID,
+		Type:          poolType,
+		SpreadFactor:  spreadFactor,
+		TokenOutDenom: tokenOutDenom,
+		TakerFee:      takerFee,
+		CodeID:        codeID,
+		SwapBreakdown: &swapBreakdown,
+	}
+}
+
 // GetId implements sqsdomain.RoutablePool.
 func (r *routableResultPoolImpl) GetId() uint64 {
 	return r.ID
@@ -105,6 +121,17 @@ func (r *routableResultPoolImpl) CalculateTokenOutByTokenIn(ctx context.Context,
 	return sdk.Coin{}, errors.New("not implemented")
 }
 
+// CalculateTokenInByTokenOut implements RoutablePool.
+func (r *routableResultPoolImpl) CalculateTokenInByTokenOut(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error) {
+	return sdk.Coin{}, errors.New("not implemented")
+}
+
+// GetTokenInDenom implements RoutablePool.
+// Result pools do not track the token in denom.
+func (r *routableResultPoolImpl) GetTokenInDenom() string {
+	return ""
+}
+
 // GetTokenOutDenom implements RoutablePool.
 func (r *routableResultPoolImpl) GetTokenOutDenom() string {
 	return r.TokenOutDenom
@@ -122,6 +149,12 @@ func (r *routableResultPoolImpl) ChargeTakerFeeExactIn(tokenIn sdk.Coin) (tokenI
 	return tokenInAfterTakerFee
 }
 
+// ChargeTakerFeeExactOut implements sqsdomain.RoutablePool.
+func (r *routableResultPoolImpl) ChargeTakerFeeExactOut(tokenIn sdk.Coin) (tokenInAfterFee sdk.Coin) {
+	tokenInAfterTakerFee, _ := poolmanager.CalcTakerFeeExactOut(tokenIn, r.TakerFee)
+	return tokenInAfterTakerFee
+}
+
 // GetTakerFee implements sqsdomain.RoutablePool.
 func (r *routableResultPoolImpl) GetTakerFee() math.LegacyDec {
 	return r.TakerFee
@@ -132,6 +165,11 @@ func (r *routableResultPoolImpl) GetBalances() sdk.Coins {
 	return r.Balances
 }
 
+// GetSwapBreakdown implements domain.RoutableResultPool.
+func (r *routableResultPoolImpl) GetSwapBreakdown() *domain.PoolSwapBreakdown {
+	return r.SwapBreakdown
+}
+
 // SetTokenOutDenom implements sqsdomain.RoutablePool.
 func (r *routableResultPoolImpl) SetTokenOutDenom(tokenOutDenom string) {
 	r.TokenOutDenom = tokenOutDenom
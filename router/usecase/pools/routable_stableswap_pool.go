@@ -8,6 +8,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
 	"github.com/osmosis-labs/sqs/sqsdomain"
 
 	"github.com/osmosis-labs/osmosis/v25/x/poolmanager"
@@ -16,10 +17,14 @@ import (
 	"github.com/osmosis-labs/osmosis/v25/x/gamm/pool-models/stableswap"
 )
 
-var _ sqsdomain.RoutablePool = &routableStableswapPoolImpl{}
+var (
+	_ sqsdomain.RoutablePool      = &routableStableswapPoolImpl{}
+	_ domain.StatefulRoutablePool = &routableStableswapPoolImpl{}
+)
 
 type routableStableswapPoolImpl struct {
 	ChainPool     *stableswap.Pool "json:\"pool\""
+	TokenInDenom  string           "json:\"token_in_denom,omitempty\""
 	TokenOutDenom string           "json:\"token_out_denom\""
 	TakerFee      osmomath.Dec     "json:\"taker_fee\""
 }
@@ -34,6 +39,40 @@ func (r *routableStableswapPoolImpl) CalculateTokenOutByTokenIn(ctx context.Cont
 	return tokenOut, nil
 }
 
+// SwapOutGivenIn implements domain.StatefulRoutablePool.
+// The swap is applied to a copy of the chain pool so that the receiver is not mutated.
+func (r *routableStableswapPoolImpl) SwapOutGivenIn(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (sdk.Coin, domain.StatefulRoutablePool, error) {
+	// Note that the pool liquidity is replaced rather than updated in place by the swap.
+	chainPool := *r.ChainPool
+
+	tokenOut, err := chainPool.SwapOutAmtGivenIn(sdk.Context{}, sdk.Coins{tokenIn}, tokenOutDenom, r.GetSpreadFactor())
+	if err != nil {
+		return sdk.Coin{}, nil, err
+	}
+
+	return tokenOut, &routableStableswapPoolImpl{
+		ChainPool:     &chainPool,
+		TokenInDenom:  r.TokenInDenom,
+		TokenOutDenom: r.TokenOutDenom,
+		TakerFee:      r.TakerFee,
+	}, nil
+}
+
+// CalculateTokenInByTokenOut implements RoutablePool.
+func (r *routableStableswapPoolImpl) CalculateTokenInByTokenOut(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error) {
+	tokenIn, err := r.ChainPool.CalcInAmtGivenOut(sdk.Context{}, sdk.Coins{tokenOut}, r.TokenInDenom, r.GetSpreadFactor())
+	if err != nil {
+		return sdk.Coin{}, err
+	}
+
+	return tokenIn, nil
+}
+
+// GetTokenInDenom implements RoutablePool.
+func (r *routableStableswapPoolImpl) GetTokenInDenom() string {
+	return r.TokenInDenom
+}
+
 // GetTokenOutDenom implements RoutablePool.
 func (r *routableStableswapPoolImpl) GetTokenOutDenom() string {
 	return r.TokenOutDenom
@@ -51,6 +90,13 @@ func (r *routableStableswapPoolImpl) ChargeTakerFeeExactIn(tokenIn sdk.Coin) (to
 	return tokenInAfterTakerFee
 }
 
+// ChargeTakerFeeExactOut implements sqsdomain.RoutablePool.
+// Returns the token in amount that, after the taker fee is charged, equals the given token in.
+func (r *routableStableswapPoolImpl) ChargeTakerFeeExactOut(tokenIn sdk.Coin) (tokenInAfterFee sdk.Coin) {
+	tokenInAfterTakerFee, _ := poolmanager.CalcTakerFeeExactOut(tokenIn, r.TakerFee)
+	return tokenInAfterTakerFee
+}
+
 // GetTakerFee implements sqsdomain.RoutablePool.
 func (r *routableStableswapPoolImpl) GetTakerFee() math.LegacyDec {
 	return r.TakerFee
@@ -20,6 +20,11 @@ type quoteImpl struct {
 	EffectiveFee            osmomath.Dec        "json:\"effective_fee\""
 	PriceImpact             osmomath.Dec        "json:\"price_impact\""
 	InBaseOutQuoteSpotPrice osmomath.Dec        "json:\"in_base_out_quote_spot_price\""
+	// Truncated is true if the quote search was cut short by the latency budget.
+	// In that case, the quote is the best one found before the deadline.
+	Truncated bool "json:\"truncated,omitempty\""
+	// EstimatedGas is the estimated gas of executing the quote across all routes.
+	EstimatedGas uint64 "json:\"estimated_gas\""
 }
 
 var (
@@ -34,6 +39,8 @@ var _ domain.Quote = &quoteImpl{}
 // Specifically:
 // It strips away unnecessary fields from each pool in the route.
 // Computes an effective spread factor from all routes.
+// The routes are prepared in order, swapping over the shared pools in the state left by the previous
+// routes, the same way they are simulated jointly when split.
 //
 // Returns the updated route and the effective spread factor.
 func (q *quoteImpl) PrepareResult(ctx context.Context, scalingFactor osmomath.Dec) ([]domain.SplitRoute, osmomath.Dec, error) {
@@ -45,6 +52,9 @@ func (q *quoteImpl) PrepareResult(ctx context.Context, scalingFactor osmomath.De
 
 	resultRoutes := make([]domain.SplitRoute, 0, len(q.Route))
 
+	// The state of every stateful pool swapped over so far keyed by the pool ID.
+	poolStates := make(map[uint64]domain.StatefulRoutablePool)
+
 	for _, curRoute := range q.Route {
 		routeTotalFee := osmomath.ZeroDec()
 		routeAmountInFraction := curRoute.GetAmountIn().ToLegacyDec().Quo(totalAmountIn)
@@ -66,7 +76,7 @@ func (q *quoteImpl) PrepareResult(ctx context.Context, scalingFactor osmomath.De
 		totalFeeAcrossRoutes.AddMut(routeTotalFee.MulMut(routeAmountInFraction))
 
 		amountInFraction := q.AmountIn.Amount.ToLegacyDec().MulMut(routeAmountInFraction).TruncateInt()
-		newPools, routeSpotPriceInBaseOutQuote, effectiveSpotPriceInBaseOutQuote, err := curRoute.PrepareResultPools(ctx, sdk.NewCoin(q.AmountIn.Denom, amountInFraction))
+		newPools, routeSpotPriceInBaseOutQuote, effectiveSpotPriceInBaseOutQuote, err := curRoute.PrepareResultPools(ctx, sdk.NewCoin(q.AmountIn.Denom, amountInFraction), poolStates)
 		if err != nil {
 			return nil, osmomath.Dec{}, err
 		}
@@ -79,8 +89,9 @@ func (q *quoteImpl) PrepareResult(ctx context.Context, scalingFactor osmomath.De
 				Pools:                      newPools,
 				HasGeneralizedCosmWasmPool: curRoute.ContainsGeneralizedCosmWasmPool(),
 			},
-			InAmount:  curRoute.GetAmountIn(),
-			OutAmount: curRoute.GetAmountOut(),
+			InAmount:     curRoute.GetAmountIn(),
+			OutAmount:    curRoute.GetAmountOut(),
+			EstimatedGas: curRoute.GetEstimatedGas(),
 		})
 	}
 
@@ -129,6 +140,11 @@ func (q *quoteImpl) String() string {
 	return builder.String()
 }
 
+// GetEstimatedGas implements domain.Quote.
+func (q *quoteImpl) GetEstimatedGas() uint64 {
+	return q.EstimatedGas
+}
+
 // GetPriceImpact implements domain.Quote.
 func (q *quoteImpl) GetPriceImpact() osmomath.Dec {
 	return q.PriceImpact
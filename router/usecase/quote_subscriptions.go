@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/osmosis-labs/sqs/domain"
+)
+
+var (
+	// sqs_router_quote_subscriptions
+	//
+	// gauge that tracks the number of live quote subscriptions.
+	quoteSubscriptionsGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sqs_router_quote_subscriptions",
+			Help: "gauge that tracks the number of live quote subscriptions",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(quoteSubscriptionsGauge)
+}
+
+// quoteSubscription is a live subscription to the optimal quote of a single request.
+type quoteSubscription struct {
+	request domain.QuoteRequest
+	// requestKey identifies the request after normalizing its options.
+	// The quote is computed once per key for all subscriptions sharing it.
+	requestKey string
+	// Buffered with capacity one. Holds at most the latest update not yet received by the subscriber.
+	updates chan domain.QuoteUpdate
+
+	// mu serializes the pushes with each other and with closing the updates channel.
+	mu sync.Mutex
+	// lastHeight is the height of the last pushed update. Updates at lower heights are stale.
+	lastHeight uint64
+	// lastUpdateKey identifies the amount out and route, or the error, of the last pushed update.
+	lastUpdateKey string
+	isClosed      bool
+}
+
+// SubscribeQuote implements mvc.RouterUsecase.
+// The current quote is computed in the background over the latest state snapshot.
+// Returns domain.QuoteSubscriptionsLimitReachedError if the configured max quote subscriptions are live.
+func (r *routerUseCaseImpl) SubscribeQuote(request domain.QuoteRequest) (<-chan domain.QuoteUpdate, func(), error) {
+	subscription := &quoteSubscription{
+		request:    request,
+		requestKey: r.formatQuoteRequestKey(request),
+		updates:    make(chan domain.QuoteUpdate, 1),
+	}
+
+	r.quoteSubscriptionsMu.Lock()
+	if maxQuoteSubscriptions := r.defaultConfig.MaxQuoteSubscriptions; maxQuoteSubscriptions > 0 && len(r.quoteSubscriptions) >= maxQuoteSubscriptions {
+		r.quoteSubscriptionsMu.Unlock()
+		return nil, nil, domain.QuoteSubscriptionsLimitReachedError{Limit: maxQuoteSubscriptions}
+	}
+	subscriptionID := r.nextQuoteSubscriptionID
+	r.nextQuoteSubscriptionID++
+	r.quoteSubscriptions[subscriptionID] = subscription
+	quoteSubscriptionsGauge.Set(float64(len(r.quoteSubscriptions)))
+	r.quoteSubscriptionsMu.Unlock()
+
+	go func() {
+		ctx, snapshot, _ := r.pinStateSnapshot(context.Background())
+		quote, err := r.GetOptimalQuote(ctx, request.TokenIn, request.TokenOutDenom, request.Options...)
+		subscription.push(domain.QuoteUpdate{Height: snapshot.GetHeight(), Quote: quote, Err: err})
+	}()
+
+	var unsubscribeOnce sync.Once
+	unsubscribe := func() {
+		unsubscribeOnce.Do(func() {
+			r.quoteSubscriptionsMu.Lock()
+			delete(r.quoteSubscriptions, subscriptionID)
+			quoteSubscriptionsGauge.Set(float64(len(r.quoteSubscriptions)))
+			r.quoteSubscriptionsMu.Unlock()
+
+			subscription.close()
+		})
+	}
+
+	return subscription.updates, unsubscribe, nil
+}
+
+// PushQuoteUpdatesAsync implements mvc.RouterUsecase.
+// The quotes are computed in the background over the latest state snapshot at the time of the call.
+// It is skipped if there are no subscriptions or if the previous updates are still being pushed.
+// In the latter case, the subscribers catch up after the next block.
+func (r *routerUseCaseImpl) PushQuoteUpdatesAsync(height uint64) {
+	subscriptions := r.getQuoteSubscriptions()
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	if !r.isPushingQuoteUpdates.CompareAndSwap(false, true) {
+		r.logger.Info("skipping quote updates, previous updates are in progress", zap.Uint64("height", height))
+		return
+	}
+
+	snapshot := r.routerRepository.GetStateSnapshot()
+
+	go func() {
+		defer r.isPushingQuoteUpdates.Store(false)
+
+		r.pushQuoteUpdates(domain.WithStateSnapshot(context.Background(), snapshot), height, subscriptions)
+	}()
+}
+
+// pushQuoteUpdates computes the quotes of the given subscriptions over the state snapshot pinned
+// to the context, or the latest one if none is pinned, and pushes them as the updates at the given height.
+// The quote is computed once for all subscriptions with the same request key.
+func (r *routerUseCaseImpl) pushQuoteUpdates(ctx context.Context, height uint64, subscriptions []*quoteSubscription) {
+	startTime := time.Now()
+
+	subscriptionsByKey := make(map[string][]*quoteSubscription)
+	requests := make([]domain.QuoteRequest, 0, len(subscriptions))
+	requestKeys := make([]string, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		if _, ok := subscriptionsByKey[subscription.requestKey]; !ok {
+			requests = append(requests, subscription.request)
+			requestKeys = append(requestKeys, subscription.requestKey)
+		}
+		subscriptionsByKey[subscription.requestKey] = append(subscriptionsByKey[subscription.requestKey], subscription)
+	}
+
+	results := r.GetOptimalQuotes(ctx, requests)
+
+	numPushed := 0
+	for i, result := range results {
+		for _, subscription := range subscriptionsByKey[requestKeys[i]] {
+			// Every subscriber prepares the quote for output which mutates it.
+			if subscription.push(domain.QuoteUpdate{Height: height, Quote: copyQuote(result.Quote), Err: result.Err}) {
+				numPushed++
+			}
+		}
+	}
+
+	r.logger.Info("completed quote updates", zap.Uint64("height", height), zap.Int("num_subscriptions", len(subscriptions)), zap.Int("num_requests", len(requests)), zap.Int("num_pushed", numPushed), zap.Duration("duration", time.Since(startTime)))
+}
+
+// getQuoteSubscriptions returns the live quote subscriptions.
+func (r *routerUseCaseImpl) getQuoteSubscriptions() []*quoteSubscription {
+	r.quoteSubscriptionsMu.Lock()
+	defer r.quoteSubscriptionsMu.Unlock()
+
+	subscriptions := make([]*quoteSubscription, 0, len(r.quoteSubscriptions))
+	for _, subscription := range r.quoteSubscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions
+}
+
+// push sends the update to the subscriber unless it is stale, the amount out and route
+// are the same as in the last pushed update or the subscription is closed.
+// The pending update not yet received by the subscriber, if any, is replaced.
+// Returns true if the update was pushed.
+func (s *quoteSubscription) push(update domain.QuoteUpdate) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isClosed || update.Height < s.lastHeight {
+		return false
+	}
+
+	updateKey := getQuoteUpdateKey(update)
+	if updateKey == s.lastUpdateKey {
+		return false
+	}
+
+	s.lastHeight = update.Height
+	s.lastUpdateKey = updateKey
+
+	// Since pushes are serialized, the channel has room after draining it.
+	select {
+	case <-s.updates:
+	default:
+	}
+	s.updates <- update
+
+	return true
+}
+
+// close closes the updates channel. No updates are pushed afterwards.
+func (s *quoteSubscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.isClosed = true
+	close(s.updates)
+}
+
+// getQuoteUpdateKey returns the key identifying the amount out and the route of the quote in the update.
+// That is, the pool IDs and the amount in of every split route. If the update failed, the key identifies the error.
+func getQuoteUpdateKey(update domain.QuoteUpdate) string {
+	if update.Err != nil {
+		return "error:" + update.Err.Error()
+	}
+
+	var key strings.Builder
+	key.WriteString(update.Quote.GetAmountOut().String())
+	for _, route := range update.Quote.GetRoute() {
+		key.WriteString(";")
+		key.WriteString(route.GetAmountIn().String())
+		for _, pool := range route.GetPools() {
+			key.WriteString(",")
+			key.WriteString(strconv.FormatUint(pool.GetId(), 10))
+		}
+	}
+
+	return key.String()
+}
+
+// formatQuoteRequestKey returns the key identifying the token in, the token out denom and the router options
+// of the request applied over the default config. Requests with equal keys have the same optimal quote.
+func (r *routerUseCaseImpl) formatQuoteRequestKey(request domain.QuoteRequest) string {
+	options := r.getRouterOptions(request.Options...)
+
+	gasPriceInTokenOut := ""
+	if !options.GasPriceInTokenOut.IsNil() {
+		gasPriceInTokenOut = options.GasPriceInTokenOut.String()
+	}
+
+	return strings.Join([]string{
+		request.TokenIn.String(),
+		request.TokenOutDenom,
+		strconv.Itoa(options.MaxPoolsPerRoute),
+		strconv.Itoa(options.MaxRoutes),
+		strconv.Itoa(options.MaxSplitRoutes),
+		strconv.Itoa(options.MaxSplitIterations),
+		strconv.Itoa(options.MinOSMOLiquidity),
+		options.MaxLatency.String(),
+		gasPriceInTokenOut,
+	}, denomSeparatorChar) + formatRouteFiltersCacheKey(options.CandidateRouteFilters)
+}
+
+// copyQuote returns a shallow copy of the quote so that preparing the copy for output
+// does not mutate the original. Nil is returned as is.
+func copyQuote(quote domain.Quote) domain.Quote {
+	impl, ok := quote.(*quoteImpl)
+	if !ok || impl == nil {
+		return quote
+	}
+
+	quoteCopy := *impl
+	return &quoteCopy
+}
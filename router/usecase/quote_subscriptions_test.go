@@ -0,0 +1,211 @@
+package usecase_test
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/domain/cache"
+	"github.com/osmosis-labs/sqs/log"
+	poolsusecase "github.com/osmosis-labs/sqs/pools/usecase"
+	routerrepo "github.com/osmosis-labs/sqs/router/repository"
+	routerusecase "github.com/osmosis-labs/sqs/router/usecase"
+	"github.com/osmosis-labs/sqs/router/usecase/routertesting"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// quoteUpdateTimeout is the time to wait for a quote update pushed in the background.
+const quoteUpdateTimeout = 10 * time.Second
+
+// This test validates that the quote subscription receives the current quote right away
+// and afterwards only the quotes that changed at a higher height until unsubscribed.
+// It also validates that the subscriptions with equivalent requests share the pushed quote
+// and that subscribing beyond the max quote subscriptions fails.
+func (s *RouterTestSuite) TestSubscribeQuote() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	// Two identical pools so that splitting the large amount in between them beats either single route.
+	poolOne := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwo := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(sqsdomain.TakerFeeMap{})
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools([]sqsdomain.PoolI{poolOne, poolTwo})
+
+	routerConfig := routertesting.DefaultRouterConfig
+	routerConfig.MaxQuoteSubscriptions = 2
+
+	routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routerConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+	routerUsecase.SetSortedPools([]sqsdomain.PoolI{poolOne})
+
+	routerUsecaseImpl, ok := routerUsecase.(*routerusecase.RouterUseCaseImpl)
+	s.Require().True(ok)
+
+	tokenIn := sdk.NewCoin(DenomOne, osmomath.NewInt(100_000_000))
+
+	updates, unsubscribe, err := routerUsecase.SubscribeQuote(domain.QuoteRequest{
+		TokenIn:       tokenIn,
+		TokenOutDenom: DenomTwo,
+	})
+	s.Require().NoError(err)
+
+	// Same request as above since the options match the default config.
+	equivalentUpdates, unsubscribeEquivalent, err := routerUsecase.SubscribeQuote(domain.QuoteRequest{
+		TokenIn:       tokenIn,
+		TokenOutDenom: DenomTwo,
+		Options:       []domain.RouterOption{domain.WithMaxRoutes(routerConfig.MaxRoutes)},
+	})
+	s.Require().NoError(err)
+	defer unsubscribeEquivalent()
+
+	s.Run("current quote is pushed right away", func() {
+		update := s.receiveQuoteUpdate(updates)
+		s.Require().NoError(update.Err)
+
+		s.Require().Equal(uint64(0), update.Height)
+		s.Require().Len(update.Quote.GetRoute(), 1)
+
+		s.receiveQuoteUpdate(equivalentUpdates)
+	})
+
+	s.Run("subscribing beyond the max quote subscriptions fails", func() {
+		_, _, err := routerUsecase.SubscribeQuote(domain.QuoteRequest{
+			TokenIn:       tokenIn,
+			TokenOutDenom: DenomTwo,
+		})
+		s.Require().ErrorIs(err, domain.QuoteSubscriptionsLimitReachedError{Limit: 2})
+	})
+
+	s.Run("unchanged quote is not pushed", func() {
+		routerUsecaseImpl.PushQuoteUpdates(1)
+
+		s.requireNoQuoteUpdate(updates)
+	})
+
+	s.Run("changed quote is pushed", func() {
+		// Pool two is created in the block that updates pool one.
+		routerUsecase.SetSortedPools([]sqsdomain.PoolI{poolOne, poolTwo})
+		routerUsecase.EvictCachedRoutes([]uint64{poolOne.GetId(), poolTwo.GetId()}, routerusecase.DiffSortedPools([]sqsdomain.PoolI{poolOne}, []sqsdomain.PoolI{poolOne, poolTwo}))
+
+		routerUsecaseImpl.PushQuoteUpdates(2)
+
+		update := s.receiveQuoteUpdate(updates)
+		s.Require().NoError(update.Err)
+
+		s.Require().Equal(uint64(2), update.Height)
+		s.Require().Len(update.Quote.GetRoute(), 2)
+
+		// The equivalent subscription receives its own copy of the same quote.
+		equivalentUpdate := s.receiveQuoteUpdate(equivalentUpdates)
+		s.Require().Equal(update.Quote, equivalentUpdate.Quote)
+		s.Require().NotSame(update.Quote, equivalentUpdate.Quote)
+	})
+
+	s.Run("stale quote is not pushed", func() {
+		routerUsecase.SetSortedPools([]sqsdomain.PoolI{poolOne})
+		routerUsecase.EvictCachedRoutes([]uint64{poolOne.GetId(), poolTwo.GetId()}, routerusecase.DiffSortedPools([]sqsdomain.PoolI{poolOne, poolTwo}, []sqsdomain.PoolI{poolOne}))
+
+		routerUsecaseImpl.PushQuoteUpdates(1)
+
+		s.requireNoQuoteUpdate(updates)
+	})
+
+	s.Run("unsubscribe closes the updates", func() {
+		unsubscribe()
+
+		routerUsecaseImpl.PushQuoteUpdates(3)
+
+		_, ok := <-updates
+		s.Require().False(ok)
+	})
+}
+
+// This test validates that the quote request key only differs for requests with different quotes.
+func (s *RouterTestSuite) TestFormatQuoteRequestKey() {
+	routerUsecase := routerusecase.NewRouterUsecase(routerrepo.New(), nil, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+
+	routerUsecaseImpl, ok := routerUsecase.(*routerusecase.RouterUseCaseImpl)
+	s.Require().True(ok)
+
+	request := domain.QuoteRequest{
+		TokenIn:       sdk.NewCoin(DenomOne, osmomath.NewInt(100)),
+		TokenOutDenom: DenomTwo,
+		Options:       []domain.RouterOption{domain.WithExcludedPoolIDs(1, 2)},
+	}
+	key := routerUsecaseImpl.FormatQuoteRequestKey(request)
+
+	tests := map[string]struct {
+		request       domain.QuoteRequest
+		expectedEqual bool
+	}{
+		"reordered filters and default options": {
+			request: domain.QuoteRequest{
+				TokenIn:       request.TokenIn,
+				TokenOutDenom: DenomTwo,
+				Options:       []domain.RouterOption{domain.WithExcludedPoolIDs(2, 1), domain.WithMaxRoutes(routertesting.DefaultRouterConfig.MaxRoutes)},
+			},
+			expectedEqual: true,
+		},
+		"different amount in": {
+			request: domain.QuoteRequest{
+				TokenIn:       sdk.NewCoin(DenomOne, osmomath.NewInt(101)),
+				TokenOutDenom: DenomTwo,
+				Options:       request.Options,
+			},
+		},
+		"different filters": {
+			request: domain.QuoteRequest{
+				TokenIn:       request.TokenIn,
+				TokenOutDenom: DenomTwo,
+				Options:       []domain.RouterOption{domain.WithExcludedPoolIDs(1)},
+			},
+		},
+		"splits disabled": {
+			request: domain.QuoteRequest{
+				TokenIn:       request.TokenIn,
+				TokenOutDenom: DenomTwo,
+				Options:       append([]domain.RouterOption{domain.WithDisableSplitRoutes()}, request.Options...),
+			},
+		},
+		"gas price": {
+			request: domain.QuoteRequest{
+				TokenIn:       request.TokenIn,
+				TokenOutDenom: DenomTwo,
+				Options:       append([]domain.RouterOption{domain.WithGasPrice(osmomath.OneDec(), osmomath.OneDec())}, request.Options...),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		s.Run(name, func() {
+			s.Require().Equal(tc.expectedEqual, routerUsecaseImpl.FormatQuoteRequestKey(tc.request) == key)
+		})
+	}
+}
+
+// receiveQuoteUpdate waits for the next quote update. Fails if none is pushed within quoteUpdateTimeout.
+func (s *RouterTestSuite) receiveQuoteUpdate(updates <-chan domain.QuoteUpdate) domain.QuoteUpdate {
+	select {
+	case update, ok := <-updates:
+		s.Require().True(ok)
+		return update
+	case <-time.After(quoteUpdateTimeout):
+		s.FailNow("timed out waiting for quote update")
+		return domain.QuoteUpdate{}
+	}
+}
+
+// requireNoQuoteUpdate fails if there is a pending quote update.
+func (s *RouterTestSuite) requireNoQuoteUpdate(updates <-chan domain.QuoteUpdate) {
+	select {
+	case update := <-updates:
+		s.FailNow("unexpected quote update", "height %d", update.Height)
+	default:
+	}
+}
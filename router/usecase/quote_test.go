@@ -136,12 +136,14 @@ func (s *RouterTestSuite) TestPrepareResult() {
 					Pools: []sqsdomain.RoutablePool{
 						s.newRoutablePool(
 							sqsdomain.NewPool(poolOne, poolOne.GetSpreadFactor(sdk.Context{}), poolOneBalances),
+							ETH,
 							USDT,
 							takerFeeOne,
 							domain.CosmWasmPoolRouterConfig{},
 						),
 						s.newRoutablePool(
 							sqsdomain.NewPool(poolTwo, poolTwo.GetSpreadFactor(sdk.Context{}), poolTwoBalances),
+							USDT,
 							USDC,
 							takerFeeTwo,
 							domain.CosmWasmPoolRouterConfig{},
@@ -159,6 +161,7 @@ func (s *RouterTestSuite) TestPrepareResult() {
 					Pools: []sqsdomain.RoutablePool{
 						s.newRoutablePool(
 							sqsdomain.NewPool(poolThree, poolThree.GetSpreadFactor(sdk.Context{}), poolThreeBalances),
+							ETH,
 							USDC,
 							takerFeeThree,
 							domain.CosmWasmPoolRouterConfig{},
@@ -336,8 +339,8 @@ func (s *RouterTestSuite) validateRoutes(expectedRoutes []domain.SplitRoute, act
 	}
 }
 
-func (s *RouterTestSuite) newRoutablePool(pool sqsdomain.PoolI, tokenOutDenom string, takerFee osmomath.Dec, cosmWasmConfig domain.CosmWasmPoolRouterConfig) sqsdomain.RoutablePool {
-	routablePool, err := pools.NewRoutablePool(pool, tokenOutDenom, takerFee, cosmWasmConfig)
+func (s *RouterTestSuite) newRoutablePool(pool sqsdomain.PoolI, tokenInDenom string, tokenOutDenom string, takerFee osmomath.Dec, cosmWasmConfig domain.CosmWasmPoolRouterConfig) sqsdomain.RoutablePool {
+	routablePool, err := pools.NewRoutablePool(pool, tokenInDenom, tokenOutDenom, takerFee, cosmWasmConfig)
 	s.Require().NoError(err)
 	return routablePool
 }
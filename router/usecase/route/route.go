@@ -47,10 +47,12 @@ var (
 // - Spread Factor
 // - Token Out Denom
 // - Taker Fee
+// - Swap Breakdown (see domain.PoolSwapBreakdown)
 // Note that it mutates the route.
+// The pools with a state in poolStates are swapped over that state. See domain.Route.
 // Returns spot price before swap and the effective spot price
 // with token in as base and token out as quote.
-func (r RouteImpl) PrepareResultPools(ctx context.Context, tokenIn sdk.Coin) ([]sqsdomain.RoutablePool, osmomath.Dec, osmomath.Dec, error) {
+func (r RouteImpl) PrepareResultPools(ctx context.Context, tokenIn sdk.Coin, poolStates map[uint64]domain.StatefulRoutablePool) ([]sqsdomain.RoutablePool, osmomath.Dec, osmomath.Dec, error) {
 	var (
 		routeSpotPriceInBaseOutQuote     = osmomath.OneDec()
 		effectiveSpotPriceInBaseOutQuote = osmomath.OneDec()
@@ -59,8 +61,13 @@ func (r RouteImpl) PrepareResultPools(ctx context.Context, tokenIn sdk.Coin) ([]
 	newPools := make([]sqsdomain.RoutablePool, 0, len(r.Pools))
 
 	for _, pool := range r.Pools {
-		// Compute spot price before swap.
-		spotPriceInBaseOutQuote, err := pool.CalcSpotPrice(ctx, tokenIn.Denom, pool.GetTokenOutDenom())
+		// Compute spot price before swap in the state left by the previous routes, if any.
+		poolBeforeSwap := pool
+		if poolState, ok := poolStates[pool.GetId()].(sqsdomain.RoutablePool); ok {
+			poolBeforeSwap = poolState
+		}
+
+		spotPriceInBaseOutQuote, err := poolBeforeSwap.CalcSpotPrice(ctx, tokenIn.Denom, pool.GetTokenOutDenom())
 		if err != nil {
 			// We don't want to fail the entire quote if one pool fails to calculate spot price.
 			// This might cause miestimaions downsream but we a
@@ -71,27 +78,49 @@ func (r RouteImpl) PrepareResultPools(ctx context.Context, tokenIn sdk.Coin) ([]
 			spotPriceErrorResultCounter.WithLabelValues(tokenIn.Denom, pool.GetTokenOutDenom(), routeTokenOutDenom).Inc()
 		}
 
+		tokenInBeforeTakerFee := tokenIn
+
 		// Charge taker fee
 		tokenIn = pool.ChargeTakerFeeExactIn(tokenIn)
 
-		tokenOut, err := pool.CalculateTokenOutByTokenIn(ctx, tokenIn)
+		tokenOut, poolAfterSwap, err := swapOutGivenIn(ctx, pool, poolStates, tokenIn)
 		if err != nil {
 			return nil, osmomath.Dec{}, osmomath.Dec{}, err
 		}
 
+		spreadFactor := pool.GetSpreadFactor()
+
+		spreadFeeCharged := sdk.NewCoin(tokenIn.Denom, tokenIn.Amount.ToLegacyDec().MulMut(spreadFactor).TruncateInt())
+		if spreadFeePool, ok := poolBeforeSwap.(domain.SpreadFeeRoutablePool); ok {
+			spreadFeeCharged, err = spreadFeePool.CalculateSpreadFeeCharged(ctx, tokenIn)
+			if err != nil {
+				return nil, osmomath.Dec{}, osmomath.Dec{}, err
+			}
+		}
+
+		swapBreakdown := domain.PoolSwapBreakdown{
+			AmountIn:         tokenInBeforeTakerFee,
+			AmountOut:        tokenOut,
+			TakerFeeCharged:  sdk.NewCoin(tokenIn.Denom, tokenInBeforeTakerFee.Amount.Sub(tokenIn.Amount)),
+			SpreadFeeCharged: spreadFeeCharged,
+			SpotPriceBefore:  spotPriceInBaseOutQuote.Dec(),
+			SpotPriceAfter:   getSpotPriceAfterSwap(ctx, poolAfterSwap, tokenIn.Denom, tokenOut.Denom),
+		}
+
 		// Update effective spot price
 		effectiveSpotPriceInBaseOutQuote.MulMut(tokenOut.Amount.ToLegacyDec().QuoMut(tokenIn.Amount.ToLegacyDec()))
 
 		// Note, in the future we may want to increase the precision of the spot price
 		routeSpotPriceInBaseOutQuote.MulMut(spotPriceInBaseOutQuote.Dec())
 
-		newPool := pools.NewRoutableResultPool(
+		newPool := pools.NewRoutableResultPoolWithSwapBreakdown(
 			pool.GetId(),
 			pool.GetType(),
-			pool.GetSpreadFactor(),
+			spreadFactor,
 			pool.GetTokenOutDenom(),
 			pool.GetTakerFee(),
 			pool.GetCodeID(),
+			swapBreakdown,
 		)
 
 		newPools = append(newPools, newPool)
@@ -101,17 +130,71 @@ func (r RouteImpl) PrepareResultPools(ctx context.Context, tokenIn sdk.Coin) ([]
 	return newPools, routeSpotPriceInBaseOutQuote, effectiveSpotPriceInBaseOutQuote, nil
 }
 
+// swapOutGivenIn swaps the given token in over the pool. If the pool implements domain.StatefulRoutablePool,
+// it is swapped over its state in poolStates, if any, and the pool with the state after the swap is returned
+// as well as recorded in poolStates unless it is nil. Otherwise, the returned pool is nil.
+func swapOutGivenIn(ctx context.Context, pool sqsdomain.RoutablePool, poolStates map[uint64]domain.StatefulRoutablePool, tokenIn sdk.Coin) (sdk.Coin, sqsdomain.RoutablePool, error) {
+	statefulPool, ok := poolStates[pool.GetId()]
+	if !ok {
+		statefulPool, ok = pool.(domain.StatefulRoutablePool)
+	}
+	if !ok {
+		tokenOut, err := pool.CalculateTokenOutByTokenIn(ctx, tokenIn)
+		return tokenOut, nil, err
+	}
+
+	tokenOut, statefulPoolAfterSwap, err := statefulPool.SwapOutGivenIn(ctx, tokenIn, pool.GetTokenOutDenom())
+	if err != nil {
+		return sdk.Coin{}, nil, err
+	}
+
+	if poolStates != nil {
+		poolStates[pool.GetId()] = statefulPoolAfterSwap
+	}
+
+	poolAfterSwap, ok := statefulPoolAfterSwap.(sqsdomain.RoutablePool)
+	if !ok {
+		return tokenOut, nil, nil
+	}
+
+	return tokenOut, poolAfterSwap, nil
+}
+
+// getSpotPriceAfterSwap returns the spot price of the given pool with the state after the swap
+// with token in as base and token out as quote.
+// Returns zero if the pool is nil or if the spot price fails to be computed.
+func getSpotPriceAfterSwap(ctx context.Context, poolAfterSwap sqsdomain.RoutablePool, tokenInDenom, tokenOutDenom string) osmomath.Dec {
+	if poolAfterSwap == nil {
+		return osmomath.ZeroDec()
+	}
+
+	spotPrice, err := poolAfterSwap.CalcSpotPrice(ctx, tokenInDenom, tokenOutDenom)
+	if err != nil {
+		return osmomath.ZeroDec()
+	}
+
+	return spotPrice.Dec()
+}
+
 // GetPools implements Route.
 func (r *RouteImpl) GetPools() []sqsdomain.RoutablePool {
 	return r.Pools
 }
 
 // CalculateTokenOutByTokenIn implements Route.
-func (r *RouteImpl) CalculateTokenOutByTokenIn(ctx context.Context, tokenIn sdk.Coin) (tokenOut sdk.Coin, err error) {
+func (r *RouteImpl) CalculateTokenOutByTokenIn(ctx context.Context, tokenIn sdk.Coin) (sdk.Coin, error) {
+	tokenOut, _, err := r.CalculateTokenOutByTokenInWithTicksCrossed(ctx, tokenIn)
+	return tokenOut, err
+}
+
+// CalculateTokenOutByTokenInWithTicksCrossed calculates the token out amount similarly to CalculateTokenOutByTokenIn
+// and the number of initialized ticks crossed by the pools of the route that implement domain.TickCrossingRoutablePool.
+func (r *RouteImpl) CalculateTokenOutByTokenInWithTicksCrossed(ctx context.Context, tokenIn sdk.Coin) (tokenOut sdk.Coin, ticksCrossed uint64, err error) {
 	defer func() {
 		// TODO: cover this by test
 		if r := recover(); r != nil {
 			tokenOut = sdk.Coin{}
+			ticksCrossed = 0
 			err = fmt.Errorf("error when calculating out by in in route: %v", r)
 		}
 	}()
@@ -122,18 +205,176 @@ func (r *RouteImpl) CalculateTokenOutByTokenIn(ctx context.Context, tokenIn sdk.
 		tokenInAmt := tokenIn.Amount.ToLegacyDec()
 
 		if tokenInAmt.IsNil() || tokenInAmt.IsZero() {
-			return sdk.Coin{}, nil
+			return sdk.Coin{}, 0, nil
 		}
 
-		tokenOut, err = pool.CalculateTokenOutByTokenIn(ctx, tokenIn)
+		var poolTicksCrossed uint64
+		if tickCrossingPool, ok := pool.(domain.TickCrossingRoutablePool); ok {
+			tokenOut, poolTicksCrossed, err = tickCrossingPool.CalculateTokenOutByTokenInWithTicksCrossed(ctx, tokenIn)
+		} else {
+			tokenOut, err = pool.CalculateTokenOutByTokenIn(ctx, tokenIn)
+		}
 		if err != nil {
-			return sdk.Coin{}, err
+			return sdk.Coin{}, 0, err
 		}
 
+		ticksCrossed += poolTicksCrossed
 		tokenIn = tokenOut
 	}
 
-	return tokenOut, nil
+	return tokenOut, ticksCrossed, nil
+}
+
+// CalculateTokenOutByTokenInBatch calculates the token out amount for each of the given token in amounts.
+// See CalculateTokenOutByTokenInBatchWithTicksCrossed for details.
+func (r *RouteImpl) CalculateTokenOutByTokenInBatch(ctx context.Context, tokensIn []sdk.Coin) ([]sdk.Coin, []error) {
+	tokensOut, _, errs := r.CalculateTokenOutByTokenInBatchWithTicksCrossed(ctx, tokensIn)
+	return tokensOut, errs
+}
+
+// CalculateTokenOutByTokenInBatchWithTicksCrossed calculates the token out amount and the number of initialized
+// ticks crossed for each of the given token in amounts.
+// The route is walked once with all amounts so that the pools implementing domain.BatchRoutablePool
+// estimate all amounts at each hop at once. Other pools estimate the amounts one by one.
+// Similarly to CalculateTokenOutByTokenIn, the token out is empty without an error for the amounts
+// that become zero after charging the taker fee.
+// Returns the token outs, the ticks crossed and the errors at the same indexes as the token ins.
+func (r *RouteImpl) CalculateTokenOutByTokenInBatchWithTicksCrossed(ctx context.Context, tokensIn []sdk.Coin) ([]sdk.Coin, []uint64, []error) {
+	tokensOut := make([]sdk.Coin, len(tokensIn))
+	ticksCrossed := make([]uint64, len(tokensIn))
+	errs := make([]error, len(tokensIn))
+
+	// Token ins of the current hop and the indexes of the amounts they are swapped for.
+	hopTokensIn := make([]sdk.Coin, len(tokensIn))
+	copy(hopTokensIn, tokensIn)
+	hopIndexes := make([]int, 0, len(tokensIn))
+	for i := range tokensIn {
+		hopIndexes = append(hopIndexes, i)
+	}
+
+	for _, pool := range r.Pools {
+		nextHopTokensIn := make([]sdk.Coin, 0, len(hopIndexes))
+		nextHopIndexes := make([]int, 0, len(hopIndexes))
+
+		for j, i := range hopIndexes {
+			// Charge taker fee
+			tokenIn := pool.ChargeTakerFeeExactIn(hopTokensIn[j])
+
+			if tokenIn.Amount.IsNil() || tokenIn.Amount.IsZero() {
+				tokensOut[i] = sdk.Coin{}
+				ticksCrossed[i] = 0
+				continue
+			}
+
+			nextHopTokensIn = append(nextHopTokensIn, tokenIn)
+			nextHopIndexes = append(nextHopIndexes, i)
+		}
+
+		poolTokensOut, poolTicksCrossed, poolErrs := calculatePoolTokenOutByTokenInBatch(ctx, pool, nextHopTokensIn)
+
+		hopTokensIn = hopTokensIn[:0]
+		hopIndexes = hopIndexes[:0]
+		for j, i := range nextHopIndexes {
+			if poolErrs[j] != nil {
+				tokensOut[i] = sdk.Coin{}
+				ticksCrossed[i] = 0
+				errs[i] = poolErrs[j]
+				continue
+			}
+
+			tokensOut[i] = poolTokensOut[j]
+			ticksCrossed[i] += poolTicksCrossed[j]
+			hopTokensIn = append(hopTokensIn, poolTokensOut[j])
+			hopIndexes = append(hopIndexes, i)
+		}
+	}
+
+	return tokensOut, ticksCrossed, errs
+}
+
+// calculatePoolTokenOutByTokenInBatch calculates the token out amount and the ticks crossed of the pool
+// for each of the given token in amounts.
+// Uses the batch estimate if the pool implements domain.BatchRoutablePool. Such pools do not cross ticks.
+func calculatePoolTokenOutByTokenInBatch(ctx context.Context, pool sqsdomain.RoutablePool, tokensIn []sdk.Coin) ([]sdk.Coin, []uint64, []error) {
+	ticksCrossed := make([]uint64, len(tokensIn))
+
+	if batchPool, ok := pool.(domain.BatchRoutablePool); ok {
+		tokensOut, errs := batchPool.CalculateTokenOutByTokenInBatch(ctx, tokensIn)
+		return tokensOut, ticksCrossed, errs
+	}
+
+	tokensOut := make([]sdk.Coin, len(tokensIn))
+	errs := make([]error, len(tokensIn))
+	for i, tokenIn := range tokensIn {
+		tokensOut[i], ticksCrossed[i], errs[i] = calculatePoolTokenOutByTokenIn(ctx, pool, tokenIn)
+	}
+
+	return tokensOut, ticksCrossed, errs
+}
+
+// calculatePoolTokenOutByTokenIn calculates the token out amount of the pool for the given token in
+// and the ticks crossed if the pool implements domain.TickCrossingRoutablePool, converting panics to errors.
+func calculatePoolTokenOutByTokenIn(ctx context.Context, pool sqsdomain.RoutablePool, tokenIn sdk.Coin) (tokenOut sdk.Coin, ticksCrossed uint64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tokenOut = sdk.Coin{}
+			ticksCrossed = 0
+			err = fmt.Errorf("error when calculating out by in in pool (%d): %v", pool.GetId(), r)
+		}
+	}()
+
+	if tickCrossingPool, ok := pool.(domain.TickCrossingRoutablePool); ok {
+		return tickCrossingPool.CalculateTokenOutByTokenInWithTicksCrossed(ctx, tokenIn)
+	}
+
+	tokenOut, err = pool.CalculateTokenOutByTokenIn(ctx, tokenIn)
+	return tokenOut, 0, err
+}
+
+// CalculateTokenInByTokenOut implements Route.
+func (r *RouteImpl) CalculateTokenInByTokenOut(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error) {
+	tokenIn, _, err := r.CalculateTokenInByTokenOutWithTicksCrossed(ctx, tokenOut)
+	return tokenIn, err
+}
+
+// CalculateTokenInByTokenOutWithTicksCrossed calculates the token in amount similarly to CalculateTokenInByTokenOut
+// and the number of initialized ticks crossed by the pools of the route that implement domain.TickCrossingRoutablePool.
+// Walks the route in reverse, computing the token in required by each pool
+// for the token out of the following pool and adding the taker fee on top.
+func (r *RouteImpl) CalculateTokenInByTokenOutWithTicksCrossed(ctx context.Context, tokenOut sdk.Coin) (tokenIn sdk.Coin, ticksCrossed uint64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tokenIn = sdk.Coin{}
+			ticksCrossed = 0
+			err = fmt.Errorf("error when calculating in by out in route: %v", r)
+		}
+	}()
+
+	for i := len(r.Pools) - 1; i >= 0; i-- {
+		pool := r.Pools[i]
+
+		if tokenOut.Amount.IsNil() || tokenOut.Amount.IsZero() {
+			return sdk.Coin{}, 0, nil
+		}
+
+		var poolTicksCrossed uint64
+		if tickCrossingPool, ok := pool.(domain.TickCrossingRoutablePool); ok {
+			tokenIn, poolTicksCrossed, err = tickCrossingPool.CalculateTokenInByTokenOutWithTicksCrossed(ctx, tokenOut)
+		} else {
+			tokenIn, err = pool.CalculateTokenInByTokenOut(ctx, tokenOut)
+		}
+		if err != nil {
+			return sdk.Coin{}, 0, err
+		}
+
+		// Charge taker fee
+		tokenIn = pool.ChargeTakerFeeExactOut(tokenIn)
+
+		ticksCrossed += poolTicksCrossed
+		tokenOut = tokenIn
+	}
+
+	return tokenIn, ticksCrossed, nil
 }
 
 // String implements domain.Route.
@@ -149,6 +390,17 @@ func (r *RouteImpl) String() string {
 	return strBuilder.String()
 }
 
+// GetTokenInDenom implements domain.Route.
+// Returns token in denom of the first pool in the route.
+// If route is empty, returns empty string.
+func (r *RouteImpl) GetTokenInDenom() string {
+	if len(r.Pools) == 0 {
+		return ""
+	}
+
+	return r.Pools[0].GetTokenInDenom()
+}
+
 // GetTokenOutDenom implements domain.Route.
 // Returns token out denom of the last pool in the route.
 // If route is empty, returns empty string.
@@ -7,6 +7,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/osmosis-labs/sqs/domain"
 	"github.com/osmosis-labs/sqs/domain/mocks"
 	"github.com/osmosis-labs/sqs/router/usecase/pools"
 	"github.com/osmosis-labs/sqs/router/usecase/route"
@@ -14,6 +15,7 @@ import (
 	"github.com/osmosis-labs/sqs/sqsdomain"
 
 	"github.com/osmosis-labs/osmosis/osmomath"
+	cwpoolmodel "github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/model"
 	"github.com/osmosis-labs/osmosis/v25/x/gamm/pool-models/balancer"
 	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
 )
@@ -206,7 +208,7 @@ func (s *RouterTestSuite) TestPrepareResultPools() {
 		s.Run(name, func() {
 
 			// Note: token in is chosen arbitrarily since it is irrelevant for this test
-			actualPools, spotPriceBeforeInBaseOutQuote, _, err := tc.route.PrepareResultPools(context.TODO(), tc.tokenIn)
+			actualPools, spotPriceBeforeInBaseOutQuote, _, err := tc.route.PrepareResultPools(context.TODO(), tc.tokenIn, nil)
 			s.Require().NoError(err)
 
 			s.Require().Equal(tc.expectedSpotPriceInBaseOutQuote, spotPriceBeforeInBaseOutQuote)
@@ -216,6 +218,222 @@ func (s *RouterTestSuite) TestPrepareResultPools() {
 	}
 }
 
+// This test validates the swap breakdown of every pool in the prepared result.
+// It sets up a two-hop route over balancer pools and validates that:
+// - the amount in of every hop is the amount out of the previous hop
+// - the taker and spread fees are charged from the amount in
+// - the amount out of every hop is the balancer amount out after the taker fee
+// - the last amount out equals the route amount out
+// - the spot price after the swap is the spot price of the pool with the reserves updated by the swap
+func (s *RouterTestSuite) TestPrepareResultPools_SwapBreakdown() {
+	s.Setup()
+
+	balancerPoolTwoOneID := s.PrepareBalancerPoolWithCoins(sdk.NewCoins(
+		sdk.NewCoin(DenomOne, sdk.NewInt(2_000_000_000)),
+		sdk.NewCoin(DenomTwo, sdk.NewInt(1_000_000_000)),
+	)...)
+	balancerPoolOneThreeID := s.PrepareBalancerPoolWithCoins(sdk.NewCoins(
+		sdk.NewCoin(DenomOne, sdk.NewInt(1_000_000_000)),
+		sdk.NewCoin(DenomThree, sdk.NewInt(3_000_000_000)),
+	)...)
+
+	balancerPoolTwoOne, err := s.App.PoolManagerKeeper.GetPool(s.Ctx, balancerPoolTwoOneID)
+	s.Require().NoError(err)
+	balancerPoolOneThree, err := s.App.PoolManagerKeeper.GetPool(s.Ctx, balancerPoolOneThreeID)
+	s.Require().NoError(err)
+
+	tokenIn := sdk.NewCoin(DenomTwo, DefaultAmt0)
+
+	chainPools := []poolmanagertypes.PoolI{balancerPoolTwoOne, balancerPoolOneThree}
+	routePools := []sqsdomain.RoutablePool{
+		s.newRoutableBalancerPool(balancerPoolTwoOne, DenomTwo, DenomOne),
+		s.newRoutableBalancerPool(balancerPoolOneThree, DenomOne, DenomThree),
+	}
+	testRoute := WithRoutePools(emptyRoute, routePools)
+
+	expectedRouteTokenOut, err := testRoute.CalculateTokenOutByTokenIn(context.TODO(), tokenIn)
+	s.Require().NoError(err)
+
+	// System under test.
+	actualPools, _, _, err := testRoute.PrepareResultPools(context.TODO(), tokenIn, nil)
+	s.Require().NoError(err)
+	s.Require().Len(actualPools, len(routePools))
+
+	hopTokenIn := tokenIn
+	for i, actualPool := range actualPools {
+		resultPool, ok := actualPool.(domain.RoutableResultPool)
+		s.Require().True(ok)
+
+		swapBreakdown := resultPool.GetSwapBreakdown()
+		s.Require().NotNil(swapBreakdown)
+
+		routePool := routePools[i]
+
+		tokenInAfterTakerFee := routePool.ChargeTakerFeeExactIn(hopTokenIn).Amount
+		expectedTakerFee := hopTokenIn.Amount.Sub(tokenInAfterTakerFee)
+		expectedSpreadFee := routePool.GetSpreadFactor().MulInt(tokenInAfterTakerFee).TruncateInt()
+
+		expectedTokenOut, err := routePool.CalculateTokenOutByTokenIn(context.TODO(), sdk.NewCoin(hopTokenIn.Denom, tokenInAfterTakerFee))
+		s.Require().NoError(err)
+
+		expectedSpotPriceBefore, err := routePool.CalcSpotPrice(context.TODO(), hopTokenIn.Denom, routePool.GetTokenOutDenom())
+		s.Require().NoError(err)
+
+		s.Require().Equal(hopTokenIn, swapBreakdown.AmountIn)
+		s.Require().Equal(sdk.NewCoin(hopTokenIn.Denom, expectedTakerFee), swapBreakdown.TakerFeeCharged)
+		s.Require().Equal(sdk.NewCoin(hopTokenIn.Denom, expectedSpreadFee), swapBreakdown.SpreadFeeCharged)
+		s.Require().Equal(expectedTokenOut, swapBreakdown.AmountOut)
+		s.Require().Equal(expectedSpotPriceBefore.Dec(), swapBreakdown.SpotPriceBefore)
+
+		// The spot price after the swap is of the pool with the token in added to
+		// and the token out removed from its reserves. Swapping in moves the price against the token in.
+		chainPool, ok := chainPools[i].(*balancer.Pool)
+		s.Require().True(ok)
+		chainPoolAfterSwap := *chainPool
+		chainPoolAfterSwap.PoolAssets = make([]balancer.PoolAsset, len(chainPool.PoolAssets))
+		copy(chainPoolAfterSwap.PoolAssets, chainPool.PoolAssets)
+		for j, poolAsset := range chainPoolAfterSwap.PoolAssets {
+			switch poolAsset.Token.Denom {
+			case hopTokenIn.Denom:
+				chainPoolAfterSwap.PoolAssets[j].Token.Amount = poolAsset.Token.Amount.Add(tokenInAfterTakerFee)
+			case expectedTokenOut.Denom:
+				chainPoolAfterSwap.PoolAssets[j].Token.Amount = poolAsset.Token.Amount.Sub(expectedTokenOut.Amount)
+			}
+		}
+
+		expectedSpotPriceAfter, err := chainPoolAfterSwap.SpotPrice(sdk.Context{}, expectedTokenOut.Denom, hopTokenIn.Denom)
+		s.Require().NoError(err)
+
+		s.Require().Equal(expectedSpotPriceAfter.Dec(), swapBreakdown.SpotPriceAfter)
+		s.Require().True(swapBreakdown.SpotPriceAfter.LT(swapBreakdown.SpotPriceBefore))
+
+		hopTokenIn = swapBreakdown.AmountOut
+	}
+
+	s.Require().Equal(expectedRouteTokenOut, hopTokenIn)
+}
+
+// Tests that the swap breakdown of the pool charging the spread factor from the token out
+// reports the spread fee it charged in the token out denom.
+func (s *RouterTestSuite) TestPrepareResultPools_SwapBreakdown_ConstantProduct() {
+	cosmwasmPool := &cwpoolmodel.CosmWasmPool{PoolId: 1, CodeId: 773, ContractAddress: "osmo1contract"}
+
+	nativePools, err := pools.NewNativeCosmWasmPoolRegistry(map[string][]uint64{
+		pools.ConstantProductNativeCosmWasmPool: {cosmwasmPool.CodeId},
+	})
+	s.Require().NoError(err)
+
+	balances := sdk.NewCoins(sdk.NewCoin(USDC, osmomath.NewInt(1_000_000)), sdk.NewCoin(ETH, osmomath.NewInt(2_000_000)))
+	constantProductPool, err := pools.NewRoutablePool(&mocks.MockRoutablePool{ChainPoolModel: cosmwasmPool, Balances: balances, PoolType: poolmanagertypes.CosmWasm}, USDC, ETH, noTakerFee, domain.CosmWasmPoolRouterConfig{
+		NativePools: nativePools,
+	})
+	s.Require().NoError(err)
+
+	testRoute := WithRoutePools(emptyRoute, []sqsdomain.RoutablePool{constantProductPool})
+
+	// System under test.
+	actualPools, _, _, err := testRoute.PrepareResultPools(context.TODO(), sdk.NewCoin(USDC, osmomath.NewInt(100_000)), nil)
+	s.Require().NoError(err)
+	s.Require().Len(actualPools, 1)
+
+	resultPool, ok := actualPools[0].(domain.RoutableResultPool)
+	s.Require().True(ok)
+
+	swapBreakdown := resultPool.GetSwapBreakdown()
+	s.Require().NotNil(swapBreakdown)
+
+	// 2_000_000 * 100_000 / (1_000_000 + 100_000) = 181_818.18, rounded down to 181_818
+	// 181_818 * 0.005 = 909.09, rounded down to 909
+	s.Require().Equal(sdk.NewCoin(ETH, osmomath.NewInt(909)).String(), swapBreakdown.SpreadFeeCharged.String())
+	s.Require().Equal(sdk.NewCoin(ETH, osmomath.NewInt(181_818-909)).String(), swapBreakdown.AmountOut.String())
+	s.Require().True(swapBreakdown.TakerFeeCharged.IsZero())
+}
+
+// newRoutableBalancerPool returns the routable pool over the given balancer chain pool with the default taker fee.
+func (s *RouterTestSuite) newRoutableBalancerPool(chainPool poolmanagertypes.PoolI, tokenInDenom, tokenOutDenom string) sqsdomain.RoutablePool {
+	routablePool, err := pools.NewRoutablePool(&sqsdomain.PoolWrapper{
+		ChainModel: chainPool,
+		SQSModel: sqsdomain.SQSPool{
+			SpreadFactor: chainPool.GetSpreadFactor(sdk.Context{}),
+		},
+	}, tokenInDenom, tokenOutDenom, DefaultTakerFee, domain.CosmWasmPoolRouterConfig{})
+	s.Require().NoError(err)
+	return routablePool
+}
+
+// batchRoutablePool is a routable pool that counts the batch estimates delegated to the single ones.
+type batchRoutablePool struct {
+	sqsdomain.RoutablePool
+
+	numBatches int
+}
+
+var _ domain.BatchRoutablePool = &batchRoutablePool{}
+
+func (p *batchRoutablePool) CalculateTokenOutByTokenInBatch(ctx context.Context, tokensIn []sdk.Coin) ([]sdk.Coin, []error) {
+	p.numBatches++
+
+	tokensOut := make([]sdk.Coin, len(tokensIn))
+	errs := make([]error, len(tokensIn))
+	for i, tokenIn := range tokensIn {
+		tokensOut[i], errs[i] = p.RoutablePool.CalculateTokenOutByTokenIn(ctx, tokenIn)
+	}
+	return tokensOut, errs
+}
+
+// Tests that the batch estimate over the route matches the estimates of each amount one by one
+// and that the batch pools estimate all amounts of a hop at once.
+func (s *RouterTestSuite) TestCalculateTokenOutByTokenInBatch() {
+	s.Setup()
+
+	balancerPoolTwoOneID := s.PrepareBalancerPoolWithCoins(sdk.NewCoins(
+		sdk.NewCoin(DenomOne, sdk.NewInt(2_000_000_000)),
+		sdk.NewCoin(DenomTwo, sdk.NewInt(1_000_000_000)),
+	)...)
+	balancerPoolOneThreeID := s.PrepareBalancerPoolWithCoins(sdk.NewCoins(
+		sdk.NewCoin(DenomOne, sdk.NewInt(1_000_000_000)),
+		sdk.NewCoin(DenomThree, sdk.NewInt(3_000_000_000)),
+	)...)
+
+	balancerPoolTwoOne, err := s.App.PoolManagerKeeper.GetPool(s.Ctx, balancerPoolTwoOneID)
+	s.Require().NoError(err)
+	balancerPoolOneThree, err := s.App.PoolManagerKeeper.GetPool(s.Ctx, balancerPoolOneThreeID)
+	s.Require().NoError(err)
+
+	batchPool := &batchRoutablePool{
+		RoutablePool: mocks.WithChainPoolModel(mocks.WithTokenOutDenom(DefaultPool, DenomThree), balancerPoolOneThree),
+	}
+
+	testRoute := WithRoutePools(emptyRoute, []sqsdomain.RoutablePool{
+		mocks.WithChainPoolModel(mocks.WithTokenOutDenom(DefaultPool, DenomOne), balancerPoolTwoOne),
+		batchPool,
+	})
+
+	tokensIn := []sdk.Coin{
+		// Zero after the taker fee.
+		sdk.NewCoin(DenomTwo, osmomath.ZeroInt()),
+		sdk.NewCoin(DenomTwo, osmomath.OneInt()),
+		sdk.NewCoin(DenomTwo, osmomath.NewInt(1_000)),
+		sdk.NewCoin(DenomTwo, DefaultAmt0),
+		sdk.NewCoin(DenomTwo, osmomath.NewInt(500_000_000)),
+	}
+
+	// System under test.
+	tokensOut, errs := testRoute.CalculateTokenOutByTokenInBatch(context.TODO(), tokensIn)
+
+	s.Require().Len(tokensOut, len(tokensIn))
+	s.Require().Len(errs, len(tokensIn))
+
+	for i, tokenIn := range tokensIn {
+		expectedTokenOut, expectedErr := testRoute.CalculateTokenOutByTokenIn(context.TODO(), tokenIn)
+
+		s.Require().Equal(expectedErr, errs[i])
+		s.Require().Equal(expectedTokenOut, tokensOut[i])
+	}
+
+	s.Require().Equal(1, batchPool.numBatches)
+}
+
 func WithRoutePools(r route.RouteImpl, pools []sqsdomain.RoutablePool) route.RouteImpl {
 	return routertesting.WithRoutePools(r, pools)
 }
@@ -0,0 +1,306 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.uber.org/zap"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
+)
+
+// routeLookupDecayFactor is the factor the ranked route lookup counts are multiplied by after every warming
+// so that the learned pairs follow the recent traffic.
+const routeLookupDecayFactor = 0.5
+
+// routeWarmingCtxKey marks the context of the route warming. The cached routes are ignored under it
+// so that they are recomputed, and the lookups are not counted towards the learned pairs.
+type routeWarmingCtxKey struct{}
+
+// warmedRouteKey identifies the ranked routes warmed for the token in amounts of a single order of magnitude.
+type warmedRouteKey struct {
+	tokenInDenom     string
+	tokenOutDenom    string
+	orderOfMagnitude int
+}
+
+// routeLookupCounter counts the ranked route cache lookups by pair and order of magnitude.
+type routeLookupCounter struct {
+	mu     sync.Mutex
+	counts map[warmedRouteKey]float64
+}
+
+// warmedPair identifies the ranked routes of a pair warmed for the given orders of magnitude in increasing order.
+type warmedPair struct {
+	tokenInDenom      string
+	tokenOutDenom     string
+	ordersOfMagnitude []int
+}
+
+// routeCacheKeys are the keys of cached candidate and ranked routes.
+type routeCacheKeys struct {
+	candidate map[string]struct{}
+	ranked    map[string]struct{}
+}
+
+// WarmRoutesAsync implements mvc.RouterUsecase.
+// The routes are recomputed in the background over the latest state snapshot at the time of the call.
+// It is skipped if no pairs are configured or learned, or if the previous warming is still running.
+// Otherwise, the cached routes of the warmed keys are kept by EvictCachedRoutes until the warming replaces them.
+func (r *routerUseCaseImpl) WarmRoutesAsync(height uint64) {
+	// Nothing is kept from eviction unless the warming starts.
+	r.warmingRouteCacheKeys.Store(nil)
+
+	if !r.defaultConfig.RouteCacheEnabled {
+		return
+	}
+
+	keys := r.getWarmedRouteKeys()
+	if len(keys) == 0 {
+		return
+	}
+
+	if !r.isWarmingRoutes.CompareAndSwap(false, true) {
+		routeWarmingsSkipped.Inc()
+		r.logger.Info("skipping route warming, previous warming is in progress", zap.Uint64("height", height))
+		return
+	}
+
+	pairs := groupWarmedRouteKeysByPair(keys)
+
+	r.warmingRouteCacheKeys.Store(r.getWarmedRouteCacheKeys(pairs))
+
+	snapshot := r.routerRepository.GetStateSnapshot()
+
+	go func() {
+		defer r.isWarmingRoutes.Store(false)
+
+		startTime := time.Now()
+
+		numWarmed := r.warmRoutes(domain.WithStateSnapshot(context.Background(), snapshot), pairs)
+
+		r.logger.Info("completed route warming", zap.Uint64("height", height), zap.Int("num_keys", len(keys)), zap.Int("num_warmed", numWarmed), zap.Duration("duration", time.Since(startTime)))
+	}()
+}
+
+// warmRoutes recomputes and caches the candidate and ranked routes for every given pair
+// over the state snapshot pinned to the context, or the latest one if none is pinned.
+// The candidate routes are computed once per pair for the token in amount of the lowest order of magnitude
+// since they are cached regardless of the amount. They are then ranked with the default router options
+// and no filters for the token in amount of 10^orderOfMagnitude of every order of magnitude.
+// The cached routes that fail to be recomputed are deleted since they are kept from eviction for the warming.
+// Returns the number of pairs and orders of magnitude warmed successfully.
+func (r *routerUseCaseImpl) warmRoutes(ctx context.Context, pairs []warmedPair) int {
+	ctx, _, graph := r.pinStateSnapshot(ctx)
+	ctx = context.WithValue(ctx, routeWarmingCtxKey{}, true)
+
+	options := r.getRouterOptions()
+
+	numWarmed := 0
+	for _, pair := range pairs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		tokenIn := sdk.NewCoin(pair.tokenInDenom, osmomath.NewIntWithDecimal(1, pair.ordersOfMagnitude[0]))
+
+		candidateRoutes, err := r.handleCandidateRoutes(ctx, graph, tokenIn, pair.tokenOutDenom, options.MaxRoutes, options.MaxPoolsPerRoute, options.MinOSMOLiquidity, options.CandidateRouteFilters)
+		if err != nil {
+			r.logger.Debug("failed to warm candidate routes", zap.String("token_in_denom", pair.tokenInDenom), zap.String("token_out_denom", pair.tokenOutDenom), zap.Error(err))
+
+			r.candidateRouteCache.Delete(formatCandidateRouteCacheKey(pair.tokenInDenom, pair.tokenOutDenom, options.CandidateRouteFilters))
+			for _, orderOfMagnitude := range pair.ordersOfMagnitude {
+				r.rankedRouteCache.Delete(formatRankedRouteCacheKey(pair.tokenInDenom, pair.tokenOutDenom, orderOfMagnitude, options.CandidateRouteFilters))
+			}
+			continue
+		}
+
+		for _, orderOfMagnitude := range pair.ordersOfMagnitude {
+			tokenIn := sdk.NewCoin(pair.tokenInDenom, osmomath.NewIntWithDecimal(1, orderOfMagnitude))
+
+			if _, _, err := r.rankAndCacheRoutesByDirectQuote(ctx, candidateRoutes, tokenIn, pair.tokenOutDenom, options); err != nil {
+				r.logger.Debug("failed to warm routes", zap.String("token_in", tokenIn.String()), zap.String("token_out_denom", pair.tokenOutDenom), zap.Error(err))
+
+				// The absence of candidate routes is cached in place.
+				if len(candidateRoutes.Routes) > 0 {
+					r.rankedRouteCache.Delete(formatRankedRouteCacheKey(pair.tokenInDenom, pair.tokenOutDenom, orderOfMagnitude, options.CandidateRouteFilters))
+				}
+				continue
+			}
+
+			numWarmed++
+		}
+	}
+
+	return numWarmed
+}
+
+// groupWarmedRouteKeysByPair groups the given keys by pair in the order of their first key.
+// The orders of magnitude of every pair are sorted in increasing order.
+func groupWarmedRouteKeysByPair(keys []warmedRouteKey) []warmedPair {
+	pairs := []warmedPair{}
+	pairIndexes := make(map[[2]string]int)
+	for _, key := range keys {
+		denoms := [2]string{key.tokenInDenom, key.tokenOutDenom}
+
+		i, ok := pairIndexes[denoms]
+		if !ok {
+			i = len(pairs)
+			pairIndexes[denoms] = i
+			pairs = append(pairs, warmedPair{tokenInDenom: key.tokenInDenom, tokenOutDenom: key.tokenOutDenom})
+		}
+
+		pairs[i].ordersOfMagnitude = append(pairs[i].ordersOfMagnitude, key.orderOfMagnitude)
+	}
+
+	for _, pair := range pairs {
+		sort.Ints(pair.ordersOfMagnitude)
+	}
+
+	return pairs
+}
+
+// getWarmedRouteCacheKeys returns the keys of the candidate and ranked routes cached by warming the given pairs.
+func (r *routerUseCaseImpl) getWarmedRouteCacheKeys(pairs []warmedPair) *routeCacheKeys {
+	filters := r.getRouterOptions().CandidateRouteFilters
+
+	keys := &routeCacheKeys{
+		candidate: make(map[string]struct{}, len(pairs)),
+		ranked:    make(map[string]struct{}),
+	}
+	for _, pair := range pairs {
+		keys.candidate[formatCandidateRouteCacheKey(pair.tokenInDenom, pair.tokenOutDenom, filters)] = struct{}{}
+
+		for _, orderOfMagnitude := range pair.ordersOfMagnitude {
+			keys.ranked[formatRankedRouteCacheKey(pair.tokenInDenom, pair.tokenOutDenom, orderOfMagnitude, filters)] = struct{}{}
+		}
+	}
+
+	return keys
+}
+
+// getWarmingRouteCacheKeys returns the keys of the cached routes replaced in place by the warming
+// started at the latest height. Both sets are empty if no warming is started.
+func (r *routerUseCaseImpl) getWarmingRouteCacheKeys() routeCacheKeys {
+	keys := r.warmingRouteCacheKeys.Load()
+	if keys == nil {
+		return routeCacheKeys{}
+	}
+
+	return *keys
+}
+
+// getWarmedRouteKeys returns the keys of the routes to warm: the configured hot pairs for every configured
+// order of magnitude followed by the most frequently looked up pairs and orders of magnitude, up to MaxLearnedPairs.
+// The lookup counts decay on every call.
+func (r *routerUseCaseImpl) getWarmedRouteKeys() []warmedRouteKey {
+	warmingConfig := r.defaultConfig.RouteWarming
+
+	keys := make([]warmedRouteKey, 0, len(warmingConfig.HotPairs)*len(warmingConfig.OrdersOfMagnitude)+warmingConfig.MaxLearnedPairs)
+	isIncluded := make(map[warmedRouteKey]struct{}, cap(keys))
+	for _, hotPair := range warmingConfig.HotPairs {
+		for _, orderOfMagnitude := range warmingConfig.OrdersOfMagnitude {
+			key := warmedRouteKey{tokenInDenom: hotPair.TokenInDenom, tokenOutDenom: hotPair.TokenOutDenom, orderOfMagnitude: orderOfMagnitude}
+			if _, ok := isIncluded[key]; ok {
+				continue
+			}
+
+			isIncluded[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+
+	if warmingConfig.MaxLearnedPairs == 0 {
+		return keys
+	}
+
+	for _, key := range r.routeLookups.popMostFrequent(warmingConfig.MaxLearnedPairs + len(keys)) {
+		if len(keys) == cap(keys) {
+			break
+		}
+
+		if _, ok := isIncluded[key]; ok {
+			continue
+		}
+
+		isIncluded[key] = struct{}{}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// recordRankedRouteLookup counts the ranked route cache lookup towards the learned pairs
+// unless learning is disabled, the lookup is made by the route warming or the routes are filtered.
+func (r *routerUseCaseImpl) recordRankedRouteLookup(ctx context.Context, tokenInDenom, tokenOutDenom string, tokenInOrderOfMagnitude int, filters domain.CandidateRouteFilters) {
+	if r.defaultConfig.RouteWarming.MaxLearnedPairs == 0 || isRouteWarmingContext(ctx) || !filters.IsEmpty() {
+		return
+	}
+
+	r.routeLookups.increment(warmedRouteKey{tokenInDenom: tokenInDenom, tokenOutDenom: tokenOutDenom, orderOfMagnitude: tokenInOrderOfMagnitude})
+}
+
+// increment increments the lookup count of the given key.
+func (c *routeLookupCounter) increment(key warmedRouteKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts == nil {
+		c.counts = make(map[warmedRouteKey]float64)
+	}
+	c.counts[key]++
+}
+
+// popMostFrequent returns up to n keys with the highest lookup counts in decreasing order.
+// Afterwards, all counts decay by routeLookupDecayFactor and the ones below a single lookup are dropped.
+func (c *routeLookupCounter) popMostFrequent(n int) []warmedRouteKey {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]warmedRouteKey, 0, len(c.counts))
+	for key, count := range c.counts {
+		if count >= 1 {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if c.counts[keys[i]] != c.counts[keys[j]] {
+			return c.counts[keys[i]] > c.counts[keys[j]]
+		}
+
+		// Break ties deterministically.
+		if keys[i].tokenInDenom != keys[j].tokenInDenom {
+			return keys[i].tokenInDenom < keys[j].tokenInDenom
+		}
+		if keys[i].tokenOutDenom != keys[j].tokenOutDenom {
+			return keys[i].tokenOutDenom < keys[j].tokenOutDenom
+		}
+		return keys[i].orderOfMagnitude < keys[j].orderOfMagnitude
+	})
+
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+
+	for key, count := range c.counts {
+		count *= routeLookupDecayFactor
+		if count < 1 {
+			delete(c.counts, key)
+			continue
+		}
+		c.counts[key] = count
+	}
+
+	return keys
+}
+
+// isRouteWarmingContext returns true if the context is of the route warming.
+func isRouteWarmingContext(ctx context.Context) bool {
+	isWarming, _ := ctx.Value(routeWarmingCtxKey{}).(bool)
+	return isWarming
+}
@@ -0,0 +1,193 @@
+package usecase_test
+
+import (
+	"context"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/domain/cache"
+	"github.com/osmosis-labs/sqs/log"
+	poolsusecase "github.com/osmosis-labs/sqs/pools/usecase"
+	routerrepo "github.com/osmosis-labs/sqs/router/repository"
+	routerusecase "github.com/osmosis-labs/sqs/router/usecase"
+	"github.com/osmosis-labs/sqs/router/usecase/routertesting"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// Validates that the routes of the hot pairs are cached for every configured order of magnitude
+// after the warming so that the following quotes hit the ranked route cache.
+func (s *RouterTestSuite) TestWarmRoutesAsync_HotPairs() {
+	s.Setup()
+
+	routerConfig := routertesting.DefaultRouterConfig
+	routerConfig.RouteWarming = domain.RouteWarmingConfig{
+		HotPairs:          []domain.HotPair{{TokenInDenom: DenomOne, TokenOutDenom: DenomTwo}},
+		OrdersOfMagnitude: []int{6, 8},
+	}
+
+	routerUsecase, _ := s.setupRouteWarmingUsecase(routerConfig)
+
+	// System under test.
+	routerUsecase.WarmRoutesAsync(1)
+
+	s.Require().Eventually(func() bool {
+		for _, orderOfMagnitude := range []int{6, 8} {
+			rankedRoutes, err := routerUsecase.GetCachedRankedRoutes(context.Background(), DenomOne, DenomTwo, orderOfMagnitude)
+			if err != nil || len(rankedRoutes.Routes) == 0 {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, isCached, err := routerUsecase.GetCachedCandidateRoutes(context.Background(), DenomOne, DenomTwo)
+	s.Require().NoError(err)
+	s.Require().True(isCached)
+
+	// The pairs and orders of magnitude that are not configured are not warmed.
+	rankedRoutes, err := routerUsecase.GetCachedRankedRoutes(context.Background(), DenomOne, DenomTwo, 7)
+	s.Require().NoError(err)
+	s.Require().Empty(rankedRoutes.Routes)
+
+	rankedRoutes, err = routerUsecase.GetCachedRankedRoutes(context.Background(), DenomTwo, DenomOne, 6)
+	s.Require().NoError(err)
+	s.Require().Empty(rankedRoutes.Routes)
+
+	// A quote of the warmed order of magnitude hits the ranked route cache.
+	ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+	_, err = routerUsecase.GetOptimalQuote(ctx, sdk.NewCoin(DenomOne, osmomath.NewInt(5_000_000)), DenomTwo)
+	s.Require().NoError(err)
+	s.Require().Equal([]domain.CacheLookupResult{domain.CacheLookupHit}, getCacheLookupResults(debugInfo))
+}
+
+// Validates that the most frequently looked up pairs and orders of magnitude are learned
+// and warmed after their cached routes are evicted.
+func (s *RouterTestSuite) TestWarmRoutesAsync_LearnedPairs() {
+	s.Setup()
+
+	routerConfig := routertesting.DefaultRouterConfig
+	routerConfig.RouteWarming = domain.RouteWarmingConfig{
+		MaxLearnedPairs: 1,
+	}
+
+	routerUsecase, poolIDs := s.setupRouteWarmingUsecase(routerConfig)
+
+	// Nothing is looked up yet so there is nothing to warm.
+	routerUsecase.WarmRoutesAsync(1)
+
+	// The first pair is looked up more frequently than the second one.
+	for _, tokenIn := range []sdk.Coin{
+		sdk.NewCoin(DenomOne, osmomath.NewInt(10_000_000)),
+		sdk.NewCoin(DenomOne, osmomath.NewInt(20_000_000)),
+		sdk.NewCoin(DenomTwo, osmomath.NewInt(10_000_000)),
+	} {
+		tokenOutDenom := DenomTwo
+		if tokenIn.Denom == DenomTwo {
+			tokenOutDenom = DenomOne
+		}
+
+		_, err := routerUsecase.GetOptimalQuote(context.Background(), tokenIn, tokenOutDenom)
+		s.Require().NoError(err)
+	}
+
+	routerUsecase.EvictCachedRoutes(poolIDs, domain.SortedPoolsDiff{})
+
+	// System under test.
+	routerUsecase.WarmRoutesAsync(2)
+
+	s.Require().Eventually(func() bool {
+		rankedRoutes, err := routerUsecase.GetCachedRankedRoutes(context.Background(), DenomOne, DenomTwo, 7)
+		return err == nil && len(rankedRoutes.Routes) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Only the most frequent pair is warmed.
+	rankedRoutes, err := routerUsecase.GetCachedRankedRoutes(context.Background(), DenomTwo, DenomOne, 7)
+	s.Require().NoError(err)
+	s.Require().Empty(rankedRoutes.Routes)
+}
+
+// Validates that the cached routes of the hot pairs are kept by the eviction following the start of the warming
+// so that they are replaced in place, while the other cached routes are evicted.
+func (s *RouterTestSuite) TestWarmRoutesAsync_ReplacesInPlace() {
+	s.Setup()
+
+	routerConfig := routertesting.DefaultRouterConfig
+	routerConfig.RouteWarming = domain.RouteWarmingConfig{
+		HotPairs:          []domain.HotPair{{TokenInDenom: DenomOne, TokenOutDenom: DenomTwo}},
+		OrdersOfMagnitude: []int{6},
+	}
+
+	routerUsecase, poolIDs := s.setupRouteWarmingUsecase(routerConfig)
+
+	routerUsecase.WarmRoutesAsync(1)
+	s.Require().Eventually(func() bool {
+		return !routerUsecase.IsWarmingRoutes()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Cache the routes of the pair that is not warmed.
+	_, err := routerUsecase.GetOptimalQuote(context.Background(), sdk.NewCoin(DenomTwo, osmomath.NewInt(5_000_000)), DenomOne)
+	s.Require().NoError(err)
+
+	// System under test.
+	routerUsecase.WarmRoutesAsync(2)
+	routerUsecase.EvictCachedRoutes(poolIDs, domain.SortedPoolsDiff{})
+
+	// The routes of the hot pair are cached throughout the warming.
+	rankedRoutes, err := routerUsecase.GetCachedRankedRoutes(context.Background(), DenomOne, DenomTwo, 6)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(rankedRoutes.Routes)
+
+	rankedRoutes, err = routerUsecase.GetCachedRankedRoutes(context.Background(), DenomTwo, DenomOne, 6)
+	s.Require().NoError(err)
+	s.Require().Empty(rankedRoutes.Routes)
+
+	s.Require().Eventually(func() bool {
+		return !routerUsecase.IsWarmingRoutes()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rankedRoutes, err = routerUsecase.GetCachedRankedRoutes(context.Background(), DenomOne, DenomTwo, 6)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(rankedRoutes.Routes)
+
+	// Once the warming is skipped, the routes of the hot pair are evicted as well.
+	routerConfig.RouteWarming.HotPairs = nil
+	routerUsecase, poolIDs = s.setupRouteWarmingUsecase(routerConfig)
+
+	_, err = routerUsecase.GetOptimalQuote(context.Background(), sdk.NewCoin(DenomOne, osmomath.NewInt(5_000_000)), DenomTwo)
+	s.Require().NoError(err)
+
+	routerUsecase.WarmRoutesAsync(2)
+	routerUsecase.EvictCachedRoutes(poolIDs, domain.SortedPoolsDiff{})
+
+	rankedRoutes, err = routerUsecase.GetCachedRankedRoutes(context.Background(), DenomOne, DenomTwo, 6)
+	s.Require().NoError(err)
+	s.Require().Empty(rankedRoutes.Routes)
+}
+
+// setupRouteWarmingUsecase returns the router usecase with the given config over two pools
+// between DenomOne and DenomTwo as well as the IDs of the pools.
+func (s *RouterTestSuite) setupRouteWarmingUsecase(routerConfig domain.RouterConfig) (*routerusecase.RouterUseCaseImpl, []uint64) {
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	pools := []sqsdomain.PoolI{
+		withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64()),
+		withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount.MulRaw(2))), liquidityAmount.Int64()),
+	}
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(sqsdomain.TakerFeeMap{})
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools(pools)
+
+	routerUsecase := routerusecase.NewRouterUsecase(routerRepository, poolsUsecase, routerConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+	routerUsecase.SetSortedPools(pools)
+
+	routerUsecaseImpl, ok := routerUsecase.(*routerusecase.RouterUseCaseImpl)
+	s.Require().True(ok)
+
+	return routerUsecaseImpl, []uint64{pools[0].GetId(), pools[1].GetId()}
+}
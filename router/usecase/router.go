@@ -48,42 +48,58 @@ func ValidateAndSortPools(pools []sqsdomain.PoolI, cosmWasmPoolsConfig domain.Co
 
 	// Make a copy and filter pools
 	for _, pool := range pools {
-		// TODO: the zero argument can be removed in a future release
-		// since we will be filtering at a different layer of abstraction.
-		if err := pool.Validate(zero); err != nil {
-			logger.Debug("pool validation failed, skip silently", zap.Uint64("pool_id", pool.GetId()), zap.Error(err))
+		if !isValidRouterPool(pool, cosmWasmPoolsConfig, logger) {
 			continue
 		}
 
-		// Confirm that a cosmwasm code ID is whitelisted via config.
-		if pool.GetType() == poolmanagertypes.CosmWasm {
-			cosmWasmPool, ok := pool.GetUnderlyingPool().(cosmwasmpooltypes.CosmWasmExtension)
-			if !ok {
-				logger.Debug("failed to cast a cosm wasm pool, skip silently", zap.Uint64("pool_id", pool.GetId()))
-				continue
-			}
-
-			_, isGeneralCosmWasmCodeID := cosmWasmPoolsConfig.GeneralCosmWasmCodeIDs[cosmWasmPool.GetCodeId()]
-			_, isTransmuterCodeID := cosmWasmPoolsConfig.TransmuterCodeIDs[cosmWasmPool.GetCodeId()]
-			if !(isGeneralCosmWasmCodeID || isTransmuterCodeID) {
-				logger.Debug("cw pool code id is enot added to config, skip silently", zap.Uint64("pool_id", pool.GetId()))
-				continue
-			}
-		}
-
 		filteredPools = append(filteredPools, pool)
 
 		totalTVL = totalTVL.Add(pool.GetTotalValueLockedUSDC())
 	}
 
+	logger.Info("validated pools", zap.Int("num_pools", len(filteredPools)))
+
+	return sortPools(filteredPools, cosmWasmPoolsConfig.TransmuterCodeIDs, totalTVL, getPreferredPoolIDsMap(preferredPoolIDs), logger)
+}
+
+// isValidRouterPool returns true if the given pool can be used in the router.
+// Pools that fail validation and cosmwasm pools with code IDs that are not
+// whitelisted via config are invalid.
+func isValidRouterPool(pool sqsdomain.PoolI, cosmWasmPoolsConfig domain.CosmWasmPoolRouterConfig, logger log.Logger) bool {
+	// TODO: the zero argument can be removed in a future release
+	// since we will be filtering at a different layer of abstraction.
+	if err := pool.Validate(zero); err != nil {
+		logger.Debug("pool validation failed, skip silently", zap.Uint64("pool_id", pool.GetId()), zap.Error(err))
+		return false
+	}
+
+	// Confirm that a cosmwasm code ID is whitelisted via config.
+	if pool.GetType() == poolmanagertypes.CosmWasm {
+		cosmWasmPool, ok := pool.GetUnderlyingPool().(cosmwasmpooltypes.CosmWasmExtension)
+		if !ok {
+			logger.Debug("failed to cast a cosm wasm pool, skip silently", zap.Uint64("pool_id", pool.GetId()))
+			return false
+		}
+
+		_, isGeneralCosmWasmCodeID := cosmWasmPoolsConfig.GeneralCosmWasmCodeIDs[cosmWasmPool.GetCodeId()]
+		_, isTransmuterCodeID := cosmWasmPoolsConfig.TransmuterCodeIDs[cosmWasmPool.GetCodeId()]
+		isNativeCodeID := cosmWasmPoolsConfig.NativePools.IsRegistered(cosmWasmPool.GetCodeId())
+		if !(isGeneralCosmWasmCodeID || isTransmuterCodeID || isNativeCodeID) {
+			logger.Debug("cw pool code id is enot added to config, skip silently", zap.Uint64("pool_id", pool.GetId()))
+			return false
+		}
+	}
+
+	return true
+}
+
+// getPreferredPoolIDsMap converts the given preferred pool IDs into a set.
+func getPreferredPoolIDsMap(preferredPoolIDs []uint64) map[uint64]struct{} {
 	preferredPoolIDsMap := make(map[uint64]struct{})
 	for _, poolID := range preferredPoolIDs {
 		preferredPoolIDsMap[poolID] = struct{}{}
 	}
-
-	logger.Info("validated pools", zap.Int("num_pools", len(filteredPools)))
-
-	return sortPools(filteredPools, cosmWasmPoolsConfig.TransmuterCodeIDs, totalTVL, preferredPoolIDsMap, logger)
+	return preferredPoolIDsMap
 }
 
 // sortPools sorts the given pools so that the most appropriate pools are at the top.
@@ -91,7 +107,7 @@ func ValidateAndSortPools(pools []sqsdomain.PoolI, cosmWasmPoolsConfig domain.Co
 // - Initial rating equals to the pool's total value locked denominated in OSMO.
 // - If the pool has no error in TVL, add 1/100 of total value locked across all pools to the rating.
 // - If the pool is a preferred pool, add the total value locked across all pools to the rating.
-// - If the pool is a concentrated pool, add 1/2 of total value locked across all pools to the rating.
+// - If the pool is a concentrated or an orderbook pool, add 1/2 of total value locked across all pools to the rating.
 // - If the pool is a transmuter pool, add 3/2 of total value locked across all pools to the rating.
 // - Sort all pools by the rating score.
 //
@@ -109,38 +125,9 @@ func sortPools(pools []sqsdomain.PoolI, transmuterCodeIDs map[uint64]struct{}, t
 
 	ratedPools := make([]ratedPool, 0, len(pools))
 	for _, pool := range pools {
-		// Initialize rating to TVL.
-		rating, _ := pool.GetTotalValueLockedUSDC().BigIntMut().Float64()
-
-		// rating += 1/ 100 of TVL of asset across all pools
-		// (Ignoring any pool with an error in TVL)
-		if pool.GetSQSPoolModel().TotalValueLockedError == noTotalValueLockedError {
-			rating += totalTVLFloat / 100
-		}
-
-		// Preferred pools get a boost equal to the total value locked across all pools
-		_, isPreferred := preferredPoolIDsMap[pool.GetId()]
-		if isPreferred {
-			rating += totalTVLFloat
-		}
-
-		// Concentrated pools get a boost equal to 1/2 of total value locked across all pools
-		isConcentrated := pool.GetType() == poolmanagertypes.Concentrated
-		if isConcentrated {
-			rating += totalTVLFloat / 2
-		}
-
-		// Transmuter pools get a boost equal to 3/2 of total value locked across all pools
-		if pool.GetType() == poolmanagertypes.CosmWasm {
-			cosmWasmPool, ok := pool.GetUnderlyingPool().(cosmwasmpooltypes.CosmWasmExtension)
-			if !ok {
-				logger.Debug("failed to cast a cosm wasm pool, skip silently", zap.Uint64("pool_id", pool.GetId()))
-				continue
-			}
-			_, isTransmuter := transmuterCodeIDs[cosmWasmPool.GetCodeId()]
-			if isTransmuter {
-				rating += totalTVLFloat * 1.5
-			}
+		rating, ok := ratePool(pool, transmuterCodeIDs, totalTVLFloat, preferredPoolIDsMap, logger)
+		if !ok {
+			continue
 		}
 
 		ratedPools = append(ratedPools, ratedPool{
@@ -151,7 +138,7 @@ func sortPools(pools []sqsdomain.PoolI, transmuterCodeIDs map[uint64]struct{}, t
 
 	// Sort all pools by the rating score
 	sort.Slice(ratedPools, func(i, j int) bool {
-		return ratedPools[i].rating > ratedPools[j].rating
+		return ratedPoolLess(ratedPools[i], ratedPools[j])
 	})
 
 	logger.Info("sorted pools", zap.Int("pool_count", len(ratedPools)))
@@ -163,5 +150,47 @@ func sortPools(pools []sqsdomain.PoolI, transmuterCodeIDs map[uint64]struct{}, t
 		logger.Debug("pool", zap.Int("index", i), zap.Any("pool", pool.GetId()), zap.Float64("rate", ratedPool.rating), zap.Stringer("tvl", sqsModel.TotalValueLockedUSDC), zap.String("tvl_error", sqsModel.TotalValueLockedError))
 		pools[i] = ratedPool.pool
 	}
-	return pools
+	return pools[:len(ratedPools)]
+}
+
+// ratePool returns the rating of the given pool as described in sortPools.
+// Returns false if the pool cannot be rated.
+func ratePool(pool sqsdomain.PoolI, transmuterCodeIDs map[uint64]struct{}, totalTVLFloat float64, preferredPoolIDsMap map[uint64]struct{}, logger log.Logger) (float64, bool) {
+	// Initialize rating to TVL.
+	rating, _ := pool.GetTotalValueLockedUSDC().BigIntMut().Float64()
+
+	// rating += 1/ 100 of TVL of asset across all pools
+	// (Ignoring any pool with an error in TVL)
+	if pool.GetSQSPoolModel().TotalValueLockedError == noTotalValueLockedError {
+		rating += totalTVLFloat / 100
+	}
+
+	// Preferred pools get a boost equal to the total value locked across all pools
+	_, isPreferred := preferredPoolIDsMap[pool.GetId()]
+	if isPreferred {
+		rating += totalTVLFloat
+	}
+
+	// Concentrated and orderbook pools get a boost equal to 1/2 of total value locked across all pools
+	isConcentrated := pool.GetType() == poolmanagertypes.Concentrated
+	_, orderbookModelErr := pool.GetOrderbookModel()
+	isOrderbook := pool.GetType() == poolmanagertypes.CosmWasm && orderbookModelErr == nil
+	if isConcentrated || isOrderbook {
+		rating += totalTVLFloat / 2
+	}
+
+	// Transmuter pools get a boost equal to 3/2 of total value locked across all pools
+	if pool.GetType() == poolmanagertypes.CosmWasm {
+		cosmWasmPool, ok := pool.GetUnderlyingPool().(cosmwasmpooltypes.CosmWasmExtension)
+		if !ok {
+			logger.Debug("failed to cast a cosm wasm pool, skip silently", zap.Uint64("pool_id", pool.GetId()))
+			return 0, false
+		}
+		_, isTransmuter := transmuterCodeIDs[cosmWasmPool.GetCodeId()]
+		if isTransmuter {
+			rating += totalTVLFloat * 1.5
+		}
+	}
+
+	return rating, true
 }
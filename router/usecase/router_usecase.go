@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -37,10 +40,34 @@ type routerUseCaseImpl struct {
 
 	rankedRouteCache *cache.Cache
 
-	sortedPoolsMu sync.RWMutex
-	sortedPools   []sqsdomain.PoolI
+	// poolGraph is the routing graph over the sorted pools of the snapshot it was built for.
+	// It is rebuilt the first time a new snapshot is read.
+	poolGraph atomic.Pointer[snapshotPoolGraph]
+	// poolGraphMu serializes building the routing graph.
+	poolGraphMu sync.Mutex
 
 	candidateRouteCache *cache.Cache
+
+	// arbitrage is the result of the latest arbitrage detection.
+	arbitrage   domain.ArbitrageResult
+	arbitrageMu sync.RWMutex
+	// isDetectingArbitrage is set while the arbitrage detection is running.
+	isDetectingArbitrage atomic.Bool
+
+	// quoteSubscriptions are the live quote subscriptions by subscription ID.
+	quoteSubscriptions      map[uint64]*quoteSubscription
+	nextQuoteSubscriptionID uint64
+	quoteSubscriptionsMu    sync.Mutex
+	// isPushingQuoteUpdates is set while the quote updates are being pushed.
+	isPushingQuoteUpdates atomic.Bool
+
+	// routeLookups counts the ranked route cache lookups to learn the pairs to warm.
+	routeLookups routeLookupCounter
+	// isWarmingRoutes is set while the routes are being warmed.
+	isWarmingRoutes atomic.Bool
+	// warmingRouteCacheKeys are the cache keys of the routes replaced in place by the warming
+	// started at the latest height, if any.
+	warmingRouteCacheKeys atomic.Pointer[routeCacheKeys]
 }
 
 const (
@@ -75,6 +102,19 @@ var (
 		},
 		[]string{"route", "cache_type", "token_in", "token_out", "token_in_order_of_magnitude"},
 	)
+	cacheEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sqs_routes_cache_evictions_total",
+			Help: "Total number of cached routes evicted due to a change in one of their pools",
+		},
+		[]string{"cache_type"},
+	)
+	routeWarmingsSkipped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "sqs_routes_warming_skipped_total",
+			Help: "Total number of route warmings skipped because the previous warming was still in progress",
+		},
+	)
 
 	zero = sdk.ZeroInt()
 )
@@ -82,6 +122,8 @@ var (
 func init() {
 	prometheus.MustRegister(cacheHits)
 	prometheus.MustRegister(cacheMisses)
+	prometheus.MustRegister(cacheEvictions)
+	prometheus.MustRegister(routeWarmingsSkipped)
 }
 
 // NewRouterUsecase will create a new pools use case object
@@ -96,8 +138,7 @@ func NewRouterUsecase(routerRepository routerrepo.RouterRepository, poolsUsecase
 		rankedRouteCache:    rankedRouteCache,
 		candidateRouteCache: candidateRouteCache,
 
-		sortedPools:   make([]sqsdomain.PoolI, 0),
-		sortedPoolsMu: sync.RWMutex{},
+		quoteSubscriptions: make(map[uint64]*quoteSubscription),
 	}
 }
 
@@ -110,32 +151,150 @@ func NewRouterUsecase(routerRepository routerrepo.RouterRepository, poolsUsecase
 // are present in cache, they are used without re-computing them. Otherwise, they are computed and cached.
 // In the future, we will support caching of ranked routes that are constructed from candidate and sorted
 // by the decreasing amount out within an order of magnitude of token in. Similarly, We will also support optimal split caching
+// If the max latency option is set and the deadline is exceeded, the best quote found so far is returned
+// flagged as truncated instead of an error. An error is only returned if no route was quoted by then.
 // Returns error if:
 // - fails to estimate direct quotes for ranked routes
 // - fails to retrieve candidate routes
 func (r *routerUseCaseImpl) GetOptimalQuote(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string, opts ...domain.RouterOption) (domain.Quote, error) {
-	options := domain.RouterOptions{
-		MaxPoolsPerRoute:                 r.defaultConfig.MaxPoolsPerRoute,
-		MaxRoutes:                        r.defaultConfig.MaxRoutes,
-		MaxSplitIterations:               r.defaultConfig.MaxSplitIterations,
-		MinOSMOLiquidity:                 r.defaultConfig.MinOSMOLiquidity,
-		CandidateRouteCacheExpirySeconds: r.defaultConfig.CandidateRouteCacheExpirySeconds,
-		RankedRouteCacheExpirySeconds:    r.defaultConfig.RankedRouteCacheExpirySeconds,
-		MaxSplitRoutes:                   r.defaultConfig.MaxSplitRoutes,
+	ctx, _, graph := r.pinStateSnapshot(ctx)
+	return r.getOptimalQuote(ctx, graph, tokenIn, tokenOutDenom, opts...)
+}
+
+// GetOptimalQuotes implements mvc.RouterUsecase.
+// The state snapshot is pinned once so that all quotes are computed over the same pools.
+// The quotes are computed by a pool of at most MaxBatchQuoteWorkers workers.
+// A failure of one quote does not affect the others. Once the context is done,
+// the remaining quotes fail with the context error.
+func (r *routerUseCaseImpl) GetOptimalQuotes(ctx context.Context, requests []domain.QuoteRequest) []domain.QuoteResult {
+	ctx, _, graph := r.pinStateSnapshot(ctx)
+
+	results := make([]domain.QuoteResult, len(requests))
+
+	numWorkers := min(r.defaultConfig.MaxBatchQuoteWorkers, len(requests))
+	if numWorkers <= 0 {
+		numWorkers = 1
 	}
 
-	// Apply options
-	for _, opt := range opts {
-		opt(&options)
+	requestIndexes := make(chan int, len(requests))
+	for i := range requests {
+		requestIndexes <- i
 	}
+	close(requestIndexes)
+
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Every worker writes only to the results of the requests it picked up.
+			for i := range requestIndexes {
+				if err := ctx.Err(); err != nil {
+					results[i] = domain.QuoteResult{Err: err}
+					continue
+				}
+
+				request := requests[i]
+				quote, err := r.getOptimalQuote(ctx, graph, request.TokenIn, request.TokenOutDenom, request.Options...)
+				results[i] = domain.QuoteResult{Quote: quote, Err: err}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// quoteDepthRoutesCtxKey is the context key of the routes reused across the amounts of a quote depth ladder.
+type quoteDepthRoutesCtxKey struct{}
+
+// quoteDepthRoutes are the routes computed for the amounts of a quote depth ladder so far.
+// They are reused for the remaining amounts even if the route cache is disabled or the snapshot is not the latest.
+// Not safe for concurrent use since the amounts are quoted sequentially.
+type quoteDepthRoutes struct {
+	// candidate routes of the pair. Nil until computed.
+	candidate *sqsdomain.CandidateRoutes
+	// ranked routes by the order of magnitude of the token in amount.
+	ranked map[int]sqsdomain.CandidateRoutes
+}
+
+// getQuoteDepthRoutes returns the routes of the quote depth ladder attached to the context.
+// Returns nil if the context is not of a quote depth ladder.
+func getQuoteDepthRoutes(ctx context.Context) *quoteDepthRoutes {
+	depthRoutes, _ := ctx.Value(quoteDepthRoutesCtxKey{}).(*quoteDepthRoutes)
+	return depthRoutes
+}
+
+// GetQuoteDepth implements mvc.RouterUsecase.
+// The state snapshot is pinned once so that all quotes are computed over the same pools.
+// The quotes are computed sequentially so that the candidate routes are computed once for the ladder and
+// the routes ranked for the first amount of every order of magnitude are reused for the remaining amounts
+// of the same order of magnitude, the same way they would be served from the route cache.
+// A failure of one quote does not affect the others. Once the context is done,
+// the remaining quotes fail with the context error.
+func (r *routerUseCaseImpl) GetQuoteDepth(ctx context.Context, tokenInDenom, tokenOutDenom string, amounts []osmomath.Int, opts ...domain.RouterOption) []domain.QuoteResult {
+	ctx, _, graph := r.pinStateSnapshot(ctx)
+	ctx = context.WithValue(ctx, quoteDepthRoutesCtxKey{}, &quoteDepthRoutes{ranked: make(map[int]sqsdomain.CandidateRoutes)})
+
+	results := make([]domain.QuoteResult, len(amounts))
+	for i, amount := range amounts {
+		if err := ctx.Err(); err != nil {
+			results[i] = domain.QuoteResult{Err: err}
+			continue
+		}
+
+		quote, err := r.getOptimalQuote(ctx, graph, sdk.NewCoin(tokenInDenom, amount), tokenOutDenom, opts...)
+		results[i] = domain.QuoteResult{Quote: quote, Err: err}
+	}
+
+	return results
+}
+
+// getOptimalQuote returns the optimal quote over the given routing graph.
+// CONTRACT: the graph is built from the sorted pools of the state snapshot pinned to the context.
+// See GetOptimalQuote for details.
+func (r *routerUseCaseImpl) getOptimalQuote(ctx context.Context, graph *PoolGraph, tokenIn sdk.Coin, tokenOutDenom string, opts ...domain.RouterOption) (quote domain.Quote, err error) {
+	options := r.getRouterOptions(opts...)
+
+	// Every phase stops once the latency budget is exceeded. See markTruncated for details.
+	if options.MaxLatency > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.MaxLatency)
+		defer cancel()
+	}
+
+	// The gas is estimated once the quote is selected unless it was already estimated to select the split.
+	// Deferred after the cancellation above so that it runs before it and is only skipped past the deadline.
+	defer func() {
+		if err == nil && quote.GetEstimatedGas() == 0 {
+			r.setEstimatedGas(ctx, quote)
+		}
+	}()
+
+	debugInfo := domain.GetQuoteDebugInfoFromContext(ctx)
 
 	// Get an order of magnitude for the token in amount
 	// This is used for caching ranked routes as these might differ depending on the amount swapped in.
 	tokenInOrderOfMagnitude := GetPrecomputeOrderOfMagnitude(tokenIn.Amount)
 
-	candidateRankedRoutes, err := r.GetCachedRankedRoutes(ctx, tokenIn.Denom, tokenOutDenom, tokenInOrderOfMagnitude)
-	if err != nil {
-		return nil, err
+	// The routes ranked for the previous amounts of a quote depth ladder take precedence over the cached ones.
+	depthRoutes := getQuoteDepthRoutes(ctx)
+
+	var (
+		candidateRankedRoutes sqsdomain.CandidateRoutes
+		isDepthRanked         bool
+	)
+	if depthRoutes != nil {
+		candidateRankedRoutes, isDepthRanked = depthRoutes.ranked[tokenInOrderOfMagnitude]
+	}
+
+	if !isDepthRanked {
+		candidateRankedRoutes, err = r.getCachedRankedRoutes(ctx, tokenIn.Denom, tokenOutDenom, tokenInOrderOfMagnitude, options.CandidateRouteFilters)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	var (
@@ -148,10 +307,10 @@ func (r *routerUseCaseImpl) GetOptimalQuote(ctx context.Context, tokenIn sdk.Coi
 	// As a result, they are incorrectly excluded despite having appropriate liquidity.
 	// So we want to calculate price, but we never cache routes for pricing the are below the minOSMOLiquidity value, as these are returned to users.
 	if options.MinOSMOLiquidity == 0 {
-		pools := r.getSortedPoolsShallowCopy()
-
-		// Compute candidate routes.
-		candidateRoutes, err := GetCandidateRoutes(pools, tokenIn, tokenOutDenom, options.MaxRoutes, options.MaxPoolsPerRoute, r.logger)
+		// Compute candidate routes unless computed for a previous amount of the quote depth ladder.
+		candidateRoutes, err := r.getDepthCandidateRoutes(ctx, func() (sqsdomain.CandidateRoutes, error) {
+			return getCandidateRoutes(ctx, graph, tokenIn, tokenOutDenom, options.MaxRoutes, options.MaxPoolsPerRoute, options.MinOSMOLiquidity, options.CandidateRouteFilters, debugInfo, r.logger)
+		})
 		if err != nil {
 			r.logger.Error("error getting candidate routes for pricing", zap.Error(err))
 			return nil, err
@@ -164,16 +323,13 @@ func (r *routerUseCaseImpl) GetOptimalQuote(ctx context.Context, tokenIn sdk.Coi
 			return nil, err
 		}
 	} else if len(candidateRankedRoutes.Routes) == 0 {
-		poolsAboveMinLiquidity := r.getSortedPoolsShallowCopy()
+		// Pools below the min liquidity are skipped during the candidate route search.
+		topSingleRouteQuote, rankedRoutes, err = r.computeAndRankRoutesByDirectQuote(ctx, graph, tokenIn, tokenOutDenom, options)
 
-		// Zero implies no filtering, so we skip the iterations.
-		if options.MinOSMOLiquidity > 0 {
-			poolsAboveMinLiquidity = FilterPoolsByMinLiquidity(poolsAboveMinLiquidity, options.MinOSMOLiquidity)
+		// Routes ranked after the context is done might be incomplete so they are not reused.
+		if err == nil && depthRoutes != nil && ctx.Err() == nil {
+			depthRoutes.ranked[tokenInOrderOfMagnitude] = convertRankedToCandidateRoutes(rankedRoutes)
 		}
-
-		r.logger.Info("filtered pools", zap.Int("num_pools", len(poolsAboveMinLiquidity)))
-
-		topSingleRouteQuote, rankedRoutes, err = r.computeAndRankRoutesByDirectQuote(ctx, poolsAboveMinLiquidity, tokenIn, tokenOutDenom, options)
 	} else {
 		// Otherwise, simply compute quotes over cached ranked routes
 		topSingleRouteQuote, rankedRoutes, err = r.rankRoutesByDirectQuote(ctx, candidateRankedRoutes, tokenIn, tokenOutDenom, options.MaxRoutes)
@@ -182,33 +338,71 @@ func (r *routerUseCaseImpl) GetOptimalQuote(ctx context.Context, tokenIn sdk.Coi
 		return nil, err
 	}
 
+	// The deadline was hit while searching or ranking the routes.
+	if ctx.Err() != nil {
+		debugInfo.SetSelectedQuote(domain.SingleRouteQuoteSelected)
+		return markTruncated(topSingleRouteQuote), nil
+	}
+
 	if len(rankedRoutes) == 1 || options.MaxSplitRoutes == domain.DisableSplitRoutes {
+		debugInfo.SetSelectedQuote(domain.SingleRouteQuoteSelected)
 		return topSingleRouteQuote, nil
 	}
 
-	// Filter out generalized cosmWasm pool routes
-	rankedRoutes = filterOutGeneralizedCosmWasmPoolRoutes(rankedRoutes)
+	// Filter out generalized cosmWasm pool routes unless their simulations are cheap enough for splits.
+	if !r.defaultConfig.GeneralizedCosmWasmPoolSplitsEnabled {
+		filteredRankedRoutes := filterOutGeneralizedCosmWasmPoolRoutes(rankedRoutes)
+		addFilteredRoutesDebugInfo(debugInfo, rankedRoutes, filteredRankedRoutes, domain.FilterOutGeneralizedCosmWasmRoutesFilter, "contains a generalized cosmwasm pool")
+		rankedRoutes = filteredRankedRoutes
+	}
 
 	// If filtering leads to a single route left, return it.
 	if len(rankedRoutes) == 1 {
+		debugInfo.SetSelectedQuote(domain.SingleRouteQuoteSelected)
 		return topSingleRouteQuote, nil
 	}
 
+	// The split found over the coarse increments is refined further in the adaptive mode.
+	maxRefinementIterations := 0
+	if r.defaultConfig.AdaptiveSplitsEnabled {
+		maxRefinementIterations = options.MaxSplitIterations
+	}
+
 	// Compute split route quote
-	topSplitQuote, err := getSplitQuote(ctx, rankedRoutes, tokenIn)
+	topSplitQuote, err := getSplitQuote(ctx, rankedRoutes, tokenIn, getSplitGasCosts(r.defaultConfig.Gas, rankedRoutes, options.GasPriceInTokenOut), maxRefinementIterations, r.logger)
 	if err != nil {
+		// The deadline was hit while computing the split. Fall back to the best single route.
+		if ctx.Err() != nil {
+			debugInfo.SetSelectedQuote(domain.SingleRouteQuoteSelected)
+			return markTruncated(topSingleRouteQuote), nil
+		}
+
+		// No split returns more than its gas costs. Fall back to the best single route.
+		if !options.GasPriceInTokenOut.IsNil() {
+			debugInfo.SetSelectedQuote(domain.SingleRouteQuoteSelected)
+			return topSingleRouteQuote, nil
+		}
+
 		return nil, err
 	}
 
 	finalQuote := topSingleRouteQuote
+	selectedQuote := domain.SingleRouteQuoteSelected
+
+	// The split and the single route quotes are compared net of the gas costs including the ticks crossed.
+	if !options.GasPriceInTokenOut.IsNil() {
+		r.setEstimatedGas(ctx, topSingleRouteQuote)
+		r.setEstimatedGas(ctx, topSplitQuote)
+	}
 
 	// If the split route quote is better than the single route quote, return the split route quote
-	if topSplitQuote.GetAmountOut().GT(topSingleRouteQuote.GetAmountOut()) {
+	if getNetAmountOut(topSplitQuote, options.GasPriceInTokenOut).GT(getNetAmountOut(topSingleRouteQuote, options.GasPriceInTokenOut)) {
 		routes := topSplitQuote.GetRoute()
 
 		r.logger.Debug("split route selected", zap.Int("route_count", len(routes)))
 
 		finalQuote = topSplitQuote
+		selectedQuote = domain.SplitQuoteSelected
 	}
 
 	r.logger.Debug("single route selected", zap.Stringer("route", finalQuote.GetRoute()[0]))
@@ -217,9 +411,127 @@ func (r *routerUseCaseImpl) GetOptimalQuote(ctx context.Context, tokenIn sdk.Coi
 		return nil, errors.New("best we can do is no tokens out")
 	}
 
+	debugInfo.SetSelectedQuote(selectedQuote)
+
 	return finalQuote, nil
 }
 
+// markTruncated flags the given quote as the best one found before the context was done
+// and returns it. With the latency budget, the candidate route search, the ranking and the split
+// computation stop once the deadline is exceeded. Neither the partially found nor the partially
+// ranked routes are cached.
+func markTruncated(quote domain.Quote) domain.Quote {
+	if q, ok := quote.(*quoteImpl); ok {
+		q.Truncated = true
+	}
+	return quote
+}
+
+// GetOptimalQuoteInGivenOut returns the optimal quote for receiving exactly the given token out
+// in exchange for the token in denom by estimating the optimal route(s) through pools.
+// Routes are ranked by the lowest amount in required. If splits are enabled, the token out
+// is split across the ranked routes so that the total amount in is minimized.
+// Unlike the exact amount in splits, the routes sharing a pool with a better ranked route are always
+// excluded from the split since domain.StatefulRoutablePool only simulates the state after swapping
+// a given token in. The amounts in of such routes cannot be estimated jointly as a result.
+// Uses default router config if no options parameter is provided.
+// Candidate routes are shared with the exact amount in quotes via the candidate route cache.
+// Ranked routes are not cached.
+// Returns error if:
+// - fails to retrieve candidate routes
+// - no route can provide the token out amount
+func (r *routerUseCaseImpl) GetOptimalQuoteInGivenOut(ctx context.Context, tokenOut sdk.Coin, tokenInDenom string, opts ...domain.RouterOption) (quote domain.Quote, err error) {
+	options := r.getRouterOptions(opts...)
+
+	defer func() {
+		if err == nil {
+			r.setEstimatedGas(ctx, quote)
+		}
+	}()
+
+	// Note that the amount is irrelevant for the candidate route search when swapping
+	// by token out. Zero skips the token in balance check for the first pool.
+	tokenIn := sdk.NewCoin(tokenInDenom, zero)
+
+	ctx, _, graph := r.pinStateSnapshot(ctx)
+
+	candidateRoutes, err := r.handleCandidateRoutes(ctx, graph, tokenIn, tokenOut.Denom, options.MaxRoutes, options.MaxPoolsPerRoute, options.MinOSMOLiquidity, options.CandidateRouteFilters)
+	if err != nil {
+		r.logger.Error("error handling routes", zap.Error(err))
+		return nil, err
+	}
+
+	if len(candidateRoutes.Routes) == 0 {
+		return nil, fmt.Errorf("no candidate routes found")
+	}
+
+	routes, err := r.poolsUsecase.GetRoutesFromCandidates(ctx, candidateRoutes, tokenInDenom, tokenOut.Denom)
+	if err != nil {
+		return nil, err
+	}
+
+	topSingleRouteQuote, rankedRoutes, err := estimateDirectQuoteInGivenOut(ctx, routes, tokenOut, options.MaxRoutes, r.logger)
+	if err != nil {
+		return nil, fmt.Errorf("%s, tokenInDenom (%s)", err, tokenInDenom)
+	}
+
+	if len(rankedRoutes) == 1 || options.MaxSplitRoutes == domain.DisableSplitRoutes {
+		return topSingleRouteQuote, nil
+	}
+
+	// Filter out generalized cosmWasm pool routes and routes that share pools
+	// with the better ranked ones, including the stateful ones. See above.
+	rankedRoutes = filterOutGeneralizedCosmWasmPoolRoutes(rankedRoutes)
+	rankedRoutes = filterDuplicatePoolIDRoutes(rankedRoutes)
+
+	// If filtering leads to a single route left, return it.
+	if len(rankedRoutes) == 1 {
+		return topSingleRouteQuote, nil
+	}
+
+	topSplitQuote, err := getSplitQuoteInGivenOut(ctx, rankedRoutes, tokenOut)
+	if err != nil {
+		// The top single route is able to provide the token out so we do not fail the quote.
+		r.logger.Debug("failed to compute split quote in given out", zap.Error(err))
+		return topSingleRouteQuote, nil
+	}
+
+	// If the split route quote requires less token in than the single route quote, return the split route quote
+	if topSplitQuote.GetAmountIn().Amount.LT(topSingleRouteQuote.GetAmountIn().Amount) {
+		r.logger.Debug("split route selected", zap.Int("route_count", len(topSplitQuote.GetRoute())))
+
+		return topSplitQuote, nil
+	}
+
+	return topSingleRouteQuote, nil
+}
+
+// getRouterOptions returns the router options initialized from the default config
+// with the given options applied on top.
+func (r *routerUseCaseImpl) getRouterOptions(opts ...domain.RouterOption) domain.RouterOptions {
+	options := domain.RouterOptions{
+		MaxPoolsPerRoute:                 r.defaultConfig.MaxPoolsPerRoute,
+		MaxRoutes:                        r.defaultConfig.MaxRoutes,
+		MaxSplitIterations:               r.defaultConfig.MaxSplitIterations,
+		MinOSMOLiquidity:                 r.defaultConfig.MinOSMOLiquidity,
+		CandidateRouteCacheExpirySeconds: r.defaultConfig.CandidateRouteCacheExpirySeconds,
+		RankedRouteCacheExpirySeconds:    r.defaultConfig.RankedRouteCacheExpirySeconds,
+		MaxSplitRoutes:                   r.defaultConfig.MaxSplitRoutes,
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// The routes searched with fewer or more pools per route than the default must not be served from the default cache keys.
+	if options.MaxPoolsPerRoute != r.defaultConfig.MaxPoolsPerRoute {
+		options.CandidateRouteFilters.MaxPoolsPerRoute = options.MaxPoolsPerRoute
+	}
+
+	return options
+}
+
 // filterDuplicatePoolIDRoutes filters routes that contain duplicate pool IDs.
 // CONTRACT: rankedRoutes are sorted in decreasing order by amount out
 // from first to last.
@@ -266,6 +578,41 @@ func filterDuplicatePoolIDRoutes(rankedRoutes []route.RouteImpl) []route.RouteIm
 	return filteredRankedRoutes
 }
 
+// filterSharedPoolRoutes filters routes that share a pool with a better ranked route
+// unless the pool is a domain.StatefulRoutablePool. The routes sharing such pools are kept
+// since the split simulates them jointly. See getSharedPoolSplitQuote for details.
+// CONTRACT: rankedRoutes are sorted in decreasing order by amount out
+// from first to last.
+func filterSharedPoolRoutes(rankedRoutes []route.RouteImpl) []route.RouteImpl {
+	combinedPoolIDsMap := make(map[uint64]struct{})
+	filteredRankedRoutes := make([]route.RouteImpl, 0, len(rankedRoutes))
+
+	for _, route := range rankedRoutes {
+		isShareable := true
+		for _, pool := range route.GetPools() {
+			if _, exists := combinedPoolIDsMap[pool.GetId()]; !exists {
+				continue
+			}
+
+			if _, ok := pool.(domain.StatefulRoutablePool); !ok {
+				isShareable = false
+				break
+			}
+		}
+
+		if !isShareable {
+			continue
+		}
+
+		for _, pool := range route.GetPools() {
+			combinedPoolIDsMap[pool.GetId()] = struct{}{}
+		}
+
+		filteredRankedRoutes = append(filteredRankedRoutes, route)
+	}
+	return filteredRankedRoutes
+}
+
 // rankRoutesByDirectQuote ranks the given candidate routes by estimating direct quotes over each route.
 // Returns the top quote as well as the ranked routes in decrease order of amount out.
 // Returns error if:
@@ -273,9 +620,10 @@ func filterDuplicatePoolIDRoutes(rankedRoutes []route.RouteImpl) []route.RouteIm
 // - fails to convert candidate routes to routes
 // - fails to estimate direct quotes
 func (r *routerUseCaseImpl) rankRoutesByDirectQuote(ctx context.Context, candidateRoutes sqsdomain.CandidateRoutes, tokenIn sdk.Coin, tokenOutDenom string, maxRoutes int) (domain.Quote, []route.RouteImpl, error) {
-	// Note that retrieving pools and taker fees is done in separate transactions.
-	// This is fine because taker fees don't change often.
-	routes, err := r.poolsUsecase.GetRoutesFromCandidates(candidateRoutes, tokenIn.Denom, tokenOutDenom)
+	domain.GetQuoteDebugInfoFromContext(ctx).SetCandidateRoutes(candidateRoutes.Routes)
+
+	// Note that the pools and taker fees are retrieved from the state snapshot pinned to the context.
+	routes, err := r.poolsUsecase.GetRoutesFromCandidates(ctx, candidateRoutes, tokenIn.Denom, tokenOutDenom)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -289,31 +637,44 @@ func (r *routerUseCaseImpl) rankRoutesByDirectQuote(ctx context.Context, candida
 }
 
 // computeAndRankRoutesByDirectQuote computes candidate routes and ranks them by token out after estimating direct quotes.
-func (r *routerUseCaseImpl) computeAndRankRoutesByDirectQuote(ctx context.Context, pools []sqsdomain.PoolI, tokenIn sdk.Coin, tokenOutDenom string, routingOptions domain.RouterOptions) (domain.Quote, []route.RouteImpl, error) {
-	tokenInOrderOfMagnitude := GetPrecomputeOrderOfMagnitude(tokenIn.Amount)
-
+func (r *routerUseCaseImpl) computeAndRankRoutesByDirectQuote(ctx context.Context, graph *PoolGraph, tokenIn sdk.Coin, tokenOutDenom string, routingOptions domain.RouterOptions) (domain.Quote, []route.RouteImpl, error) {
 	// If top routes are not present in cache, retrieve unranked candidate routes
-	candidateRoutes, err := r.handleCandidateRoutes(ctx, pools, tokenIn, tokenOutDenom, routingOptions.MaxRoutes, routingOptions.MaxPoolsPerRoute)
+	candidateRoutes, err := r.handleCandidateRoutes(ctx, graph, tokenIn, tokenOutDenom, routingOptions.MaxRoutes, routingOptions.MaxPoolsPerRoute, routingOptions.MinOSMOLiquidity, routingOptions.CandidateRouteFilters)
 	if err != nil {
 		r.logger.Error("error handling routes", zap.Error(err))
 		return nil, nil, err
 	}
 
+	return r.rankAndCacheRoutesByDirectQuote(ctx, candidateRoutes, tokenIn, tokenOutDenom, routingOptions)
+}
+
+// rankAndCacheRoutesByDirectQuote ranks the given candidate routes by token out after estimating direct quotes
+// and caches the candidate and ranked routes. The absence of candidate routes is cached as well.
+func (r *routerUseCaseImpl) rankAndCacheRoutesByDirectQuote(ctx context.Context, candidateRoutes sqsdomain.CandidateRoutes, tokenIn sdk.Coin, tokenOutDenom string, routingOptions domain.RouterOptions) (domain.Quote, []route.RouteImpl, error) {
+	tokenInOrderOfMagnitude := GetPrecomputeOrderOfMagnitude(tokenIn.Amount)
+
 	// Get request path for metrics
 	requestURLPath, err := domain.GetURLPathFromContext(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// Routes found after the context is done might be incomplete so they are not cached.
+	isCacheable := ctx.Err() == nil && r.isLatestStateSnapshot(ctx)
+
 	if len(candidateRoutes.Routes) > 0 {
-		cacheWrite.WithLabelValues(requestURLPath, candidateRouteCacheLabel, tokenIn.Denom, tokenOutDenom, noOrderOfMagnitude).Inc()
+		if isCacheable {
+			cacheWrite.WithLabelValues(requestURLPath, candidateRouteCacheLabel, tokenIn.Denom, tokenOutDenom, noOrderOfMagnitude).Inc()
 
-		r.candidateRouteCache.Set(formatCandidateRouteCacheKey(tokenIn.Denom, tokenOutDenom), candidateRoutes, time.Duration(routingOptions.CandidateRouteCacheExpirySeconds)*time.Second)
+			r.candidateRouteCache.SetWithDependenciesAtHeight(formatCandidateRouteCacheKey(tokenIn.Denom, tokenOutDenom, routingOptions.CandidateRouteFilters), candidateRoutes, time.Duration(routingOptions.CandidateRouteCacheExpirySeconds)*time.Second, getCandidateRoutesPoolIDs(candidateRoutes), getRouteCacheTags(tokenIn.Denom, tokenOutDenom), r.getStateSnapshotHeight(ctx))
+		}
 	} else {
-		// If no candidate routes found, cache them for quarter of the duration
-		r.candidateRouteCache.Set(formatCandidateRouteCacheKey(tokenIn.Denom, tokenOutDenom), candidateRoutes, time.Duration(routingOptions.CandidateRouteCacheExpirySeconds/4)*time.Second)
+		if isCacheable {
+			// If no candidate routes found, cache them for quarter of the duration
+			r.candidateRouteCache.SetWithDependenciesAtHeight(formatCandidateRouteCacheKey(tokenIn.Denom, tokenOutDenom, routingOptions.CandidateRouteFilters), candidateRoutes, time.Duration(routingOptions.CandidateRouteCacheExpirySeconds/4)*time.Second, getCandidateRoutesPoolIDs(candidateRoutes), getRouteCacheTags(tokenIn.Denom, tokenOutDenom), r.getStateSnapshotHeight(ctx))
 
-		r.rankedRouteCache.Set(formatRankedRouteCacheKey(tokenIn.Denom, tokenOutDenom, tokenInOrderOfMagnitude), candidateRoutes, time.Duration(routingOptions.RankedRouteCacheExpirySeconds/4)*time.Second)
+			r.rankedRouteCache.SetWithDependenciesAtHeight(formatRankedRouteCacheKey(tokenIn.Denom, tokenOutDenom, tokenInOrderOfMagnitude, routingOptions.CandidateRouteFilters), candidateRoutes, time.Duration(routingOptions.RankedRouteCacheExpirySeconds/4)*time.Second, getCandidateRoutesPoolIDs(candidateRoutes), getRouteCacheTags(tokenIn.Denom, tokenOutDenom), r.getStateSnapshotHeight(ctx))
+		}
 
 		return nil, nil, fmt.Errorf("no candidate routes found")
 	}
@@ -330,15 +691,18 @@ func (r *routerUseCaseImpl) computeAndRankRoutesByDirectQuote(ctx context.Contex
 	}
 
 	// Update ranked routes with filtered ranked routes
-	rankedRoutes = filterDuplicatePoolIDRoutes(rankedRoutes)
+	filteredRankedRoutes := filterSharedPoolRoutes(rankedRoutes)
+	addFilteredRoutesDebugInfo(domain.GetQuoteDebugInfoFromContext(ctx), rankedRoutes, filteredRankedRoutes, domain.FilterSharedPoolRoutesFilter, "shares a pool that cannot be simulated jointly with a better ranked route")
+	rankedRoutes = filteredRankedRoutes
 
 	// Convert ranked routes back to candidate for caching
 	candidateRoutes = convertRankedToCandidateRoutes(rankedRoutes)
 
-	if len(rankedRoutes) > 0 {
+	// Routes ranked after the context is done might be incomplete so they are not cached.
+	if len(rankedRoutes) > 0 && ctx.Err() == nil && r.isLatestStateSnapshot(ctx) {
 		cacheWrite.WithLabelValues(requestURLPath, rankedRouteCacheLabel, tokenIn.Denom, tokenOutDenom, strconv.FormatInt(int64(tokenInOrderOfMagnitude), 10)).Inc()
 
-		r.rankedRouteCache.Set(formatRankedRouteCacheKey(tokenIn.Denom, tokenOutDenom, tokenInOrderOfMagnitude), candidateRoutes, time.Duration(routingOptions.RankedRouteCacheExpirySeconds)*time.Second)
+		r.rankedRouteCache.SetWithDependenciesAtHeight(formatRankedRouteCacheKey(tokenIn.Denom, tokenOutDenom, tokenInOrderOfMagnitude, routingOptions.CandidateRouteFilters), candidateRoutes, time.Duration(routingOptions.RankedRouteCacheExpirySeconds)*time.Second, getCandidateRoutesPoolIDs(candidateRoutes), getRouteCacheTags(tokenIn.Denom, tokenOutDenom), r.getStateSnapshotHeight(ctx))
 	}
 
 	return topSingleRouteQuote, rankedRoutes, nil
@@ -375,81 +739,156 @@ func estimateDirectQuote(ctx context.Context, routes []route.RouteImpl, tokenIn
 	return topQuote, routes, nil
 }
 
+// estimateDirectQuoteInGivenOut estimates and returns the direct quote for receiving the given token out over the given routes.
+// Also, returns the routes that can provide the token out ranked by amount in in increasing order.
+// Returns error if:
+// - fails to estimate direct quotes
+func estimateDirectQuoteInGivenOut(ctx context.Context, routes []route.RouteImpl, tokenOut sdk.Coin, maxRoutes int, logger log.Logger) (domain.Quote, []route.RouteImpl, error) {
+	topQuote, routesSortedByAmtIn, err := estimateAndRankSingleRouteQuoteInGivenOut(ctx, routes, tokenOut, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	numRoutes := len(routesSortedByAmtIn)
+
+	// If split routes are disabled, return a single the top route
+	if maxRoutes == 0 && numRoutes > 0 {
+		numRoutes = 1
+		// If there are more routes than the max routes, keep only the top routes
+	} else if numRoutes > maxRoutes {
+		numRoutes = maxRoutes
+	}
+
+	rankedRoutes := make([]route.RouteImpl, 0, numRoutes)
+	for i := 0; i < numRoutes; i++ {
+		rankedRoutes = append(rankedRoutes, routesSortedByAmtIn[i].RouteImpl)
+	}
+
+	return topQuote, rankedRoutes, nil
+}
+
 // GetBestSingleRouteQuote returns the best single route quote to be done directly without a split.
 func (r *routerUseCaseImpl) GetBestSingleRouteQuote(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (domain.Quote, error) {
-	// Filter pools by minimum liquidity
-	poolsAboveMinLiquidity := FilterPoolsByMinLiquidity(r.getSortedPoolsShallowCopy(), r.defaultConfig.MinOSMOLiquidity)
+	ctx, _, graph := r.pinStateSnapshot(ctx)
 
-	candidateRoutes, err := r.handleCandidateRoutes(ctx, poolsAboveMinLiquidity, tokenIn, tokenOutDenom, r.defaultConfig.MaxRoutes, r.defaultConfig.MaxPoolsPerRoute)
+	// Pools below the min liquidity are skipped during the candidate route search.
+	candidateRoutes, err := r.handleCandidateRoutes(ctx, graph, tokenIn, tokenOutDenom, r.defaultConfig.MaxRoutes, r.defaultConfig.MaxPoolsPerRoute, r.defaultConfig.MinOSMOLiquidity, domain.CandidateRouteFilters{})
 	if err != nil {
 		return nil, err
 	}
 	// TODO: abstract this
 
-	routes, err := r.poolsUsecase.GetRoutesFromCandidates(candidateRoutes, tokenIn.Denom, tokenOutDenom)
+	routes, err := r.poolsUsecase.GetRoutesFromCandidates(ctx, candidateRoutes, tokenIn.Denom, tokenOutDenom)
 	if err != nil {
 		return nil, err
 	}
 
-	return getBestSingleRouteQuote(ctx, tokenIn, routes, r.logger)
-}
-
-// GetCustomDirectQuote implements mvc.RouterUsecase.
-func (r *routerUseCaseImpl) GetCustomDirectQuote(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string, poolID uint64) (domain.Quote, error) {
-	pool, err := r.poolsUsecase.GetPool(poolID)
+	quote, err := getBestSingleRouteQuote(ctx, tokenIn, routes, r.logger)
 	if err != nil {
 		return nil, err
 	}
 
-	poolDenoms := pool.GetPoolDenoms()
+	r.setEstimatedGas(ctx, quote)
+
+	return quote, nil
+}
 
-	if !osmoutils.Contains(poolDenoms, tokenIn.Denom) {
-		return nil, fmt.Errorf("token in denom %s not found in pool %d", tokenIn.Denom, poolID)
+// GetCustomDirectQuote implements mvc.RouterUsecase.
+func (r *routerUseCaseImpl) GetCustomDirectQuote(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string, poolID uint64) (domain.Quote, error) {
+	return r.GetCustomDirectQuoteMultiPool(ctx, tokenIn, []string{tokenOutDenom}, []uint64{poolID})
+}
+
+// GetCustomDirectQuoteMultiPool implements mvc.RouterUsecase.
+// It constructs a single route over the given pool IDs where the i-th pool swaps
+// into the i-th token out denom. The output of each hop is the input of the next one.
+// Returns error if:
+// - the number of pool IDs does not match the number of token out denoms
+// - any of the pools is not found
+// - any of the pools does not contain the token in or token out denom of its hop
+// - the taker fee is not found for any of the hops
+// - fails to compute the quote
+func (r *routerUseCaseImpl) GetCustomDirectQuoteMultiPool(ctx context.Context, tokenIn sdk.Coin, tokenOutDenoms []string, poolIDs []uint64) (domain.Quote, error) {
+	if len(poolIDs) == 0 {
+		return nil, errors.New("at least one pool ID is required")
 	}
-	if !osmoutils.Contains(poolDenoms, tokenOutDenom) {
-		return nil, fmt.Errorf("token out denom %s not found in pool %d", tokenOutDenom, poolID)
+
+	if len(poolIDs) != len(tokenOutDenoms) {
+		return nil, fmt.Errorf("number of pool IDs (%d) does not match number of token out denoms (%d)", len(poolIDs), len(tokenOutDenoms))
 	}
 
-	// Retrieve taker fee for the pool
-	takerFee, ok := r.routerRepository.GetTakerFee(tokenIn.Denom, tokenOutDenom)
-	if !ok {
-		return nil, fmt.Errorf("taker fee not found for pool %d, denom in (%s), denom out (%s)", poolID, tokenIn.Denom, tokenOutDenom)
+	ctx, snapshot, _ := r.pinStateSnapshot(ctx)
+
+	candidateRoute := sqsdomain.CandidateRoute{
+		Pools: make([]sqsdomain.CandidatePool, 0, len(poolIDs)),
 	}
+	uniquePoolIDs := make(map[uint64]struct{}, len(poolIDs))
+
+	// Validate that the hops connect.
+	hopTokenInDenom := tokenIn.Denom
+	for i, poolID := range poolIDs {
+		hopTokenOutDenom := tokenOutDenoms[i]
+
+		pool, err := snapshot.GetPool(poolID)
+		if err != nil {
+			return nil, err
+		}
 
-	// Create a taker fee map with the taker fee for the pool
-	takerFeeMap := sqsdomain.TakerFeeMap{}
-	takerFeeMap.SetTakerFee(tokenIn.Denom, tokenOutDenom, takerFee)
+		poolDenoms := pool.GetPoolDenoms()
+
+		if !osmoutils.Contains(poolDenoms, hopTokenInDenom) {
+			return nil, fmt.Errorf("token in denom %s not found in pool %d", hopTokenInDenom, poolID)
+		}
+		if !osmoutils.Contains(poolDenoms, hopTokenOutDenom) {
+			return nil, fmt.Errorf("token out denom %s not found in pool %d", hopTokenOutDenom, poolID)
+		}
+		if hopTokenInDenom == hopTokenOutDenom {
+			return nil, fmt.Errorf("token in denom and token out denom are the same (%s) for pool %d", hopTokenInDenom, poolID)
+		}
+
+		// Ensure that the taker fee is present for the hop.
+		// It is applied per pair when converting the candidate route into a route.
+		if _, ok := snapshot.GetTakerFee(hopTokenInDenom, hopTokenOutDenom); !ok {
+			return nil, fmt.Errorf("taker fee not found for pool %d, denom in (%s), denom out (%s)", poolID, hopTokenInDenom, hopTokenOutDenom)
+		}
+
+		candidateRoute.Pools = append(candidateRoute.Pools, sqsdomain.CandidatePool{
+			ID:            poolID,
+			TokenOutDenom: hopTokenOutDenom,
+		})
+		uniquePoolIDs[poolID] = struct{}{}
+
+		hopTokenInDenom = hopTokenOutDenom
+	}
 
-	// Create a candidate route with the desired pool
 	candidateRoutes := sqsdomain.CandidateRoutes{
-		Routes: []sqsdomain.CandidateRoute{
-			{
-				Pools: []sqsdomain.CandidatePool{
-					{
-						ID:            poolID,
-						TokenOutDenom: tokenOutDenom,
-					},
-				},
-			},
-		},
-		UniquePoolIDs: map[uint64]struct{}{
-			poolID: {},
-		},
+		Routes:        []sqsdomain.CandidateRoute{candidateRoute},
+		UniquePoolIDs: uniquePoolIDs,
 	}
 
 	// Convert candidate route into a route with all the pool data
-	routes, err := r.poolsUsecase.GetRoutesFromCandidates(candidateRoutes, tokenIn.Denom, tokenOutDenom)
+	routes, err := r.poolsUsecase.GetRoutesFromCandidates(ctx, candidateRoutes, tokenIn.Denom, hopTokenInDenom)
 	if err != nil {
 		return nil, err
 	}
 
 	// Compute direct quote
-	return getBestSingleRouteQuote(ctx, tokenIn, routes, r.logger)
+	quote, err := getBestSingleRouteQuote(ctx, tokenIn, routes, r.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	r.setEstimatedGas(ctx, quote)
+
+	return quote, nil
 }
 
 // GetCandidateRoutes implements domain.RouterUsecase.
-func (r *routerUseCaseImpl) GetCandidateRoutes(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string) (sqsdomain.CandidateRoutes, error) {
-	candidateRoutes, err := r.handleCandidateRoutes(ctx, r.getSortedPoolsShallowCopy(), tokenIn, tokenOutDenom, r.defaultConfig.MaxRoutes, r.defaultConfig.MaxPoolsPerRoute)
+func (r *routerUseCaseImpl) GetCandidateRoutes(ctx context.Context, tokenIn sdk.Coin, tokenOutDenom string, opts ...domain.RouterOption) (sqsdomain.CandidateRoutes, error) {
+	options := r.getRouterOptions(opts...)
+
+	ctx, _, graph := r.pinStateSnapshot(ctx)
+
+	candidateRoutes, err := r.handleCandidateRoutes(ctx, graph, tokenIn, tokenOutDenom, options.MaxRoutes, options.MaxPoolsPerRoute, 0, options.CandidateRouteFilters)
 	if err != nil {
 		return sqsdomain.CandidateRoutes{}, err
 	}
@@ -458,8 +897,10 @@ func (r *routerUseCaseImpl) GetCandidateRoutes(ctx context.Context, tokenIn sdk.
 }
 
 // GetTakerFee implements mvc.RouterUsecase.
-func (r *routerUseCaseImpl) GetTakerFee(poolID uint64) ([]sqsdomain.TakerFeeForPair, error) {
-	pool, err := r.poolsUsecase.GetPool(poolID)
+func (r *routerUseCaseImpl) GetTakerFee(ctx context.Context, poolID uint64) ([]sqsdomain.TakerFeeForPair, error) {
+	_, snapshot, _ := r.pinStateSnapshot(ctx)
+
+	pool, err := snapshot.GetPool(poolID)
 	if err != nil {
 		return []sqsdomain.TakerFeeForPair{}, err
 	}
@@ -473,7 +914,7 @@ func (r *routerUseCaseImpl) GetTakerFee(poolID uint64) ([]sqsdomain.TakerFeeForP
 			denom0 := poolDenoms[i]
 			denom1 := poolDenoms[j]
 
-			takerFee, ok := r.routerRepository.GetTakerFee(denom0, denom1)
+			takerFee, ok := snapshot.GetTakerFee(denom0, denom1)
 			if !ok {
 				return []sqsdomain.TakerFeeForPair{}, fmt.Errorf("taker fee not found for pool %d, denom in (%s), denom out (%s)", poolID, denom0, denom1)
 			}
@@ -491,7 +932,22 @@ func (r *routerUseCaseImpl) GetTakerFee(poolID uint64) ([]sqsdomain.TakerFeeForP
 
 // GetCachedCandidateRoutes implements mvc.RouterUsecase.
 func (r *routerUseCaseImpl) GetCachedCandidateRoutes(ctx context.Context, tokenInDenom string, tokenOutDenom string) (sqsdomain.CandidateRoutes, bool, error) {
-	if !r.defaultConfig.RouteCacheEnabled {
+	return r.getCachedCandidateRoutes(ctx, tokenInDenom, tokenOutDenom, domain.CandidateRouteFilters{})
+}
+
+// getCachedCandidateRoutes returns the candidate routes computed with the given filters from cache.
+// Routes computed with different filters are cached under different keys.
+func (r *routerUseCaseImpl) getCachedCandidateRoutes(ctx context.Context, tokenInDenom string, tokenOutDenom string, filters domain.CandidateRouteFilters) (sqsdomain.CandidateRoutes, bool, error) {
+	debugInfo := domain.GetQuoteDebugInfoFromContext(ctx)
+	cacheKey := formatCandidateRouteCacheKey(tokenInDenom, tokenOutDenom, filters)
+
+	if !r.defaultConfig.RouteCacheEnabled || !r.isLatestStateSnapshot(ctx) {
+		debugInfo.AddCacheLookup(candidateRouteCacheLabel, cacheKey, domain.CacheLookupDisabled)
+		return sqsdomain.CandidateRoutes{}, false, nil
+	}
+
+	// The route warming recomputes the cached routes.
+	if isRouteWarmingContext(ctx) {
 		return sqsdomain.CandidateRoutes{}, false, nil
 	}
 
@@ -501,10 +957,11 @@ func (r *routerUseCaseImpl) GetCachedCandidateRoutes(ctx context.Context, tokenI
 		return sqsdomain.CandidateRoutes{}, false, err
 	}
 
-	cachedCandidateRoutes, found := r.candidateRouteCache.Get(formatCandidateRouteCacheKey(tokenInDenom, tokenOutDenom))
+	cachedCandidateRoutes, found := r.candidateRouteCache.Get(cacheKey)
 	if !found {
 		// Increase cache misses
 		cacheMisses.WithLabelValues(requestURLPath, candidateRouteCacheLabel, tokenInDenom, tokenOutDenom, noOrderOfMagnitude).Inc()
+		debugInfo.AddCacheLookup(candidateRouteCacheLabel, cacheKey, domain.CacheLookupMiss)
 
 		return sqsdomain.CandidateRoutes{
 			Routes:        []sqsdomain.CandidateRoute{},
@@ -513,6 +970,7 @@ func (r *routerUseCaseImpl) GetCachedCandidateRoutes(ctx context.Context, tokenI
 	}
 
 	cacheHits.WithLabelValues(requestURLPath, candidateRouteCacheLabel, tokenInDenom, tokenOutDenom, noOrderOfMagnitude).Inc()
+	debugInfo.AddCacheLookup(candidateRouteCacheLabel, cacheKey, domain.CacheLookupHit)
 
 	candidateRoutes, ok := cachedCandidateRoutes.(sqsdomain.CandidateRoutes)
 	if !ok {
@@ -524,25 +982,44 @@ func (r *routerUseCaseImpl) GetCachedCandidateRoutes(ctx context.Context, tokenI
 
 // GetCachedRankedRoutes implements mvc.RouterUsecase.
 func (r *routerUseCaseImpl) GetCachedRankedRoutes(ctx context.Context, tokenInDenom string, tokenOutDenom string, tokenInOrderOfMagnitude int) (sqsdomain.CandidateRoutes, error) {
-	if !r.defaultConfig.RouteCacheEnabled {
+	return r.getCachedRankedRoutes(ctx, tokenInDenom, tokenOutDenom, tokenInOrderOfMagnitude, domain.CandidateRouteFilters{})
+}
+
+// getCachedRankedRoutes returns the ranked routes computed with the given filters from cache.
+// Routes computed with different filters are cached under different keys.
+func (r *routerUseCaseImpl) getCachedRankedRoutes(ctx context.Context, tokenInDenom string, tokenOutDenom string, tokenInOrderOfMagnitude int, filters domain.CandidateRouteFilters) (sqsdomain.CandidateRoutes, error) {
+	debugInfo := domain.GetQuoteDebugInfoFromContext(ctx)
+	cacheKey := formatRankedRouteCacheKey(tokenInDenom, tokenOutDenom, tokenInOrderOfMagnitude, filters)
+
+	if !r.defaultConfig.RouteCacheEnabled || !r.isLatestStateSnapshot(ctx) {
+		debugInfo.AddCacheLookup(rankedRouteCacheLabel, cacheKey, domain.CacheLookupDisabled)
 		return sqsdomain.CandidateRoutes{}, nil
 	}
 
+	// The route warming recomputes the cached routes.
+	if isRouteWarmingContext(ctx) {
+		return sqsdomain.CandidateRoutes{}, nil
+	}
+
+	r.recordRankedRouteLookup(ctx, tokenInDenom, tokenOutDenom, tokenInOrderOfMagnitude, filters)
+
 	// Get request path for metrics
 	requestURLPath, err := domain.GetURLPathFromContext(ctx)
 	if err != nil {
 		return sqsdomain.CandidateRoutes{}, err
 	}
 
-	cachedRankedRoutes, found := r.rankedRouteCache.Get(formatRankedRouteCacheKey(tokenInDenom, tokenOutDenom, tokenInOrderOfMagnitude))
+	cachedRankedRoutes, found := r.rankedRouteCache.Get(cacheKey)
 	if !found {
 		// Increase cache misses
 		cacheMisses.WithLabelValues(requestURLPath, rankedRouteCacheLabel, tokenInDenom, tokenOutDenom, strconv.FormatInt(int64(tokenInOrderOfMagnitude), 10)).Inc()
+		debugInfo.AddCacheLookup(rankedRouteCacheLabel, cacheKey, domain.CacheLookupMiss)
 
 		return sqsdomain.CandidateRoutes{}, nil
 	}
 
 	cacheHits.WithLabelValues(requestURLPath, rankedRouteCacheLabel, tokenInDenom, tokenOutDenom, strconv.FormatInt(int64(tokenInOrderOfMagnitude), 10)).Inc()
+	debugInfo.AddCacheLookup(rankedRouteCacheLabel, cacheKey, domain.CacheLookupHit)
 
 	rankedRoutes, ok := cachedRankedRoutes.(sqsdomain.CandidateRoutes)
 	if !ok {
@@ -559,16 +1036,42 @@ func (r *routerUseCaseImpl) GetCachedRankedRoutes(ctx context.Context, tokenInDe
 // - there is an error retrieving routes from cache
 // - there are no routes cached and there is an error computing them
 // - fails to persist the computed routes in cache
-func (r *routerUseCaseImpl) handleCandidateRoutes(ctx context.Context, pools []sqsdomain.PoolI, tokenIn sdk.Coin, tokenOutDenom string, maxRoutes, maxPoolsPerRoutes int) (candidateRoutes sqsdomain.CandidateRoutes, err error) {
+func (r *routerUseCaseImpl) handleCandidateRoutes(ctx context.Context, graph *PoolGraph, tokenIn sdk.Coin, tokenOutDenom string, maxRoutes, maxPoolsPerRoutes, minOSMOLiquidity int, filters domain.CandidateRouteFilters) (candidateRoutes sqsdomain.CandidateRoutes, err error) {
+	return r.getDepthCandidateRoutes(ctx, func() (sqsdomain.CandidateRoutes, error) {
+		return r.getOrComputeCandidateRoutes(ctx, graph, tokenIn, tokenOutDenom, maxRoutes, maxPoolsPerRoutes, minOSMOLiquidity, filters)
+	})
+}
+
+// getDepthCandidateRoutes returns the candidate routes computed for a previous amount of the quote depth ladder
+// attached to the context. Otherwise, returns the ones computed by the given function, which are reused for
+// the remaining amounts of the ladder unless the computation was cut short by the context.
+func (r *routerUseCaseImpl) getDepthCandidateRoutes(ctx context.Context, computeCandidateRoutes func() (sqsdomain.CandidateRoutes, error)) (sqsdomain.CandidateRoutes, error) {
+	depthRoutes := getQuoteDepthRoutes(ctx)
+	if depthRoutes != nil && depthRoutes.candidate != nil {
+		return *depthRoutes.candidate, nil
+	}
+
+	candidateRoutes, err := computeCandidateRoutes()
+	if err != nil {
+		return sqsdomain.CandidateRoutes{}, err
+	}
+
+	if depthRoutes != nil && ctx.Err() == nil {
+		depthRoutes.candidate = &candidateRoutes
+	}
+
+	return candidateRoutes, nil
+}
+
+// getOrComputeCandidateRoutes returns the cached candidate routes or computes and caches them.
+// See handleCandidateRoutes for details.
+func (r *routerUseCaseImpl) getOrComputeCandidateRoutes(ctx context.Context, graph *PoolGraph, tokenIn sdk.Coin, tokenOutDenom string, maxRoutes, maxPoolsPerRoutes, minOSMOLiquidity int, filters domain.CandidateRouteFilters) (candidateRoutes sqsdomain.CandidateRoutes, err error) {
 	r.logger.Debug("getting routes")
 
 	// Check cache for routes if enabled
-	var isFoundCached bool
-	if r.defaultConfig.RouteCacheEnabled {
-		candidateRoutes, isFoundCached, err = r.GetCachedCandidateRoutes(ctx, tokenIn.Denom, tokenOutDenom)
-		if err != nil {
-			return sqsdomain.CandidateRoutes{}, err
-		}
+	candidateRoutes, isFoundCached, err := r.getCachedCandidateRoutes(ctx, tokenIn.Denom, tokenOutDenom, filters)
+	if err != nil {
+		return sqsdomain.CandidateRoutes{}, err
 	}
 
 	r.logger.Debug("cached routes", zap.Int("num_routes", len(candidateRoutes.Routes)))
@@ -577,15 +1080,15 @@ func (r *routerUseCaseImpl) handleCandidateRoutes(ctx context.Context, pools []s
 	if !isFoundCached {
 		r.logger.Debug("calculating routes")
 
-		candidateRoutes, err = GetCandidateRoutes(pools, tokenIn, tokenOutDenom, maxRoutes, maxPoolsPerRoutes, r.logger)
+		candidateRoutes, err = getCandidateRoutes(ctx, graph, tokenIn, tokenOutDenom, maxRoutes, maxPoolsPerRoutes, minOSMOLiquidity, filters, domain.GetQuoteDebugInfoFromContext(ctx), r.logger)
 		if err != nil {
 			return sqsdomain.CandidateRoutes{}, err
 		}
 
 		r.logger.Info("calculated routes", zap.Int("num_routes", len(candidateRoutes.Routes)))
 
-		// Persist routes
-		if r.defaultConfig.RouteCacheEnabled {
+		// Persist routes unless the search was cut short by the context.
+		if r.defaultConfig.RouteCacheEnabled && ctx.Err() == nil && r.isLatestStateSnapshot(ctx) {
 			cacheDurationSeconds := r.defaultConfig.CandidateRouteCacheExpirySeconds
 			if len(candidateRoutes.Routes) == 0 {
 				// If there are no routes, we want to cache the result for a shorter duration
@@ -594,7 +1097,7 @@ func (r *routerUseCaseImpl) handleCandidateRoutes(ctx context.Context, pools []s
 			}
 
 			r.logger.Debug("persisting routes", zap.Int("num_routes", len(candidateRoutes.Routes)))
-			r.candidateRouteCache.Set(formatCandidateRouteCacheKey(tokenIn.Denom, tokenOutDenom), candidateRoutes, time.Duration(cacheDurationSeconds)*time.Second)
+			r.candidateRouteCache.SetWithDependenciesAtHeight(formatCandidateRouteCacheKey(tokenIn.Denom, tokenOutDenom, filters), candidateRoutes, time.Duration(cacheDurationSeconds)*time.Second, getCandidateRoutesPoolIDs(candidateRoutes), getRouteCacheTags(tokenIn.Denom, tokenOutDenom), r.getStateSnapshotHeight(ctx))
 		}
 	}
 
@@ -604,7 +1107,7 @@ func (r *routerUseCaseImpl) handleCandidateRoutes(ctx context.Context, pools []s
 // StoreRouterStateFiles implements domain.RouterUsecase.
 // TODO: clean up
 func (r *routerUseCaseImpl) StoreRouterStateFiles() error {
-	routerState, err := r.GetRouterState()
+	routerState, err := r.GetRouterState(context.Background())
 	if err != nil {
 		return err
 	}
@@ -621,9 +1124,11 @@ func (r *routerUseCaseImpl) StoreRouterStateFiles() error {
 }
 
 // GetRouterStateJSON implements mvc.RouterUsecase.
-func (r *routerUseCaseImpl) GetRouterState() (domain.RouterState, error) {
+func (r *routerUseCaseImpl) GetRouterState(ctx context.Context) (domain.RouterState, error) {
+	ctx, snapshot, _ := r.pinStateSnapshot(ctx)
+
 	// These pools do not contain tick model
-	pools, err := r.poolsUsecase.GetAllPools()
+	pools, err := r.poolsUsecase.GetAllPools(ctx)
 
 	if err != nil {
 		return domain.RouterState{}, err
@@ -636,7 +1141,7 @@ func (r *routerUseCaseImpl) GetRouterState() (domain.RouterState, error) {
 		}
 	}
 
-	tickModelMap, err := r.poolsUsecase.GetTickModelMap(concentratedpoolIDs)
+	tickModelMap, err := r.poolsUsecase.GetTickModelMap(ctx, concentratedpoolIDs)
 	if err != nil {
 		return domain.RouterState{}, err
 	}
@@ -645,7 +1150,7 @@ func (r *routerUseCaseImpl) GetRouterState() (domain.RouterState, error) {
 		return domain.RouterState{}, err
 	}
 
-	takerFeesMap := r.routerRepository.GetAllTakerFees()
+	takerFeesMap := snapshot.GetAllTakerFees()
 
 	return domain.RouterState{
 		Pools:     pools,
@@ -659,14 +1164,91 @@ func formatRouteCacheKey(tokenInDenom string, tokenOutDenom string) string {
 	return fmt.Sprintf("%s%s%s", tokenInDenom, denomSeparatorChar, tokenOutDenom)
 }
 
-// formatRankedRouteCacheKey formats the given token in and token out denoms and order of magnitude to a string.
-func formatRankedRouteCacheKey(tokenInDenom string, tokenOutDenom string, tokenIOrderOfMagnitude int) string {
-	return fmt.Sprintf("%s%s%d", formatRouteCacheKey(tokenInDenom, tokenOutDenom), denomSeparatorChar, tokenIOrderOfMagnitude)
+// formatRankedRouteCacheKey formats the given token in and token out denoms, order of magnitude and filters to a string.
+func formatRankedRouteCacheKey(tokenInDenom string, tokenOutDenom string, tokenIOrderOfMagnitude int, filters domain.CandidateRouteFilters) string {
+	return fmt.Sprintf("%s%s%d%s", formatRouteCacheKey(tokenInDenom, tokenOutDenom), denomSeparatorChar, tokenIOrderOfMagnitude, formatRouteFiltersCacheKey(filters))
 }
 
-// formatCandidateRouteCacheKey formats the given token in and token out denoms to a string.
-func formatCandidateRouteCacheKey(tokenInDenom string, tokenOutDenom string) string {
-	return fmt.Sprintf("cr%s", formatRouteCacheKey(tokenInDenom, tokenOutDenom))
+// formatCandidateRouteCacheKey formats the given token in and token out denoms and filters to a string.
+func formatCandidateRouteCacheKey(tokenInDenom string, tokenOutDenom string, filters domain.CandidateRouteFilters) string {
+	return fmt.Sprintf("cr%s%s", formatRouteCacheKey(tokenInDenom, tokenOutDenom), formatRouteFiltersCacheKey(filters))
+}
+
+// formatRouteFiltersCacheKey formats the given candidate route filters to a string suffix for cache keys.
+// Returns an empty string if no filters are set so that unfiltered routes share the default keys.
+// The values are sorted so that the key does not depend on the order in which they were provided.
+func formatRouteFiltersCacheKey(filters domain.CandidateRouteFilters) string {
+	if filters.IsEmpty() {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	if len(filters.ExcludedPoolIDs) > 0 {
+		sb.WriteString(denomSeparatorChar + "xp:" + formatSortedPoolIDs(filters.ExcludedPoolIDs))
+	}
+
+	if len(filters.AllowedPoolIDs) > 0 {
+		sb.WriteString(denomSeparatorChar + "ap:" + formatSortedPoolIDs(filters.AllowedPoolIDs))
+	}
+
+	if len(filters.AllowedPoolTypes) > 0 {
+		poolTypes := make([]int, 0, len(filters.AllowedPoolTypes))
+		for poolType := range filters.AllowedPoolTypes {
+			poolTypes = append(poolTypes, int(poolType))
+		}
+		sort.Ints(poolTypes)
+
+		poolTypeStrs := make([]string, 0, len(poolTypes))
+		for _, poolType := range poolTypes {
+			poolTypeStrs = append(poolTypeStrs, strconv.Itoa(poolType))
+		}
+
+		sb.WriteString(denomSeparatorChar + "at:" + strings.Join(poolTypeStrs, ","))
+	}
+
+	if len(filters.ExcludedDenoms) > 0 {
+		sb.WriteString(denomSeparatorChar + "xd:" + formatSortedDenoms(filters.ExcludedDenoms))
+	}
+
+	if len(filters.PreferredDenoms) > 0 {
+		sb.WriteString(denomSeparatorChar + "pd:" + formatSortedDenoms(filters.PreferredDenoms))
+	}
+
+	if filters.MaxPoolsPerRoute > 0 {
+		sb.WriteString(denomSeparatorChar + "mp:" + strconv.Itoa(filters.MaxPoolsPerRoute))
+	}
+
+	return sb.String()
+}
+
+// formatSortedDenoms formats the given denoms as a comma-separated string in increasing order.
+func formatSortedDenoms(denoms map[string]struct{}) string {
+	sortedDenoms := make([]string, 0, len(denoms))
+	for denom := range denoms {
+		sortedDenoms = append(sortedDenoms, denom)
+	}
+	sort.Strings(sortedDenoms)
+
+	return strings.Join(sortedDenoms, ",")
+}
+
+// formatSortedPoolIDs formats the given pool IDs as a comma-separated string in increasing order.
+func formatSortedPoolIDs(poolIDs map[uint64]struct{}) string {
+	sortedPoolIDs := make([]uint64, 0, len(poolIDs))
+	for poolID := range poolIDs {
+		sortedPoolIDs = append(sortedPoolIDs, poolID)
+	}
+	sort.Slice(sortedPoolIDs, func(i, j int) bool {
+		return sortedPoolIDs[i] < sortedPoolIDs[j]
+	})
+
+	poolIDStrs := make([]string, 0, len(sortedPoolIDs))
+	for _, poolID := range sortedPoolIDs {
+		poolIDStrs = append(poolIDStrs, strconv.FormatUint(poolID, 10))
+	}
+
+	return strings.Join(poolIDStrs, ",")
 }
 
 // convertRankedToCandidateRoutes converts the given ranked routes to candidate routes.
@@ -700,7 +1282,9 @@ func convertRankedToCandidateRoutes(rankedRoutes []route.RouteImpl) sqsdomain.Ca
 
 // GetPoolSpotPrice implements mvc.RouterUsecase.
 func (r *routerUseCaseImpl) GetPoolSpotPrice(ctx context.Context, poolID uint64, quoteAsset, baseAsset string) (osmomath.BigDec, error) {
-	poolTakerFee, ok := r.routerRepository.GetTakerFee(quoteAsset, baseAsset)
+	ctx, snapshot, _ := r.pinStateSnapshot(ctx)
+
+	poolTakerFee, ok := snapshot.GetTakerFee(quoteAsset, baseAsset)
 	if !ok {
 		return osmomath.BigDec{}, fmt.Errorf("taker fee not found for pool %d, denom in (%s), denom out (%s)", poolID, quoteAsset, baseAsset)
 	}
@@ -714,10 +1298,87 @@ func (r *routerUseCaseImpl) GetPoolSpotPrice(ctx context.Context, poolID uint64,
 }
 
 // SetSortedPools implements mvc.RouterUsecase.
+// Replaces the latest state snapshot with a copy that has the given sorted pools.
 func (r *routerUseCaseImpl) SetSortedPools(pools []sqsdomain.PoolI) {
-	r.sortedPoolsMu.Lock()
-	r.sortedPools = pools
-	r.sortedPoolsMu.Unlock()
+	r.routerRepository.UpdateStateSnapshot(func(latest *domain.StateSnapshot) *domain.StateSnapshot {
+		return latest.WithSortedPools(pools)
+	})
+}
+
+// GetStateSnapshot implements mvc.RouterUsecase.
+func (r *routerUseCaseImpl) GetStateSnapshot() *domain.StateSnapshot {
+	return r.routerRepository.GetStateSnapshot()
+}
+
+// GetStateSnapshotAtHeight implements mvc.RouterUsecase.
+func (r *routerUseCaseImpl) GetStateSnapshotAtHeight(height uint64) (*domain.StateSnapshot, error) {
+	return r.routerRepository.GetStateSnapshotAtHeight(height)
+}
+
+// StoreStateSnapshot implements mvc.RouterUsecase.
+// The routing graph is built before the snapshot is swapped in so that the first
+// requests at the new height do not wait for it.
+func (r *routerUseCaseImpl) StoreStateSnapshot(snapshot *domain.StateSnapshot) {
+	graph := NewPoolGraph(snapshot.GetSortedPools())
+
+	// The graph is cached before the snapshot is swapped in while holding the lock so that
+	// it is not replaced by a graph built concurrently for the previous snapshot.
+	r.poolGraphMu.Lock()
+	r.poolGraph.Store(&snapshotPoolGraph{snapshot: snapshot, graph: graph})
+	r.routerRepository.UpdateStateSnapshot(func(_ *domain.StateSnapshot) *domain.StateSnapshot {
+		return snapshot
+	})
+	r.poolGraphMu.Unlock()
+}
+
+// EvictCachedRoutes implements mvc.RouterUsecase.
+// The caches are evicted even if no pool changed so that the routes computed over
+// the previous snapshots are no longer cached, except the routes of the warming started
+// at the latest height that it replaces in place.
+func (r *routerUseCaseImpl) EvictCachedRoutes(updatedPoolIDs []uint64, sortedPoolsDiff domain.SortedPoolsDiff) {
+	height := r.routerRepository.GetStateSnapshot().GetHeight()
+
+	// The routes being warmed are kept until the warming replaces them in place.
+	retainedKeys := r.getWarmingRouteCacheKeys()
+
+	numCandidateEvicted := r.candidateRouteCache.EvictDependentsAtHeightExcept(height, retainedKeys.candidate, sortedPoolsDiff.RankChangedPoolIDs...)
+
+	numRankedEvicted := r.rankedRouteCache.EvictDependentsAtHeightExcept(height, retainedKeys.ranked, updatedPoolIDs...)
+	numRankedEvicted += r.rankedRouteCache.EvictDependentsExcept(retainedKeys.ranked, sortedPoolsDiff.RankChangedPoolIDs...)
+
+	// The routes between the denoms of the added or re-ranked pools might now go through them
+	// even if the cached ones do not.
+	numCandidateEvicted += r.candidateRouteCache.EvictTaggedExcept(retainedKeys.candidate, sortedPoolsDiff.RankChangedDenoms...)
+	numRankedEvicted += r.rankedRouteCache.EvictTaggedExcept(retainedKeys.ranked, sortedPoolsDiff.RankChangedDenoms...)
+
+	// The absences of routes depend on no pool but a new pool might connect the denoms.
+	if sortedPoolsDiff.IsPoolSetChanged {
+		numCandidateEvicted += r.candidateRouteCache.EvictIndependentsExcept(retainedKeys.candidate)
+		numRankedEvicted += r.rankedRouteCache.EvictIndependentsExcept(retainedKeys.ranked)
+	}
+
+	cacheEvictions.WithLabelValues(candidateRouteCacheLabel).Add(float64(numCandidateEvicted))
+	cacheEvictions.WithLabelValues(rankedRouteCacheLabel).Add(float64(numRankedEvicted))
+
+	r.logger.Debug("evicted cached routes", zap.Uint64("height", height), zap.Int("num_updated_pools", len(updatedPoolIDs)), zap.Int("num_rank_changed_pools", len(sortedPoolsDiff.RankChangedPoolIDs)), zap.Int("num_candidate_evicted", numCandidateEvicted), zap.Int("num_ranked_evicted", numRankedEvicted))
+}
+
+// getRouteCacheTags returns the tags of the cached routes between the given denoms
+// so that they can be evicted once a pool with either of them is added or re-ranked.
+func getRouteCacheTags(tokenInDenom string, tokenOutDenom string) []string {
+	return []string{tokenInDenom, tokenOutDenom}
+}
+
+// getCandidateRoutesPoolIDs returns the IDs of the pools across all candidate routes
+// so that the cached routes can be evicted once any of them is updated.
+func getCandidateRoutesPoolIDs(candidateRoutes sqsdomain.CandidateRoutes) []uint64 {
+	poolIDs := []uint64{}
+	for _, route := range candidateRoutes.Routes {
+		for _, pool := range route.Pools {
+			poolIDs = append(poolIDs, pool.ID)
+		}
+	}
+	return poolIDs
 }
 
 // SetTakerFees implements mvc.RouterUsecase.
@@ -726,9 +1387,9 @@ func (r *routerUseCaseImpl) SetTakerFees(takerFees sqsdomain.TakerFeeMap) {
 }
 
 // GetSortedPools implements mvc.RouterUsecase.
-// Note that this method is not thread safe.
+// Returns the sorted pools of the latest state snapshot.
 func (r *routerUseCaseImpl) GetSortedPools() []sqsdomain.PoolI {
-	return r.sortedPools
+	return r.routerRepository.GetStateSnapshot().GetSortedPools()
 }
 
 // GetConfig implements mvc.RouterUsecase.
@@ -736,13 +1397,65 @@ func (r *routerUseCaseImpl) GetConfig() domain.RouterConfig {
 	return r.defaultConfig
 }
 
-// getSortedPoolsShallowCopy returns a shallow copy of the sorted pools.
-// This is to avoid concurrent read and write to the same address by ingester.
-func (r *routerUseCaseImpl) getSortedPoolsShallowCopy() []sqsdomain.PoolI {
-	r.sortedPoolsMu.RLock()
-	poolsCopy := r.sortedPools
-	r.sortedPoolsMu.RUnlock()
-	return poolsCopy
+// snapshotPoolGraph is the routing graph over the sorted pools of the snapshot.
+type snapshotPoolGraph struct {
+	snapshot *domain.StateSnapshot
+	graph    *PoolGraph
+}
+
+// pinStateSnapshot returns the context with the state snapshot pinned together with the snapshot
+// and the routing graph over its sorted pools. The snapshot already pinned to the context is reused.
+// Otherwise, the latest snapshot is pinned.
+func (r *routerUseCaseImpl) pinStateSnapshot(ctx context.Context) (context.Context, *domain.StateSnapshot, *PoolGraph) {
+	snapshot := domain.GetStateSnapshotFromContext(ctx)
+	if snapshot == nil {
+		snapshot = r.routerRepository.GetStateSnapshot()
+		ctx = domain.WithStateSnapshot(ctx, snapshot)
+	}
+
+	return ctx, snapshot, r.getPoolGraph(snapshot)
+}
+
+// getPoolGraph returns the routing graph over the sorted pools of the given snapshot.
+// Only the graph of the latest snapshot is cached. The graphs of the past snapshots are built on every call
+// so that the queries at a past height do not evict the graph serving all other requests.
+// The graph is immutable so it is safe to be read concurrently with a new one being built.
+func (r *routerUseCaseImpl) getPoolGraph(snapshot *domain.StateSnapshot) *PoolGraph {
+	if cached := r.poolGraph.Load(); cached != nil && cached.snapshot == snapshot {
+		return cached.graph
+	}
+
+	r.poolGraphMu.Lock()
+	defer r.poolGraphMu.Unlock()
+
+	// The graph might have been built while waiting for the lock.
+	if cached := r.poolGraph.Load(); cached != nil && cached.snapshot == snapshot {
+		return cached.graph
+	}
+
+	graph := NewPoolGraph(snapshot.GetSortedPools())
+	if snapshot == r.routerRepository.GetStateSnapshot() {
+		r.poolGraph.Store(&snapshotPoolGraph{snapshot: snapshot, graph: graph})
+	}
+	return graph
+}
+
+// getStateSnapshotHeight returns the height of the state snapshot pinned to the context
+// or of the latest one if none is pinned.
+// The routes cached with the height are dropped if the caches have already been evicted at a later height.
+func (r *routerUseCaseImpl) getStateSnapshotHeight(ctx context.Context) uint64 {
+	if snapshot := domain.GetStateSnapshotFromContext(ctx); snapshot != nil {
+		return snapshot.GetHeight()
+	}
+	return r.routerRepository.GetStateSnapshot().GetHeight()
+}
+
+// isLatestStateSnapshot returns true if no state snapshot is pinned to the context or if it is the latest one.
+// The routes over a past snapshot are neither read from nor written to the route caches
+// since these hold the routes over the latest one.
+func (r *routerUseCaseImpl) isLatestStateSnapshot(ctx context.Context) bool {
+	snapshot := domain.GetStateSnapshotFromContext(ctx)
+	return snapshot == nil || snapshot == r.routerRepository.GetStateSnapshot()
 }
 
 // filterOutGeneralizedCosmWasmPoolRoutes filters out routes that contain generalized cosm wasm pool.
@@ -766,3 +1479,40 @@ func filterOutGeneralizedCosmWasmPoolRoutes(rankedRoutes []route.RouteImpl) []ro
 
 	return result
 }
+
+// addFilteredRoutesDebugInfo records the routes that were removed from the given routes by the filter
+// in the debug info if it is non-nil.
+// CONTRACT: the filter preserves the order of the remaining routes.
+func addFilteredRoutesDebugInfo(debugInfo *domain.QuoteDebugInfo, routes, remainingRoutes []route.RouteImpl, filter, reason string) {
+	if debugInfo == nil {
+		return
+	}
+
+	candidateRoutes := convertRankedToCandidateRoutes(routes).Routes
+	remainingCandidateRoutes := convertRankedToCandidateRoutes(remainingRoutes).Routes
+
+	j := 0
+	for _, candidateRoute := range candidateRoutes {
+		if j < len(remainingCandidateRoutes) && isSameCandidateRoute(candidateRoute, remainingCandidateRoutes[j]) {
+			j++
+			continue
+		}
+
+		debugInfo.AddFilteredRoute(candidateRoute, filter, reason)
+	}
+}
+
+// isSameCandidateRoute returns true if both routes consist of the same pools with the same token out denoms.
+func isSameCandidateRoute(a, b sqsdomain.CandidateRoute) bool {
+	if len(a.Pools) != len(b.Pools) {
+		return false
+	}
+
+	for i := range a.Pools {
+		if a.Pools[i] != b.Pools[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/osmosis-labs/sqs/domain/mocks"
 	"github.com/osmosis-labs/sqs/domain/mvc"
 	"github.com/osmosis-labs/sqs/log"
+	poolsusecase "github.com/osmosis-labs/sqs/pools/usecase"
 	routerrepo "github.com/osmosis-labs/sqs/router/repository"
 	"github.com/osmosis-labs/sqs/router/usecase"
 	"github.com/osmosis-labs/sqs/router/usecase/route"
@@ -22,6 +23,7 @@ import (
 
 	"github.com/osmosis-labs/osmosis/osmomath"
 	"github.com/osmosis-labs/osmosis/v25/x/gamm/pool-models/balancer"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
 )
 
 const (
@@ -283,6 +285,63 @@ func (s *RouterTestSuite) TestHandleRoutes() {
 	}
 }
 
+// Validates that the route filters are formatted into a cache key suffix
+// that does not depend on the order in which the filters were provided.
+func (s *RouterTestSuite) TestFormatRouteFiltersCacheKey() {
+	getFilters := func(opts ...domain.RouterOption) domain.CandidateRouteFilters {
+		options := domain.RouterOptions{}
+		for _, opt := range opts {
+			opt(&options)
+		}
+		return options.CandidateRouteFilters
+	}
+
+	tests := map[string]struct {
+		filters domain.CandidateRouteFilters
+
+		expectedKey string
+	}{
+		"no filters": {
+			filters: domain.CandidateRouteFilters{},
+
+			expectedKey: "",
+		},
+		"excluded pool IDs are sorted": {
+			filters: getFilters(domain.WithExcludedPoolIDs(20, 3, 100)),
+
+			expectedKey: "|xp:3,20,100",
+		},
+		"all filters": {
+			filters: getFilters(
+				domain.WithExcludedDenoms(DenomTwo, DenomOne),
+				domain.WithAllowedPoolTypes(poolmanagertypes.Concentrated, poolmanagertypes.Balancer),
+				domain.WithAllowedPoolIDs(2, 1),
+				domain.WithExcludedPoolIDs(3),
+			),
+
+			expectedKey: fmt.Sprintf("|xp:3|ap:1,2|at:0,2|xd:%s,%s", DenomOne, DenomTwo),
+		},
+		"preferred denoms and max pools per route": {
+			filters: func() domain.CandidateRouteFilters {
+				filters := getFilters(domain.WithPreferredDenoms(DenomThree, DenomOne))
+				filters.MaxPoolsPerRoute = 2
+				return filters
+			}(),
+
+			expectedKey: fmt.Sprintf("|pd:%s,%s|mp:2", DenomOne, DenomThree),
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		s.Run(name, func() {
+			actualKey := usecase.FormatRouteFiltersCacheKey(tc.filters)
+
+			s.Require().Equal(tc.expectedKey, actualKey)
+		})
+	}
+}
+
 // Tests that routes that overlap in pools IDs get filtered out.
 // Tests that the order of the routes is in decreasing priority.
 // That is, if routes A and B overlap where A comes before B, then B is filtered out.
@@ -603,6 +662,297 @@ func (s *RouterTestSuite) TestGetOptimalQuote_Cache_Overwrites() {
 	}
 }
 
+// This test validates that:
+// - the ranked routes are evicted once any of their pools is updated while the candidate routes are kept
+// - both the ranked and the candidate routes are evicted once any of their pools is re-ranked
+// - the cached absences of routes are evicted once the set of sorted pools changes
+// - the cached routes not going through the changed pools are kept
+func (s *RouterTestSuite) TestEvictCachedRoutes() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	poolOneTwo := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwoThree := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomTwo, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount)), liquidityAmount.Int64())
+
+	pools := []sqsdomain.PoolI{poolOneTwo, poolTwoThree}
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(sqsdomain.TakerFeeMap{})
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools(pools)
+
+	routerUsecase := usecase.NewRouterUsecase(routerRepository, poolsUsecase, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+	routerUsecase.SetSortedPools(pools)
+
+	tokenIn := sdk.NewCoin(DenomOne, osmomath.NewInt(1_000_000))
+
+	// Returns the ranked and the candidate route cache lookup results in that order.
+	getCacheLookupResultsForQuote := func(tokenOutDenom string) []domain.CacheLookupResult {
+		ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+
+		// The quotes to the denom without routes fail.
+		_, _ = routerUsecase.GetOptimalQuote(ctx, tokenIn, tokenOutDenom)
+
+		return getCacheLookupResults(debugInfo)
+	}
+
+	var (
+		miss = domain.CacheLookupMiss
+		hit  = domain.CacheLookupHit
+	)
+
+	// Populate the caches.
+	s.Require().Equal([]domain.CacheLookupResult{miss, miss}, getCacheLookupResultsForQuote(DenomTwo))
+	s.Require().Equal([]domain.CacheLookupResult{hit}, getCacheLookupResultsForQuote(DenomTwo))
+
+	// Cache the absence of routes to a denom without pools.
+	s.Require().Equal([]domain.CacheLookupResult{miss, miss}, getCacheLookupResultsForQuote(DenomFour))
+	s.Require().Equal([]domain.CacheLookupResult{hit, hit}, getCacheLookupResultsForQuote(DenomFour))
+
+	// Update of a pool outside of the cached route keeps it.
+	routerUsecase.EvictCachedRoutes([]uint64{poolTwoThree.GetId()}, domain.SortedPoolsDiff{})
+	s.Require().Equal([]domain.CacheLookupResult{hit}, getCacheLookupResultsForQuote(DenomTwo))
+
+	// Update of the reserves of the pool in the cached route only evicts the ranked routes.
+	routerUsecase.EvictCachedRoutes([]uint64{poolOneTwo.GetId()}, domain.SortedPoolsDiff{})
+	s.Require().Equal([]domain.CacheLookupResult{miss, hit}, getCacheLookupResultsForQuote(DenomTwo))
+
+	// Re-ranking of the pool in the cached route evicts both the ranked and the candidate routes.
+	routerUsecase.EvictCachedRoutes([]uint64{poolOneTwo.GetId()}, domain.SortedPoolsDiff{RankChangedPoolIDs: []uint64{poolOneTwo.GetId()}})
+	s.Require().Equal([]domain.CacheLookupResult{miss, miss}, getCacheLookupResultsForQuote(DenomTwo))
+
+	// The absence of routes is kept until the set of sorted pools changes.
+	s.Require().Equal([]domain.CacheLookupResult{hit, hit}, getCacheLookupResultsForQuote(DenomFour))
+
+	routerUsecase.EvictCachedRoutes([]uint64{}, usecase.DiffSortedPools(pools[:1], pools))
+	s.Require().Equal([]domain.CacheLookupResult{miss, miss}, getCacheLookupResultsForQuote(DenomFour))
+
+	// The pool next to the added one in the sorted order is re-ranked.
+	s.Require().Equal([]domain.CacheLookupResult{miss, miss}, getCacheLookupResultsForQuote(DenomTwo))
+}
+
+// This test validates that the cached routes between the denoms of an added pool are evicted
+// even if the pool only re-ranks pools outside of the cached routes so that the better direct
+// route through the added pool is found.
+func (s *RouterTestSuite) TestEvictCachedRoutes_AddedPool() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	poolOneTwo := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwoThree := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomTwo, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount)), liquidityAmount.Int64())
+	poolFourFive := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomFour, liquidityAmount), sdk.NewCoin(DenomFive, liquidityAmount)), liquidityAmount.Int64())
+
+	pools := []sqsdomain.PoolI{poolOneTwo, poolTwoThree, poolFourFive}
+
+	routerRepository := routerrepo.New()
+	routerRepository.SetTakerFees(sqsdomain.TakerFeeMap{})
+
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	poolsUsecase.StorePools(pools)
+
+	routerUsecase := usecase.NewRouterUsecase(routerRepository, poolsUsecase, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+	routerUsecase.SetSortedPools(pools)
+
+	tokenIn := sdk.NewCoin(DenomOne, osmomath.NewInt(1_000_000))
+
+	// Returns the quote and the ranked and the candidate route cache lookup results in that order.
+	getQuote := func() (domain.Quote, []domain.CacheLookupResult) {
+		ctx, debugInfo := domain.WithQuoteDebugInfo(context.Background())
+
+		quote, err := routerUsecase.GetOptimalQuote(ctx, tokenIn, DenomThree)
+		s.Require().NoError(err)
+
+		return quote, getCacheLookupResults(debugInfo)
+	}
+
+	// Cache the route through the denom two.
+	quote, cacheLookupResults := getQuote()
+	s.Require().Equal([]domain.CacheLookupResult{domain.CacheLookupMiss, domain.CacheLookupMiss}, cacheLookupResults)
+	s.Require().Len(quote.GetRoute(), 1)
+	s.Require().Len(quote.GetRoute()[0].GetPools(), 2)
+
+	// The direct pool is added last in the sorted order so that only the pool four five is re-ranked
+	// among the previous pools.
+	poolOneThree := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount)), liquidityAmount.Int64()/2)
+	newPools := []sqsdomain.PoolI{poolOneTwo, poolTwoThree, poolFourFive, poolOneThree}
+
+	poolsUsecase.StorePools(newPools)
+	routerUsecase.SetSortedPools(newPools)
+
+	sortedPoolsDiff := usecase.DiffSortedPools(pools, newPools)
+	s.Require().NotContains(sortedPoolsDiff.RankChangedPoolIDs, poolOneTwo.GetId())
+	s.Require().NotContains(sortedPoolsDiff.RankChangedPoolIDs, poolTwoThree.GetId())
+
+	routerUsecase.EvictCachedRoutes([]uint64{}, sortedPoolsDiff)
+
+	// The routes are recomputed and go through the direct pool.
+	quote, cacheLookupResults = getQuote()
+	s.Require().Equal([]domain.CacheLookupResult{domain.CacheLookupMiss, domain.CacheLookupMiss}, cacheLookupResults)
+
+	var poolIDs []uint64
+	for _, route := range quote.GetRoute() {
+		for _, pool := range route.GetPools() {
+			poolIDs = append(poolIDs, pool.GetId())
+		}
+	}
+	s.Require().Contains(poolIDs, poolOneThree.GetId())
+}
+
+// This test validates that a request with the state snapshot pinned to its context keeps being served
+// from that snapshot after a new one is stored while the requests without a pinned snapshot observe the new one.
+func (s *RouterTestSuite) TestStoreStateSnapshot_PinnedSnapshot() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	// Two identical pools so that splitting the large amount in between them beats either single route.
+	poolOne := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwo := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+
+	routerRepository := routerrepo.New()
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+
+	// Disable the route cache so that the routes are computed from the snapshot of every request.
+	routerConfig := routertesting.DefaultRouterConfig
+	routerConfig.RouteCacheEnabled = false
+
+	routerUsecase := usecase.NewRouterUsecase(routerRepository, poolsUsecase, routerConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+
+	firstSnapshot := routerUsecase.GetStateSnapshot().WithPools([]sqsdomain.PoolI{poolOne}).WithSortedPools([]sqsdomain.PoolI{poolOne}).WithHeight(1)
+	routerUsecase.StoreStateSnapshot(firstSnapshot)
+
+	pinnedCtx := domain.WithStateSnapshot(context.Background(), routerUsecase.GetStateSnapshot())
+
+	secondSnapshot := firstSnapshot.WithPools([]sqsdomain.PoolI{poolTwo}).WithSortedPools([]sqsdomain.PoolI{poolOne, poolTwo}).WithHeight(2)
+	routerUsecase.StoreStateSnapshot(secondSnapshot)
+
+	s.Require().Equal(uint64(2), routerUsecase.GetStateSnapshot().GetHeight())
+
+	// The previous snapshot is not mutated by the new one.
+	s.Require().Equal(uint64(1), firstSnapshot.GetHeight())
+	s.Require().Len(firstSnapshot.GetAllPools(), 1)
+	s.Require().Len(secondSnapshot.GetAllPools(), 2)
+
+	tokenIn := sdk.NewCoin(DenomOne, osmomath.NewInt(100_000_000))
+
+	s.Run("pinned snapshot", func() {
+		quote, err := routerUsecase.GetOptimalQuote(pinnedCtx, tokenIn, DenomTwo)
+		s.Require().NoError(err)
+		s.Require().Len(quote.GetRoute(), 1)
+
+		_, err = poolsUsecase.GetPool(pinnedCtx, poolTwo.GetId())
+		s.Require().ErrorIs(err, domain.PoolNotFoundError{PoolID: poolTwo.GetId()})
+	})
+
+	s.Run("latest snapshot", func() {
+		quote, err := routerUsecase.GetOptimalQuote(context.Background(), tokenIn, DenomTwo)
+		s.Require().NoError(err)
+		s.Require().Len(quote.GetRoute(), 2)
+
+		_, err = poolsUsecase.GetPool(context.Background(), poolTwo.GetId())
+		s.Require().NoError(err)
+	})
+}
+
+// This test validates that the state snapshots of the latest blocks are retained in memory, the older ones
+// are spilled to disk up to the spill size and that quotes can be computed at a retained height.
+func (s *RouterTestSuite) TestGetStateSnapshotAtHeight() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	poolOne := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+	poolTwo := withTVL(s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount)), liquidityAmount.Int64())
+
+	routerRepository := routerrepo.NewWithStateHistory(domain.StateHistoryConfig{
+		Size:      2,
+		SpillDir:  s.T().TempDir(),
+		SpillSize: 1,
+	}, &log.NoOpLogger{})
+	poolsUsecase := poolsusecase.NewPoolsUsecase(&domain.PoolsConfig{}, "node-uri-placeholder", routerRepository)
+	routerUsecase := usecase.NewRouterUsecase(routerRepository, poolsUsecase, routertesting.DefaultRouterConfig, emptyCosmWasmPoolsRouterConfig, &log.NoOpLogger{}, cache.New(), cache.New())
+
+	takerFee := osmomath.MustNewDecFromStr("0.001")
+
+	// Pool two is created at height 3.
+	snapshot := routerUsecase.GetStateSnapshot().WithPools([]sqsdomain.PoolI{poolOne}).WithTakerFees(sqsdomain.TakerFeeMap{{Denom0: DenomOne, Denom1: DenomTwo}: takerFee}).WithSortedPools([]sqsdomain.PoolI{poolOne})
+	for height := uint64(1); height <= 4; height++ {
+		if height == 3 {
+			snapshot = snapshot.WithPools([]sqsdomain.PoolI{poolTwo}).WithSortedPools([]sqsdomain.PoolI{poolOne, poolTwo})
+		}
+
+		routerUsecase.StoreStateSnapshot(snapshot.WithHeight(height))
+	}
+
+	// The snapshots evicted from memory are spilled in the background.
+	s.Require().Eventually(func() bool {
+		_, err := routerUsecase.GetStateSnapshotAtHeight(1)
+		return err != nil
+	}, 10*time.Second, time.Millisecond)
+
+	tests := []struct {
+		name   string
+		height uint64
+
+		expectedNumPools int
+		expectedErr      error
+	}{
+		{
+			name:             "latest",
+			height:           4,
+			expectedNumPools: 2,
+		},
+		{
+			name:             "in memory",
+			height:           3,
+			expectedNumPools: 2,
+		},
+		{
+			name:             "spilled to disk",
+			height:           2,
+			expectedNumPools: 1,
+		},
+		{
+			name:        "removed from disk beyond the spill size",
+			height:      1,
+			expectedErr: domain.StateSnapshotNotRetainedError{Height: 1, LatestHeight: 4},
+		},
+		{
+			name:        "not ingested yet",
+			height:      5,
+			expectedErr: domain.StateSnapshotNotRetainedError{Height: 5, LatestHeight: 4},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		s.Run(tc.name, func() {
+			snapshot, err := routerUsecase.GetStateSnapshotAtHeight(tc.height)
+			if tc.expectedErr != nil {
+				s.Require().ErrorIs(err, tc.expectedErr)
+				return
+			}
+			s.Require().NoError(err)
+
+			s.Require().Equal(tc.height, snapshot.GetHeight())
+			s.Require().Len(snapshot.GetAllPools(), tc.expectedNumPools)
+			s.Require().Len(snapshot.GetSortedPools(), tc.expectedNumPools)
+
+			actualTakerFee, ok := snapshot.GetTakerFee(DenomTwo, DenomOne)
+			s.Require().True(ok)
+			s.Require().Equal(takerFee, actualTakerFee)
+
+			// The quote is computed over the pools at the height.
+			quote, err := routerUsecase.GetOptimalQuote(domain.WithStateSnapshot(context.Background(), snapshot), sdk.NewCoin(DenomOne, osmomath.NewInt(100_000_000)), DenomTwo)
+			s.Require().NoError(err)
+			s.Require().Len(quote.GetRoute(), tc.expectedNumPools)
+		})
+	}
+}
+
 // This test validates that routes can be found for all supported tokens.
 // Fails if not.
 // We use this test in CI for detecting tokens with unsupported pricing.
@@ -647,7 +997,7 @@ func (s *RouterTestSuite) TestGetCandidateRoutes_Chain_FindUnsupportedRoutes() {
 	s.Require().NotZero(len(tokenMetadata))
 	for chainDenom, tokenMeta := range tokenMetadata {
 
-		routes, err := usecase.GetCandidateRoutes(mainnetState.Pools, sdk.NewCoin(chainDenom, one), USDC, config.Router.MaxRoutes, config.Router.MaxPoolsPerRoute, noOpLogger)
+		routes, err := usecase.GetCandidateRoutes(usecase.NewPoolGraph(mainnetState.Pools), sdk.NewCoin(chainDenom, one), USDC, config.Router.MaxRoutes, config.Router.MaxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
 		if err != nil {
 			fmt.Printf("Error for %s  -- %s\n", chainDenom, tokenMeta.HumanDenom)
 			errorCounter++
@@ -677,7 +1027,7 @@ func (s *RouterTestSuite) TestGetCandidateRoutes_Chain_FindUnsupportedRoutes() {
 
 	for chainDenom, tokenMeta := range tokenMetadata {
 
-		routes, err := usecase.GetCandidateRoutes(mainnetState.Pools, sdk.NewCoin(chainDenom, one), USDC, config.Router.MaxRoutes, config.Router.MaxPoolsPerRoute, noOpLogger)
+		routes, err := usecase.GetCandidateRoutes(usecase.NewPoolGraph(mainnetState.Pools), sdk.NewCoin(chainDenom, one), USDC, config.Router.MaxRoutes, config.Router.MaxPoolsPerRoute, 0, domain.CandidateRouteFilters{}, noOpLogger)
 		if err != nil {
 			fmt.Printf("Error for %s  -- %s\n", chainDenom, tokenMeta.HumanDenom)
 			errorCounter++
@@ -755,7 +1105,7 @@ func (s *RouterTestSuite) TestSortPools() {
 
 	mainnetUseCase := s.SetupRouterAndPoolsUsecase(mainnetState)
 
-	pools, err := mainnetUseCase.Pools.GetAllPools()
+	pools, err := mainnetUseCase.Pools.GetAllPools(context.Background())
 	s.Require().NoError(err)
 
 	// Validate and sort pools
@@ -8,21 +8,9 @@ import (
 	"github.com/osmosis-labs/sqs/sqsdomain"
 	"github.com/osmosis-labs/sqs/sqsdomain/json"
 
-	concentratedmodel "github.com/osmosis-labs/osmosis/v25/x/concentrated-liquidity/model"
-	cosmwasmpoolmodel "github.com/osmosis-labs/osmosis/v25/x/cosmwasmpool/model"
-	"github.com/osmosis-labs/osmosis/v25/x/gamm/pool-models/balancer"
-	"github.com/osmosis-labs/osmosis/v25/x/gamm/pool-models/stableswap"
 	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
 )
 
-// SerializedPool is a struct that is used to serialize a pool to JSON.
-type SerializedPool struct {
-	Type      poolmanagertypes.PoolType `json:"type"`
-	ChainPool json.RawMessage           `json:"data"`
-	SQSModel  sqsdomain.SQSPool         `json:"sqs_model"`
-	TickModel *sqsdomain.TickModel      `json:"tick_model,omitempty"`
-}
-
 // StorePools stores the pools to a file.
 func StorePools(actualPools []sqsdomain.PoolI, tickModelMap map[uint64]*sqsdomain.TickModel, poolsFile string) error {
 	_, err := os.Stat(poolsFile)
@@ -48,7 +36,7 @@ func StorePools(actualPools []sqsdomain.PoolI, tickModelMap map[uint64]*sqsdomai
 				}
 			}
 
-			poolData, err := MarshalPool(pool)
+			poolData, err := domain.MarshalPool(pool)
 			if err != nil {
 				return err
 			}
@@ -127,7 +115,7 @@ func ReadPools(poolsFile string) ([]sqsdomain.PoolI, map[uint64]*sqsdomain.TickM
 		return nil, nil, err
 	}
 
-	var serializedPools []SerializedPool
+	var serializedPools []domain.SerializedPool
 	err = json.Unmarshal(poolBytes, &serializedPools)
 	if err != nil {
 		return nil, nil, err
@@ -138,7 +126,7 @@ func ReadPools(poolsFile string) ([]sqsdomain.PoolI, map[uint64]*sqsdomain.TickM
 	tickMap := make(map[uint64]*sqsdomain.TickModel)
 
 	for _, pool := range serializedPools {
-		poolWrapper, err := UnmarshalPool(pool)
+		poolWrapper, err := domain.UnmarshalPool(pool)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -184,85 +172,3 @@ func ReadTokensMetadata(tokensMetadataFileName string) (map[string]domain.Token,
 
 	return tokensMetadata, nil
 }
-
-// MarshalPool marshals a pool to JSON.
-func MarshalPool(pool sqsdomain.PoolI) (json.RawMessage, error) {
-	poolType := pool.GetType()
-
-	underlyingPool := pool.GetUnderlyingPool()
-
-	chainPoolBz, err := json.Marshal(underlyingPool)
-	if err != nil {
-		return nil, err
-	}
-
-	var tickModel *sqsdomain.TickModel
-	if poolType == poolmanagertypes.Concentrated {
-		tickModel, err = pool.GetTickModel()
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	serializedPool := SerializedPool{
-		Type:      poolType,
-		ChainPool: chainPoolBz,
-		SQSModel:  pool.GetSQSPoolModel(),
-		TickModel: tickModel,
-	}
-
-	poolData, err := json.Marshal(serializedPool)
-	if err != nil {
-		return nil, err
-	}
-
-	return poolData, nil
-}
-
-// UnmarshalPool unmarshals a pool from JSON.
-func UnmarshalPool(serializedPool SerializedPool) (sqsdomain.PoolI, error) {
-	var (
-		chainModel poolmanagertypes.PoolI
-	)
-
-	switch serializedPool.Type {
-	case poolmanagertypes.Concentrated:
-		var concentratedPool concentratedmodel.Pool
-		err := json.Unmarshal(serializedPool.ChainPool, &concentratedPool)
-		if err != nil {
-			return nil, err
-		}
-		chainModel = &concentratedPool
-	case poolmanagertypes.CosmWasm:
-		var transmuterPool cosmwasmpoolmodel.CosmWasmPool
-		err := json.Unmarshal(serializedPool.ChainPool, &transmuterPool)
-		if err != nil {
-			return nil, err
-		}
-		chainModel = &transmuterPool
-	case poolmanagertypes.Stableswap:
-		var balancerPool stableswap.Pool
-		err := json.Unmarshal(serializedPool.ChainPool, &balancerPool)
-		if err != nil {
-			return nil, err
-		}
-		chainModel = &balancerPool
-	case poolmanagertypes.Balancer:
-		var balancerPool balancer.Pool
-		err := json.Unmarshal(serializedPool.ChainPool, &balancerPool)
-		if err != nil {
-			return nil, err
-		}
-		chainModel = &balancerPool
-	default:
-		return nil, domain.InvalidPoolTypeError{PoolType: int32(serializedPool.Type)}
-	}
-
-	poolWrapper := sqsdomain.PoolWrapper{
-		ChainModel: chainModel,
-		SQSModel:   serializedPool.SQSModel,
-		TickModel:  serializedPool.TickModel,
-	}
-
-	return &poolWrapper, nil
-}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/osmosis-labs/sqs/domain"
 	"github.com/osmosis-labs/sqs/domain/mocks"
 	"github.com/osmosis-labs/sqs/router/usecase/routertesting"
 	"github.com/osmosis-labs/sqs/router/usecase/routertesting/parsing"
@@ -101,14 +102,14 @@ func TestStoreFilesAndReadBack(t *testing.T) {
 
 // This test validates that unmarshalling and marshalling a pool works as expected.
 func TestMarshalUnmarshalPool(t *testing.T) {
-	serializedPools, err := parsing.MarshalPool(testPoolToMarshal)
+	serializedPools, err := domain.MarshalPool(testPoolToMarshal)
 	require.NoError(t, err)
 
-	var interimPools parsing.SerializedPool
+	var interimPools domain.SerializedPool
 	err = json.Unmarshal(serializedPools, &interimPools)
 	require.NoError(t, err)
 
-	unmarshalledPool, err := parsing.UnmarshalPool(interimPools)
+	unmarshalledPool, err := domain.UnmarshalPool(interimPools)
 	require.NoError(t, err)
 
 	require.Equal(t, testPoolToMarshal.GetUnderlyingPool(), unmarshalledPool.GetUnderlyingPool())
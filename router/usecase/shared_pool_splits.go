@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.uber.org/zap"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/log"
+	"github.com/osmosis-labs/sqs/router/usecase/route"
+)
+
+// getSharedPoolSplitQuote returns the best quote for the given routes and tokenIn when some of the routes share pools.
+// The amount out of a route depends on the amounts swapped over its shared pools by the other routes.
+// As a result, the routes cannot be estimated independently as in getSplitQuote. Instead, the token in
+// is allocated one increment at a time to the route that increases the amount out of the joint simulation the most.
+// See simulateSharedPoolSplit for details.
+// If gasCosts are given, the increments are allocated by the amount out net of the gas costs of the routes used.
+// The outcome of the split is recorded in the quote debug info if it is attached to the context.
+// If maxRefinementIterations is positive, the split is refined further. See refineSplitQuote for details.
+// The time complexity is O(n^2 * m) route estimates, where n is the number of routes and m is the totalIncrements.
+func getSharedPoolSplitQuote(ctx context.Context, routes []route.RouteImpl, tokenIn sdk.Coin, gasCosts []osmomath.Int, maxRefinementIterations int, logger log.Logger) (_ domain.Quote, err error) {
+	inIncrements := getInIncrements(tokenIn)
+
+	routeIncrements := make([]uint8, len(routes))
+	var (
+		outAmounts   []osmomath.Int
+		ticksCrossed []uint64
+	)
+
+	for x := uint8(1); x <= totalIncrements; x++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var (
+			bestRouteIndex   = -1
+			bestOutAmounts   []osmomath.Int
+			bestTicksCrossed []uint64
+			bestNetOut       osmomath.Int
+		)
+
+		for j := range routes {
+			routeIncrements[j]++
+			candidateTokensIn := getRouteTokensIn(routeIncrements, inIncrements)
+			candidateOutAmounts, candidateTicksCrossed, err := simulateSharedPoolSplit(ctx, routes, candidateTokensIn, logger)
+			if err == nil {
+				candidateNetOut := getSplitNetAmountOut(candidateOutAmounts, candidateTokensIn, gasCosts)
+				if bestRouteIndex == -1 || candidateNetOut.GT(bestNetOut) {
+					bestRouteIndex = j
+					bestOutAmounts = candidateOutAmounts
+					bestTicksCrossed = candidateTicksCrossed
+					bestNetOut = candidateNetOut
+				}
+			}
+			routeIncrements[j]--
+		}
+
+		if bestRouteIndex == -1 {
+			return nil, fmt.Errorf("no route can swap increment (%d) of token in (%s)", x, tokenIn)
+		}
+
+		routeIncrements[bestRouteIndex]++
+		outAmounts = bestOutAmounts
+		ticksCrossed = bestTicksCrossed
+	}
+
+	bestSplit := split{
+		routeIncrements: routeIncrements,
+		amountOut:       osmomath.ZeroInt(),
+	}
+	for _, outAmount := range outAmounts {
+		bestSplit.amountOut = bestSplit.amountOut.Add(outAmount)
+	}
+
+	var refinement *domain.SplitRefinementDebugInfo
+	if debugInfo := domain.GetQuoteDebugInfoFromContext(ctx); debugInfo != nil {
+		defer func() {
+			debugInfo.SetSplit(newSplitDebugInfo(routes, bestSplit, refinement, err))
+		}()
+	}
+
+	if bestSplit.amountOut.IsZero() {
+		return nil, errors.New("amount out is zero, try increasing amount in")
+	}
+
+	resultRoutes := make([]domain.SplitRoute, 0, len(routes))
+	for i, currentRouteIncrement := range routeIncrements {
+		if currentRouteIncrement == 0 {
+			continue
+		}
+
+		inAmount := inIncrements[currentRouteIncrement].Amount
+		outAmount := outAmounts[i]
+
+		if inAmount.IsZero() {
+			return nil, fmt.Errorf("in amount is zero when out is not (%s), route index (%d)", outAmount, i)
+		}
+
+		if outAmount.IsZero() {
+			return nil, fmt.Errorf("out amount is zero when in is not (%s), route index (%d)", inAmount, i)
+		}
+
+		resultRoutes = append(resultRoutes, &RouteWithOutAmount{
+			RouteImpl:    routes[i],
+			InAmount:     inAmount,
+			OutAmount:    outAmount,
+			TicksCrossed: ticksCrossed[i],
+		})
+	}
+
+	quote := &quoteImpl{
+		AmountIn:  tokenIn,
+		AmountOut: bestSplit.amountOut,
+		Route:     resultRoutes,
+	}
+
+	if maxRefinementIterations > 0 && !containsGeneralizedCosmWasmPoolRoute(routes) {
+		refinedQuote, refinementDebugInfo := refineSplitQuote(ctx, routes, tokenIn, routeIncrements, gasCosts, maxRefinementIterations, quote, newSharedPoolSplitEstimator(routes, logger))
+		refinement = &refinementDebugInfo
+		return refinedQuote, nil
+	}
+
+	return quote, nil
+}
+
+// simulateSharedPoolSplit returns the token out amount and the number of initialized ticks crossed of every route
+// for the given token in of every route.
+// The routes are simulated in order, the same way the split is executed on chain. Every shared pool that
+// implements domain.StatefulRoutablePool is swapped over in the state left by the swaps of the previous routes.
+// The other pools are swapped over in their original state.
+// Returns error if any of the routes with a non-zero token in fails to swap. Panics of the pools are returned
+// as errors as well and logged at the error level with the stack since they signify a bug.
+func simulateSharedPoolSplit(ctx context.Context, routes []route.RouteImpl, tokensIn []sdk.Coin, logger log.Logger) (outAmounts []osmomath.Int, ticksCrossed []uint64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("panic when simulating split over shared pools", zap.Any("panic", r), zap.Stack("stack"))
+
+			outAmounts = nil
+			ticksCrossed = nil
+			err = fmt.Errorf("error when simulating split over shared pools: %v", r)
+		}
+	}()
+
+	// The state of every stateful pool swapped over so far keyed by the pool ID.
+	poolStates := make(map[uint64]domain.StatefulRoutablePool)
+
+	outAmounts = make([]osmomath.Int, len(routes))
+	ticksCrossed = make([]uint64, len(routes))
+	for i, currentRoute := range routes {
+		outAmounts[i] = zero
+
+		tokenIn := tokensIn[i]
+		for _, pool := range currentRoute.GetPools() {
+			// Similarly to route.CalculateTokenOutByTokenIn, nothing is swapped out for nothing swapped in.
+			tokenIn = pool.ChargeTakerFeeExactIn(tokenIn)
+			if tokenIn.IsZero() {
+				tokenIn = sdk.NewCoin(tokenIn.Denom, zero)
+				break
+			}
+
+			poolState, ok := poolStates[pool.GetId()]
+			if !ok {
+				poolState, ok = pool.(domain.StatefulRoutablePool)
+			}
+
+			var (
+				tokenOut         sdk.Coin
+				poolTicksCrossed uint64
+			)
+			tickCrossingPoolState, isTickCrossing := poolState.(domain.TickCrossingRoutablePool)
+			switch {
+			case !ok:
+				tokenOut, err = pool.CalculateTokenOutByTokenIn(ctx, tokenIn)
+			case isTickCrossing:
+				tokenOut, poolTicksCrossed, poolState, err = tickCrossingPoolState.SwapOutGivenInWithTicksCrossed(ctx, tokenIn, pool.GetTokenOutDenom())
+				poolStates[pool.GetId()] = poolState
+			default:
+				tokenOut, poolState, err = poolState.SwapOutGivenIn(ctx, tokenIn, pool.GetTokenOutDenom())
+				poolStates[pool.GetId()] = poolState
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+
+			ticksCrossed[i] += poolTicksCrossed
+			tokenIn = tokenOut
+		}
+
+		outAmounts[i] = tokenIn.Amount
+	}
+
+	return outAmounts, ticksCrossed, nil
+}
+
+// getRouteTokensIn returns the token in of every route for the given increments.
+func getRouteTokensIn(routeIncrements []uint8, inIncrements []sdk.Coin) []sdk.Coin {
+	tokensIn := make([]sdk.Coin, len(routeIncrements))
+	for i, routeIncrement := range routeIncrements {
+		tokensIn[i] = inIncrements[routeIncrement]
+	}
+	return tokensIn
+}
+
+// getSplitNetAmountOut returns the total amount out of the split net of the gas costs of the routes used.
+// Returns the total gross amount out if gasCosts are nil.
+func getSplitNetAmountOut(outAmounts []osmomath.Int, tokensIn []sdk.Coin, gasCosts []osmomath.Int) osmomath.Int {
+	netAmountOut := osmomath.ZeroInt()
+	for i, outAmount := range outAmounts {
+		netAmountOut = netAmountOut.Add(outAmount)
+
+		if gasCosts != nil && tokensIn[i].IsPositive() {
+			netAmountOut = netAmountOut.Sub(gasCosts[i])
+		}
+	}
+	return netAmountOut
+}
+
+// hasSharedPools returns true if any pool is used by more than one of the given routes.
+func hasSharedPools(routes []route.RouteImpl) bool {
+	poolIDs := make(map[uint64]struct{})
+	for _, currentRoute := range routes {
+		for _, pool := range currentRoute.GetPools() {
+			if _, ok := poolIDs[pool.GetId()]; ok {
+				return true
+			}
+		}
+
+		for _, pool := range currentRoute.GetPools() {
+			poolIDs[pool.GetId()] = struct{}{}
+		}
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"math"
+
+	"github.com/google/btree"
+	"go.uber.org/zap"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/log"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// sortedPoolsBTreeDegree is the degree of the b-tree backing the sorted pools.
+const sortedPoolsBTreeDegree = 32
+
+// SortedPools maintains the validated router pools in the order defined by sortPools().
+// Contrary to ValidateAndSortPools, it supports updating individual pools in O(log n)
+// so that only the pools modified in a block have to be re-positioned.
+//
+// The total TVL-derived boosts in the ratings are computed from the total TVL at the time of the
+// last full re-rating. All pools are re-rated only once the total TVL drifts past the configured
+// relative threshold from that value.
+//
+// SortedPools is not safe for concurrent use.
+type SortedPools struct {
+	tree *btree.BTreeG[ratedPool]
+	// pool ID -> rated pool currently in the tree.
+	ratedPoolsByID map[uint64]ratedPool
+
+	// Total TVL across all valid pools.
+	totalTVL osmomath.Int
+	// Total TVL that the current ratings were computed from.
+	ratingTotalTVL osmomath.Int
+
+	tvlDriftThreshold   float64
+	cosmWasmPoolsConfig domain.CosmWasmPoolRouterConfig
+	preferredPoolIDsMap map[uint64]struct{}
+
+	logger log.Logger
+}
+
+// NewSortedPools returns a new empty sorted pools structure.
+// tvlDriftThreshold is the relative drift of the total TVL after which all pools are re-rated.
+func NewSortedPools(cosmWasmPoolsConfig domain.CosmWasmPoolRouterConfig, preferredPoolIDs []uint64, tvlDriftThreshold float64, logger log.Logger) *SortedPools {
+	return &SortedPools{
+		tree:           btree.NewG(sortedPoolsBTreeDegree, ratedPoolLess),
+		ratedPoolsByID: make(map[uint64]ratedPool),
+
+		totalTVL:       osmomath.ZeroInt(),
+		ratingTotalTVL: osmomath.ZeroInt(),
+
+		tvlDriftThreshold:   tvlDriftThreshold,
+		cosmWasmPoolsConfig: cosmWasmPoolsConfig,
+		preferredPoolIDsMap: getPreferredPoolIDsMap(preferredPoolIDs),
+
+		logger: logger,
+	}
+}
+
+// Update inserts the given pools or re-positions them if they are already present.
+// Pools that are no longer valid for the router are removed.
+// If the total TVL drifts past the threshold as a result, all pools are re-rated.
+func (s *SortedPools) Update(pools []sqsdomain.PoolI) {
+	ratingTotalTVLFloat, _ := s.ratingTotalTVL.BigIntMut().Float64()
+
+	for _, pool := range pools {
+		s.remove(pool.GetId())
+
+		if !isValidRouterPool(pool, s.cosmWasmPoolsConfig, s.logger) {
+			continue
+		}
+
+		s.insert(pool, ratingTotalTVLFloat)
+	}
+
+	if s.hasTotalTVLDrifted() {
+		s.rerate()
+	}
+}
+
+// GetSortedPools returns all pools in the sorted order.
+func (s *SortedPools) GetSortedPools() []sqsdomain.PoolI {
+	sortedPools := make([]sqsdomain.PoolI, 0, s.tree.Len())
+	s.tree.Ascend(func(item ratedPool) bool {
+		sortedPools = append(sortedPools, item.pool)
+		return true
+	})
+	return sortedPools
+}
+
+// Len returns the number of pools.
+func (s *SortedPools) Len() int {
+	return s.tree.Len()
+}
+
+// insert rates the given pool against the given total TVL and inserts it.
+// CONTRACT: the pool is valid and is not present.
+func (s *SortedPools) insert(pool sqsdomain.PoolI, ratingTotalTVLFloat float64) {
+	rating, ok := ratePool(pool, s.cosmWasmPoolsConfig.TransmuterCodeIDs, ratingTotalTVLFloat, s.preferredPoolIDsMap, s.logger)
+	if !ok {
+		return
+	}
+
+	item := ratedPool{
+		pool:   pool,
+		rating: rating,
+	}
+
+	s.tree.ReplaceOrInsert(item)
+	s.ratedPoolsByID[pool.GetId()] = item
+	s.totalTVL = s.totalTVL.Add(pool.GetTotalValueLockedUSDC())
+}
+
+// remove removes the pool with the given ID if it is present.
+func (s *SortedPools) remove(poolID uint64) {
+	item, ok := s.ratedPoolsByID[poolID]
+	if !ok {
+		return
+	}
+
+	s.tree.Delete(item)
+	delete(s.ratedPoolsByID, poolID)
+	s.totalTVL = s.totalTVL.Sub(item.pool.GetTotalValueLockedUSDC())
+}
+
+// hasTotalTVLDrifted returns true if the total TVL drifted from the one the ratings
+// were computed from by more than the threshold.
+func (s *SortedPools) hasTotalTVLDrifted() bool {
+	if s.totalTVL.Equal(s.ratingTotalTVL) {
+		return false
+	}
+
+	if s.ratingTotalTVL.IsZero() {
+		return true
+	}
+
+	totalTVLFloat, _ := s.totalTVL.BigIntMut().Float64()
+	ratingTotalTVLFloat, _ := s.ratingTotalTVL.BigIntMut().Float64()
+
+	return math.Abs(totalTVLFloat-ratingTotalTVLFloat)/ratingTotalTVLFloat > s.tvlDriftThreshold
+}
+
+// rerate re-rates all pools against the current total TVL and rebuilds the tree.
+func (s *SortedPools) rerate() {
+	s.logger.Info("re-rating all pools", zap.Stringer("total_tvl", s.totalTVL), zap.Stringer("previous_total_tvl", s.ratingTotalTVL))
+
+	pools := s.GetSortedPools()
+
+	ratingTotalTVL := s.totalTVL
+	ratingTotalTVLFloat, _ := ratingTotalTVL.BigIntMut().Float64()
+
+	s.tree.Clear(false)
+	s.ratedPoolsByID = make(map[uint64]ratedPool, len(pools))
+	s.totalTVL = osmomath.ZeroInt()
+
+	for _, pool := range pools {
+		s.insert(pool, ratingTotalTVLFloat)
+	}
+
+	s.ratingTotalTVL = ratingTotalTVL
+}
+
+// sortedPoolNeighbours are the IDs of the pools before and after a pool in the sorted order.
+// Zero if there is none since no pool has ID zero.
+type sortedPoolNeighbours struct {
+	previousID uint64
+	nextID     uint64
+}
+
+// DiffSortedPools returns the change from the previous to the current sorted pools.
+// A pool is re-ranked if it was added or removed or if the pools before or after it changed.
+// As a result, both the pool that moved and its previous and current neighbours are re-ranked.
+// The denoms of the added and re-ranked pools are collected as well.
+func DiffSortedPools(previousSortedPools, sortedPools []sqsdomain.PoolI) domain.SortedPoolsDiff {
+	previousNeighbours := getSortedPoolNeighbours(previousSortedPools)
+	neighbours := getSortedPoolNeighbours(sortedPools)
+
+	diff := domain.SortedPoolsDiff{
+		RankChangedPoolIDs: []uint64{},
+		RankChangedDenoms:  []string{},
+	}
+
+	rankChangedDenoms := make(map[string]struct{})
+
+	for _, pool := range sortedPools {
+		poolID := pool.GetId()
+
+		previous, ok := previousNeighbours[poolID]
+		if !ok {
+			diff.IsPoolSetChanged = true
+		}
+
+		if !ok || previous != neighbours[poolID] {
+			diff.RankChangedPoolIDs = append(diff.RankChangedPoolIDs, poolID)
+
+			for _, denom := range pool.GetPoolDenoms() {
+				if _, ok := rankChangedDenoms[denom]; !ok {
+					rankChangedDenoms[denom] = struct{}{}
+					diff.RankChangedDenoms = append(diff.RankChangedDenoms, denom)
+				}
+			}
+		}
+	}
+
+	for _, pool := range previousSortedPools {
+		if _, ok := neighbours[pool.GetId()]; !ok {
+			diff.IsPoolSetChanged = true
+			diff.RankChangedPoolIDs = append(diff.RankChangedPoolIDs, pool.GetId())
+		}
+	}
+
+	return diff
+}
+
+// getSortedPoolNeighbours returns the neighbours of every pool by pool ID.
+func getSortedPoolNeighbours(sortedPools []sqsdomain.PoolI) map[uint64]sortedPoolNeighbours {
+	neighbours := make(map[uint64]sortedPoolNeighbours, len(sortedPools))
+	for i, pool := range sortedPools {
+		var poolNeighbours sortedPoolNeighbours
+		if i > 0 {
+			poolNeighbours.previousID = sortedPools[i-1].GetId()
+		}
+		if i < len(sortedPools)-1 {
+			poolNeighbours.nextID = sortedPools[i+1].GetId()
+		}
+		neighbours[pool.GetId()] = poolNeighbours
+	}
+	return neighbours
+}
+
+// ratedPoolLess orders the rated pools by rating in descending order.
+// Ties are broken by pool ID in ascending order.
+func ratedPoolLess(a, b ratedPool) bool {
+	if a.rating != b.rating {
+		return a.rating > b.rating
+	}
+	return a.pool.GetId() < b.pool.GetId()
+}
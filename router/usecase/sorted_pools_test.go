@@ -0,0 +1,185 @@
+package usecase_test
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"github.com/osmosis-labs/sqs/domain"
+	"github.com/osmosis-labs/sqs/domain/mocks"
+	routerusecase "github.com/osmosis-labs/sqs/router/usecase"
+	"github.com/osmosis-labs/sqs/sqsdomain"
+)
+
+// Validates that the incrementally updated sorted pools always match
+// the full re-sort with ValidateAndSortPools, and that the total TVL-derived
+// boosts are only re-computed once the total TVL drifts past the threshold.
+func (s *RouterTestSuite) TestSortedPools_Update() {
+	s.Setup()
+
+	liquidityAmount := osmomath.NewInt(1_000_000_000)
+
+	var (
+		poolOne   = s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomTwo, liquidityAmount))
+		poolTwo   = s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomTwo, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount))
+		poolThree = s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomThree, liquidityAmount))
+		poolFour  = s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomThree, liquidityAmount), sdk.NewCoin(DenomFour, liquidityAmount))
+		newPool   = s.prepareBalancerPoolWrapper(sdk.NewCoin(DenomOne, liquidityAmount), sdk.NewCoin(DenomFour, liquidityAmount))
+
+		// Pool four is preferred, pool three has the TVL error flag set.
+		preferredPoolIDs = []uint64{poolFour.GetId()}
+
+		// Total TVL is 3600.
+		// Ratings: pool four 3736, pool two 2036, pool one 1036, pool three 500.
+		initialPools = []sqsdomain.PoolI{
+			withTVL(poolOne, 1000),
+			withTVL(poolTwo, 2000),
+			withTVLError(withTVL(poolThree, 500)),
+			withTVL(poolFour, 100),
+		}
+	)
+
+	tests := map[string]struct {
+		tvlDriftThreshold float64
+		updatedPools      []sqsdomain.PoolI
+
+		expectedSortedPoolIDs  []uint64
+		expectedRatingTotalTVL int64
+	}{
+		"no update": {
+			tvlDriftThreshold: 0.05,
+
+			expectedSortedPoolIDs:  []uint64{poolFour.GetId(), poolTwo.GetId(), poolOne.GetId(), poolThree.GetId()},
+			expectedRatingTotalTVL: 3600,
+		},
+		"update within drift threshold - pool repositioned with stale boosts": {
+			tvlDriftThreshold: 1,
+			// Total TVL is 6600. Pool one is rated 4036 against the stale total TVL of 3600.
+			updatedPools: []sqsdomain.PoolI{withTVL(poolOne, 4000)},
+
+			expectedSortedPoolIDs:  []uint64{poolOne.GetId(), poolFour.GetId(), poolTwo.GetId(), poolThree.GetId()},
+			expectedRatingTotalTVL: 3600,
+		},
+		"update past drift threshold - all pools re-rated": {
+			tvlDriftThreshold: 0.05,
+			// Total TVL is 6600. Pool four is re-rated to 6766 and pool one to 4066.
+			updatedPools: []sqsdomain.PoolI{withTVL(poolOne, 4000)},
+
+			expectedSortedPoolIDs:  []uint64{poolFour.GetId(), poolOne.GetId(), poolTwo.GetId(), poolThree.GetId()},
+			expectedRatingTotalTVL: 6600,
+		},
+		"pool with no liquidity is removed": {
+			tvlDriftThreshold: 1,
+			updatedPools:      []sqsdomain.PoolI{withTVL(poolTwo, 0)},
+
+			expectedSortedPoolIDs:  []uint64{poolFour.GetId(), poolOne.GetId(), poolThree.GetId()},
+			expectedRatingTotalTVL: 3600,
+		},
+		"new pool is inserted": {
+			tvlDriftThreshold: 1,
+			updatedPools:      []sqsdomain.PoolI{withTVL(newPool, 1500)},
+
+			expectedSortedPoolIDs:  []uint64{poolFour.GetId(), poolTwo.GetId(), newPool.GetId(), poolOne.GetId(), poolThree.GetId()},
+			expectedRatingTotalTVL: 3600,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		s.Run(name, func() {
+			sortedPools := routerusecase.NewSortedPools(emptyCosmWasmPoolsRouterConfig, preferredPoolIDs, tc.tvlDriftThreshold, noOpLogger)
+
+			// The initial update sorts all pools the same way as the full re-sort.
+			sortedPools.Update(initialPools)
+			expectedInitialSortedPools := routerusecase.ValidateAndSortPools(append([]sqsdomain.PoolI{}, initialPools...), emptyCosmWasmPoolsRouterConfig, preferredPoolIDs, noOpLogger)
+			s.Require().Equal(getPoolIDs(expectedInitialSortedPools), getPoolIDs(sortedPools.GetSortedPools()))
+
+			// System under test.
+			sortedPools.Update(tc.updatedPools)
+
+			s.Require().Equal(tc.expectedSortedPoolIDs, getPoolIDs(sortedPools.GetSortedPools()))
+			s.Require().Equal(len(tc.expectedSortedPoolIDs), sortedPools.Len())
+			s.Require().Equal(osmomath.NewInt(tc.expectedRatingTotalTVL), sortedPools.GetRatingTotalTVL())
+		})
+	}
+}
+
+// withTVL returns a copy of the given pool with the given TVL.
+// Validates that the pools that were added, removed or moved are re-ranked together with
+// their previous and current neighbours in the sorted order.
+func (s *RouterTestSuite) TestDiffSortedPools() {
+	newSortedPools := func(poolIDs ...uint64) []sqsdomain.PoolI {
+		pools := make([]sqsdomain.PoolI, 0, len(poolIDs))
+		for _, poolID := range poolIDs {
+			pools = append(pools, &mocks.MockRoutablePool{ID: poolID, Denoms: []string{UOSMO, fmt.Sprintf("denom%d", poolID)}})
+		}
+		return pools
+	}
+
+	tests := map[string]struct {
+		previousSortedPools []sqsdomain.PoolI
+		sortedPools         []sqsdomain.PoolI
+
+		expectedDiff domain.SortedPoolsDiff
+	}{
+		"unchanged": {
+			previousSortedPools: newSortedPools(1, 2, 3),
+			sortedPools:         newSortedPools(1, 2, 3),
+
+			expectedDiff: domain.SortedPoolsDiff{RankChangedPoolIDs: []uint64{}, RankChangedDenoms: []string{}},
+		},
+		"pool moved": {
+			previousSortedPools: newSortedPools(1, 2, 3, 4, 5),
+			sortedPools:         newSortedPools(1, 4, 2, 3, 5),
+
+			// Pool 4 moved. Pools 1 and 2 are its current neighbours and pools 3 and 5 are its previous ones.
+			expectedDiff: domain.SortedPoolsDiff{
+				RankChangedPoolIDs: []uint64{1, 4, 2, 3, 5},
+				RankChangedDenoms:  []string{UOSMO, "denom1", "denom4", "denom2", "denom3", "denom5"},
+			},
+		},
+		"pool added": {
+			previousSortedPools: newSortedPools(1, 2, 3, 4),
+			sortedPools:         newSortedPools(1, 2, 5, 3, 4),
+
+			expectedDiff: domain.SortedPoolsDiff{
+				RankChangedPoolIDs: []uint64{2, 5, 3},
+				RankChangedDenoms:  []string{UOSMO, "denom2", "denom5", "denom3"},
+				IsPoolSetChanged:   true,
+			},
+		},
+		"pool removed": {
+			previousSortedPools: newSortedPools(1, 2, 3, 4),
+			sortedPools:         newSortedPools(1, 2, 4),
+
+			// The denoms of the removed pool are not collected since no route can go through it.
+			expectedDiff: domain.SortedPoolsDiff{
+				RankChangedPoolIDs: []uint64{2, 4, 3},
+				RankChangedDenoms:  []string{UOSMO, "denom2", "denom4"},
+				IsPoolSetChanged:   true,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		s.Run(name, func() {
+			diff := routerusecase.DiffSortedPools(tc.previousSortedPools, tc.sortedPools)
+
+			s.Require().Equal(tc.expectedDiff, diff)
+		})
+	}
+}
+
+func withTVL(pool *sqsdomain.PoolWrapper, tvl int64) *sqsdomain.PoolWrapper {
+	poolCopy := *pool
+	poolCopy.SQSModel.TotalValueLockedUSDC = osmomath.NewInt(tvl)
+	return &poolCopy
+}
+
+// withTVLError returns a copy of the given pool with the TVL error flag set.
+func withTVLError(pool *sqsdomain.PoolWrapper) *sqsdomain.PoolWrapper {
+	poolCopy := *pool
+	poolCopy.SQSModel.TotalValueLockedError = dummyTotalValueLockedErrorStr
+	return &poolCopy
+}
@@ -9,3 +9,11 @@ type ConcentratedPoolNoTickModelError struct {
 func (e ConcentratedPoolNoTickModelError) Error() string {
 	return fmt.Sprintf("concentrated pool (%d) has no tick model", e.PoolId)
 }
+
+type OrderbookPoolNoOrderbookModelError struct {
+	PoolId uint64
+}
+
+func (e OrderbookPoolNoOrderbookModelError) Error() string {
+	return fmt.Sprintf("pool (%d) has no orderbook model", e.PoolId)
+}
@@ -36,6 +36,15 @@ type PoolI interface {
 	// If this is not a concentrated pool, errors
 	SetTickModel(*TickModel) error
 
+	// GetOrderbookModel returns the orderbook model for the pool
+	// If this is an orderbook CosmWasm pool. Errors otherwise
+	// including if the orderbook model is not set
+	GetOrderbookModel() (*OrderbookModel, error)
+
+	// SetOrderbookModel sets the orderbook model for the pool
+	// If this is not a CosmWasm pool, errors
+	SetOrderbookModel(*OrderbookModel) error
+
 	// Validate validates the pool
 	// Returns nil if the pool is valid
 	// Returns error if the pool is invalid
@@ -50,6 +59,27 @@ type TickModel struct {
 	HasNoLiquidity   bool                       `json:"has_no_liquidity,omitempty"`
 }
 
+// OrderbookModel is the price level state of an orderbook CosmWasm pool.
+type OrderbookModel struct {
+	BaseDenom  string `json:"base_denom"`
+	QuoteDenom string `json:"quote_denom"`
+	// Bids are the levels that buy the base denom sorted by price in descending order.
+	// Their quantities are in the quote denom.
+	Bids []OrderbookLevel `json:"bids,omitempty"`
+	// Asks are the levels that sell the base denom sorted by price in ascending order.
+	// Their quantities are in the base denom.
+	Asks []OrderbookLevel `json:"asks,omitempty"`
+}
+
+// OrderbookLevel is the liquidity resting at a single tick of an orderbook.
+type OrderbookLevel struct {
+	TickId int64 `json:"tick_id"`
+	// Price of the base denom in the quote denom.
+	Price osmomath.Dec `json:"price"`
+	// Quantity is the total amount of the resting orders at the tick.
+	Quantity osmomath.Int `json:"quantity"`
+}
+
 type SQSPool struct {
 	TotalValueLockedUSDC  osmomath.Int `json:"total_value_locked_uosmo"`
 	TotalValueLockedError string       `json:"total_value_locked_error,omitempty"`
@@ -63,6 +93,8 @@ type PoolWrapper struct {
 	ChainModel poolmanagertypes.PoolI `json:"underlying_pool"`
 	SQSModel   SQSPool                `json:"sqs_model"`
 	TickModel  *TickModel             `json:"tick_model,omitempty"`
+	// Only set for orderbook CosmWasm pools.
+	OrderbookModel *OrderbookModel `json:"orderbook_model,omitempty"`
 }
 
 var _ PoolI = &PoolWrapper{}
@@ -133,6 +165,30 @@ func (p *PoolWrapper) SetTickModel(tickModel *TickModel) error {
 	return nil
 }
 
+// GetOrderbookModel implements PoolI.
+func (p *PoolWrapper) GetOrderbookModel() (*OrderbookModel, error) {
+	if p.GetType() != poolmanagertypes.CosmWasm {
+		return nil, fmt.Errorf("pool (%d) is not a cosmwasm pool, type (%d)", p.GetId(), p.GetType())
+	}
+
+	if p.OrderbookModel == nil {
+		return nil, OrderbookPoolNoOrderbookModelError{PoolId: p.GetId()}
+	}
+
+	return p.OrderbookModel, nil
+}
+
+// SetOrderbookModel implements PoolI.
+func (p *PoolWrapper) SetOrderbookModel(orderbookModel *OrderbookModel) error {
+	if p.GetType() != poolmanagertypes.CosmWasm {
+		return fmt.Errorf("pool (%d) is not a cosmwasm pool, type (%d)", p.GetId(), p.GetType())
+	}
+
+	p.OrderbookModel = orderbookModel
+
+	return nil
+}
+
 func (p *PoolWrapper) Validate(minUOSMOTVL osmomath.Int) error {
 	sqsModel := p.GetSQSPoolModel()
 	poolDenoms := p.GetPoolDenoms()
@@ -26,6 +26,7 @@ type RoutablePool interface {
 
 	GetPoolDenoms() []string
 
+	GetTokenInDenom() string
 	GetTokenOutDenom() string
 
 	CalcSpotPrice(ctx context.Context, baseDenom string, quoteDenom string) (osmomath.BigDec, error)
@@ -33,6 +34,13 @@ type RoutablePool interface {
 	CalculateTokenOutByTokenIn(ctx context.Context, tokenIn sdk.Coin) (sdk.Coin, error)
 	ChargeTakerFeeExactIn(tokenIn sdk.Coin) (tokenInAfterFee sdk.Coin)
 
+	// CalculateTokenInByTokenOut returns the amount of token in denom required
+	// to receive exactly the given token out from the pool.
+	CalculateTokenInByTokenOut(ctx context.Context, tokenOut sdk.Coin) (sdk.Coin, error)
+	// ChargeTakerFeeExactOut returns the token in amount increased by the taker fee
+	// so that the amount left after the fee is charged equals the given token in.
+	ChargeTakerFeeExactOut(tokenIn sdk.Coin) (tokenInAfterFee sdk.Coin)
+
 	GetTakerFee() osmomath.Dec
 
 	GetSpreadFactor() osmomath.Dec
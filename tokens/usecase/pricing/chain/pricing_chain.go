@@ -116,9 +116,9 @@ func (c *chainPricing) GetPrice(ctx context.Context, baseDenom string, quoteDeno
 		opt(&options)
 	}
 
-	// Recompute prices if desired by configuration.
+	// Recompute prices if desired by configuration or if computed at a past height.
 	// Otherwise, look into cache first.
-	if options.RecomputePrices {
+	if options.RecomputePrices || !c.isLatestStateSnapshot(ctx) {
 		return c.computePrice(ctx, baseDenom, quoteDenom, options.MinLiquidity, options.RecomputePricesIsSpotPriceComputeMethod)
 	}
 
@@ -253,8 +253,8 @@ func (c *chainPricing) computePrice(ctx context.Context, baseDenom string, quote
 	// Apply scaling facors to descale the amounts to real amounts.
 	chainPrice = chainPrice.MulMut(precisionScalingFactor)
 
-	// Only store values that are valid.
-	if !chainPrice.IsNil() {
+	// Only store values that are valid and computed at the latest height.
+	if !chainPrice.IsNil() && c.isLatestStateSnapshot(ctx) {
 		expirationTTL := c.cacheExpiryNs
 		// We pre-compute the price for the default quote denom in ingest handler via the background
 		// pricing worker. As a result, we store them indefinitely.
@@ -272,3 +272,10 @@ func (c *chainPricing) computePrice(ctx context.Context, baseDenom string, quote
 func (c *chainPricing) InitializeCache(cache *cache.Cache) {
 	c.cache = cache
 }
+
+// isLatestStateSnapshot returns true if no state snapshot is pinned to the context or if it is the latest one.
+// The prices at a past height are neither read from nor written to the cache.
+func (c *chainPricing) isLatestStateSnapshot(ctx context.Context) bool {
+	snapshot := domain.GetStateSnapshotFromContext(ctx)
+	return snapshot == nil || snapshot == c.RUsecase.GetStateSnapshot()
+}